@@ -0,0 +1,117 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Aggregate is the summary of the numeric values matched by AggregatePath
+type Aggregate struct {
+	Count int
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Mean returns the average of the values (or 0 if there were none)
+func (a Aggregate) Mean() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Count)
+}
+
+// add includes a value in the Aggregate
+func (a *Aggregate) add(value float64) {
+	if a.Count == 0 || value < a.Min {
+		a.Min = value
+	}
+	if a.Count == 0 || value > a.Max {
+		a.Max = value
+	}
+	a.Count++
+	a.Sum += value
+}
+
+// elementText appends the decoded CharData directly within the element at the start of subtree to scratch
+func elementText(scratch []byte, subtree []byte) ([]byte, error) {
+	s := NewScanner(subtree)
+	first, _, err := s.Next()
+	if err != nil || IsSelfClosing(first) {
+		return scratch, err
+	}
+	for depth := 1; depth > 0; {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return scratch, err
+		}
+		if chardata {
+			if depth == 1 {
+				if scratch, err = CharDataAppend(scratch, token); err != nil {
+					return scratch, err
+				}
+			}
+			continue
+		}
+		if !IsElement(token) || IsSelfClosing(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			depth--
+		} else {
+			depth++
+		}
+	}
+	return scratch, nil
+}
+
+// AggregatePath computes the count, sum, min and max of the numeric values matched by path in a single pass
+// The value is the text content of each matching element or if path ends in `/@name` the attribute of each matching element
+// Empty (or missing) values are ignored, any other value which is not a number is an error
+func AggregatePath(buf []byte, path string) (agg Aggregate, err error) {
+	var attr []byte
+	if idx := strings.LastIndex(path, "/@"); idx != -1 {
+		path, attr = path[:idx], []byte(path[idx+2:])
+	}
+	p, err := CompilePath(path)
+	if err != nil {
+		return agg, err
+	}
+	m := p.Matcher()
+	s := NewScanner(buf)
+	var scratch []byte
+	for {
+		start, end, err := m.Find(s)
+		if err == io.EOF {
+			return agg, nil
+		} else if err != nil {
+			return agg, err
+		}
+		scratch = scratch[:0]
+		if attr != nil {
+			_, attrs := Element(buf[start:end])
+			value, err := Attr(attrs, attr)
+			if err != nil {
+				return agg, err
+			}
+			scratch, err = DecodeEntitiesAppend(scratch, value)
+		} else {
+			scratch, err = elementText(scratch, buf[start:end])
+		}
+		if err != nil {
+			return agg, err
+		}
+		value := bytes.TrimSpace(scratch)
+		if len(value) == 0 {
+			continue
+		}
+		num, err := strconv.ParseFloat(String(value), 64)
+		if err != nil {
+			return agg, fmt.Errorf("failed to parse %q at offset %d: %w", value, start, err)
+		}
+		agg.add(num)
+	}
+}
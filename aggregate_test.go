@@ -0,0 +1,33 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregatePath(t *testing.T) {
+	doc := []byte(`<orders>
+		<order total="10.5"><amount> 3 </amount></order>
+		<order total="&#49;"><amount>-1.5<!-- note --></amount></order>
+		<order><amount/></order>
+		<order total="bad"><amount>4e1</amount></order>
+	</orders>`)
+	agg, err := AggregatePath(doc, "orders/order/amount")
+	assert.NoError(t, err)
+	assert.Equal(t, Aggregate{Count: 3, Sum: 41.5, Min: -1.5, Max: 40}, agg)
+	assert.InDelta(t, 13.833, agg.Mean(), 0.001)
+
+	_, err = AggregatePath(doc, "orders/order/@total")
+	assert.EqualError(t, err, `failed to parse "bad" at offset 155: strconv.ParseFloat: parsing "bad": invalid syntax`)
+
+	_, err = AggregatePath([]byte(`<orders><order><amount>1`), "orders/order/amount")
+	assert.EqualError(t, err, "unexpected EOF")
+
+	agg, err = AggregatePath(doc, "//missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, agg.Mean())
+
+	_, err = AggregatePath(doc, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// errNotStartElement is returned when an attribute is modified on a token which is not a start element
+var errNotStartElement = errors.New("expected a start element")
+
+// attrLocation is the position of an attribute within a start element token
+type attrLocation struct {
+	found      bool
+	before     int // start of the whitespace preceding the attribute
+	valueStart int // start of the value (after the opening quote)
+	valueEnd   int // end of the value (the closing quote)
+	last       int // end of the last attribute (or the name if there are none)
+}
+
+// locateAttr finds the attribute key in the start element elemToken
+func locateAttr(elemToken []byte, key []byte) (loc attrLocation, err error) {
+	if !IsElement(elemToken) || IsEndElement(elemToken) {
+		return loc, errNotStartElement
+	}
+	name, attrsToken := Element(elemToken)
+	// Offset of attrsToken within elemToken (ex: `<name `)
+	offset := len(name) + 2
+	loc.last = len(name) + 1
+	err = RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		if !loc.found && bytes.Equal(attrsToken[keyStart:keyEnd], key) {
+			loc.found = true
+			loc.before = loc.last
+			loc.valueStart, loc.valueEnd = offset+valueStart, offset+valueEnd
+		}
+		loc.last = offset + valueEnd + 1
+		return true
+	})
+	return loc, err
+}
+
+// appendNewAttr appends elemToken to dst with ` key="value"` inserted after the last attribute
+func appendNewAttr(dst []byte, elemToken []byte, last int, key []byte, value []byte) []byte {
+	dst = append(dst, elemToken[:last]...)
+	dst = append(dst, ' ')
+	dst = append(dst, key...)
+	dst = append(dst, '=', '"')
+	dst = EscapeAttr(dst, value)
+	dst = append(dst, '"')
+	return append(dst, elemToken[last:]...)
+}
+
+// SetAttr appends the start element elemToken to dst with the (decoded) value of the attribute key replaced
+// If the attribute is not present it is added after the last attribute (see AddAttr)
+// The order of the attributes and all whitespace within the element is preserved
+func SetAttr(dst []byte, elemToken []byte, key []byte, value []byte) ([]byte, error) {
+	loc, err := locateAttr(elemToken, key)
+	if err != nil {
+		return dst, err
+	}
+	if !loc.found {
+		return appendNewAttr(dst, elemToken, loc.last, key, value), nil
+	}
+	dst = append(dst, elemToken[:loc.valueStart]...)
+	dst = EscapeAttr(dst, value)
+	return append(dst, elemToken[loc.valueEnd:]...), nil
+}
+
+// AddAttr appends the start element elemToken to dst with ` key="value"` (escaping value) inserted after the last attribute
+// An error is returned if the attribute is already present
+func AddAttr(dst []byte, elemToken []byte, key []byte, value []byte) ([]byte, error) {
+	loc, err := locateAttr(elemToken, key)
+	if err != nil {
+		return dst, err
+	}
+	if loc.found {
+		return dst, fmt.Errorf("attribute %q already exists", key)
+	}
+	return appendNewAttr(dst, elemToken, loc.last, key, value), nil
+}
+
+// DeleteAttr appends the start element elemToken to dst without the attribute key and the whitespace preceding it
+// If the attribute is not present elemToken is appended unchanged
+func DeleteAttr(dst []byte, elemToken []byte, key []byte) ([]byte, error) {
+	loc, err := locateAttr(elemToken, key)
+	if err != nil {
+		return dst, err
+	}
+	if !loc.found {
+		return append(dst, elemToken...), nil
+	}
+	dst = append(dst, elemToken[:loc.before]...)
+	return append(dst, elemToken[loc.valueEnd+1:]...), nil
+}
@@ -0,0 +1,69 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAttr(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Key      string
+		Value    string
+		Expected string
+		Error    string
+	}{
+		{Input: `<doc id="1" version="1.0">`, Key: "version", Value: "2.0", Expected: `<doc id="1" version="2.0">`},
+		{Input: "<doc\n\tversion = \"1.0\"\n\tid=\"1\" />", Key: "version", Value: `"2"`, Expected: "<doc\n\tversion = \"&quot;2&quot;\"\n\tid=\"1\" />"},
+		{Input: `<doc id="1">`, Key: "version", Value: "2", Expected: `<doc id="1" version="2">`},
+		{Input: "<doc id=\"1\"\n/>", Key: "version", Value: "2", Expected: "<doc id=\"1\" version=\"2\"\n/>"},
+		{Input: `<doc/>`, Key: "version", Value: "2", Expected: `<doc version="2"/>`},
+		{Input: `</doc>`, Key: "version", Error: "expected a start element"},
+		{Input: `<doc id>`, Key: "version", Error: `expected whitespace but got "id" at offset 0`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			out, err := SetAttr(nil, []byte(tc.Input), []byte(tc.Key), []byte(tc.Value))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(out))
+			}
+		})
+	}
+}
+
+func TestAddAttr(t *testing.T) {
+	out, err := AddAttr([]byte("prefix"), []byte(`<doc  id="1" >`), []byte("lang"), []byte("en&"))
+	assert.NoError(t, err)
+	assert.Equal(t, `prefix<doc  id="1" lang="en&amp;" >`, string(out))
+	_, err = AddAttr(nil, []byte(`<doc id="1">`), []byte("id"), []byte("2"))
+	assert.EqualError(t, err, `attribute "id" already exists`)
+	_, err = AddAttr(nil, []byte(`<!-- x -->`), []byte("id"), []byte("2"))
+	assert.EqualError(t, err, "expected a start element")
+}
+
+func TestDeleteAttr(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Key      string
+		Expected string
+	}{
+		{Input: `<doc id="1" version="1.0">`, Key: "id", Expected: `<doc version="1.0">`},
+		{Input: `<doc id="1" version="1.0"/>`, Key: "version", Expected: `<doc id="1"/>`},
+		{Input: "<doc\n  a=\"1\"\n  b=\"2\"\n  c=\"3\"\n>", Key: "b", Expected: "<doc\n  a=\"1\"\n  c=\"3\"\n>"},
+		{Input: `<doc id="1">`, Key: "missing", Expected: `<doc id="1">`},
+		{Input: `<doc>`, Key: "id", Expected: `<doc>`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			out, err := DeleteAttr(nil, []byte(tc.Input), []byte(tc.Key))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(out))
+		})
+	}
+	_, err := DeleteAttr(nil, []byte(`text`), []byte("id"))
+	assert.EqualError(t, err, "expected a start element")
+}
@@ -0,0 +1,80 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+// Allocate once instead of on each bytes.HasPrefix call
+var prefixXMLNS = []byte("xmlns")
+
+// isXMLNS determines if an attribute key is a namespace declaration (xmlns or xmlns:prefix)
+func isXMLNS(key []byte) bool {
+	return bytes.HasPrefix(key, prefixXMLNS) && (len(key) == 5 || key[5] == ':')
+}
+
+// lessAttr orders namespace declarations first then everything else lexicographically by key
+func lessAttr(a, b []byte) bool {
+	if nsA, nsB := isXMLNS(a), isXMLNS(b); nsA != nsB {
+		return nsA
+	}
+	return bytes.Compare(a, b) < 0
+}
+
+// sortRawAttrs sorts attrs using lessAttr
+func sortRawAttrs(attrs []Attribute) {
+	sort.SliceStable(attrs, func(i, j int) bool {
+		return lessAttr(attrs[i].Key, attrs[j].Key)
+	})
+}
+
+// SortAttrs appends src to dst with the attributes of each start element in a deterministic order:
+// namespace declarations first followed by all other attributes, each ordered lexicographically
+// Start elements which are already sorted and all other tokens are copied unchanged
+func SortAttrs(dst, src []byte) ([]byte, error) {
+	var attrs []Attribute
+	s := NewScanner(src)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return dst, nil
+		} else if err != nil {
+			return dst, err
+		}
+		if chardata || !IsElement(token) || IsEndElement(token) {
+			dst = append(dst, token...)
+			continue
+		}
+		name, attrsToken := Element(token)
+		attrs = attrs[:0]
+		sorted := true
+		if err := Attrs(attrsToken, func(key, value []byte) bool {
+			if len(attrs) > 0 && lessAttr(key, attrs[len(attrs)-1].Key) {
+				sorted = false
+			}
+			attrs = append(attrs, Attribute{Key: key, Value: value})
+			return true
+		}); err != nil {
+			return dst, err
+		}
+		if sorted {
+			dst = append(dst, token...)
+			continue
+		}
+		sortRawAttrs(attrs)
+		dst = append(dst, '<')
+		dst = append(dst, name...)
+		for _, attr := range attrs {
+			dst = append(dst, ' ')
+			dst = append(dst, attr.Key...)
+			dst = append(dst, '=', '"')
+			dst = append(dst, attr.Value...)
+			dst = append(dst, '"')
+		}
+		if IsSelfClosing(token) {
+			dst = append(dst, '/')
+		}
+		dst = append(dst, '>')
+	}
+}
@@ -0,0 +1,47 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortAttrs(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+		Error    string
+	}{
+		{
+			Input:    `<root b="2" a="1">text</root>`,
+			Expected: `<root a="1" b="2">text</root>`,
+		},
+		{
+			Input:    `<root id="1" xmlns:b="urn:b" xmlns="urn:default" xmlns:a="urn:a"/>`,
+			Expected: `<root xmlns="urn:default" xmlns:a="urn:a" xmlns:b="urn:b" id="1"/>`,
+		},
+		{
+			Input:    "<!-- untouched --><root  a=\"1\"\n  b=\"2\" >",
+			Expected: "<!-- untouched --><root  a=\"1\"\n  b=\"2\" >",
+		},
+		{
+			Input:    `<root xmlnsfoo="1" a="2"/>`,
+			Expected: `<root a="2" xmlnsfoo="1"/>`,
+		},
+		{
+			Input: `<root b="2" a="1>`,
+			Error: `expected Token to end with '>' at offset 0`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			actual, err := SortAttrs([]byte("prefix"), []byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "prefix"+tc.Expected, string(actual))
+			}
+		})
+	}
+}
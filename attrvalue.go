@@ -0,0 +1,85 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// attrDecoded reads a specific attribute decoding its value into scratch (if needed)
+func attrDecoded(attrsToken []byte, key []byte, scratch []byte) (value []byte, ok bool, err error) {
+	raw, err := Attr(attrsToken, key)
+	if err != nil || raw == nil {
+		return nil, false, err
+	}
+	if value, err = DecodeEntities(raw, scratch[:0]); err != nil {
+		return nil, true, fmt.Errorf("attribute %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// AttrString reads a specific attribute and returns the decoded value, ok is false if it is not present
+// scratch is an optional existing byte slice used to decode the value
+func AttrString(attrsToken []byte, key []byte, scratch []byte) (value string, ok bool, err error) {
+	decoded, ok, err := attrDecoded(attrsToken, key, scratch)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	return string(decoded), true, nil
+}
+
+// AttrInt reads a specific attribute and parses the (decoded) value as a base 10 integer
+// ok is false if it is not present, surrounding whitespace is ignored
+func AttrInt(attrsToken []byte, key []byte, scratch []byte) (value int64, ok bool, err error) {
+	decoded, ok, err := attrDecoded(attrsToken, key, scratch)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = strconv.ParseInt(String(bytes.TrimSpace(decoded)), 10, 64); err != nil {
+		return 0, true, fmt.Errorf("attribute %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// AttrFloat reads a specific attribute and parses the (decoded) value as a float64
+// ok is false if it is not present, surrounding whitespace is ignored
+func AttrFloat(attrsToken []byte, key []byte, scratch []byte) (value float64, ok bool, err error) {
+	decoded, ok, err := attrDecoded(attrsToken, key, scratch)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if value, err = strconv.ParseFloat(String(bytes.TrimSpace(decoded)), 64); err != nil {
+		return 0, true, fmt.Errorf("attribute %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// AttrBool reads a specific attribute and parses the (decoded) value as an xs:boolean ("true", "false", "1" or "0")
+// ok is false if it is not present, surrounding whitespace is ignored
+func AttrBool(attrsToken []byte, key []byte, scratch []byte) (value bool, ok bool, err error) {
+	decoded, ok, err := attrDecoded(attrsToken, key, scratch)
+	if !ok || err != nil {
+		return false, ok, err
+	}
+	switch String(bytes.TrimSpace(decoded)) {
+	case "true", "1":
+		return true, true, nil
+	case "false", "0":
+		return false, true, nil
+	}
+	return false, true, fmt.Errorf("attribute %q: invalid boolean %q", key, decoded)
+}
+
+// AttrTime reads a specific attribute and parses the (decoded) value with time.Parse and layout
+// ok is false if it is not present, surrounding whitespace is ignored
+func AttrTime(attrsToken []byte, key []byte, layout string, scratch []byte) (value time.Time, ok bool, err error) {
+	decoded, ok, err := attrDecoded(attrsToken, key, scratch)
+	if !ok || err != nil {
+		return time.Time{}, ok, err
+	}
+	if value, err = time.Parse(layout, String(bytes.TrimSpace(decoded))); err != nil {
+		return time.Time{}, true, fmt.Errorf("attribute %q: %w", key, err)
+	}
+	return value, true, nil
+}
@@ -0,0 +1,96 @@
+package fastxml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrString(t *testing.T) {
+	attrs := []byte(` name="a &amp; b" empty="" bad="&bogus;"`)
+	value, ok, err := AttrString(attrs, []byte("name"), make([]byte, 0, 16))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a & b", value)
+	value, ok, err = AttrString(attrs, []byte("empty"), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+	_, ok, err = AttrString(attrs, []byte("missing"), nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = AttrString(attrs, []byte("bad"), nil)
+	assert.EqualError(t, err, `attribute "bad": unknown XML entity "bogus" at offset 0`)
+	assert.True(t, ok)
+	_, _, err = AttrString([]byte(` x=1`), []byte("y"), nil)
+	assert.Error(t, err)
+}
+
+func TestAttrInt(t *testing.T) {
+	attrs := []byte(` n=" -42 " hex="0x10" ref="&#49;2"`)
+	value, ok, err := AttrInt(attrs, []byte("n"), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-42), value)
+	value, _, err = AttrInt(attrs, []byte("ref"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), value)
+	_, ok, err = AttrInt(attrs, []byte("hex"), nil)
+	assert.EqualError(t, err, `attribute "hex": strconv.ParseInt: parsing "0x10": invalid syntax`)
+	assert.True(t, ok)
+	_, ok, err = AttrInt(attrs, []byte("missing"), nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestAttrFloat(t *testing.T) {
+	attrs := []byte(` f="1.5e3" bad="x"`)
+	value, ok, err := AttrFloat(attrs, []byte("f"), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1500.0, value)
+	_, _, err = AttrFloat(attrs, []byte("bad"), nil)
+	assert.Error(t, err)
+}
+
+func TestAttrBool(t *testing.T) {
+	testCases := []struct {
+		Value    string
+		Expected bool
+		Error    bool
+	}{
+		{Value: "true", Expected: true},
+		{Value: " 1 ", Expected: true},
+		{Value: "false"},
+		{Value: "0"},
+		{Value: "TRUE", Error: true},
+		{Value: "yes", Error: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Value, func(t *testing.T) {
+			value, ok, err := AttrBool([]byte(` b="`+tc.Value+`"`), []byte("b"), nil)
+			assert.True(t, ok)
+			if tc.Error {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, value)
+		})
+	}
+}
+
+func TestAttrTime(t *testing.T) {
+	attrs := []byte(` at="2026-10-17T01:02:03Z" bad="yesterday"`)
+	value, ok, err := AttrTime(attrs, []byte("at"), time.RFC3339, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 17, 1, 2, 3, 0, time.UTC), value)
+	_, ok, err = AttrTime(attrs, []byte("bad"), time.RFC3339, nil)
+	assert.True(t, ok)
+	assert.Error(t, err)
+	_, ok, err = AttrTime(attrs, []byte("missing"), time.RFC3339, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
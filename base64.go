@@ -0,0 +1,114 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// base64Chunk is the number of encoded bytes decoded and written at once (a multiple of 4)
+const base64Chunk = 4096
+
+// errBase64Element is returned by DecodeBase64Content when the content contains an element
+var errBase64Element = errors.New("unexpected element in base64 content")
+
+// base64Writer decodes standard base64 written in pieces of any length, ignoring XML whitespace
+type base64Writer struct {
+	w       io.Writer
+	enc     []byte // encoded bytes not decoded yet
+	dec     []byte
+	padded  bool  // if the decoded data ended with padding, no more data may follow
+	written int64 // encoded bytes decoded so far, used for the offset of errors
+}
+
+// write appends the base64 in b dropping whitespace, decoding each complete chunk
+func (bw *base64Writer) write(b []byte) error {
+	for _, c := range b {
+		if isSpace(c) {
+			continue
+		}
+		bw.enc = append(bw.enc, c)
+		if len(bw.enc) == base64Chunk {
+			if err := bw.decode(base64Chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decode decodes and writes the first n (a multiple of 4) encoded bytes
+func (bw *base64Writer) decode(n int) error {
+	if n == 0 {
+		return nil
+	}
+	if bw.padded {
+		return base64.CorruptInputError(bw.written)
+	}
+	if cap(bw.dec) < base64.StdEncoding.DecodedLen(n) {
+		bw.dec = make([]byte, base64.StdEncoding.DecodedLen(n))
+	}
+	size, err := base64.StdEncoding.Decode(bw.dec[:cap(bw.dec)], bw.enc[:n])
+	if err != nil {
+		if corrupt, ok := err.(base64.CorruptInputError); ok {
+			return base64.CorruptInputError(bw.written + int64(corrupt))
+		}
+		return err
+	}
+	bw.padded = bw.enc[n-1] == '='
+	bw.written += int64(n)
+	if _, err := bw.w.Write(bw.dec[:size]); err != nil {
+		return err
+	}
+	bw.enc = append(bw.enc[:0], bw.enc[n:]...)
+	return nil
+}
+
+// flush decodes the remaining encoded bytes which must be a multiple of 4
+func (bw *base64Writer) flush() error {
+	if len(bw.enc)%4 != 0 {
+		return base64.CorruptInputError(bw.written + int64(len(bw.enc)&^3))
+	}
+	return bw.decode(len(bw.enc))
+}
+
+// DecodeBase64Content decodes the standard base64 content of the element most recently returned by s
+// (which must not be self-closing) writing the bytes to w as they are decoded, s is advanced past its end element
+// CharData may be split by comments and contain whitespace, CDATA sections or character references but the
+// element must not contain child elements. Only a few KB of the content is held in memory at once
+func DecodeBase64Content(s *Scanner, w io.Writer) error {
+	bw := &base64Writer{w: w, enc: make([]byte, 0, base64Chunk)}
+	var scratch []byte
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if !chardata {
+			if IsEndElement(token) {
+				return bw.flush()
+			} else if IsElement(token) {
+				return fmt.Errorf("%w at offset %d", errBase64Element, offset)
+			}
+			continue
+		}
+		// Only references are decoded into scratch, CDATA sections and plain text are used as-is
+		text := token
+		if bytes.HasPrefix(token, prefixCDATA) && bytes.HasSuffix(token, suffixCDATA) {
+			text = token[len(prefixCDATA) : len(token)-len(suffixCDATA)]
+		} else if bytes.IndexByte(token, '&') != -1 {
+			if scratch, err = DecodeEntitiesAppend(scratch[:0], token); err != nil {
+				return err
+			}
+			text = scratch
+		}
+		if err := bw.write(text); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBase64Content(t *testing.T) {
+	testCases := []struct {
+		Input  string
+		Output string
+		Err    string
+	}{
+		{Input: `<a>aGVsbG8=</a>`, Output: "hello"},
+		{Input: `<a></a>`, Output: ""},
+		{Input: "<a>\n  aGVs\n  bG8g\n  d29y<!-- split -->bGQ=\n</a>", Output: "hello world"},
+		{Input: `<a><![CDATA[aGVs]]>bG8&#x3D;</a>`, Output: "hello"},
+		{Input: `<a>aGVsbG8</a>`, Err: "illegal base64 data at input byte 4"},
+		{Input: `<a>aGVs!G8=</a>`, Err: "illegal base64 data at input byte 4"},
+		{Input: `<a>aA==aA==</a>`, Err: "illegal base64 data at input byte 4"},
+		{Input: `<a>aGVs<b/>bG8=</a>`, Err: "unexpected element in base64 content at offset 7"},
+		{Input: `<a>aGVsbG8=`, Err: io.ErrUnexpectedEOF.Error()},
+	}
+	for _, tc := range testCases {
+		input := tc.Input
+		if tc.Err == "" {
+			input += `<next/>`
+		}
+		s := NewScanner([]byte(input))
+		_, _, err := s.Next()
+		assert.NoError(t, err)
+		var out bytes.Buffer
+		err = DecodeBase64Content(s, &out)
+		if tc.Err != "" {
+			assert.EqualError(t, err, tc.Err, tc.Input)
+			continue
+		}
+		if assert.NoError(t, err, tc.Input) {
+			assert.Equal(t, tc.Output, out.String(), tc.Input)
+			token, _, _ := s.Next()
+			assert.Equal(t, `<next/>`, string(token), tc.Input)
+		}
+	}
+}
+
+func TestDecodeBase64Content_Large(t *testing.T) {
+	data := make([]byte, 3*base64Chunk+7)
+	rand.New(rand.NewSource(1)).Read(data)
+	encoded := base64.StdEncoding.EncodeToString(data)
+	// Wrap the lines as MIME does
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	lines = append(lines, encoded)
+	s := NewScanner([]byte("<blob>\n" + strings.Join(lines, "\n") + "\n</blob>"))
+	_, _, _ = s.Next()
+	var out bytes.Buffer
+	assert.NoError(t, DecodeBase64Content(s, &out))
+	assert.Equal(t, data, out.Bytes())
+
+	// A corrupt byte in a later chunk is reported at its offset in the content
+	corrupt := base64.StdEncoding.EncodeToString(data)
+	corrupt = corrupt[:2*base64Chunk+5] + "!" + corrupt[2*base64Chunk+6:]
+	s = NewScanner([]byte("<blob>" + corrupt + "</blob>"))
+	_, _, _ = s.Next()
+	err := DecodeBase64Content(s, &bytes.Buffer{})
+	assert.Equal(t, base64.CorruptInputError(2*base64Chunk+5), err)
+
+	errWrite := errors.New("write failed")
+	s = NewScanner([]byte("<blob>aGVsbG8=</blob>"))
+	_, _, _ = s.Next()
+	assert.Equal(t, errWrite, DecodeBase64Content(s, failWriter{errWrite}))
+}
+
+// failWriter returns err from every Write
+type failWriter struct {
+	err error
+}
+
+func (fw failWriter) Write([]byte) (int, error) {
+	return 0, fw.err
+}
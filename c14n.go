@@ -0,0 +1,415 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+)
+
+// c14nFlushSize is the size of the buffered output before Canonicalize writes it to w
+const c14nFlushSize = 32 * 1024
+
+// c14nAttr is an attribute of an element being canonicalized
+type c14nAttr struct {
+	key        []byte
+	space      string
+	local      []byte
+	start, end int // decoded value in canonicalizer.values
+}
+
+// canonicalizer holds the state of Canonicalize
+type canonicalizer struct {
+	ns       Namespaces
+	rendered []nsBinding // namespace declarations written by each output element
+	marks    []int       // len(rendered) before each open element
+	names    [][]byte    // names of the open elements
+	decls    []nsBinding
+	attrs    []c14nAttr
+	values   []byte
+	text     []byte
+	seenRoot bool
+	// inclusive is the InclusiveNamespaces PrefixList (nil for the default namespace)
+	inclusive [][]byte
+	// undeclared writes prefixes which are not in scope without a declaration instead of failing (see HashSubtree)
+	undeclared bool
+}
+
+// Canonicalize writes the Exclusive XML Canonicalization 1.0 (without comments) of buf to w
+// Comments, the XML declaration and the DOCTYPE are removed, CDATA sections and entities are replaced
+// by their (escaped) text, line endings and attribute values are normalized, empty elements are written
+// as a start/end pair and only the namespace declarations visibly utilized by an element (and not
+// already in scope of the output) are written, with the namespace declarations and attributes sorted
+// Definitions from an internal DTD subset (default attributes, custom entities) are not applied
+// The input is assumed to be well-formed, untrusted input should be checked with Validate first
+func Canonicalize(buf []byte, w io.Writer) error {
+	var c canonicalizer
+	var out []byte
+	s := NewScanner(buf)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if out, err = c.token(out, token, chardata); err != nil {
+			return err
+		}
+		if len(out) >= c14nFlushSize {
+			if _, err := w.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+	}
+	if len(c.names) > 0 {
+		return fmt.Errorf("unclosed element <%s>", c.names[len(c.names)-1])
+	}
+	if len(out) > 0 {
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanonicalizeOptions configures CanonicalizeElement
+type CanonicalizeOptions struct {
+	// InclusivePrefixes is the InclusiveNamespaces PrefixList, these prefixes ("#default" for the default
+	// namespace) are treated as in inclusive canonicalization: declared if in scope even if not visibly utilized
+	InclusivePrefixes []string
+	// Exclude (if set) is called with the offset of every start element within the element, the element
+	// and its content are removed if it returns true (ex: for the enveloped-signature transform)
+	Exclude func(offset int) bool
+}
+
+// CanonicalizeElement writes the Exclusive XML Canonicalization 1.0 (without comments) of the element starting at
+// offset start of buf to w, the same as Canonicalize does for a document. The namespace declarations of its
+// ancestors are in scope (and written where visibly utilized) so the output is the same regardless of where
+// the element is in the document, as is needed to compute the digest of a signed element
+func CanonicalizeElement(buf []byte, start int, w io.Writer, opts *CanonicalizeOptions) error {
+	var c canonicalizer
+	if opts == nil {
+		opts = &CanonicalizeOptions{}
+	}
+	for _, prefix := range opts.InclusivePrefixes {
+		if prefix == "#default" {
+			c.inclusive = append(c.inclusive, nil)
+		} else {
+			c.inclusive = append(c.inclusive, []byte(prefix))
+		}
+	}
+	var out []byte
+	s := NewScanner(buf)
+	// Track the namespace declarations of the ancestors of the element
+	for {
+		offset := s.Offset()
+		if offset == start {
+			break
+		} else if offset > start {
+			return fmt.Errorf("no element at offset %d", start)
+		}
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return fmt.Errorf("no element at offset %d", start)
+		} else if err != nil {
+			return err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if !IsEndElement(token) {
+			if err := c.ns.Push(token); err != nil {
+				return err
+			}
+			if !IsSelfClosing(token) {
+				continue
+			}
+		}
+		if err := c.ns.Pop(); err != nil {
+			return err
+		}
+	}
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if offset == start && (chardata || !IsElement(token) || IsEndElement(token)) {
+			return fmt.Errorf("no element at offset %d", start)
+		}
+		if offset != start && opts.Exclude != nil && !chardata && IsElement(token) && !IsEndElement(token) && opts.Exclude(offset) {
+			if !IsSelfClosing(token) {
+				if err := s.Skip(); err == io.EOF {
+					return io.ErrUnexpectedEOF
+				} else if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if out, err = c.token(out, token, chardata); err != nil {
+			return err
+		}
+		if len(c.names) == 0 {
+			break
+		}
+		if len(out) >= c14nFlushSize {
+			if _, err := w.Write(out); err != nil {
+				return err
+			}
+			out = out[:0]
+		}
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+// HashSubtree writes the Exclusive XML Canonicalization 1.0 (without comments) of the element most recently returned
+// by s (which must be a start element) to h, s is advanced past the element. The canonical form is buffered in
+// chunks so the subtree is never copied, making the sum of h a key for the content of the element (ex: to dedupe records)
+// Unlike CanonicalizeElement the namespace declarations of its ancestors are not known, a prefix they declare
+// is hashed as written without its declaration so the key depends on the prefix and not the namespace URI
+func HashSubtree(s *Scanner, h hash.Hash) error {
+	start, end := s.TokenRange()
+	token := s.buf[start:end]
+	if !IsElement(token) || IsEndElement(token) {
+		return errNotStartElement
+	}
+	c := canonicalizer{undeclared: true}
+	out, err := c.token(nil, token, false)
+	if err != nil {
+		return err
+	}
+	for len(c.names) > 0 {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if out, err = c.token(out, token, chardata); err != nil {
+			return err
+		}
+		if len(out) >= c14nFlushSize {
+			h.Write(out)
+			out = out[:0]
+		}
+	}
+	h.Write(out)
+	return nil
+}
+
+// token appends the canonical form of token to out
+func (c *canonicalizer) token(out []byte, token []byte, chardata bool) ([]byte, error) {
+	switch {
+	case chardata:
+		// Whitespace outside of the document element is removed
+		if len(c.names) == 0 {
+			return out, nil
+		}
+		if bytes.HasPrefix(token, prefixCDATA) && bytes.HasSuffix(token, suffixCDATA) {
+			c.values = appendNormalized(c.values[:0], token[9:len(token)-3], false)
+		} else {
+			var err error
+			c.text = appendNormalized(c.text[:0], token, false)
+			if c.values, err = DecodeEntitiesAppend(c.values[:0], c.text); err != nil {
+				return out, err
+			}
+		}
+		return EscapeText(out, c.values), nil
+	case IsComment(token), IsDirective(token):
+		return out, nil
+	case IsProcInst(token):
+		target, inst := ProcInst(token)
+		if String(target) == "xml" {
+			return out, nil
+		}
+		if len(c.names) == 0 && c.seenRoot {
+			out = append(out, '\n')
+		}
+		out = append(out, '<', '?')
+		out = append(out, target...)
+		if inst = bytes.TrimLeft(inst, " \t\r\n"); len(inst) > 0 {
+			out = append(out, ' ')
+			out = appendNormalized(out, inst, false)
+		}
+		out = append(out, '?', '>')
+		if len(c.names) == 0 && !c.seenRoot {
+			out = append(out, '\n')
+		}
+		return out, nil
+	case IsEndElement(token):
+		if len(c.names) == 0 {
+			return out, errUnexpectedEnd
+		}
+		name, _ := Element(token)
+		return c.end(out, name)
+	}
+	return c.start(out, token)
+}
+
+// start appends the canonical form of the start element token to out
+func (c *canonicalizer) start(out []byte, token []byte) ([]byte, error) {
+	if err := c.ns.Push(token); err != nil {
+		return out, err
+	}
+	name, attrsToken := Element(token)
+	c.names = append(c.names, name)
+	c.marks = append(c.marks, len(c.rendered))
+	c.seenRoot = true
+
+	// Collect the attributes with their normalized values
+	c.attrs, c.values, c.decls = c.attrs[:0], c.values[:0], c.decls[:0]
+	var attrErr error
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		if isXMLNS(key) {
+			return true
+		}
+		start := len(c.values)
+		c.text = appendNormalized(c.text[:0], value, true)
+		if c.values, attrErr = DecodeEntitiesAppend(c.values, c.text); attrErr != nil {
+			return false
+		}
+		space, local := c.ns.Resolve(key, true)
+		c.attrs = append(c.attrs, c14nAttr{key: key, space: space, local: local, start: start, end: len(c.values)})
+		return true
+	}); err != nil {
+		return out, err
+	} else if attrErr != nil {
+		return out, attrErr
+	}
+
+	// Declare the namespaces visibly utilized by the element and its attributes
+	prefix, _ := Name(name)
+	if err := c.utilize(prefix); err != nil {
+		return out, err
+	}
+	for _, attr := range c.attrs {
+		if prefix, _ := Name(attr.key); prefix != nil {
+			if err := c.utilize(prefix); err != nil {
+				return out, err
+			}
+		}
+	}
+	for _, prefix := range c.inclusive {
+		if _, ok := c.ns.Lookup(prefix); ok {
+			if err := c.utilize(prefix); err != nil {
+				return out, err
+			}
+		}
+	}
+	sort.Slice(c.decls, func(i, j int) bool {
+		return c.decls[i].prefix < c.decls[j].prefix
+	})
+	sort.Slice(c.attrs, func(i, j int) bool {
+		if c.attrs[i].space != c.attrs[j].space {
+			return c.attrs[i].space < c.attrs[j].space
+		}
+		return bytes.Compare(c.attrs[i].local, c.attrs[j].local) < 0
+	})
+
+	out = append(out, '<')
+	out = append(out, name...)
+	for _, decl := range c.decls {
+		out = append(out, " xmlns"...)
+		if decl.prefix != "" {
+			out = append(out, ':')
+			out = append(out, decl.prefix...)
+		}
+		out = append(out, '=', '"')
+		out = EscapeAttr(out, []byte(decl.url))
+		out = append(out, '"')
+	}
+	for _, attr := range c.attrs {
+		out = append(out, ' ')
+		out = append(out, attr.key...)
+		out = append(out, '=', '"')
+		out = EscapeAttr(out, c.values[attr.start:attr.end])
+		out = append(out, '"')
+	}
+	out = append(out, '>')
+	if IsSelfClosing(token) {
+		return c.end(out, name)
+	}
+	return out, nil
+}
+
+// utilize declares prefix on the current element unless the output already has it in scope
+func (c *canonicalizer) utilize(prefix []byte) error {
+	if String(prefix) == "xml" {
+		return nil
+	}
+	for _, decl := range c.decls {
+		if decl.prefix == String(prefix) {
+			return nil
+		}
+	}
+	url, ok := c.ns.Lookup(prefix)
+	if !ok && prefix != nil {
+		if c.undeclared {
+			return nil
+		}
+		return fmt.Errorf("undeclared namespace prefix %q", prefix)
+	}
+	// Skip if the nearest declaration written to the output matches (the default namespace is initially empty)
+	renderedURL, rendered := "", prefix == nil
+	for idx := len(c.rendered) - 1; idx >= 0; idx-- {
+		if c.rendered[idx].prefix == String(prefix) {
+			renderedURL, rendered = c.rendered[idx].url, true
+			break
+		}
+	}
+	if rendered && url == renderedURL {
+		return nil
+	}
+	decl := nsBinding{prefix: string(prefix), url: url}
+	c.decls = append(c.decls, decl)
+	c.rendered = append(c.rendered, decl)
+	return nil
+}
+
+// end appends the end element of the current element to out, name is the name of the end element read
+// which must match the start element so mismatched input can not canonicalize the same as well-formed input
+func (c *canonicalizer) end(out []byte, name []byte) ([]byte, error) {
+	if open := c.names[len(c.names)-1]; !bytes.Equal(name, open) {
+		return out, fmt.Errorf("end element </%s> does not match start element <%s>", name, open)
+	}
+	if err := c.ns.Pop(); err != nil {
+		return out, err
+	}
+	out = append(out, '<', '/')
+	out = append(out, c.names[len(c.names)-1]...)
+	out = append(out, '>')
+	c.names = c.names[:len(c.names)-1]
+	c.rendered = c.rendered[:c.marks[len(c.marks)-1]]
+	c.marks = c.marks[:len(c.marks)-1]
+	return out, nil
+}
+
+// appendNormalized appends src to dst replacing "\r\n" and "\r" with "\n"
+// if attr is true literal whitespace is also replaced with ' ' as in attribute-value normalization
+func appendNormalized(dst []byte, src []byte, attr bool) []byte {
+	for idx := 0; idx < len(src); idx++ {
+		switch b := src[idx]; {
+		case b == '\r' && idx+1 < len(src) && src[idx+1] == '\n':
+			continue
+		case b == '\r', b == '\n':
+			if attr {
+				dst = append(dst, ' ')
+			} else {
+				dst = append(dst, '\n')
+			}
+		case b == '\t' && attr:
+			dst = append(dst, ' ')
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
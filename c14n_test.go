@@ -0,0 +1,224 @@
+package fastxml
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalize(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "Prolog",
+			Input:    "<?xml version=\"1.0\"?>\n<!DOCTYPE doc>\n<!-- c -->\n<?pi   data?>\n<doc/>\n<!-- after -->\n<?end?>\n",
+			Expected: "<?pi data?>\n<doc></doc>\n<?end?>",
+		},
+		{
+			Name:  "Attributes",
+			Input: `<doc b="2" a="&lt;1&gt;" c='3'/>`,
+			Error: `expected Attr to start with '"' at offset 22`,
+		},
+		{
+			Name:     "SortAttributes",
+			Input:    `<doc b="2" a="&lt;1&gt;"></doc>`,
+			Expected: `<doc a="&lt;1>" b="2"></doc>`,
+		},
+		{
+			Name:     "Text",
+			Input:    "<doc>\r\n<![CDATA[x < y & z]]>&#65;&apos;&quot;&gt;&#xD;\r</doc>",
+			Expected: "<doc>\nx &lt; y &amp; zA'\"&gt;&#xD;\n</doc>",
+		},
+		{
+			Name:     "AttributeNormalization",
+			Input:    "<doc a=\"1\t2\r\n3&#x9;4&#10;\"></doc>",
+			Expected: `<doc a="1 2 3&#x9;4&#xA;"></doc>`,
+		},
+		{
+			Name:     "Exclusive",
+			Input:    `<n0:a xmlns:n0="urn:0" xmlns:n1="urn:1" xmlns="urn:d"><n0:b xmlns:n0="urn:0"><c n1:x="1" y="2" xml:lang="en"/></n0:b></n0:a>`,
+			Expected: `<n0:a xmlns:n0="urn:0"><n0:b><c xmlns="urn:d" xmlns:n1="urn:1" y="2" xml:lang="en" n1:x="1"></c></n0:b></n0:a>`,
+		},
+		{
+			Name:     "Redeclared",
+			Input:    `<a:a xmlns:a="urn:1"><a:b xmlns:a="urn:2"/><a:c/></a:a>`,
+			Expected: `<a:a xmlns:a="urn:1"><a:b xmlns:a="urn:2"></a:b><a:c></a:c></a:a>`,
+		},
+		{
+			Name:     "DefaultUndeclared",
+			Input:    `<a xmlns="urn:a"><b xmlns=""><c/></b></a>`,
+			Expected: `<a xmlns="urn:a"><b xmlns=""><c></c></b></a>`,
+		},
+		{
+			Name:     "DefaultEmpty",
+			Input:    `<a xmlns=""><b/></a>`,
+			Expected: `<a><b></b></a>`,
+		},
+		{
+			Name:  "UndeclaredPrefix",
+			Input: `<a:b/>`,
+			Error: `undeclared namespace prefix "a"`,
+		},
+		{
+			Name:  "Unclosed",
+			Input: `<a><b></b>`,
+			Error: `unclosed element <a>`,
+		},
+		{
+			Name:  "UnexpectedEnd",
+			Input: `<a></a></b>`,
+			Error: `unexpected end element`,
+		},
+		{
+			Name:  "MismatchedEnd",
+			Input: `<a ID="x"><b>t</c></z>`,
+			Error: `end element </c> does not match start element <b>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var sb strings.Builder
+			err := Canonicalize([]byte(tc.Input), &sb)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.Expected, sb.String())
+			}
+		})
+	}
+}
+
+func TestCanonicalize_Flush(t *testing.T) {
+	input := "<doc>" + strings.Repeat("<a>text</a>", c14nFlushSize/10) + "</doc>"
+	var sb strings.Builder
+	if assert.NoError(t, Canonicalize([]byte(input), &sb)) {
+		assert.Equal(t, input, sb.String())
+	}
+}
+
+func TestCanonicalizeElement(t *testing.T) {
+	const input = `<?xml version="1.0"?>
+<r:Response xmlns:r="urn:r" xmlns:a="urn:a" xmlns="urn:d" xmlns:x="urn:x"><other/><a:Assertion ID="1">
+	<a:Issuer>me</a:Issuer><Signature><Value/></Signature><x:empty/>
+</a:Assertion></r:Response>`
+	start := strings.Index(input, "<a:Assertion")
+	signature := strings.Index(input, "<Signature>")
+	testCases := []struct {
+		Name     string
+		Start    int
+		Options  *CanonicalizeOptions
+		Expected string
+		Error    string
+	}{
+		{
+			Name:  "Element",
+			Start: start,
+			Expected: `<a:Assertion xmlns:a="urn:a" ID="1">
+	<a:Issuer>me</a:Issuer><Signature xmlns="urn:d"><Value></Value></Signature><x:empty xmlns:x="urn:x"></x:empty>
+</a:Assertion>`,
+		},
+		{
+			Name:    "Exclude",
+			Start:   start,
+			Options: &CanonicalizeOptions{Exclude: func(offset int) bool { return offset == signature }},
+			Expected: `<a:Assertion xmlns:a="urn:a" ID="1">
+	<a:Issuer>me</a:Issuer><x:empty xmlns:x="urn:x"></x:empty>
+</a:Assertion>`,
+		},
+		{
+			Name:    "InclusivePrefixes",
+			Start:   start,
+			Options: &CanonicalizeOptions{InclusivePrefixes: []string{"#default", "x", "undeclared"}},
+			Expected: `<a:Assertion xmlns="urn:d" xmlns:a="urn:a" xmlns:x="urn:x" ID="1">
+	<a:Issuer>me</a:Issuer><Signature><Value></Value></Signature><x:empty></x:empty>
+</a:Assertion>`,
+		},
+		{
+			Name:     "SelfClosing",
+			Start:    strings.Index(input, "<other/>"),
+			Expected: `<other xmlns="urn:d"></other>`,
+		},
+		{
+			Name:  "NotElement",
+			Start: start + 1,
+			Error: "no element at offset 105",
+		},
+		{
+			Name:  "EndElement",
+			Start: strings.Index(input, "</a:Assertion>"),
+			Error: "no element at offset 191",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var sb strings.Builder
+			err := CanonicalizeElement([]byte(input), tc.Start, &sb, tc.Options)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.Expected, sb.String())
+			}
+		})
+	}
+	assert.Equal(t, io.ErrUnexpectedEOF, CanonicalizeElement([]byte(`<a><b>`), 3, &strings.Builder{}, nil))
+}
+
+func TestHashSubtree(t *testing.T) {
+	input := []byte(`<feed xmlns:a="urn:a">` +
+		`<item id="1" a:x="y"><name>A &amp; B</name><tag/></item>` +
+		`<item a:x="y"  id="1"><name><![CDATA[A & B]]></name><!-- ignored --><tag></tag></item>` +
+		`<item id="2"><name>A &amp; B</name><tag/></item>` +
+		`<empty/>` +
+		`<big>` + strings.Repeat(`<v>text</v>`, c14nFlushSize/8) + `</big>` +
+		`</feed>`)
+	s := NewScanner(input)
+	var sums []string
+	var names []string
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if chardata || !IsElement(token) || IsEndElement(token) || startNamed(token, []byte("feed")) {
+			continue
+		}
+		name, _ := Element(token)
+		names = append(names, string(name))
+		h := sha256.New()
+		assert.NoError(t, HashSubtree(s, h))
+		sums = append(sums, hex.EncodeToString(h.Sum(nil)))
+	}
+	// Every element was hashed once and the Scanner continued after each
+	assert.Equal(t, []string{"item", "item", "item", "empty", "big"}, names)
+	assert.Equal(t, sums[0], sums[1])
+	assert.NotEqual(t, sums[0], sums[2])
+	// The sum is of the canonical form
+	empty := sha256.Sum256([]byte(`<empty></empty>`))
+	assert.Equal(t, hex.EncodeToString(empty[:]), sums[3])
+	var b strings.Builder
+	assert.NoError(t, CanonicalizeElement(input, bytes.Index(input, []byte("<big>")), &b, nil))
+	big := sha256.Sum256([]byte(b.String()))
+	assert.Equal(t, hex.EncodeToString(big[:]), sums[4])
+
+	// Not a start element
+	s = NewScanner([]byte(`<a></a>`))
+	s.Next()
+	s.Next()
+	assert.Equal(t, errNotStartElement, HashSubtree(s, sha256.New()))
+	// Truncated
+	s = NewScanner([]byte(`<a><b>text`))
+	s.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, HashSubtree(s, sha256.New()))
+}
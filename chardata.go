@@ -23,3 +23,35 @@ func CharDataAppend(out []byte, charToken []byte) ([]byte, error) {
 	}
 	return DecodeEntitiesAppend(out, charToken)
 }
+
+// IsWhitespace determines if a CharData token consists solely of XML whitespace (' ', '\t', '\n' and '\r')
+// CDATA sections are never considered whitespace
+func IsWhitespace(charToken []byte) bool {
+	for _, b := range charToken {
+		if !isSpace(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// TrimWhitespace removes the leading and trailing XML whitespace from a CharData token without copying
+// CDATA sections are returned unchanged and whitespace encoded as a character reference (ex: `&#32;`) is kept
+func TrimWhitespace(charToken []byte) []byte {
+	if bytes.HasPrefix(charToken, prefixCDATA) {
+		return charToken
+	}
+	return trimSpace(charToken)
+}
+
+// trimSpace removes the leading and trailing XML whitespace from b
+func trimSpace(b []byte) []byte {
+	start, end := 0, len(b)
+	for start < end && isSpace(b[start]) {
+		start++
+	}
+	for end > start && isSpace(b[end-1]) {
+		end--
+	}
+	return b[start:end]
+}
@@ -16,3 +16,19 @@ func TestCharData(t *testing.T) {
 	_, err = CharData([]byte("&invalid;"), nil)
 	assert.Error(t, err)
 }
+
+func TestIsWhitespace(t *testing.T) {
+	assert.True(t, IsWhitespace([]byte(" \t\r\n")))
+	assert.True(t, IsWhitespace(nil))
+	assert.False(t, IsWhitespace([]byte(" x ")))
+	assert.False(t, IsWhitespace([]byte("\u00a0")))
+	assert.False(t, IsWhitespace([]byte("<![CDATA[ ]]>")))
+}
+
+func TestTrimWhitespace(t *testing.T) {
+	assert.Equal(t, "a \n b", string(TrimWhitespace([]byte("\n\t a \n b \r\n"))))
+	assert.Equal(t, "", string(TrimWhitespace([]byte(" \t\r\n"))))
+	assert.Equal(t, "&#32;x", string(TrimWhitespace([]byte(" &#32;x"))))
+	assert.Equal(t, " x ", string(TrimWhitespace([]byte(" x "))))
+	assert.Equal(t, "<![CDATA[ x ]]>", string(TrimWhitespace([]byte("<![CDATA[ x ]]>"))))
+}
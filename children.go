@@ -0,0 +1,38 @@
+package fastxml
+
+import "io"
+
+// Children calls f for each direct child of the most recently processed start element named name (see NextStart)
+// returning once the end element of the parent is reached, any other child element (and its descendants) is skipped
+// f must either consume the entire child element (ex: with Skip or ElementText) or leave s untouched in which case
+// the child is skipped once f returns. Any error returned by f stops the iteration and is returned
+func Children(s *Scanner, name []byte, f func(start []byte) error) error {
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			return nil
+		}
+		offset := s.Offset()
+		if startNamed(token, name) {
+			if err := f(token); err != nil {
+				return err
+			}
+		}
+		if s.Offset() != offset || s.SelfClosing(token) {
+			continue
+		}
+		if err := s.Skip(); err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildren(t *testing.T) {
+	s := NewScanner([]byte(`<feed><title>t</title><entry id="1"/><entry id="2"><entry id="nested"/></entry>` +
+		`<other><entry id="skipped"/></other><!-- <entry> --><ns:entry id="3">text</ns:entry></feed><after/>`))
+	_, err := s.NextStart([]byte("feed"))
+	assert.NoError(t, err)
+	var ids []string
+	assert.NoError(t, Children(s, []byte("*:entry"), func(start []byte) error {
+		_, attrs := Element(start)
+		id, err := Attr(attrs, []byte("id"))
+		ids = append(ids, string(id))
+		return err
+	}))
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "<after/>", string(token))
+
+	// f consuming the child
+	s.Reset([]byte(`<list><item>a</item><skip>b</skip><item>c<b/></item></list>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	var texts []string
+	assert.NoError(t, Children(s, []byte("item"), func(start []byte) error {
+		text, err := s.ElementText(nil)
+		texts = append(texts, string(text))
+		return err
+	}))
+	assert.Equal(t, []string{"a", "c"}, texts)
+	_, _, err = s.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// Errors from f are returned
+	errStop := errors.New("stop")
+	s.Reset([]byte(`<list><item/><item/></list>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	calls := 0
+	assert.Equal(t, errStop, Children(s, []byte("item"), func(start []byte) error {
+		calls++
+		return errStop
+	}))
+	assert.Equal(t, 1, calls)
+
+	// Unclosed parent
+	s.Reset([]byte(`<list><item><x/>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, io.ErrUnexpectedEOF, Children(s, []byte("item"), func(start []byte) error {
+		return nil
+	}))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// grepOptions are the flags of the grep command
+type grepOptions struct {
+	count    bool // only print the number of matches
+	lines    bool // print the line and column instead of the offset
+	text     bool // print the text content instead of the element
+	filename bool // prefix each match with the file name
+}
+
+// grep prints the elements matching a path in each file
+func grep(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (bool, error) {
+	var opts grepOptions
+	flags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&opts.count, "c", false, "only print the number of matching elements")
+	flags.BoolVar(&opts.lines, "n", false, "print the line and column of each match instead of the byte offset")
+	flags.BoolVar(&opts.text, "text", false, "print the text content of each match instead of the element")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: fastxml grep [-c] [-n] [-text] PATH [FILE...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return false, errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return false, errUsage
+	}
+	path, err := fastxml.CompilePath(flags.Arg(0))
+	if err != nil {
+		return false, err
+	}
+	files := flags.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	opts.filename = len(files) > 1
+	w := bufio.NewWriter(stdout)
+	found := false
+	for _, name := range files {
+		r, err := openInput(name, stdin)
+		if err != nil {
+			return found, err
+		}
+		n, err := grepReader(w, name, r, path, opts)
+		r.Close()
+		if err != nil {
+			w.Flush()
+			return found, fmt.Errorf("%s: %w", name, err)
+		}
+		found = found || n > 0
+		if opts.count {
+			if opts.filename {
+				fmt.Fprintf(w, "%s:", name)
+			}
+			fmt.Fprintf(w, "%d\n", n)
+		}
+	}
+	return found, w.Flush()
+}
+
+// grepReader writes the elements in r matching path returning the number of matches
+func grepReader(w *bufio.Writer, name string, r io.Reader, path *fastxml.Path, opts grepOptions) (int, error) {
+	d := fastxml.NewDecoderReader(r)
+	m := path.Matcher()
+	count := 0
+	var match []byte // the matching element (or its text) being collected
+	var offset int64 // offset of the matching element
+	var line, column int
+	depth := 0 // nesting within the matching element, 0 if not in one
+	collect := func(token []byte, chardata bool) (err error) {
+		if !opts.text {
+			match = append(match, token...)
+		} else if chardata {
+			match, err = fastxml.CharDataAppend(match, token)
+		}
+		return err
+	}
+	for {
+		if depth == 0 {
+			offset = d.InputOffset()
+			if opts.lines {
+				line, column = d.Position()
+			}
+		}
+		token, chardata, err := d.Next()
+		if err == io.EOF {
+			if depth > 0 || m.Depth() > 0 {
+				return count, io.ErrUnexpectedEOF
+			}
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		if depth > 0 {
+			if err := collect(token, chardata); err != nil {
+				return count, err
+			}
+			if !chardata && fastxml.IsElement(token) && !fastxml.IsSelfClosing(token) {
+				if fastxml.IsEndElement(token) {
+					depth--
+				} else {
+					depth++
+				}
+			}
+			if depth > 0 {
+				continue
+			}
+		} else {
+			if chardata || !fastxml.IsElement(token) {
+				continue
+			}
+			if fastxml.IsEndElement(token) {
+				if err := m.Pop(); err != nil {
+					return count, err
+				}
+				continue
+			}
+			matched := m.Push(token)
+			if !matched {
+				if fastxml.IsSelfClosing(token) {
+					if err := m.Pop(); err != nil {
+						return count, err
+					}
+				}
+				continue
+			}
+			match = match[:0]
+			if err := collect(token, false); err != nil {
+				return count, err
+			}
+			if !fastxml.IsSelfClosing(token) {
+				// Collect the children until the end of the element
+				depth = 1
+				continue
+			}
+		}
+		// The matching element is complete
+		if err := m.Pop(); err != nil {
+			return count, err
+		}
+		count++
+		if opts.count {
+			continue
+		}
+		if opts.filename {
+			fmt.Fprintf(w, "%s:", name)
+		}
+		if opts.lines {
+			fmt.Fprintf(w, "%d:%d: ", line, column)
+		} else {
+			fmt.Fprintf(w, "%d: ", offset)
+		}
+		w.Write(match)
+		w.WriteByte('\n')
+	}
+}
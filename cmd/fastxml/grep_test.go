@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const grepInput = `<?xml version="1.0"?>
+<lib>
+ <book id="1"><title>A &amp; B</title></book>
+ <book id="2"><title>C</title><x/></book>
+ <book id="3"/>
+</lib>`
+
+func TestGrep(t *testing.T) {
+	testCases := []struct {
+		Args   []string
+		Output string
+		Status int
+	}{
+		{
+			Args:   []string{"//title"},
+			Output: "42: <title>A &amp; B</title>\n88: <title>C</title>\n",
+		},
+		{
+			Args:   []string{"lib/book[@id='2']"},
+			Output: "75: <book id=\"2\"><title>C</title><x/></book>\n",
+		},
+		{
+			Args:   []string{"//book[@id='3']"},
+			Output: "117: <book id=\"3\"/>\n",
+		},
+		{
+			Args:   []string{"-n", "-text", "//title"},
+			Output: "3:15: A & B\n4:15: C\n",
+		},
+		{
+			Args:   []string{"-c", "//book"},
+			Output: "3\n",
+		},
+		{
+			Args:   []string{"//missing"},
+			Status: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.Args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(append([]string{"grep"}, tc.Args...), strings.NewReader(grepInput), &stdout, &stderr)
+			assert.Equal(t, tc.Status, status)
+			assert.Equal(t, tc.Output, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestGrep_Files(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.xml"), filepath.Join(dir, "b.xml")
+	assert.NoError(t, ioutil.WriteFile(a, []byte(grepInput), 0600))
+	assert.NoError(t, ioutil.WriteFile(b, []byte(`<lib><book/></lib>`), 0600))
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"grep", "-c", "//book", a, b}, nil, &stdout, &stderr))
+	assert.Equal(t, a+":3\n"+b+":1\n", stdout.String())
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"grep", "//book", b, "-"}, strings.NewReader(`<book id="x"/>`), &stdout, &stderr))
+	assert.Equal(t, b+":5: <book/>\n-:0: <book id=\"x\"/>\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
+
+func TestGrep_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run([]string{"grep", "//a"}, strings.NewReader(`<a><b`), &stdout, &stderr))
+	assert.Equal(t, "fastxml grep: -: expected Token to end with '>' at offset 3\n", stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"grep", "//a"}, strings.NewReader(`<a><b>`), &stdout, &stderr))
+	assert.Equal(t, "fastxml grep: -: unexpected EOF\n", stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"grep", "//["}, nil, &stdout, &stderr))
+	assert.Equal(t, "fastxml grep: invalid path \"//[\": invalid step \"[\"\n", stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"grep"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "usage: fastxml grep")
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,96 @@
+// Command fastxml processes (large) XML documents from the command line using the fastxml package
+//
+// Usage:
+//
+//	fastxml grep [-c] [-n] [-text] PATH [FILE...]
+//	fastxml split [-o DIR] [-json] ELEMENT [FILE...]
+//	fastxml tojson [-attr-prefix PREFIX] [-text-key KEY] [-max-depth N] [FILE...]
+//
+// If no files are given (or a file is "-") stdin is read instead.
+//
+// The grep subcommand prints every element matching a fastxml.Path (ex: `//item[@type='book']/title`) prefixed
+// with its byte offset (or line and column with -n) and the file name when more than one file is given.
+// Matches nested in another match are not reported. It exits with status 1 if nothing matched.
+// The input is streamed through a fastxml.Decoder so documents larger than memory can be searched.
+//
+// The split subcommand prints every (outermost) element named ELEMENT followed by a newline (see fastxml.SplitFunc),
+// or with -o writes each to a numbered file in DIR. With -json each record is converted to JSON (see fastxml.ToJSON)
+// producing NDJSON. It exits with status 1 if no record was found.
+//
+// The tojson subcommand converts each document to JSON (see fastxml.ToJSON) printing one line per file.
+//
+// Unlike grep the split and tojson subcommands read each document into memory.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// command is a subcommand, it returns false if it did not find anything (ex: grep without a match)
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (bool, error)
+
+// errUsage is returned by a command if its arguments are invalid after printing its usage
+var errUsage = errors.New("invalid arguments")
+
+// commands are the subcommands by name
+var commands = map[string]command{
+	"grep":   grep,
+	"split":  split,
+	"tojson": tojson,
+}
+
+// usage is printed when no (or an unknown) subcommand is given
+const usage = `usage: fastxml <command> [arguments]
+
+commands:
+  grep    print the elements matching a path
+  split   print each record element (or write each to its own file)
+  tojson  convert documents to JSON
+`
+
+// run executes the subcommand in args returning the exit status
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "fastxml: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	found, err := cmd(args[1:], stdin, stdout, stderr)
+	if err == errUsage {
+		return 2
+	} else if err != nil {
+		fmt.Fprintf(stderr, "fastxml %s: %s\n", args[0], err)
+		return 2
+	} else if !found {
+		return 1
+	}
+	return 0
+}
+
+// openInput opens the named file, "-" is stdin
+func openInput(name string, stdin io.Reader) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(stdin), nil
+	}
+	return os.Open(name)
+}
+
+// readInput reads the entire named file, "-" is stdin
+func readInput(name string, stdin io.Reader) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(nil, nil, &stdout, &stderr))
+	assert.Equal(t, usage, stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"bogus"}, nil, &stdout, &stderr))
+	assert.Equal(t, "fastxml: unknown command \"bogus\"\n"+usage, stderr.String())
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// split writes each record element in each file to stdout (followed by a newline) or its own file
+func split(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("o", "", "write each record to a numbered file in this directory instead of stdout")
+	toJSON := flags.Bool("json", false, "convert each record to JSON (NDJSON on stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: fastxml split [-o DIR] [-json] ELEMENT [FILE...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return false, errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return false, errUsage
+	}
+	name := []byte(flags.Arg(0))
+	files := flags.Args()[1:]
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return false, err
+		}
+	}
+	ext := ".xml"
+	if *toJSON {
+		ext = ".json"
+	}
+	w := bufio.NewWriter(stdout)
+	var record bytes.Buffer
+	count := 0
+	for _, file := range files {
+		buf, err := readInput(file, stdin)
+		if err != nil {
+			return count > 0, err
+		}
+		var writeErr error
+		err = fastxml.SplitFunc(buf, name, func(rec []byte) bool {
+			count++
+			if *toJSON {
+				record.Reset()
+				if writeErr = fastxml.ToJSON(&record, rec, fastxml.JSONOptions{}); writeErr != nil {
+					return false
+				}
+				rec = record.Bytes()
+			}
+			if *dir != "" {
+				writeErr = ioutil.WriteFile(filepath.Join(*dir, fmt.Sprintf("%08d%s", count, ext)), rec, 0644)
+				return writeErr == nil
+			}
+			w.Write(rec)
+			writeErr = w.WriteByte('\n')
+			return writeErr == nil
+		})
+		if err == nil {
+			err = writeErr
+		}
+		if err != nil {
+			w.Flush()
+			return count > 0, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return count > 0, w.Flush()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const splitInput = `<feed>
+ <entry id="1"><title>A</title></entry>
+ <entry id="2"><title>B &amp; C</title><entry/></entry>
+</feed>`
+
+func TestSplit(t *testing.T) {
+	testCases := []struct {
+		Args   []string
+		Output string
+		Status int
+	}{
+		{
+			Args:   []string{"entry"},
+			Output: "<entry id=\"1\"><title>A</title></entry>\n<entry id=\"2\"><title>B &amp; C</title><entry/></entry>\n",
+		},
+		{
+			Args:   []string{"-json", "title"},
+			Output: "{\"title\":\"A\"}\n{\"title\":\"B & C\"}\n",
+		},
+		{
+			Args:   []string{"missing"},
+			Status: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.Args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(append([]string{"split"}, tc.Args...), strings.NewReader(splitInput), &stdout, &stderr)
+			assert.Equal(t, tc.Status, status)
+			assert.Equal(t, tc.Output, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestSplit_Dir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"split", "-o", dir, "-json", "entry"}, strings.NewReader(splitInput), &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+	first, err := ioutil.ReadFile(filepath.Join(dir, "00000001.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"entry":{"@id":"1","title":"A"}}`, string(first))
+	second, err := ioutil.ReadFile(filepath.Join(dir, "00000002.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"entry":{"@id":"2","title":"B & C","entry":""}}`, string(second))
+}
+
+func TestSplit_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run([]string{"split", "a"}, strings.NewReader(`<r><a><b></r>`), &stdout, &stderr))
+	assert.Equal(t, "fastxml split: -: unexpected EOF\n", stderr.String())
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"split"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "usage: fastxml split")
+}
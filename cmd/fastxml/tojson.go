@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// tojson writes each file as JSON to stdout, one line per file
+func tojson(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (bool, error) {
+	var opts fastxml.JSONOptions
+	flags := flag.NewFlagSet("tojson", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.AttrPrefix, "attr-prefix", "@", "prefix of the keys of attributes")
+	flags.StringVar(&opts.TextKey, "text-key", "#text", "key of the text of elements with attributes or child elements")
+	flags.IntVar(&opts.MaxDepth, "max-depth", 0, "reject elements nested deeper than this (0 is fastxml.DefaultJSONMaxDepth, negative is unlimited)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: fastxml tojson [-attr-prefix PREFIX] [-text-key KEY] [-max-depth N] [FILE...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return false, errUsage
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	w := bufio.NewWriter(stdout)
+	for _, file := range files {
+		buf, err := readInput(file, stdin)
+		if err != nil {
+			return false, err
+		}
+		// The output of a document which fails to convert is dropped (unless it exceeded the buffer)
+		if err := fastxml.ToJSON(w, buf, opts); err != nil {
+			return false, fmt.Errorf("%s: %w", file, err)
+		}
+		w.WriteByte('\n')
+		if err := w.Flush(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"tojson"}, strings.NewReader(`<a id="1"><b>x</b><b>y</b></a>`), &stdout, &stderr))
+	assert.Equal(t, `{"a":{"@id":"1","b":["x","y"]}}`+"\n", stdout.String())
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"tojson", "-attr-prefix", "_", "-text-key", "value"}, strings.NewReader(`<a id="1">x</a>`), &stdout, &stderr))
+	assert.Equal(t, `{"a":{"_id":"1","value":"x"}}`+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
+
+func TestToJSON_Files(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.xml"), filepath.Join(dir, "b.xml")
+	assert.NoError(t, ioutil.WriteFile(a, []byte(`<a>1</a>`), 0600))
+	assert.NoError(t, ioutil.WriteFile(b, []byte(`<b><c><d/></c></b>`), 0600))
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"tojson", a, b}, nil, &stdout, &stderr))
+	assert.Equal(t, "{\"a\":\"1\"}\n{\"b\":{\"c\":{\"d\":\"\"}}}\n", stdout.String())
+	stdout.Reset()
+	assert.Equal(t, 2, run([]string{"tojson", "-max-depth", "2", a, b}, nil, &stdout, &stderr))
+	assert.Equal(t, "{\"a\":\"1\"}\n", stdout.String())
+	assert.True(t, strings.HasPrefix(stderr.String(), "fastxml tojson: "+b+": "), stderr.String())
+}
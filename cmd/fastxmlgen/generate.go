@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// annotation marks a struct for generation without listing it with -type
+const annotation = "//fastxmlgen:unmarshal"
+
+// kind is how a value is decoded
+type kind int
+
+const (
+	kindString kind = iota
+	kindBytes
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindText   // an encoding.TextUnmarshaler
+	kindStruct // a struct with generated methods
+)
+
+// fieldType is the Go type of a field
+type fieldType struct {
+	kind  kind
+	expr  string // the Go type (ex: "int8" or "time.Time") excluding any pointer or slice
+	pkg   string // the import path of a qualified type (ex: "time")
+	bits  int    // the bit size of numbers (0 for int and uint)
+	ptr   bool   // *T
+	slice bool   // []T
+}
+
+// field is a struct field decoded from an attribute, the character data or a child element
+type field struct {
+	goName string
+	name   string // the attribute or element name
+	mode   string // "attr", "chardata" or "element"
+	typ    fieldType
+}
+
+// structType is a struct to generate methods for
+type structType struct {
+	name   string
+	fields []field
+}
+
+// generator holds the state of a single generation
+type generator struct {
+	pkg      string
+	decls    map[string]*ast.TypeSpec
+	text     map[string]bool                 // types with an UnmarshalText method
+	imports  map[*ast.File]map[string]string // the import paths of each file by name
+	generate map[string]bool
+	used     map[string]bool   // the imports used by the generated code
+	aliases  map[string]string // the name of imports which differs from the last element of the path
+	buf      bytes.Buffer
+}
+
+// basicKinds are the predeclared types which are decoded with strconv
+var basicKinds = map[string]fieldType{
+	"string":  {kind: kindString},
+	"bool":    {kind: kindBool},
+	"int":     {kind: kindInt},
+	"int8":    {kind: kindInt, bits: 8},
+	"int16":   {kind: kindInt, bits: 16},
+	"int32":   {kind: kindInt, bits: 32},
+	"rune":    {kind: kindInt, bits: 32},
+	"int64":   {kind: kindInt, bits: 64},
+	"uint":    {kind: kindUint},
+	"uint8":   {kind: kindUint, bits: 8},
+	"byte":    {kind: kindUint, bits: 8},
+	"uint16":  {kind: kindUint, bits: 16},
+	"uint32":  {kind: kindUint, bits: 32},
+	"uint64":  {kind: kindUint, bits: 64},
+	"float32": {kind: kindFloat, bits: 32},
+	"float64": {kind: kindFloat, bits: 64},
+}
+
+// parsePackage parses the (non-test) Go files in dir skipping previously generated files
+func parsePackage(dir string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Comments) > 0 && strings.HasPrefix(file.Comments[0].Text(), "Code generated by fastxmlgen") {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return files, nil
+}
+
+// Generate returns the source of the UnmarshalFastXML methods for the named structs in the package in dir
+// If names is empty every struct annotated with //fastxmlgen:unmarshal is generated
+func Generate(dir string, names []string) ([]byte, error) {
+	files, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		pkg:      files[0].Name.Name,
+		decls:    make(map[string]*ast.TypeSpec),
+		text:     make(map[string]bool),
+		imports:  make(map[*ast.File]map[string]string),
+		generate: make(map[string]bool),
+		used:     make(map[string]bool),
+		aliases:  make(map[string]string),
+	}
+	specFiles := make(map[string]*ast.File)
+	for _, file := range files {
+		g.imports[file] = make(map[string]string)
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := path[strings.LastIndexByte(path, '/')+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			g.imports[file][name] = path
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil && decl.Name.Name == "UnmarshalText" && len(decl.Recv.List) == 1 {
+					recv := decl.Recv.List[0].Type
+					if star, ok := recv.(*ast.StarExpr); ok {
+						recv = star.X
+					}
+					if ident, ok := recv.(*ast.Ident); ok {
+						g.text[ident.Name] = true
+					}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					spec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					g.decls[spec.Name.Name] = spec
+					specFiles[spec.Name.Name] = file
+					if _, ok := spec.Type.(*ast.StructType); ok && len(names) == 0 && annotated(decl, spec) {
+						g.generate[spec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	for _, name := range names {
+		spec, ok := g.decls[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", name)
+		}
+		if _, ok := spec.Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		g.generate[name] = true
+	}
+	if len(g.generate) == 0 {
+		return nil, errors.New("no types to generate, use -type or annotate a struct with " + annotation)
+	}
+	var structs []structType
+	for name := range g.generate {
+		st, err := g.structType(specFiles[name], g.decls[name])
+		if err != nil {
+			return nil, err
+		}
+		structs = append(structs, st)
+	}
+	sort.Slice(structs, func(i, j int) bool {
+		return structs[i].name < structs[j].name
+	})
+	for _, st := range structs {
+		g.emit(st)
+	}
+	return g.source()
+}
+
+// annotated determines if the documentation of a struct contains the annotation
+func annotated(decl *ast.GenDecl, spec *ast.TypeSpec) bool {
+	for _, doc := range []*ast.CommentGroup{decl.Doc, spec.Doc} {
+		if doc == nil {
+			continue
+		}
+		for _, comment := range doc.List {
+			if strings.TrimSpace(comment.Text) == annotation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structType determines the fields of a struct
+func (g *generator) structType(file *ast.File, spec *ast.TypeSpec) (structType, error) {
+	st := structType{name: spec.Name.Name}
+	seen := make(map[string]bool)
+	for _, f := range spec.Type.(*ast.StructType).Fields.List {
+		tag := ""
+		if f.Tag != nil {
+			unquoted, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(unquoted).Get("xml")
+		}
+		if len(f.Names) == 0 {
+			return st, fmt.Errorf("%s: embedded field %s is not supported", st.name, types.ExprString(f.Type))
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() || ident.Name == "XMLName" || tag == "-" {
+				continue
+			}
+			fi, err := g.field(file, ident.Name, tag, f.Type)
+			if err != nil {
+				return st, fmt.Errorf("%s.%s: %w", st.name, ident.Name, err)
+			}
+			key := fi.mode + " " + fi.name
+			if fi.mode == "chardata" {
+				key = fi.mode
+			}
+			if seen[key] {
+				return st, fmt.Errorf("%s.%s: duplicate %s %q", st.name, ident.Name, fi.mode, fi.name)
+			}
+			seen[key] = true
+			st.fields = append(st.fields, fi)
+		}
+	}
+	return st, nil
+}
+
+// field determines how a struct field is decoded from its xml tag
+func (g *generator) field(file *ast.File, goName string, tag string, expr ast.Expr) (field, error) {
+	fi := field{goName: goName, name: goName, mode: "element"}
+	tokens := strings.Split(tag, ",")
+	if tokens[0] != "" {
+		fi.name = tokens[0]
+	}
+	for _, flag := range tokens[1:] {
+		switch flag {
+		case "attr":
+			fi.mode = "attr"
+		case "chardata", "cdata":
+			fi.mode = "chardata"
+		case "omitempty":
+		default:
+			return fi, fmt.Errorf("xml tag flag %q is not supported", flag)
+		}
+	}
+	if strings.ContainsAny(fi.name, " >") {
+		return fi, fmt.Errorf("xml tag %q with a namespace or parent path is not supported", tag)
+	}
+	typ, err := g.resolve(file, expr)
+	if err != nil {
+		return fi, err
+	}
+	if fi.mode != "element" && (typ.slice || typ.kind == kindStruct) {
+		return fi, fmt.Errorf("type %s is not supported for %s", types.ExprString(expr), fi.mode)
+	}
+	fi.typ = typ
+	return fi, nil
+}
+
+// resolve determines the fieldType of a Go type
+func (g *generator) resolve(file *ast.File, expr ast.Expr) (fieldType, error) {
+	if arr, ok := expr.(*ast.ArrayType); ok && arr.Len == nil {
+		if ident, ok := arr.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return fieldType{kind: kindBytes, expr: "[]byte"}, nil
+		}
+		typ, err := g.resolve(file, arr.Elt)
+		if err != nil || typ.slice || typ.kind == kindBytes {
+			return typ, fmt.Errorf("type %s is not supported", types.ExprString(expr))
+		}
+		typ.slice = true
+		return typ, nil
+	}
+	var ft fieldType
+	if star, ok := expr.(*ast.StarExpr); ok {
+		ft.ptr = true
+		expr = star.X
+	}
+	ft.expr = types.ExprString(expr)
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicKinds[t.Name]; ok {
+			ft.kind, ft.bits = basic.kind, basic.bits
+			return ft, nil
+		}
+		spec, ok := g.decls[t.Name]
+		if !ok {
+			return ft, fmt.Errorf("type %s is not supported", t.Name)
+		}
+		if g.text[t.Name] {
+			ft.kind = kindText
+			return ft, nil
+		}
+		if _, ok := spec.Type.(*ast.StructType); ok && g.generate[t.Name] {
+			ft.kind = kindStruct
+			return ft, nil
+		}
+		if underlying, ok := spec.Type.(*ast.Ident); ok {
+			if basic, ok := basicKinds[underlying.Name]; ok {
+				ft.kind, ft.bits = basic.kind, basic.bits
+				return ft, nil
+			}
+		}
+		return ft, fmt.Errorf("type %s must be generated or implement encoding.TextUnmarshaler", t.Name)
+	case *ast.SelectorExpr:
+		// Types from other packages are assumed to implement encoding.TextUnmarshaler (ex: time.Time)
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if path, ok := g.imports[file][pkg.Name]; ok {
+				ft.kind, ft.pkg = kindText, path
+				if pkg.Name != path[strings.LastIndexByte(path, '/')+1:] {
+					g.aliases[path] = pkg.Name
+				}
+				return ft, nil
+			}
+		}
+	}
+	return ft, fmt.Errorf("type %s is not supported", ft.expr)
+}
+
+// printf appends formatted source
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// typeName returns the Go type of a value recording its import
+func (g *generator) typeName(ft fieldType) string {
+	if ft.pkg != "" {
+		g.used[ft.pkg] = true
+	}
+	return ft.expr
+}
+
+// convert converts the result of a strconv function to the type of the field
+func (g *generator) convert(ft fieldType, value string) string {
+	switch ft.expr {
+	case "bool", "int64", "uint64", "float64":
+		return value
+	}
+	return g.typeName(ft) + "(" + value + ")"
+}
+
+// assign sets target (a single value) from the decoded text in src, any error is stored in err
+func (g *generator) assign(ft fieldType, target string, src string) {
+	switch ft.kind {
+	case kindString:
+		g.printf("%s = %s(%s)\n", target, g.typeName(ft), src)
+	case kindBytes:
+		g.printf("%s = append([]byte(nil), %s...)\n", target, src)
+	case kindText:
+		g.printf("err = %s.UnmarshalText(%s)\n", target, src)
+	case kindBool, kindInt, kindUint, kindFloat:
+		g.used["bytes"], g.used["strconv"] = true, true
+		parse := map[kind]string{
+			kindBool:  "strconv.ParseBool(%s)",
+			kindInt:   "strconv.ParseInt(%s, 10, " + strconv.Itoa(ft.bits) + ")",
+			kindUint:  "strconv.ParseUint(%s, 10, " + strconv.Itoa(ft.bits) + ")",
+			kindFloat: "strconv.ParseFloat(%s, " + strconv.Itoa(ft.bits) + ")",
+		}[ft.kind]
+		g.printf("if trimmed := bytes.TrimSpace(%s); len(trimmed) > 0 {\n", src)
+		g.printf("parsed, parseErr := "+parse+"\n", "string(trimmed)")
+		g.printf("%s, err = %s, parseErr\n", target, g.convert(ft, "parsed"))
+		g.printf("}\n")
+	}
+}
+
+// assignField sets the field (allocating pointers and appending to slices) from the decoded text in src
+func (g *generator) assignField(fi field, src string) {
+	target := "v." + fi.goName
+	value := func(target string) {
+		if fi.typ.kind == kindStruct {
+			g.printf("err = %s.UnmarshalFastXMLElement(s, token)\n", target)
+		} else {
+			g.assign(fi.typ, target, src)
+		}
+	}
+	deref := func(target string) string {
+		if fi.typ.kind == kindStruct || fi.typ.kind == kindText {
+			return target
+		}
+		return "*" + target
+	}
+	switch {
+	case fi.typ.slice && fi.typ.ptr:
+		g.printf("item := new(%s)\n", g.typeName(fi.typ))
+		value(deref("item"))
+		g.printf("%s = append(%s, item)\n", target, target)
+	case fi.typ.slice:
+		g.printf("var item %s\n", g.typeName(fi.typ))
+		value("item")
+		g.printf("%s = append(%s, item)\n", target, target)
+	case fi.typ.ptr:
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", target, target, g.typeName(fi.typ))
+		value(deref(target))
+	default:
+		value(target)
+	}
+}
+
+// emit appends the methods for a struct
+func (g *generator) emit(st structType) {
+	var attrs, elements []field
+	var chardata *field
+	text := false // an element is decoded from its text
+	for idx, fi := range st.fields {
+		switch fi.mode {
+		case "attr":
+			attrs = append(attrs, fi)
+		case "chardata":
+			chardata = &st.fields[idx]
+		default:
+			elements = append(elements, fi)
+			text = text || fi.typ.kind != kindStruct
+		}
+	}
+	g.used["io"] = true
+	g.printf("// UnmarshalFastXML decodes the next element read from s into v\n")
+	g.printf("func (v *%s) UnmarshalFastXML(s *fastxml.Scanner) error {\n", st.name)
+	g.printf("return fastxml.UnmarshalElement(s, v)\n}\n\n")
+	g.printf("// UnmarshalFastXMLElement decodes the element whose start element token was most recently read from s into v\n")
+	g.printf("func (v *%s) UnmarshalFastXMLElement(s *fastxml.Scanner, startToken []byte) (err error) {\n", st.name)
+	g.printf("defer func() {\nif err == io.EOF {\nerr = io.ErrUnexpectedEOF\n}\n}()\n")
+	if len(attrs) > 0 {
+		g.printf("_, attrsToken := fastxml.Element(startToken)\n")
+		g.printf("if attrErr := fastxml.DecodedAttrs(attrsToken, nil, func(key []byte, value []byte) bool {\n")
+		g.printf("switch string(key) {\n")
+		for _, fi := range attrs {
+			g.printf("case %q:\n", fi.name)
+			g.assignField(fi, "value")
+		}
+		g.printf("}\nreturn err == nil\n}); attrErr != nil {\nreturn attrErr\n} else if err != nil {\nreturn err\n}\n")
+	}
+	g.printf("if s.SelfClosing(startToken) {\nreturn nil\n}\n")
+	if chardata != nil {
+		g.printf("var text []byte\n")
+	}
+	if text {
+		g.printf("var scratch []byte\n")
+	}
+	g.printf("for {\n")
+	g.printf("token, chardata, err := s.Next()\nif err != nil {\nreturn err\n}\n")
+	if chardata != nil {
+		g.printf("if chardata {\nif text, err = fastxml.CharDataAppend(text, token); err != nil {\nreturn err\n}\ncontinue\n}\n")
+	} else {
+		g.printf("if chardata {\ncontinue\n}\n")
+	}
+	g.printf("if !fastxml.IsElement(token) {\ncontinue\n}\n")
+	g.printf("if fastxml.IsEndElement(token) {\nbreak\n}\n")
+	if len(elements) == 0 {
+		g.printf("if err := s.SkipElement(token); err != nil {\nreturn err\n}\n")
+	} else {
+		g.printf("name, _ := fastxml.Element(token)\n_, local := fastxml.Name(name)\n")
+		g.printf("switch string(local) {\n")
+		for _, fi := range elements {
+			g.printf("case %q:\n", fi.name)
+			if fi.typ.kind != kindStruct {
+				g.printf("scratch = scratch[:0]\n")
+				g.printf("if !s.SelfClosing(token) {\nif scratch, err = s.ElementText(scratch); err != nil {\nreturn err\n}\n}\n")
+			}
+			g.assignField(fi, "scratch")
+		}
+		g.printf("default:\nerr = s.SkipElement(token)\n}\n")
+		g.printf("if err != nil {\nreturn err\n}\n")
+	}
+	g.printf("}\n")
+	if chardata != nil {
+		g.assignField(*chardata, "text")
+		g.printf("return err\n}\n\n")
+	} else {
+		g.printf("return nil\n}\n\n")
+	}
+}
+
+// source returns the formatted source of the generated file
+func (g *generator) source() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by fastxmlgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg)
+	g.used["github.com/bored-engineer/fastxml"] = true
+	var std, other []string
+	for path := range g.used {
+		// The standard library is grouped before other imports
+		if first := strings.SplitN(path, "/", 2)[0]; strings.Contains(first, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	for idx, group := range [][]string{std, other} {
+		if idx > 0 && len(std) > 0 {
+			out.WriteString("\n")
+		}
+		for _, path := range group {
+			fmt.Fprintf(&out, "%s %q\n", g.aliases[path], path)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+	return format.Source(out.Bytes())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	// The generated example must be up to date
+	expected, err := ioutil.ReadFile(filepath.Join("internal", "example", "person_fastxml.go"))
+	assert.NoError(t, err)
+	actual, err := Generate(filepath.Join("internal", "example"), []string{"Person", "Address", "Email"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestGenerate_Annotated(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(`package a
+
+import stdtime "time"
+
+//fastxmlgen:unmarshal
+type A struct {
+	When []*stdtime.Time `+"`xml:\"when\"`"+`
+}
+
+type B struct {
+	Value string
+}
+`), 0644))
+	src, err := Generate(dir, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), `stdtime "time"`)
+	assert.Contains(t, string(src), "func (v *A) UnmarshalFastXMLElement(")
+	assert.Contains(t, string(src), "item := new(stdtime.Time)")
+	assert.NotContains(t, string(src), "func (v *B)")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Src   string
+		Types []string
+		Error string
+	}{
+		{Name: "missing", Src: "type A struct{}", Types: []string{"B"}, Error: "type B not found"},
+		{Name: "not struct", Src: "type A int", Types: []string{"A"}, Error: "type A is not a struct"},
+		{Name: "none", Src: "type A struct{}", Error: "no types to generate, use -type or annotate a struct with //fastxmlgen:unmarshal"},
+		{Name: "path", Src: "type A struct{ B string `xml:\"a>b\"` }", Types: []string{"A"}, Error: `A.B: xml tag "a>b" with a namespace or parent path is not supported`},
+		{Name: "flag", Src: "type A struct{ B string `xml:\",innerxml\"` }", Types: []string{"A"}, Error: `A.B: xml tag flag "innerxml" is not supported`},
+		{Name: "embedded", Src: "type B struct{}\ntype A struct{ B }", Types: []string{"A"}, Error: "A: embedded field B is not supported"},
+		{Name: "map", Src: "type A struct{ B map[string]string }", Types: []string{"A"}, Error: "A.B: type map[string]string is not supported"},
+		{Name: "not generated", Src: "type B struct{}\ntype A struct{ B B }", Types: []string{"A"}, Error: "A.B: type B must be generated or implement encoding.TextUnmarshaler"},
+		{Name: "slice attr", Src: "type A struct{ B []int `xml:\"b,attr\"` }", Types: []string{"A"}, Error: "A.B: type []int is not supported for attr"},
+		{Name: "duplicate", Src: "type A struct{ B, C string `xml:\"b\"` }", Types: []string{"A"}, Error: `A.C: duplicate element "b"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\n"+tc.Src+"\n"), 0644))
+			_, err := Generate(dir, tc.Types)
+			assert.EqualError(t, err, tc.Error)
+		})
+	}
+	_, err := Generate(os.TempDir()+"/does-not-exist", nil)
+	assert.Error(t, err)
+}
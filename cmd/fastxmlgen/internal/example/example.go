@@ -0,0 +1,52 @@
+// Package example contains structs decoded with methods generated by fastxmlgen
+package example
+
+import (
+	"strings"
+	"time"
+)
+
+//go:generate go run ../.. -type Person,Address,Email
+
+// Status is a named basic type
+type Status int
+
+// Tags implements encoding.TextUnmarshaler
+type Tags []string
+
+// UnmarshalText splits a comma-separated list
+func (t *Tags) UnmarshalText(text []byte) error {
+	*t = strings.Split(string(text), ",")
+	return nil
+}
+
+// Person is decoded from a <person> element
+type Person struct {
+	ID       int64      `xml:"id,attr"`
+	Verified bool       `xml:"verified,attr"`
+	Ratio    *float32   `xml:"ratio,attr"`
+	Name     string     `xml:"name"`
+	Nick     []byte     `xml:"nick"`
+	Age      uint8      `xml:"age"`
+	Status   Status     `xml:"status"`
+	Tags     Tags       `xml:"tags"`
+	Born     time.Time  `xml:"born"`
+	Seen     *time.Time `xml:"seen"`
+	Home     *Address   `xml:"home"`
+	Emails   []Email    `xml:"email"`
+	Scores   []int      `xml:"score"`
+	Ignored  string     `xml:"-"`
+	private  string
+}
+
+// Address is a nested struct
+type Address struct {
+	City string `xml:"city"`
+	Zip  string `xml:"zip,attr"`
+}
+
+// Email has both attributes and character data
+type Email struct {
+	Kind    string `xml:"kind,attr"`
+	Address string `xml:",chardata"`
+}
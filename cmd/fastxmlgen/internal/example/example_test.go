@@ -0,0 +1,66 @@
+package example
+
+import (
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/bored-engineer/fastxml"
+	"github.com/stretchr/testify/assert"
+)
+
+const document = `<?xml version="1.0"?>
+<person id="42" verified="true" ratio="0.5">
+	<name>Jane &amp; <b>Doe</b></name>
+	<nick><![CDATA[jd]]></nick>
+	<age> 30 </age>
+	<status>2</status>
+	<tags>a,b</tags>
+	<born>2026-10-17T01:02:03Z</born>
+	<seen>2026-10-17T04:05:06Z</seen>
+	<home zip="12345"><city>Springfield</city><unknown/></home>
+	<email kind="work">jane@example.com</email>
+	<email kind="home"/>
+	<score>1</score><score/><score>3</score>
+	<ignored>x</ignored>
+</person>`
+
+func TestPerson_UnmarshalFastXML(t *testing.T) {
+	var expected Person
+	assert.NoError(t, xml.Unmarshal([]byte(document), &expected))
+	var actual Person
+	assert.NoError(t, actual.UnmarshalFastXML(fastxml.NewScanner([]byte(document))))
+	assert.Equal(t, expected, actual)
+	// fastxml.Unmarshal uses the generated methods
+	var unmarshalled Person
+	assert.NoError(t, fastxml.Unmarshal([]byte(document), &unmarshalled))
+	assert.Equal(t, expected, unmarshalled)
+}
+
+func TestPerson_UnmarshalFastXML_Errors(t *testing.T) {
+	var p Person
+	assert.Equal(t, io.ErrUnexpectedEOF, p.UnmarshalFastXML(fastxml.NewScanner([]byte(`<person><name>x</name>`))))
+	assert.Error(t, p.UnmarshalFastXML(fastxml.NewScanner([]byte(`<person id="x"/>`))))
+	assert.Error(t, p.UnmarshalFastXML(fastxml.NewScanner([]byte(`<person><age>old</age></person>`))))
+	assert.Equal(t, io.EOF, p.UnmarshalFastXML(fastxml.NewScanner([]byte(`<!-- empty -->`))))
+}
+
+func BenchmarkPerson_UnmarshalFastXML(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p Person
+		if err := p.UnmarshalFastXML(fastxml.NewScanner([]byte(document))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPerson_EncodingXML(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p Person
+		if err := xml.Unmarshal([]byte(document), &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Code generated by fastxmlgen; DO NOT EDIT.
+
+package example
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// UnmarshalFastXML decodes the next element read from s into v
+func (v *Address) UnmarshalFastXML(s *fastxml.Scanner) error {
+	return fastxml.UnmarshalElement(s, v)
+}
+
+// UnmarshalFastXMLElement decodes the element whose start element token was most recently read from s into v
+func (v *Address) UnmarshalFastXMLElement(s *fastxml.Scanner, startToken []byte) (err error) {
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	_, attrsToken := fastxml.Element(startToken)
+	if attrErr := fastxml.DecodedAttrs(attrsToken, nil, func(key []byte, value []byte) bool {
+		switch string(key) {
+		case "zip":
+			v.Zip = string(value)
+		}
+		return err == nil
+	}); attrErr != nil {
+		return attrErr
+	} else if err != nil {
+		return err
+	}
+	if s.SelfClosing(startToken) {
+		return nil
+	}
+	var scratch []byte
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if chardata {
+			continue
+		}
+		if !fastxml.IsElement(token) {
+			continue
+		}
+		if fastxml.IsEndElement(token) {
+			break
+		}
+		name, _ := fastxml.Element(token)
+		_, local := fastxml.Name(name)
+		switch string(local) {
+		case "city":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			v.City = string(scratch)
+		default:
+			err = s.SkipElement(token)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalFastXML decodes the next element read from s into v
+func (v *Email) UnmarshalFastXML(s *fastxml.Scanner) error {
+	return fastxml.UnmarshalElement(s, v)
+}
+
+// UnmarshalFastXMLElement decodes the element whose start element token was most recently read from s into v
+func (v *Email) UnmarshalFastXMLElement(s *fastxml.Scanner, startToken []byte) (err error) {
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	_, attrsToken := fastxml.Element(startToken)
+	if attrErr := fastxml.DecodedAttrs(attrsToken, nil, func(key []byte, value []byte) bool {
+		switch string(key) {
+		case "kind":
+			v.Kind = string(value)
+		}
+		return err == nil
+	}); attrErr != nil {
+		return attrErr
+	} else if err != nil {
+		return err
+	}
+	if s.SelfClosing(startToken) {
+		return nil
+	}
+	var text []byte
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if chardata {
+			if text, err = fastxml.CharDataAppend(text, token); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fastxml.IsElement(token) {
+			continue
+		}
+		if fastxml.IsEndElement(token) {
+			break
+		}
+		if err := s.SkipElement(token); err != nil {
+			return err
+		}
+	}
+	v.Address = string(text)
+	return err
+}
+
+// UnmarshalFastXML decodes the next element read from s into v
+func (v *Person) UnmarshalFastXML(s *fastxml.Scanner) error {
+	return fastxml.UnmarshalElement(s, v)
+}
+
+// UnmarshalFastXMLElement decodes the element whose start element token was most recently read from s into v
+func (v *Person) UnmarshalFastXMLElement(s *fastxml.Scanner, startToken []byte) (err error) {
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	_, attrsToken := fastxml.Element(startToken)
+	if attrErr := fastxml.DecodedAttrs(attrsToken, nil, func(key []byte, value []byte) bool {
+		switch string(key) {
+		case "id":
+			if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseInt(string(trimmed), 10, 64)
+				v.ID, err = parsed, parseErr
+			}
+		case "verified":
+			if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseBool(string(trimmed))
+				v.Verified, err = parsed, parseErr
+			}
+		case "ratio":
+			if v.Ratio == nil {
+				v.Ratio = new(float32)
+			}
+			if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseFloat(string(trimmed), 32)
+				*v.Ratio, err = float32(parsed), parseErr
+			}
+		}
+		return err == nil
+	}); attrErr != nil {
+		return attrErr
+	} else if err != nil {
+		return err
+	}
+	if s.SelfClosing(startToken) {
+		return nil
+	}
+	var scratch []byte
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if chardata {
+			continue
+		}
+		if !fastxml.IsElement(token) {
+			continue
+		}
+		if fastxml.IsEndElement(token) {
+			break
+		}
+		name, _ := fastxml.Element(token)
+		_, local := fastxml.Name(name)
+		switch string(local) {
+		case "name":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			v.Name = string(scratch)
+		case "nick":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			v.Nick = append([]byte(nil), scratch...)
+		case "age":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			if trimmed := bytes.TrimSpace(scratch); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseUint(string(trimmed), 10, 8)
+				v.Age, err = uint8(parsed), parseErr
+			}
+		case "status":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			if trimmed := bytes.TrimSpace(scratch); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseInt(string(trimmed), 10, 0)
+				v.Status, err = Status(parsed), parseErr
+			}
+		case "tags":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			err = v.Tags.UnmarshalText(scratch)
+		case "born":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			err = v.Born.UnmarshalText(scratch)
+		case "seen":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			if v.Seen == nil {
+				v.Seen = new(time.Time)
+			}
+			err = v.Seen.UnmarshalText(scratch)
+		case "home":
+			if v.Home == nil {
+				v.Home = new(Address)
+			}
+			err = v.Home.UnmarshalFastXMLElement(s, token)
+		case "email":
+			var item Email
+			err = item.UnmarshalFastXMLElement(s, token)
+			v.Emails = append(v.Emails, item)
+		case "score":
+			scratch = scratch[:0]
+			if !s.SelfClosing(token) {
+				if scratch, err = s.ElementText(scratch); err != nil {
+					return err
+				}
+			}
+			var item int
+			if trimmed := bytes.TrimSpace(scratch); len(trimmed) > 0 {
+				parsed, parseErr := strconv.ParseInt(string(trimmed), 10, 0)
+				item, err = int(parsed), parseErr
+			}
+			v.Scores = append(v.Scores, item)
+		default:
+			err = s.SkipElement(token)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
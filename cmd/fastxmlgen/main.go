@@ -0,0 +1,54 @@
+// Command fastxmlgen generates reflection-free decoding methods for structs with xml tags
+//
+// It is intended to be used with go:generate (ex: `//go:generate fastxmlgen -type Person,Address`)
+// or with structs annotated with a //fastxmlgen:unmarshal comment when -type is not provided.
+// Each struct gets an UnmarshalFastXML(s *fastxml.Scanner) method and an UnmarshalFastXMLElement method
+// (implementing fastxml.ElementUnmarshaler, which fastxml.Unmarshal uses instead of reflection)
+// that dispatch on attribute and element names with byte comparisons.
+//
+// Supported fields are attributes (`xml:"name,attr"`), character data (`xml:",chardata"` or `xml:",cdata"`)
+// and child elements (`xml:"name"` or the field name) of a basic type (string, []byte, bool, integers or floats),
+// an encoding.TextUnmarshaler or another generated struct and pointers or slices of them.
+// Elements are matched by their local name and decoded from their text (see fastxml.Scanner.ElementText).
+// Other fields (ex: `xml:"a>b"`, `xml:",innerxml"` or embedded structs) are reported as an error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct names, defaults to annotated structs")
+	output := flag.String("output", "", "output file name, defaults to <type>_fastxml.go")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	src, err := Generate(dir, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fastxmlgen:", err)
+		os.Exit(1)
+	}
+	name := *output
+	if name == "" {
+		name = "fastxml_gen.go"
+		if len(names) > 0 {
+			name = strings.ToLower(names[0]) + "_fastxml.go"
+		}
+		name = filepath.Join(dir, name)
+	}
+	if err := ioutil.WriteFile(name, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "fastxmlgen:", err)
+		os.Exit(1)
+	}
+}
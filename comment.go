@@ -1,5 +1,11 @@
 package fastxml
 
+// Allocate once instead of on each bytes.Contains/HasSuffix call
+var (
+	dash     = []byte("-")
+	dashDash = []byte("--")
+)
+
 // IsComment determines if a Directive is a comment (<!--)
 func IsComment(token []byte) bool {
 	return len(token) > 4 && token[0] == '<' && token[1] == '!' && token[2] == '-' && token[3] == '-'
@@ -0,0 +1,109 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnsupportedCompression is returned by NewAutoScanner for compressed input it cannot decompress
+// (ex: zstd when NewZstdReader is not set)
+var ErrUnsupportedCompression = errors.New("unsupported compression")
+
+// NewZstdReader (if set) decompresses Zstandard input for NewAutoScanner, the standard library has no zstd decoder
+// so one must be supplied to avoid a dependency, ex: with github.com/klauspost/compress/zstd
+//
+//	fastxml.NewZstdReader = func(r io.Reader) (io.ReadCloser, error) {
+//		zr, err := zstd.NewReader(r)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return zr.IOReadCloser(), nil
+//	}
+var NewZstdReader func(r io.Reader) (io.ReadCloser, error)
+
+// Magic bytes at the start of compressed input, see NewAutoScanner
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// AutoScanner is a *Decoder over input which may be compressed, see NewAutoScanner
+// The offsets of the Decoder (ex: InputOffset and the offsets of errors) are in the decompressed input
+type AutoScanner struct {
+	*Decoder
+	// Compression is the format of the input, "gzip", "bzip2", "zstd" or "" if it was not compressed
+	Compression string
+
+	cr     *countingReader
+	br     *bufio.Reader
+	closer io.Closer // the decompressor, if any
+}
+
+// NewAutoScanner creates an *AutoScanner reading from r, decompressing it if it starts with the magic bytes of
+// gzip (including multiple concatenated members), bzip2 or Zstandard (if NewZstdReader is set)
+// ErrUnsupportedCompression is returned for Zstandard input otherwise so it is not parsed as XML
+func NewAutoScanner(r io.Reader) (*AutoScanner, error) {
+	as := &AutoScanner{cr: &countingReader{r: r}}
+	// A *bufio.Reader is an io.ByteReader so the decompressors read from it directly without buffering again
+	as.br = bufio.NewReader(as.cr)
+	magic, err := as.br.Peek(len(magicZstd))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var input io.Reader = as.br
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		zr, err := gzip.NewReader(as.br)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		as.Compression, as.closer, input = "gzip", zr, zr
+	case bytes.HasPrefix(magic, magicBzip2):
+		as.Compression, input = "bzip2", bzip2.NewReader(as.br)
+	case bytes.HasPrefix(magic, magicZstd):
+		if NewZstdReader == nil {
+			return nil, fmt.Errorf("%w: zstd", ErrUnsupportedCompression)
+		}
+		zr, err := NewZstdReader(as.br)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		as.Compression, as.closer, input = "zstd", zr, zr
+	}
+	as.Decoder = NewDecoderReader(input)
+	return as, nil
+}
+
+// CompressedOffset returns the number of bytes of the (compressed) input consumed so far
+// The decompressor and Decoder read ahead so it is past the compressed position of InputOffset,
+// it is intended for progress reporting against the size of the compressed input (ex: a file)
+func (as *AutoScanner) CompressedOffset() int64 {
+	return as.cr.n - int64(as.br.Buffered())
+}
+
+// Close releases the decompressor, it does not close the io.Reader passed to NewAutoScanner
+func (as *AutoScanner) Close() error {
+	if as.closer == nil {
+		return nil
+	}
+	return as.closer.Close()
+}
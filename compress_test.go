@@ -0,0 +1,118 @@
+package fastxml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gzipped compresses each member as a separate gzip member
+func gzipped(t *testing.T, members ...string) []byte {
+	var buf bytes.Buffer
+	for _, member := range members {
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write([]byte(member))
+		assert.NoError(t, err)
+		assert.NoError(t, zw.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestNewAutoScanner(t *testing.T) {
+	// bzip2 of `<a><b>bzip2</b></a>`, the standard library has no bzip2 compressor
+	bzipped, err := hex.DecodeString("425a6839314159265359e5b61b2600000299800000900530204010200031064c40d3469a69639221b05b2549be2ee48a70a121cb6c364c")
+	assert.NoError(t, err)
+	large := `<a>` + strings.Repeat(`<b>text</b>`, 1000) + `</a>`
+	testCases := []struct {
+		Name        string
+		Input       []byte
+		Compression string
+		Expected    string
+	}{
+		{"plain", []byte(`<a><b>plain</b></a>`), "", `<a><b>plain</b></a>`},
+		{"empty", nil, "", ``},
+		{"short", []byte(`x`), "", `x`},
+		{"gzip", gzipped(t, large), "gzip", large},
+		{"gzip members", gzipped(t, `<a>`, `<b>text</b>`, `</a>`), "gzip", `<a><b>text</b></a>`},
+		{"bzip2", bzipped, "bzip2", `<a><b>bzip2</b></a>`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			as, err := NewAutoScanner(bytes.NewReader(tc.Input))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer as.Close()
+			assert.Equal(t, tc.Compression, as.Compression)
+			tokens, err := decoderTokens(as.Decoder)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, strings.Join(tokens, ""))
+			assert.Equal(t, int64(len(tc.Expected)), as.InputOffset())
+			assert.Equal(t, int64(len(tc.Input)), as.CompressedOffset())
+		})
+	}
+}
+
+// fakeZstd is a NewZstdReader which "decompresses" by dropping the magic bytes and counts the readers closed
+type fakeZstd struct {
+	io.Reader
+	closed *int
+}
+
+func (z fakeZstd) Close() error {
+	*z.closed++
+	return nil
+}
+
+func TestNewAutoScanner_Zstd(t *testing.T) {
+	closed := 0
+	defer func() { NewZstdReader = nil }()
+	NewZstdReader = func(r io.Reader) (io.ReadCloser, error) {
+		if _, err := io.ReadFull(r, make([]byte, len(magicZstd))); err != nil {
+			return nil, err
+		}
+		return fakeZstd{Reader: r, closed: &closed}, nil
+	}
+	input := append(append([]byte(nil), magicZstd...), `<a><b>zstd</b></a>`...)
+	as, err := NewAutoScanner(bytes.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "zstd", as.Compression)
+	tokens, err := decoderTokens(as.Decoder)
+	assert.NoError(t, err)
+	assert.Equal(t, `<a><b>zstd</b></a>`, strings.Join(tokens, ""))
+	assert.Equal(t, int64(len(input)), as.CompressedOffset())
+	assert.NoError(t, as.Close())
+	assert.Equal(t, 1, closed)
+}
+
+func TestNewAutoScanner_Errors(t *testing.T) {
+	zstd := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}
+	_, err := NewAutoScanner(bytes.NewReader(zstd))
+	assert.True(t, errors.Is(err, ErrUnsupportedCompression))
+	assert.EqualError(t, err, "unsupported compression: zstd")
+	// The errors of NewZstdReader are returned
+	defer func() { NewZstdReader = nil }()
+	NewZstdReader = func(io.Reader) (io.ReadCloser, error) {
+		return nil, errors.New("bad frame")
+	}
+	_, err = NewAutoScanner(bytes.NewReader(zstd))
+	assert.EqualError(t, err, "zstd: bad frame")
+	// Truncated gzip header
+	_, err = NewAutoScanner(bytes.NewReader([]byte{0x1f, 0x8b, 0x08}))
+	assert.Error(t, err)
+	// Corrupt data is reported by Next
+	input := gzipped(t, `<a><b>text</b></a>`)
+	input[len(input)-5]++ // CRC-32
+	as, err := NewAutoScanner(bytes.NewReader(input))
+	assert.NoError(t, err)
+	_, err = decoderTokens(as.Decoder)
+	assert.Equal(t, gzip.ErrChecksum, err)
+}
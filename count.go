@@ -0,0 +1,106 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+)
+
+// CountElements returns the number of start (and self-closing) elements named name in buf (including nested elements)
+// If name starts with `*:` the local part is matched ignoring any prefix (see NextStart)
+// Unlike Count no path is compiled or tracked and documents which do not contain name are rejected without being scanned
+func CountElements(buf []byte, name []byte) (int, error) {
+	local := name
+	prefixed := bytes.HasPrefix(name, anyPrefix)
+	if prefixed {
+		local = name[len(anyPrefix):]
+	}
+	if bytes.Index(buf, local) == -1 {
+		return 0, nil
+	}
+	s := NewScanner(buf)
+	count := 0
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		// Only start elements of at least the length of the name are worth extracting the name of
+		if chardata || len(token) < len(local)+2 || token[1] == '/' || token[1] == '!' || token[1] == '?' {
+			continue
+		}
+		if prefixed {
+			if startNamed(token, name) {
+				count++
+			}
+		} else if bytes.HasPrefix(token[1:], name) && isNameEnd(token[len(name)+1]) {
+			// Compare the name in place instead of extracting it with Element
+			count++
+		}
+	}
+}
+
+// DocumentStats are the number of each kind of token in a document, see Stats
+type DocumentStats struct {
+	Elements   int // start and self-closing elements
+	Attrs      int // attributes of the elements
+	CharData   int // CharData tokens (excluding CDATA sections)
+	CDATA      int
+	Comments   int
+	ProcInsts  int
+	Directives int
+	MaxDepth   int // deepest nesting of elements
+}
+
+// Stats counts the tokens of buf without decoding them, any error is returned with the counts up to it
+func Stats(buf []byte) (DocumentStats, error) {
+	var stats DocumentStats
+	s := NewScanner(buf)
+	depth := 0
+	for {
+		token, kind, err := s.NextKind()
+		if err == io.EOF {
+			return stats, nil
+		} else if err != nil {
+			return stats, err
+		}
+		switch kind {
+		case KindCharData:
+			stats.CharData++
+		case KindCDATA:
+			stats.CDATA++
+		case KindComment:
+			stats.Comments++
+		case KindProcInst:
+			stats.ProcInsts++
+		case KindDirective:
+			stats.Directives++
+		case KindEndElement:
+			depth--
+		case KindStartElement, KindSelfClosing:
+			stats.Elements++
+			if depth+1 > stats.MaxDepth {
+				stats.MaxDepth = depth + 1
+			}
+			if kind == KindStartElement {
+				depth++
+			}
+			name, attrsToken := Element(token)
+			if len(attrsToken) == 0 {
+				continue
+			}
+			if err := RawAttrs(attrsToken, func(int, int, int, int) bool {
+				stats.Attrs++
+				return true
+			}); err != nil {
+				// The offset of the *TokenError is relative to attrsToken
+				if attrErr, ok := err.(*TokenError); ok {
+					start, _ := s.TokenRange()
+					attrErr.Offset += start + len(name) + 2
+				}
+				return stats, err
+			}
+		}
+	}
+}
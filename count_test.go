@@ -0,0 +1,86 @@
+package fastxml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountElements(t *testing.T) {
+	doc := []byte(`<?xml version="1.0"?><feed xmlns:a="urn:a"><!-- <item/> --><item><item/></item><items/><a:item x="<item>">x</a:item><![CDATA[<item/>]]></item-list></feed>`)
+	testCases := map[string]int{
+		"item":    2,
+		"a:item":  1,
+		"*:item":  3,
+		"items":   1,
+		"ite":     0,
+		"missing": 0,
+		"feed":    1,
+	}
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			count, err := CountElements(doc, []byte(name))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, count)
+		})
+	}
+	count, err := CountElements([]byte(`<a><item/><item`), []byte("item"))
+	assert.Equal(t, 1, count)
+	assert.EqualError(t, err, `expected Token to end with '>' at offset 10`)
+}
+
+func TestStats(t *testing.T) {
+	doc := []byte(`<?xml version="1.0"?><!DOCTYPE feed><feed a="1" b="2"><!-- c --><entry id="1">text<![CDATA[x]]><link href="x"/></entry><entry/></feed>`)
+	stats, err := Stats(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentStats{
+		Elements:   4,
+		Attrs:      4,
+		CharData:   1,
+		CDATA:      1,
+		Comments:   1,
+		ProcInsts:  1,
+		Directives: 1,
+		MaxDepth:   3,
+	}, stats)
+
+	stats, err = Stats([]byte(`<a><b x="1" y></b></a>`))
+	assert.Equal(t, DocumentStats{Elements: 2, Attrs: 1, MaxDepth: 2}, stats)
+	assert.True(t, errors.Is(err, ErrBadAttr))
+	var tokenErr *TokenError
+	if assert.True(t, errors.As(err, &tokenErr)) {
+		assert.Equal(t, 12, tokenErr.Offset)
+	}
+
+	_, err = Stats([]byte(`<a><!-- x`))
+	assert.True(t, errors.Is(err, ErrUnterminatedComment))
+}
+
+func BenchmarkCountElements(b *testing.B) {
+	data := benchData(b)
+	name := []byte("Entry")
+	for n := 0; n < b.N; n++ {
+		if _, err := CountElements(data, name); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		if _, err := Count(data, "//Entry"); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkStats(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		if _, err := Stats(data); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -2,22 +2,19 @@ package fastxml
 
 import (
 	"bytes"
-	"encoding/xml"
-	"errors"
-	"fmt"
 	"strconv"
 	"unicode/utf8"
 )
 
-// decodeEntities appends to scratch
-func decodeEntities(scratch []byte, in []byte, start int) ([]byte, error) {
+// decodeEntities appends to scratch, if html is false only the predefined XML entities are resolved
+func decodeEntities(scratch []byte, in []byte, start int, html bool) ([]byte, error) {
 	scratch = append(scratch, in[:start]...)
 	start++
 	for {
 		// Find the end of the entity
 		end := bytes.IndexRune(in[start:], ';')
 		if end == -1 {
-			return scratch, errors.New("expected ';' to end XML entity, not found")
+			return scratch, entityError(ErrBadEntity, start-1, "expected ';' to end XML entity, not found")
 		}
 		// rune based on hex/decimal value
 		if in[start] == '#' {
@@ -31,7 +28,7 @@ func decodeEntities(scratch []byte, in []byte, start int) ([]byte, error) {
 			// rune is a int32
 			num, err := strconv.ParseInt(str, base, 32)
 			if err != nil {
-				return scratch, fmt.Errorf("failed to decode %q: %w", str, err)
+				return scratch, entityError(ErrBadEntity, start-1, "failed to decode %q: %s", str, err)
 			}
 			// Make room for utf8.UTFMax if needed before hitting capacity
 			size := len(scratch)
@@ -55,16 +52,21 @@ func decodeEntities(scratch []byte, in []byte, start int) ([]byte, error) {
 			case "quot":
 				scratch = append(scratch, '"')
 			default:
+				if !html {
+					return scratch, entityError(ErrUnknownEntity, start-1, "unknown XML entity %q", entity)
+				}
 				// Check from more expensive map
-				decoded, ok := xml.HTMLEntity[entity]
+				decoded, ok := htmlEntity[entity]
 				if !ok {
-					return scratch, fmt.Errorf("unknown XML entity %q", entity)
+					return scratch, entityError(ErrUnknownEntity, start-1, "unknown XML entity %q", entity)
 				}
 				scratch = append(scratch, decoded...)
 			}
 		}
 		// Find next entity
 		if idx := bytes.IndexRune(in[start+end:], '&'); idx != -1 {
+			// Copy any bytes between the entities
+			scratch = append(scratch, in[start+end+1:start+end+idx]...)
 			start += end + idx + 1
 		} else {
 			// No more entities, copy rest of bytes and return
@@ -88,7 +90,7 @@ func DecodeEntities(in []byte, scratch []byte) ([]byte, error) {
 		// The final result will always be smaller than the input length
 		scratch = make([]byte, 0, len(in))
 	}
-	return decodeEntities(scratch, in, start)
+	return decodeEntities(scratch, in, start, true)
 }
 
 // DecodeEntitiesAppend will efficiently append the decoded in to out
@@ -99,5 +101,29 @@ func DecodeEntitiesAppend(out []byte, in []byte) ([]byte, error) {
 		// No entities, memmove as-is (fast)
 		return append(out, in...), nil
 	}
-	return decodeEntities(out, in, start)
+	return decodeEntities(out, in, start, true)
+}
+
+// DecodePredefinedEntities is DecodeEntities but only resolves the five predefined XML entities
+// (lt, gt, amp, apos and quot) and character references, any other entity (ex: `&nbsp;`) is an error
+// as it is not well-formed XML without a DTD declaring it
+func DecodePredefinedEntities(in []byte, scratch []byte) ([]byte, error) {
+	start := bytes.IndexRune(in, '&')
+	if start == -1 {
+		return in, nil
+	}
+	if scratch == nil {
+		scratch = make([]byte, 0, len(in))
+	}
+	return decodeEntities(scratch, in, start, false)
+}
+
+// DecodePredefinedEntitiesAppend will efficiently append the decoded in to out
+// Behaves the same as DecodePredefinedEntities
+func DecodePredefinedEntitiesAppend(out []byte, in []byte) ([]byte, error) {
+	start := bytes.IndexRune(in, '&')
+	if start == -1 {
+		return append(out, in...), nil
+	}
+	return decodeEntities(out, in, start, false)
 }
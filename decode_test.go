@@ -1,6 +1,7 @@
 package fastxml
 
 import (
+	"encoding/xml"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,9 @@ func TestDecodeEntities(t *testing.T) {
 		}, {
 			Input:    `Fast&amp;&quot;&apos;&gt;&lt;Path`,
 			Expected: `Fast&"'><Path`,
+		}, {
+			Input:    `a &lt; b &amp;&amp; c &gt; d`,
+			Expected: `a < b && c > d`,
 		}, {
 			Input:    `It costs &pound;1`,
 			Expected: `It costs £1`,
@@ -32,16 +36,16 @@ func TestDecodeEntities(t *testing.T) {
 			Expected: `1 < 2`,
 		}, {
 			Input: `&#1234567891011;`,
-			Error: `failed to decode "1234567891011": strconv.ParseInt: parsing "1234567891011": value out of range`,
+			Error: `failed to decode "1234567891011": strconv.ParseInt: parsing "1234567891011": value out of range at offset 0`,
 		}, {
 			Input: `&#xnothex;`,
-			Error: `failed to decode "nothex": strconv.ParseInt: parsing "nothex": invalid syntax`,
+			Error: `failed to decode "nothex": strconv.ParseInt: parsing "nothex": invalid syntax at offset 0`,
 		}, {
 			Input: `&`,
-			Error: `expected ';' to end XML entity, not found`,
+			Error: `expected ';' to end XML entity, not found at offset 0`,
 		}, {
 			Input: `&invalid;`,
-			Error: `unknown XML entity "invalid"`,
+			Error: `unknown XML entity "invalid" at offset 0`,
 		},
 	}
 	for _, tc := range testCases {
@@ -65,3 +69,40 @@ func TestDecodeEntities(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodePredefinedEntities(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Error    string
+		Expected string
+	}{
+		{Input: `Hello World`, Expected: `Hello World`},
+		{Input: `Fast&amp;&quot;&apos;&gt;&lt;Path`, Expected: `Fast&"'><Path`},
+		{Input: `&#x00A9; &#174;`, Expected: `© ®`},
+		{Input: `It costs &pound;1`, Error: `unknown XML entity "pound" at offset 9`},
+		{Input: `a&nbsp;b`, Error: `unknown XML entity "nbsp" at offset 1`},
+		{Input: `&`, Error: `expected ';' to end XML entity, not found at offset 0`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			actual, err := DecodePredefinedEntities([]byte(tc.Input), nil)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(actual))
+			}
+			actual, err = DecodePredefinedEntitiesAppend([]byte("prepend"), []byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "prepend"+tc.Expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestHTMLEntity(t *testing.T) {
+	assert.Equal(t, xml.HTMLEntity, htmlEntity)
+}
@@ -0,0 +1,101 @@
+package fastxml
+
+import "io"
+
+// mapFrame is an open element while decoding a map
+type mapFrame struct {
+	name  string
+	value map[string]interface{} // nil until the element has an attribute or child element
+	text  []byte
+}
+
+// addMapValue adds the value of a child element to m, a repeated child becomes a []interface{}
+func addMapValue(m map[string]interface{}, name string, value interface{}) {
+	existing, ok := m[name]
+	if !ok {
+		m[name] = value
+		return
+	}
+	if values, ok := existing.([]interface{}); ok {
+		m[name] = append(values, value)
+	} else {
+		m[name] = []interface{}{existing, value}
+	}
+}
+
+// DecodeToMap decodes buf into a map with a single key for the root element using the same representation as ToJSON:
+// each element is a string of its text if it has no attributes or child elements, otherwise a map[string]interface{}
+// with a key for each attribute (prefixed with "@"), its text (as "#text") and each child element where a repeated
+// child element is a []interface{} (ex: `<a id="1"><b>x</b><b>y</b></a>` ->
+// {"a": {"@id": "1", "b": ["x", "y"]}}). Every string is copied so the result does not reference buf
+func DecodeToMap(buf []byte) (map[string]interface{}, error) {
+	var stack []mapFrame
+	var names Interner // the names of repeated elements and attributes are only copied once
+	var key []byte
+	s := NewScanner(buf)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			if len(stack) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, errNoRoot
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case chardata:
+			if len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				if top.text, err = CharDataAppend(top.text, token); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case !IsElement(token):
+			continue
+		case IsEndElement(token):
+			if len(stack) == 0 {
+				return nil, errUnexpectedEnd
+			}
+		default:
+			name, attrsToken := Element(token)
+			frame := mapFrame{name: names.Intern(name)}
+			if err := DecodedAttrs(attrsToken, nil, func(attr, value []byte) bool {
+				if frame.value == nil {
+					frame.value = make(map[string]interface{})
+				}
+				key = append(append(key[:0], defaultAttrPrefix...), attr...)
+				frame.value[names.Intern(key)] = string(value)
+				return true
+			}); err != nil {
+				return nil, err
+			}
+			stack = append(stack, frame)
+			if !IsSelfClosing(token) {
+				continue
+			}
+		}
+		// Close the element at the top of the stack
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		var value interface{}
+		text := trimSpace(top.text)
+		if top.value == nil {
+			value = string(text)
+		} else {
+			if len(text) > 0 {
+				top.value[defaultTextKey] = string(text)
+			}
+			value = top.value
+		}
+		if len(stack) == 0 {
+			return map[string]interface{}{top.name: value}, nil
+		}
+		parent := &stack[len(stack)-1]
+		if parent.value == nil {
+			parent.value = make(map[string]interface{})
+		}
+		addMapValue(parent.value, top.name, value)
+	}
+}
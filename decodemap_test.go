@@ -0,0 +1,83 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeToMap(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected map[string]interface{}
+		Error    string
+	}{
+		{
+			Name:     "Text",
+			Input:    `<?xml version="1.0"?><name>Fast &amp; XML</name>`,
+			Expected: map[string]interface{}{"name": "Fast & XML"},
+		},
+		{
+			Name: "Nested",
+			Input: `<order id="1">
+  <item sku="a">first</item>
+  <note><![CDATA[<gift>]]></note>
+  <item sku="b"/>
+  <item>third</item>
+</order>`,
+			Expected: map[string]interface{}{
+				"order": map[string]interface{}{
+					"@id": "1",
+					"item": []interface{}{
+						map[string]interface{}{"@sku": "a", "#text": "first"},
+						map[string]interface{}{"@sku": "b"},
+						"third",
+					},
+					"note": "<gift>",
+				},
+			},
+		},
+		{
+			Name:     "Mixed",
+			Input:    `<p> Hello <b>world</b>! <br/></p>`,
+			Expected: map[string]interface{}{"p": map[string]interface{}{"#text": "Hello !", "b": "world", "br": ""}},
+		},
+		{
+			Name:     "SelfClosingRoot",
+			Input:    `<!-- c --><a k="&lt;"/>`,
+			Expected: map[string]interface{}{"a": map[string]interface{}{"@k": "<"}},
+		},
+		{
+			Name:  "NoRoot",
+			Input: ` `,
+			Error: "expected a root element",
+		},
+		{
+			Name:  "Unclosed",
+			Input: `<a><b></b>`,
+			Error: "unexpected EOF",
+		},
+		{
+			Name:  "Unbalanced",
+			Input: `</a>`,
+			Error: "unexpected end element",
+		},
+		{
+			Name:  "Entity",
+			Input: `<a>&bogus;</a>`,
+			Error: `unknown XML entity "bogus" at offset 0`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := DecodeToMap([]byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, m)
+			}
+		})
+	}
+}
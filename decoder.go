@@ -0,0 +1,265 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+)
+
+// defaultDecoderBufferSize is the initial buffer size of a Decoder reading from an io.Reader
+const defaultDecoderBufferSize = 4096
+
+// Decoder produces the same tokens as a Scanner but can also read from an io.Reader
+// Only enough of the input is buffered to produce a complete token, the buffer grows if a token does not fit
+// A token is only valid until the next call to Next as the buffer is re-used
+type Decoder struct {
+	// TrackLines wraps every error returned by Next in a *SyntaxError with the line and column it occurred at
+	TrackLines bool
+	// TrimCharData removes the leading and trailing whitespace of each CharData token (see TrimWhitespace)
+	// suppressing any which are then empty, by default all whitespace is preserved
+	TrimCharData bool
+	// Mode controls how much malformed input is tolerated, see ParseMode and Scanner.Mode
+	// In ParseStrict and ParseHTML modes the name of every open start element is copied
+	Mode ParseMode
+	// Limits (if any are set) reject tokens exceeding them with a *LimitError, see Limits
+	Limits
+	// Progress (if set) is called by Next with the offset in the input of the next token each time another
+	// ProgressInterval bytes have been read and once more when the end of the input is reached (see Scanner.Progress)
+	Progress func(offset int64)
+	// ProgressInterval is the minimum number of bytes between calls to Progress, DefaultProgressInterval if 0
+	ProgressInterval int
+
+	r         io.Reader // nil if the entire input is in buf
+	err       error     // sticky error from r
+	buf       []byte
+	start     int   // start of the unread data in buf
+	end       int   // end of the unread data in buf
+	offset    int64 // offset in the input of buf[0]
+	lines     int   // number of newlines in the input before buf[0]
+	lineStart int64 // offset in the input of the start of the line containing buf[0]
+	depth     int   // current element nesting, only tracked for Limits
+	progress  int64 // offset at which Progress is next called, -1 once the end has been reported
+	scanned   int   // length of the unread data when it was last found to be an incomplete token
+	s         Scanner
+}
+
+// NewDecoder creates a *Decoder for a given byte slice, tokens reference buf directly
+func NewDecoder(buf []byte) *Decoder {
+	return &Decoder{buf: buf, end: len(buf)}
+}
+
+// NewDecoderReader creates a *Decoder which reads from r as tokens are requested
+func NewDecoderReader(r io.Reader) *Decoder {
+	return NewDecoderReaderSize(r, defaultDecoderBufferSize)
+}
+
+// NewDecoderReaderSize is NewDecoderReader with an initial buffer size
+func NewDecoderReaderSize(r io.Reader, size int) *Decoder {
+	if size < 16 {
+		size = 16
+	}
+	return &Decoder{r: r, buf: make([]byte, size)}
+}
+
+// InputOffset returns the offset in the input of the next token
+func (d *Decoder) InputOffset() int64 {
+	return d.offset + int64(d.start)
+}
+
+// Position returns the 1-based line and column (in bytes) of the next token
+func (d *Decoder) Position() (line int, column int) {
+	return d.position(d.start)
+}
+
+// position computes the line and column of buf[idx]
+func (d *Decoder) position(idx int) (line int, column int) {
+	lineStart := d.lineStart
+	if nl := bytes.LastIndexByte(d.buf[:idx], '\n'); nl != -1 {
+		lineStart = d.offset + int64(nl) + 1
+	}
+	return d.lines + bytes.Count(d.buf[:idx], []byte{'\n'}) + 1, int(d.offset+int64(idx)-lineStart) + 1
+}
+
+// fill reads more data from r, moving the unread data to the start of buf or growing it as needed
+func (d *Decoder) fill() {
+	if d.start > 0 {
+		// Keep the position of the discarded data for Position
+		discarded := d.buf[:d.start]
+		if nl := bytes.LastIndexByte(discarded, '\n'); nl != -1 {
+			d.lines += bytes.Count(discarded, []byte{'\n'})
+			d.lineStart = d.offset + int64(nl) + 1
+		}
+		d.offset += int64(d.start)
+		d.end = copy(d.buf, d.buf[d.start:d.end])
+		d.start = 0
+	}
+	if d.end == len(d.buf) {
+		buf := make([]byte, len(d.buf)*2)
+		copy(buf, d.buf[:d.end])
+		d.buf = buf
+	}
+	n, err := d.r.Read(d.buf[d.end:])
+	d.end += n
+	if err != nil {
+		d.err = err
+	}
+}
+
+// more determines if the token produced by the Scanner may be incomplete
+func (d *Decoder) more(chardata bool, err error) bool {
+	if d.r == nil || d.err != nil {
+		return false
+	}
+	return d.s.incomplete(chardata, err)
+}
+
+// window points the Scanner at the unread data in buf, unlike Scanner.Reset the open elements are kept
+func (d *Decoder) window() {
+	d.s.buf, d.s.pos, d.s.start = d.buf[d.start:d.end], 0, 0
+	d.s.Mode = d.Mode
+	d.s.partial = d.r != nil && d.err == nil
+}
+
+// filter is Scanner.filter for the current window returning if the token may be incomplete
+// The offsets recorded by the Scanner are relative to the window so they are rebased to the input
+func (d *Decoder) filter() (token []byte, chardata bool, more bool, err error) {
+	open := len(d.s.open)
+	token, chardata, err = d.s.filter()
+	if err == errPartial {
+		return nil, false, true, nil
+	}
+	base := int(d.InputOffset())
+	if n := len(d.s.open); n > open {
+		elem := &d.s.open[n-1]
+		elem.offset += base
+		if d.r != nil {
+			// The buffer is re-used so the name is copied
+			elem.name = append([]byte(nil), elem.name...)
+		}
+	}
+	switch e := err.(type) {
+	case *SyntaxError:
+		e.Offset += base
+		e.Line, e.Column = d.position(e.Offset - int(d.offset))
+	case *MismatchError:
+		e.EndOffset += base
+	}
+	return token, chardata, false, err
+}
+
+// mayComplete determines if the data read since the first scanned bytes of pending were found to be an incomplete
+// token could complete it, so the token is not tokenized again (from its start) after every read
+// It is conservative, if it can't tell it returns true
+func mayComplete(pending []byte, scanned int, lenient bool) bool {
+	// CharData (and bare '<' in lenient modes) ends at the next '<'
+	if bytes.IndexByte(pending[scanned:], '<') != -1 && (lenient || pending[0] != '<') {
+		return true
+	} else if pending[0] != '<' {
+		return false
+	}
+	suffix := []byte{'>'}
+	switch {
+	case bytes.HasPrefix(pending, prefixComment):
+		suffix = suffixComment
+	case bytes.HasPrefix(pending, prefixCDATA):
+		suffix = suffixCDATA
+	case bytes.HasPrefix(pending, prefixProcInst):
+		suffix = suffixProcInst
+	}
+	// The suffix may have started before the new data
+	if scanned -= len(suffix) - 1; scanned < 0 {
+		scanned = 0
+	}
+	return bytes.Index(pending[scanned:], suffix) != -1
+}
+
+// Next produces the next token from the decoder
+// When no more tokens are available io.EOF is returned
+func (d *Decoder) Next() (token []byte, chardata bool, err error) {
+	for {
+		if d.scanned > 0 && d.err == nil && (d.MaxTokenSize == 0 || d.end-d.start <= d.MaxTokenSize) &&
+			!mayComplete(d.buf[d.start:d.end], d.scanned, d.Mode == ParseLenient || d.Mode == ParseHTML) {
+			d.scanned = d.end - d.start
+			d.fill()
+			continue
+		}
+		d.scanned = 0
+		d.window()
+		var more bool
+		if d.Mode == ParseDefault {
+			token, chardata, err = d.s.next()
+			more = d.more(chardata, err)
+		} else {
+			token, chardata, more, err = d.filter()
+		}
+		if more {
+			if d.MaxTokenSize == 0 || d.end-d.start <= d.MaxTokenSize {
+				d.scanned = d.end - d.start
+				d.fill()
+				continue
+			}
+			// The incomplete token already exceeds the limit, stop buffering it
+			token, chardata = nil, false
+			err = &LimitError{Limit: LimitTokenSize, Max: d.MaxTokenSize, Value: d.end - d.start, Offset: int(d.InputOffset())}
+		} else if err == nil && chardata && d.TrimCharData {
+			if token = TrimWhitespace(token); len(token) == 0 {
+				d.start += d.s.pos
+				continue
+			}
+		}
+		if err == nil && d.Limits != (Limits{}) {
+			if err = d.Limits.check(d.depth, int(d.InputOffset()), token, chardata); err != nil {
+				token, chardata = nil, false
+			} else if !chardata && IsElement(token) && !d.s.SelfClosing(token) {
+				if !IsEndElement(token) {
+					d.depth++
+				} else if d.depth > 0 {
+					d.depth--
+				}
+			}
+		}
+		if err == io.EOF && d.err != nil && d.err != io.EOF {
+			err = d.err
+		} else if err != nil && err != io.EOF {
+			err = tokenError(err, int(d.InputOffset())+d.s.pos)
+			if _, ok := err.(*SyntaxError); d.TrackLines && !ok {
+				offset := errorOffset(err, int(d.InputOffset())+d.s.pos)
+				line, column := d.position(offset - int(d.offset))
+				err = &SyntaxError{Msg: errorMessage(err), Offset: offset, Line: line, Column: column, Err: err}
+			}
+		}
+		d.start += d.s.pos
+		if d.Progress != nil {
+			d.reportProgress(err == io.EOF)
+		}
+		return token, chardata, err
+	}
+}
+
+// reportProgress calls Progress if another ProgressInterval bytes have been read or the end was reached
+func (d *Decoder) reportProgress(end bool) {
+	offset := d.InputOffset()
+	switch {
+	case d.progress < 0:
+		return
+	case end:
+		d.progress = -1
+	case offset < d.progress:
+		return
+	default:
+		interval := d.ProgressInterval
+		if interval <= 0 {
+			interval = DefaultProgressInterval
+		}
+		d.progress = offset + int64(interval)
+	}
+	d.Progress(offset)
+}
+
+// NextKind is Next but returns the Kind of the token (see Scanner.NextKind)
+func (d *Decoder) NextKind() (token []byte, kind Kind, err error) {
+	token, chardata, err := d.Next()
+	if err != nil {
+		return token, 0, err
+	}
+	return token, TokenKind(token, chardata), nil
+}
@@ -0,0 +1,235 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errReader always fails with err
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// decoderTokens reads every token from d
+func decoderTokens(d *Decoder) ([]string, error) {
+	var tokens []string
+	for {
+		token, _, err := d.Next()
+		if err == io.EOF {
+			return tokens, nil
+		} else if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, string(token))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	inputs := []string{
+		``,
+		`text only`,
+		`<?xml version="1.0"?><!DOCTYPE root><root a="1">some text<![CDATA[<not> an element]]><!-- comment --><child/></root>trailing`,
+		`<root title="a > b" empty="" c=">">text<child d="/>"/></root>`,
+		`<root><!-- a > b -- c --><?pi x > y?>text</root>`,
+		`<root>` + strings.Repeat(`<item key="`+strings.Repeat("v", 100)+`">text</item>`, 100) + `</root>`,
+	}
+	for _, input := range inputs {
+		s := NewScanner([]byte(input))
+		var expected []string
+		for {
+			token, _, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			expected = append(expected, string(token))
+		}
+		readers := map[string]io.Reader{
+			"bytes":   bytes.NewReader([]byte(input)),
+			"onebyte": iotest.OneByteReader(strings.NewReader(input)),
+			"half":    iotest.HalfReader(strings.NewReader(input)),
+		}
+		for name, r := range readers {
+			t.Run(name, func(t *testing.T) {
+				actual, err := decoderTokens(NewDecoderReaderSize(r, 16))
+				assert.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			})
+		}
+		actual, err := decoderTokens(NewDecoder([]byte(input)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestDecoder_InputOffset(t *testing.T) {
+	d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(`<a>text</a>`)), 16)
+	var offsets []int64
+	for {
+		offsets = append(offsets, d.InputOffset())
+		if _, _, err := d.Next(); err != nil {
+			break
+		}
+	}
+	assert.Equal(t, []int64{0, 3, 7, 11}, offsets)
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	_, err := decoderTokens(NewDecoderReader(strings.NewReader(`<a><b`)))
+	assert.Equal(t, &TokenError{Offset: 3, Err: ErrUnterminatedElement}, err)
+	_, err = decoderTokens(NewDecoderReader(strings.NewReader(`<a><![CDATA[text`)))
+	assert.True(t, errors.Is(err, ErrUnterminatedCDATA))
+	readErr := errors.New("read failed")
+	tokens, err := decoderTokens(NewDecoderReader(io.MultiReader(strings.NewReader(`<a>`), &errReader{readErr})))
+	assert.Equal(t, []string{"<a>"}, tokens)
+	assert.Equal(t, readErr, err)
+}
+
+func TestDecoder_TrimCharData(t *testing.T) {
+	input := "<config>\n  <name>  value  </name>\n  <empty>   </empty>\n</config>\n"
+	expected := []string{`<config>`, `<name>`, `value`, `</name>`, `<empty>`, `</empty>`, `</config>`}
+	d := NewDecoder([]byte(input))
+	d.TrimCharData = true
+	tokens, err := decoderTokens(d)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tokens)
+	d = NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.TrimCharData = true
+	tokens, err = decoderTokens(d)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tokens)
+}
+
+func TestDecoder_NextKind(t *testing.T) {
+	d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(`<a>text<b/></a>`)), 16)
+	var kinds []Kind
+	for {
+		_, kind, err := d.NextKind()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		kinds = append(kinds, kind)
+	}
+	assert.Equal(t, []Kind{KindStartElement, KindCharData, KindSelfClosing, KindEndElement}, kinds)
+}
+
+func TestDecoder_TrackLines(t *testing.T) {
+	input := strings.Repeat("<a>line</a>\n", 10) + "  <b"
+	d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.TrackLines = true
+	tokens, err := decoderTokens(d)
+	assert.Len(t, tokens, 40)
+	assert.EqualError(t, err, "syntax error at line 11, column 3: expected Token to end with '>'")
+	var syntaxErr *SyntaxError
+	if assert.True(t, errors.As(err, &syntaxErr)) {
+		assert.Equal(t, len(input)-2, syntaxErr.Offset)
+		assert.True(t, errors.Is(syntaxErr, ErrUnterminatedElement))
+	}
+	line, column := d.Position()
+	assert.Equal(t, 11, line)
+	assert.Equal(t, 3, column)
+}
+
+func TestDecoder_Progress(t *testing.T) {
+	input := `<a>` + strings.Repeat(`<b>text</b>`, 10) + `</a>`
+	d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.ProgressInterval = 30
+	var offsets []int64
+	d.Progress = func(offset int64) {
+		offsets = append(offsets, offset)
+	}
+	tokens, err := decoderTokens(d)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 32)
+	assert.Equal(t, []int64{3, 36, 69, 102, int64(len(input))}, offsets)
+}
+
+func TestDecoder_SelfClosingWhitespace(t *testing.T) {
+	d := NewDecoderReader(strings.NewReader(`<a><b / ><b	/></a>`))
+	d.MaxDepth = 2
+	tokens, err := decoderTokens(d)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`<a>`, `<b / >`, "<b\t/>", `</a>`}, tokens)
+}
+
+func TestDecoder_Mode(t *testing.T) {
+	// The open elements outlive the buffer they were read from
+	input := `<html><body>` + strings.Repeat(`<p>text<br>`, 20) + `<p>R&D</body>`
+	d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.Mode = ParseHTML
+	tokens, err := decoderTokens(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>R&amp;D`+strings.Repeat(`</p>`, 21)+`</body></html>`, strings.Join(tokens[len(tokens)-25:], ""))
+
+	// Errors are reported at their offset in the input
+	input = strings.Repeat(`<a>`, 10) + "\n" + strings.Repeat(`</a>`, 9) + `</b>`
+	d = NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.Mode = ParseStrict
+	_, err = decoderTokens(d)
+	assert.Equal(t, &MismatchError{StartName: "a", StartOffset: 0, EndName: "b", EndOffset: len(input) - 4}, err)
+	d = NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input+"x")), 16)
+	d.Mode = ParseStrict
+	d.TrackLines = true
+	_, err = decoderTokens(d)
+	assert.EqualError(t, err, "syntax error at line 2, column 37: element <a> at offset 0 closed by </b> at offset 67")
+	input = strings.Repeat(`<a>`, 10) + "\n  <b x=\"1\" x=\"2\"/>"
+	d = NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+	d.Mode = ParseStrict
+	_, err = decoderTokens(d)
+	assert.EqualError(t, err, `syntax error at line 2, column 12: duplicate attribute "x"`)
+	var syntaxErr *SyntaxError
+	if assert.True(t, errors.As(err, &syntaxErr)) {
+		assert.Equal(t, strings.LastIndex(input, "x="), syntaxErr.Offset)
+	}
+}
+
+// chunkReader reads at most n bytes from r at a time
+type chunkReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	return r.r.Read(p)
+}
+
+func TestDecoder_LargeToken(t *testing.T) {
+	// Tokens larger than a read are not tokenized again after every read (which was quadratic)
+	large := strings.Repeat("x", 4<<20)
+	for _, input := range []string{
+		`<a>` + large + `</a>`,
+		`<a><!--` + large + `--></a>`,
+		`<a><![CDATA[` + large + `]]></a>`,
+		`<a b="` + large + `"/>`,
+	} {
+		d := NewDecoderReader(&chunkReader{strings.NewReader(input), 4096})
+		tokens, err := decoderTokens(d)
+		assert.NoError(t, err)
+		assert.Equal(t, input, strings.Join(tokens, ""))
+	}
+	// The end of a token split across reads is still found
+	for _, mode := range []ParseMode{ParseDefault, ParseLenient} {
+		input := `<a x='">'><!-- > -- -> --><![CDATA[ ]> ]] ]]><?p ? > ?>text<b>< 2</b></a>`
+		d := NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16)
+		d.Mode = mode
+		tokens, err := decoderTokens(d)
+		assert.NoError(t, err)
+		assert.Equal(t, `<!-- > -- -> -->`, tokens[1])
+		assert.Equal(t, `<![CDATA[ ]> ]] ]]>`, tokens[2])
+		assert.Equal(t, `<?p ? > ?>`, tokens[3])
+	}
+}
@@ -0,0 +1,93 @@
+package fastxml
+
+import "strconv"
+
+// ChangeType is the kind of a Change
+type ChangeType uint8
+
+const (
+	// ChangeAdded is a node or attribute only present in the new document
+	ChangeAdded ChangeType = iota
+	// ChangeRemoved is a node or attribute only present in the old document
+	ChangeRemoved
+	// ChangeModified is a node or attribute whose value differs between the documents
+	ChangeModified
+)
+
+// changeTypeNames are the names of each ChangeType
+var changeTypeNames = [...]string{"Added", "Removed", "Modified"}
+
+// String returns the name of the change type
+func (t ChangeType) String() string {
+	if int(t) < len(changeTypeNames) {
+		return changeTypeNames[t]
+	}
+	return "Unknown"
+}
+
+// Change is a single structural difference between two documents
+type Change struct {
+	Type ChangeType
+	// Path is the XPath of the node or attribute (ex: `/root[1]/item[2]/@id`)
+	// Removed nodes are located by their position in the old document, all others by their position in the new document
+	Path string
+	// Attr is true if the change is to an attribute rather than a node
+	Attr bool
+	// Old and New are the node (as XML) or attribute value in each document (nil if it is not present)
+	Old, New []byte
+}
+
+// changeTypes maps each editOp to its ChangeType
+var changeTypes = [...]ChangeType{editAdd: ChangeAdded, editRemove: ChangeRemoved, editReplace: ChangeModified}
+
+// Diff tokenizes a and b and produces the structural differences between them: added, removed and changed
+// elements (and other nodes) and attributes, a changed element name is reported as a modified node
+// Children are matched in order so only the elements which actually differ are reported
+func Diff(a, b []byte) ([]Change, error) {
+	edits, err := diffTrees(a, b)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]Change, 0, len(edits))
+	for _, e := range edits {
+		c := Change{Type: changeTypes[e.op], Path: e.path, Attr: e.attr != nil, Old: e.oldValue, New: e.newValue}
+		if e.old != nil {
+			c.Old = e.old.appendXML(nil)
+		}
+		if e.new != nil {
+			c.New = e.new.appendXML(nil)
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// appendDiffLine appends a single line of a textual diff (ex: `+/root[1]/@id: "1"`)
+func appendDiffLine(dst []byte, sign byte, path string, value []byte) []byte {
+	dst = append(dst, sign)
+	dst = append(dst, path...)
+	dst = append(dst, ':', ' ')
+	dst = strconv.AppendQuote(dst, String(value))
+	return append(dst, '\n')
+}
+
+// DiffText produces a human readable, unified-style report of the structural differences between a and b (see Diff)
+// Each removed (-) or added (+) node and attribute is written on its own line as its XPath and value,
+// a changed node or attribute is written as a removal followed by an addition
+// Removed nodes are located by their position in a, all others by their position in b
+func DiffText(a, b []byte) ([]byte, error) {
+	changes, err := Diff(a, b)
+	if err != nil {
+		return nil, err
+	}
+	var dst []byte
+	for _, c := range changes {
+		if c.Type != ChangeAdded {
+			dst = appendDiffLine(dst, '-', c.Path, c.Old)
+		}
+		if c.Type != ChangeRemoved {
+			dst = appendDiffLine(dst, '+', c.Path, c.New)
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,57 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffText(t *testing.T) {
+	report, err := DiffText(
+		[]byte(`<root a="1" b="2"><item>one</item><old/><item>x</item></root>`),
+		[]byte(`<root a="1" b="3" c="4"><new/><item>two</item><item>x</item></root>`),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, `-/root[1]/@b: "2"
++/root[1]/@b: "3"
++/root[1]/@c: "4"
+-/root[1]/old[1]: "<old/>"
+-/root[1]/item[1]/text()[1]: "one"
++/root[1]/item[1]/text()[1]: "two"
++/root[1]/new[1]: "<new/>"
+`, string(report))
+
+	report, err = DiffText([]byte(`<same/>`), []byte(`<same/>`))
+	assert.NoError(t, err)
+	assert.Empty(t, report)
+
+	_, err = DiffText([]byte(`<a>`), []byte(`<a/>`))
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(
+		[]byte(`<config version="1"><server host="a" port="80"/><!-- c --><feature>on</feature></config>`),
+		[]byte(`<config version="2"><server host="a" tls="true"/><feature>off</feature><extra/></config>`),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Type: ChangeModified, Path: "/config[1]/@version", Attr: true, Old: []byte("1"), New: []byte("2")},
+		{Type: ChangeRemoved, Path: "/config[1]/comment()[1]", Old: []byte("<!-- c -->")},
+		{Type: ChangeRemoved, Path: "/config[1]/server[1]/@port", Attr: true, Old: []byte("80")},
+		{Type: ChangeAdded, Path: "/config[1]/server[1]/@tls", Attr: true, New: []byte("true")},
+		{Type: ChangeModified, Path: "/config[1]/feature[1]/text()[1]", Old: []byte("on"), New: []byte("off")},
+		{Type: ChangeAdded, Path: "/config[1]/extra[1]", New: []byte("<extra/>")},
+	}, changes)
+	assert.Equal(t, "Added", ChangeAdded.String())
+	assert.Equal(t, "Removed", ChangeRemoved.String())
+	assert.Equal(t, "Modified", ChangeModified.String())
+	assert.Equal(t, "Unknown", ChangeType(42).String())
+
+	changes, err = Diff([]byte(`<same/>`), []byte(`<same/>`))
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = Diff([]byte(`<a/>`), []byte(`<a>`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,463 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DTD is the document type declaration of a document (ex: `<!DOCTYPE root SYSTEM "root.dtd" [...]>`)
+// Only the internal subset is parsed, an external DTD is never loaded
+type DTD struct {
+	Name     string // the name of the root element
+	PublicID string
+	SystemID string
+	Elements []ElementDecl
+	Attrs    []AttrDecl
+	Entities []EntityDecl
+}
+
+// ElementDecl is an element type declaration (ex: `<!ELEMENT a (b|c)*>`)
+type ElementDecl struct {
+	Name    string
+	Content string // the content specification (ex: `EMPTY`, `ANY` or `(#PCDATA|b)*`)
+}
+
+// AttrDecl is an attribute declared by an attribute list declaration (ex: `<!ATTLIST a id ID #REQUIRED>`)
+type AttrDecl struct {
+	Element string
+	Name    string
+	Type    string // ex: `CDATA`, `ID`, `(yes|no)` or `NOTATION (gif|png)`
+	Default string // `#REQUIRED`, `#IMPLIED`, `#FIXED` or empty if there is only a default Value
+	Value   string // the default (or fixed) value, entities are not decoded
+}
+
+// EntityDecl is an entity declaration (ex: `<!ENTITY name "value">`)
+type EntityDecl struct {
+	Name      string
+	Parameter bool   // if it is a parameter entity (ex: `<!ENTITY % name "value">`)
+	Value     string // the replacement text of an internal entity, references are not expanded
+	PublicID  string
+	SystemID  string // non-empty (or PublicID) for an external entity
+	Notation  string // the NDATA notation of an unparsed entity
+}
+
+// External determines if the entity references an external resource instead of having a Value
+func (e *EntityDecl) External() bool {
+	return e.SystemID != "" || e.PublicID != ""
+}
+
+// Allocate these once instead of on each bytes.HasPrefix call
+var (
+	prefixElementDecl  = []byte("<!ELEMENT")
+	prefixAttlistDecl  = []byte("<!ATTLIST")
+	prefixNotationDecl = []byte("<!NOTATION")
+)
+
+// errDTDEnd is returned when a declaration of the internal subset is not terminated
+var errDTDEnd = errors.New("invalid DTD: expected declaration to end with '>'")
+
+// doctypeEnd returns the index of the '>' ending the DOCTYPE at the start of buf (or -1)
+// The '>' of the declarations, comments and processing instructions in the internal subset and
+// any '>' in a quoted literal does not end it
+func doctypeEnd(buf []byte) int {
+	depth := 0
+	for idx := 2; idx < len(buf); idx++ {
+		switch c := buf[idx]; c {
+		case '"', '\'':
+			end := bytes.IndexByte(buf[idx+1:], c)
+			if end == -1 {
+				return -1
+			}
+			idx += end + 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '<':
+			var suffix []byte
+			if bytes.HasPrefix(buf[idx:], prefixComment) {
+				suffix = suffixComment
+			} else if bytes.HasPrefix(buf[idx:], prefixProcInst) {
+				suffix = suffixProcInst
+			} else {
+				continue
+			}
+			end := bytes.Index(buf[idx+2:], suffix)
+			if end == -1 {
+				return -1
+			}
+			idx += end + 2 + len(suffix) - 1
+		case '>':
+			if depth <= 0 {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+// dtdParser reads the declarations of a DOCTYPE
+type dtdParser struct {
+	buf []byte
+	pos int
+}
+
+// space skips any whitespace returning if there was any
+func (p *dtdParser) space() bool {
+	start := p.pos
+	for p.pos < len(p.buf) && isSpace(p.buf[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// name reads a name (or name token)
+func (p *dtdParser) name() (string, error) {
+	start := p.pos
+	for p.pos < len(p.buf) && !isSpace(p.buf[p.pos]) && !bytes.ContainsAny(p.buf[p.pos:p.pos+1], `"'<>[]()|,%;`) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a name")
+	}
+	return string(p.buf[start:p.pos]), nil
+}
+
+// literal reads a quoted literal returning it without the quotes
+func (p *dtdParser) literal() (string, error) {
+	if p.pos == len(p.buf) || (p.buf[p.pos] != '"' && p.buf[p.pos] != '\'') {
+		return "", p.errorf("expected a quoted literal")
+	}
+	end := bytes.IndexByte(p.buf[p.pos+1:], p.buf[p.pos])
+	if end == -1 {
+		return "", p.errorf("unterminated quoted literal")
+	}
+	value := string(p.buf[p.pos+1 : p.pos+1+end])
+	p.pos += end + 2
+	return value, nil
+}
+
+// group reads a parenthesized group (ex: `(a|b)*`) including any trailing occurrence indicator
+func (p *dtdParser) group() (string, error) {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.buf); p.pos++ {
+		switch p.buf[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		return "", p.errorf("unterminated group")
+	}
+	p.pos++
+	if p.pos < len(p.buf) && (p.buf[p.pos] == '?' || p.buf[p.pos] == '*' || p.buf[p.pos] == '+') {
+		p.pos++
+	}
+	return string(p.buf[start:p.pos]), nil
+}
+
+// end expects the '>' ending a declaration
+func (p *dtdParser) end() error {
+	p.space()
+	if p.pos == len(p.buf) || p.buf[p.pos] != '>' {
+		return errDTDEnd
+	}
+	p.pos++
+	return nil
+}
+
+// errorf creates an error at the current position
+func (p *dtdParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid DTD at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// externalID reads an optional `SYSTEM "uri"` or `PUBLIC "id" "uri"`
+func (p *dtdParser) externalID() (publicID string, systemID string, err error) {
+	start := p.pos
+	keyword, _ := p.name()
+	switch keyword {
+	case "SYSTEM":
+		p.space()
+		systemID, err = p.literal()
+	case "PUBLIC":
+		p.space()
+		if publicID, err = p.literal(); err != nil {
+			return
+		}
+		// The system literal is optional in a NOTATION declaration
+		if p.space() && p.pos < len(p.buf) && p.buf[p.pos] != '>' {
+			systemID, err = p.literal()
+		}
+	default:
+		p.pos = start
+	}
+	return
+}
+
+// ParseDTD parses a DOCTYPE directive (ex: the contents returned by Directive) extracting the ELEMENT,
+// ATTLIST and ENTITY declarations of its internal subset. Comments, processing instructions, NOTATION
+// declarations and parameter entity references in the internal subset are skipped (parameter entities
+// are not expanded)
+func ParseDTD(directive []byte) (*DTD, error) {
+	if IsDirective(directive) {
+		directive = Directive(directive)
+	}
+	if !bytes.HasPrefix(directive, prefixDoctype) {
+		return nil, errors.New("invalid DTD: expected DOCTYPE")
+	}
+	p := &dtdParser{buf: directive, pos: len(prefixDoctype)}
+	d := &DTD{}
+	if !p.space() {
+		return nil, p.errorf("expected whitespace after DOCTYPE")
+	}
+	var err error
+	if d.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	p.space()
+	if d.PublicID, d.SystemID, err = p.externalID(); err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.buf) && p.buf[p.pos] == '[' {
+		p.pos++
+		if err := p.subset(d); err != nil {
+			return nil, err
+		}
+		p.space()
+	}
+	if p.pos != len(p.buf) {
+		return nil, p.errorf("unexpected %q", p.buf[p.pos:])
+	}
+	return d, nil
+}
+
+// subset parses the declarations of the internal subset up to the closing ']'
+func (p *dtdParser) subset(d *DTD) error {
+	for {
+		p.space()
+		rest := p.buf[p.pos:]
+		switch {
+		case len(rest) == 0:
+			return p.errorf("expected ']' to end the internal subset")
+		case rest[0] == ']':
+			p.pos++
+			return nil
+		case rest[0] == '%':
+			end := bytes.IndexByte(rest, ';')
+			if end == -1 {
+				return p.errorf("expected ';' to end parameter entity reference")
+			}
+			p.pos += end + 1
+		case bytes.HasPrefix(rest, prefixComment), bytes.HasPrefix(rest, prefixProcInst):
+			suffix := suffixComment
+			if rest[1] == '?' {
+				suffix = suffixProcInst
+			}
+			end := bytes.Index(rest[2:], suffix)
+			if end == -1 {
+				return p.errorf("expected %q", suffix)
+			}
+			p.pos += 2 + end + len(suffix)
+		case bytes.HasPrefix(rest, prefixElementDecl):
+			p.pos += len(prefixElementDecl)
+			if err := p.elementDecl(d); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(rest, prefixAttlistDecl):
+			p.pos += len(prefixAttlistDecl)
+			if err := p.attlistDecl(d); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(rest, prefixEntity):
+			p.pos += len(prefixEntity)
+			if err := p.entityDecl(d); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(rest, prefixNotationDecl):
+			p.pos += len(prefixNotationDecl)
+			p.space()
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			p.space()
+			if _, _, err := p.externalID(); err != nil {
+				return err
+			}
+			if err := p.end(); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("unexpected %q in internal subset", rest)
+		}
+	}
+}
+
+// elementDecl parses the rest of `<!ELEMENT name content>`
+func (p *dtdParser) elementDecl(d *DTD) error {
+	p.space()
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	p.space()
+	var content string
+	if p.pos < len(p.buf) && p.buf[p.pos] == '(' {
+		content, err = p.group()
+	} else {
+		content, err = p.name()
+	}
+	if err != nil {
+		return err
+	}
+	d.Elements = append(d.Elements, ElementDecl{Name: name, Content: content})
+	return p.end()
+}
+
+// attlistDecl parses the rest of `<!ATTLIST element (name type default)*>`
+func (p *dtdParser) attlistDecl(d *DTD) error {
+	p.space()
+	element, err := p.name()
+	if err != nil {
+		return err
+	}
+	for {
+		p.space()
+		if p.pos < len(p.buf) && p.buf[p.pos] == '>' {
+			p.pos++
+			return nil
+		}
+		attr := AttrDecl{Element: element}
+		if attr.Name, err = p.name(); err != nil {
+			return err
+		}
+		p.space()
+		if p.pos < len(p.buf) && p.buf[p.pos] == '(' {
+			attr.Type, err = p.group()
+		} else if attr.Type, err = p.name(); err == nil && attr.Type == "NOTATION" {
+			p.space()
+			var notations string
+			notations, err = p.group()
+			attr.Type += " " + notations
+		}
+		if err != nil {
+			return err
+		}
+		p.space()
+		if p.pos < len(p.buf) && p.buf[p.pos] == '#' {
+			p.pos++
+			if attr.Default, err = p.name(); err != nil {
+				return err
+			}
+			attr.Default = "#" + attr.Default
+			switch attr.Default {
+			case "#REQUIRED", "#IMPLIED":
+			case "#FIXED":
+				p.space()
+				if attr.Value, err = p.literal(); err != nil {
+					return err
+				}
+			default:
+				return p.errorf("unknown attribute default %q", attr.Default)
+			}
+		} else if attr.Value, err = p.literal(); err != nil {
+			return err
+		}
+		d.Attrs = append(d.Attrs, attr)
+	}
+}
+
+// entityDecl parses the rest of `<!ENTITY [%] name (value | external ID [NDATA notation])>`
+func (p *dtdParser) entityDecl(d *DTD) error {
+	p.space()
+	var entity EntityDecl
+	if p.pos < len(p.buf) && p.buf[p.pos] == '%' {
+		entity.Parameter = true
+		p.pos++
+		if !p.space() {
+			return p.errorf("expected whitespace after '%%'")
+		}
+	}
+	var err error
+	if entity.Name, err = p.name(); err != nil {
+		return err
+	}
+	p.space()
+	if p.pos < len(p.buf) && (p.buf[p.pos] == '"' || p.buf[p.pos] == '\'') {
+		if entity.Value, err = p.literal(); err != nil {
+			return err
+		}
+	} else {
+		if entity.PublicID, entity.SystemID, err = p.externalID(); err != nil {
+			return err
+		}
+		if entity.SystemID == "" {
+			return p.errorf("expected an entity value or external ID for %q", entity.Name)
+		}
+		p.space()
+		if start := p.pos; !entity.Parameter {
+			if keyword, _ := p.name(); keyword == "NDATA" {
+				p.space()
+				if entity.Notation, err = p.name(); err != nil {
+					return err
+				}
+			} else {
+				p.pos = start
+			}
+		}
+	}
+	d.Entities = append(d.Entities, entity)
+	return p.end()
+}
+
+// EntityDecoder returns an EntityDecoder resolving the internal general entities declared by the DTD
+// in addition to the entities resolved by DecodeEntities, if an entity is declared more than once the
+// first declaration is used. External entities are never resolved and remain an error to reference
+func (d *DTD) EntityDecoder() *EntityDecoder {
+	entities := make(map[string]string, len(d.Entities))
+	for _, entity := range d.Entities {
+		if entity.Parameter || entity.External() {
+			continue
+		}
+		if _, ok := entities[entity.Name]; !ok {
+			entities[entity.Name] = entity.Value
+		}
+	}
+	return &EntityDecoder{Entity: entities}
+}
+
+// DocumentEntities returns an EntityDecoder for the entities declared by the DOCTYPE of buf (if any)
+// so that documents defining their own entities can be decoded (ex: with EntityDecoder.CharData)
+// Only the prolog is scanned, the decoder resolves the same entities as DecodeEntities without a DOCTYPE
+func DocumentEntities(buf []byte) (*EntityDecoder, error) {
+	s := NewScanner(buf)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return &EntityDecoder{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case chardata, IsComment(token), IsProcInst(token):
+		case IsDirective(token):
+			if bytes.HasPrefix(Directive(token), prefixDoctype) {
+				d, err := ParseDTD(Directive(token))
+				if err != nil {
+					return nil, err
+				}
+				return d.EntityDecoder(), nil
+			}
+		default:
+			return &EntityDecoder{}, nil
+		}
+	}
+}
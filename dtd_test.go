@@ -0,0 +1,131 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDTD(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected *DTD
+		Error    string
+	}{
+		{
+			Input:    `DOCTYPE html`,
+			Expected: &DTD{Name: "html"},
+		},
+		{
+			Input:    `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`,
+			Expected: &DTD{Name: "html", PublicID: "-//W3C//DTD XHTML 1.0 Strict//EN", SystemID: "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"},
+		},
+		{
+			Input: `DOCTYPE note SYSTEM 'note.dtd' [
+	<!-- declarations > -->
+	<?pi data?>
+	<!ELEMENT note (to,from,body?)+>
+	<!ELEMENT to (#PCDATA)>
+	<!ELEMENT br EMPTY>
+	<!ATTLIST note
+		id ID #REQUIRED
+		lang CDATA #IMPLIED
+		version CDATA #FIXED "1.0"
+		kind (a|b) "a"
+		img NOTATION (gif) #IMPLIED>
+	<!ENTITY company "Fast &amp; XML">
+	<!ENTITY footer '&company; > "all"'>
+	<!ENTITY % common "lang CDATA #IMPLIED">
+	%common;
+	<!ENTITY logo SYSTEM "logo.gif" NDATA gif>
+	<!ENTITY ext PUBLIC "-//x" "ext.xml">
+	<!NOTATION gif PUBLIC "image/gif">
+]`,
+			Expected: &DTD{
+				Name:     "note",
+				SystemID: "note.dtd",
+				Elements: []ElementDecl{
+					{Name: "note", Content: "(to,from,body?)+"},
+					{Name: "to", Content: "(#PCDATA)"},
+					{Name: "br", Content: "EMPTY"},
+				},
+				Attrs: []AttrDecl{
+					{Element: "note", Name: "id", Type: "ID", Default: "#REQUIRED"},
+					{Element: "note", Name: "lang", Type: "CDATA", Default: "#IMPLIED"},
+					{Element: "note", Name: "version", Type: "CDATA", Default: "#FIXED", Value: "1.0"},
+					{Element: "note", Name: "kind", Type: "(a|b)", Value: "a"},
+					{Element: "note", Name: "img", Type: "NOTATION (gif)", Default: "#IMPLIED"},
+				},
+				Entities: []EntityDecl{
+					{Name: "company", Value: "Fast &amp; XML"},
+					{Name: "footer", Value: `&company; > "all"`},
+					{Name: "common", Parameter: true, Value: "lang CDATA #IMPLIED"},
+					{Name: "logo", SystemID: "logo.gif", Notation: "gif"},
+					{Name: "ext", PublicID: "-//x", SystemID: "ext.xml"},
+				},
+			},
+		},
+		{
+			Input: `ELEMENT a EMPTY`,
+			Error: "invalid DTD: expected DOCTYPE",
+		},
+		{
+			Input: `DOCTYPE a [<!ELEMENT a EMPTY>`,
+			Error: "invalid DTD at offset 29: expected ']' to end the internal subset",
+		},
+		{
+			Input: `DOCTYPE a [<!ENTITY a "b"]>`,
+			Error: "invalid DTD: expected declaration to end with '>'",
+		},
+		{
+			Input: `DOCTYPE a [<!ATTLIST a b CDATA #DEFAULT>]`,
+			Error: `invalid DTD at offset 39: unknown attribute default "#DEFAULT"`,
+		},
+		{
+			Input: `DOCTYPE a [<!ENTITY a>]`,
+			Error: `invalid DTD at offset 21: expected an entity value or external ID for "a"`,
+		},
+		{
+			Input: `DOCTYPE a [<![INCLUDE[]]>]`,
+			Error: `invalid DTD at offset 11: unexpected "<![INCLUDE[]]>]" in internal subset`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Error, func(t *testing.T) {
+			d, err := ParseDTD([]byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.Expected, d)
+			}
+		})
+	}
+}
+
+func TestDocumentEntities(t *testing.T) {
+	buf := []byte(`<?xml version="1.0"?>
+<!DOCTYPE a [
+	<!ENTITY company "Fast &amp; XML">
+	<!ENTITY footer "&copy; &company; <b>">
+	<!ENTITY company "ignored">
+	<!ENTITY xxe SYSTEM "file:///etc/passwd">
+]>
+<a title="&company;">&footer;&xxe;</a>`)
+	d, err := DocumentEntities(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{"company": "Fast &amp; XML", "footer": "&copy; &company; <b>"}, d.Entity)
+	decoded, err := d.Decode([]byte(`&footer;&lt;`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "© Fast & XML <b><", string(decoded))
+	_, err = d.Decode([]byte(`&xxe;`), nil)
+	assert.EqualError(t, err, `unknown XML entity "xxe" at offset 0`)
+
+	d, err = DocumentEntities([]byte(`<a>&nbsp;</a><!DOCTYPE b [<!ENTITY nbsp "x">]>`))
+	if assert.NoError(t, err) {
+		assert.Empty(t, d.Entity)
+	}
+	_, err = DocumentEntities([]byte(`<!DOCTYPE a [<!ENTITY a>]><a/>`))
+	assert.Error(t, err)
+}
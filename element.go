@@ -4,15 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"unicode"
 )
 
 // Allocate the errors once and return the same structs
-var (
-	errAttrKeyWhitespace = errors.New(`expected Attr to have a non-whitespace key`)
-	errAttrPrefix        = errors.New(`expected Attr to start with '"'`)
-	errAttrSuffix        = errors.New(`expected Attr to end with '"'`)
-)
+var errAttrIndex = errors.New("attribute index out of range")
 
 // IsElement checks if a []byte is an element (is not a ProcInst or Directive)
 func IsElement(token []byte) bool {
@@ -21,11 +16,31 @@ func IsElement(token []byte) bool {
 }
 
 // IsSelfClosing checks if a []byte is an self closing element (<element/>)
+// Whitespace is allowed between the '/' and '>' (ex: `<element / >`) but a '/' within a quoted
+// attribute value (ex: `<a href='/>`) does not make the element self-closing
 func IsSelfClosing(token []byte) bool {
+	return selfClosingSlash(token) != -1
+}
+
+// selfClosingSlash returns the index of the '/' which makes token a self-closing element (or -1)
+func selfClosingSlash(token []byte) int {
 	if len(token) <= 2 {
-		return false
+		return -1
 	}
-	return token[len(token)-2] == '/'
+	slash := len(token) - 2
+	for slash > 1 && isSpace(token[slash]) {
+		slash--
+	}
+	if token[slash] != '/' || inQuotes(token[:slash]) {
+		return -1
+	}
+	return slash
+}
+
+// inQuotes checks if the end of b is within a (single or double) quoted attribute value
+func inQuotes(b []byte) bool {
+	// The end of b is treated as the '>' ending an element, it is quoted if there is no '>' after it
+	return elementEnd(b, len(b)) == -1
 }
 
 // IsEndElement checks if a []byte is a </element>
@@ -38,6 +53,21 @@ func IsStartElement(token []byte) bool {
 	return len(token) >= 2 && token[0] == '<' && token[1] != '/'
 }
 
+// isSpace determines if c is XML whitespace (' ', '\t', '\r' or '\n')
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// indexSpace returns the index of the first XML whitespace in b (or -1)
+func indexSpace(b []byte) int {
+	for idx, c := range b {
+		if isSpace(c) {
+			return idx
+		}
+	}
+	return -1
+}
+
 // Element extracts the name of the element (ex: `<foo:bar key="val"/>` -> `foo:bar`) and attribute sections
 func Element(token []byte) (name []byte, attrs []byte) {
 	if len(token) < 3 {
@@ -50,20 +80,40 @@ func Element(token []byte) (name []byte, attrs []byte) {
 		start++ // handle end elements
 	}
 	// handle self-closing elements
-	if token[end-1] == '/' {
-		end--
+	if slash := selfClosingSlash(token); slash != -1 {
+		end = slash
+	}
+	// ex: `</>`
+	if end < start {
+		return nil, nil
 	}
-	// If there are attributes present
-	if space := bytes.IndexByte(token[start:end], ' '); space != -1 {
+	// If there are attributes present, separated from the name by any whitespace (ex: `<foo\n\tkey="val">`)
+	if space := indexSpace(token[start:end]); space != -1 {
 		return token[start : start+space], token[space+start+1 : end]
 	}
 	// No attributes
 	return token[start:end], nil
 }
 
-// notSpace is the inverse of unicode.IsSpace
-func notSpace(r rune) bool {
-	return !unicode.IsSpace(r)
+// indexNotSpace returns the index of the first byte in b which is not XML whitespace (or -1)
+// Only the 4 ASCII whitespace characters are XML whitespace so multi-byte characters never are
+func indexNotSpace(b []byte) int {
+	for idx, c := range b {
+		if !isSpace(c) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// lastIndexNotSpace returns the index of the last byte in b which is not XML whitespace (or -1)
+func lastIndexNotSpace(b []byte) int {
+	for idx := len(b) - 1; idx >= 0; idx-- {
+		if !isSpace(b[idx]) {
+			return idx
+		}
+	}
+	return -1
 }
 
 // RawAttrs calls f for each key="value" in token, stopping if f returns false
@@ -81,14 +131,14 @@ func RawAttrs(attrsToken []byte, f func(keyStart, keyEnd, valueStart, valueEnd i
 		// Extract the key offsets
 		keyStart := offset
 		// Trim any whitespace on the key name
-		if idx := bytes.IndexFunc(attrsToken[offset:equals], notSpace); idx == -1 {
-			return errAttrKeyWhitespace
+		if idx := indexNotSpace(attrsToken[offset:equals]); idx == -1 {
+			return attrError(offset, "expected Attr to have a non-whitespace key")
 		} else if idx > 0 {
 			keyStart += idx
 		}
-		// Don't need to check for -1 here as IndexFunc would have found it
+		// Don't need to check for -1 here as indexNotSpace would have found it
 		keyEnd := keyStart
-		if idx := bytes.LastIndexFunc(attrsToken[keyStart:equals], notSpace); idx > 0 {
+		if idx := lastIndexNotSpace(attrsToken[keyStart:equals]); idx >= 0 {
 			keyEnd += idx + 1
 		}
 		// Move past the end of the equals statement
@@ -98,7 +148,7 @@ func RawAttrs(attrsToken []byte, f func(keyStart, keyEnd, valueStart, valueEnd i
 		// Find the `"` to start the value
 		valueStart := bytes.IndexByte(attrsToken[equals:], '"')
 		if valueStart == -1 {
-			return errAttrPrefix
+			return attrError(equals, `expected Attr to start with '"'`)
 		}
 		// ` key = "value"`
 		//          ^
@@ -108,7 +158,7 @@ func RawAttrs(attrsToken []byte, f func(keyStart, keyEnd, valueStart, valueEnd i
 		//               ^
 		valueEnd := bytes.IndexByte(attrsToken[valueStart:], '"')
 		if valueEnd == -1 {
-			return errAttrSuffix
+			return attrError(valueStart-1, `expected Attr to end with '"'`)
 		}
 		valueEnd += valueStart
 		// Move to end of value
@@ -121,12 +171,70 @@ func RawAttrs(attrsToken []byte, f func(keyStart, keyEnd, valueStart, valueEnd i
 		}
 	}
 	// Make sure no extra values in
-	if idx := bytes.IndexFunc(attrsToken[offset:], notSpace); idx != -1 {
-		return fmt.Errorf("expected whitespace but got %q", String(attrsToken[offset+idx:]))
+	if idx := indexNotSpace(attrsToken[offset:]); idx != -1 {
+		return attrError(offset+idx, "expected whitespace but got %q", String(attrsToken[offset+idx:]))
 	}
 	return nil
 }
 
+// RawAttrsLenient is RawAttrs also accepting attributes without a value (ex: `<input disabled>`)
+// which are reported with an empty value (valueStart and valueEnd are both keyEnd)
+func RawAttrsLenient(attrsToken []byte, f func(keyStart, keyEnd, valueStart, valueEnd int) bool) error {
+	offset := 0
+	for {
+		// Skip the whitespace before the key
+		for offset < len(attrsToken) && isSpace(attrsToken[offset]) {
+			offset++
+		}
+		if offset == len(attrsToken) {
+			return nil
+		}
+		keyStart := offset
+		for offset < len(attrsToken) && !isSpace(attrsToken[offset]) && attrsToken[offset] != '=' {
+			offset++
+		}
+		keyEnd := offset
+		if keyStart == keyEnd {
+			return attrError(keyStart, "expected Attr to have a non-whitespace key") // ex: ` ="value"`
+		}
+		for offset < len(attrsToken) && isSpace(attrsToken[offset]) {
+			offset++
+		}
+		// ` key other="value"`
+		//       ^
+		if offset == len(attrsToken) || attrsToken[offset] != '=' {
+			if !f(keyStart, keyEnd, keyEnd, keyEnd) {
+				return nil
+			}
+			continue
+		}
+		offset++
+		for offset < len(attrsToken) && isSpace(attrsToken[offset]) {
+			offset++
+		}
+		if offset == len(attrsToken) || attrsToken[offset] != '"' {
+			return attrError(offset, `expected Attr to start with '"'`)
+		}
+		valueStart := offset + 1
+		valueEnd := bytes.IndexByte(attrsToken[valueStart:], '"')
+		if valueEnd == -1 {
+			return attrError(valueStart-1, `expected Attr to end with '"'`)
+		}
+		valueEnd += valueStart
+		offset = valueEnd + 1
+		if !f(keyStart, keyEnd, valueStart, valueEnd) {
+			return nil
+		}
+	}
+}
+
+// AttrsLenient is Attrs also accepting attributes without a value which are reported with an empty value
+func AttrsLenient(attrsToken []byte, f func(key []byte, value []byte) bool) error {
+	return RawAttrsLenient(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		return f(attrsToken[keyStart:keyEnd], attrsToken[valueStart:valueEnd])
+	})
+}
+
 // Attrs calls f for each key="value" in token, stopping if f returns false
 // The value will _not_ be decoded yet
 func Attrs(attrsToken []byte, f func(key []byte, value []byte) bool) error {
@@ -135,6 +243,46 @@ func Attrs(attrsToken []byte, f func(key []byte, value []byte) bool) error {
 	})
 }
 
+// EachAttr calls f for each key="value" in token, stopping at the first error returned by f which is returned as-is
+// The value will _not_ be decoded yet, use AllAttrs to iterate with a for-range loop instead (Go 1.23+)
+func EachAttr(attrsToken []byte, f func(key []byte, value []byte) error) error {
+	var fErr error
+	err := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		fErr = f(attrsToken[keyStart:keyEnd], attrsToken[valueStart:valueEnd])
+		return fErr == nil
+	})
+	if fErr != nil {
+		return fErr
+	}
+	return err
+}
+
+// DecodedAttrs calls f for each key="value" in token with the value decoded, stopping if f returns false
+// Values are decoded into scratch (if they contain entities) which is re-used for each attribute
+// so the value is only valid until f returns
+func DecodedAttrs(attrsToken []byte, scratch []byte, f func(key []byte, value []byte) bool) error {
+	var decodeErr error
+	err := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		key, value := attrsToken[keyStart:keyEnd], attrsToken[valueStart:valueEnd]
+		if bytes.IndexByte(value, '&') != -1 {
+			scratch, decodeErr = DecodeEntitiesAppend(scratch[:0], value)
+			if decodeErr != nil {
+				// The offset is relative to the value, report it relative to attrsToken instead
+				if err, ok := decodeErr.(*TokenError); ok {
+					err.Offset += valueStart
+				}
+				return false
+			}
+			value = scratch
+		}
+		return f(key, value)
+	})
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
 // RawAttr reads a specific attribute value (or -1 if not found)
 func RawAttr(attrsToken []byte, attrKey []byte) (start int, stop int, err error) {
 	start, stop = -1, -1
@@ -148,6 +296,53 @@ func RawAttr(attrsToken []byte, attrKey []byte) (start int, stop int, err error)
 	return
 }
 
+// maxLinearAttrs is the number of attributes CheckDuplicateAttrs compares pairwise before switching to a map
+const maxLinearAttrs = 8
+
+// CheckDuplicateAttrs returns a *TokenError wrapping ErrDuplicateAttr at the offset of the first attribute in
+// attrsToken whose name repeats an earlier one (ex: `id="1" id="2"`), which XML forbids. Names are compared as
+// written, `a:id` and `b:id` are not duplicates even if both prefixes are bound to the same namespace
+func CheckDuplicateAttrs(attrsToken []byte) error {
+	var dupErr error
+	// Attributes are rare enough that a linear search is faster than a map, until there are many of them
+	var keys [maxLinearAttrs][]byte
+	var seen map[string]struct{}
+	count := 0
+	if err := RawAttrs(attrsToken, func(keyStart, keyEnd, _, _ int) bool {
+		key := attrsToken[keyStart:keyEnd]
+		duplicate := false
+		if seen != nil {
+			_, duplicate = seen[String(key)]
+			seen[String(key)] = struct{}{}
+		} else {
+			for _, prev := range keys[:count] {
+				if bytes.Equal(prev, key) {
+					duplicate = true
+					break
+				}
+			}
+			if count < len(keys) {
+				keys[count] = key
+				count++
+			} else {
+				seen = make(map[string]struct{}, 2*len(keys))
+				for _, prev := range keys {
+					seen[String(prev)] = struct{}{}
+				}
+				seen[String(key)] = struct{}{}
+			}
+		}
+		if duplicate {
+			dupErr = &TokenError{Msg: fmt.Sprintf("duplicate attribute %q", key), Offset: keyStart, Err: ErrDuplicateAttr}
+			return false
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	return dupErr
+}
+
 // Attr reads a specific attribute and returns the (non-decoded) value
 func Attr(attrsToken []byte, attrKey []byte) (attrValue []byte, err error) {
 	start, stop, err := RawAttr(attrsToken, attrKey)
@@ -158,3 +353,55 @@ func Attr(attrsToken []byte, attrKey []byte) (attrValue []byte, err error) {
 	}
 	return attrsToken[start:stop], nil
 }
+
+// AttrsNS calls f for each attribute split into its prefix and local part (ex: `xlink:href` -> (`xlink`, `href`))
+// stopping if f returns false. The value will _not_ be decoded yet
+func AttrsNS(attrsToken []byte, f func(space []byte, local []byte, value []byte) bool) error {
+	return Attrs(attrsToken, func(key []byte, value []byte) bool {
+		space, local := Name(key)
+		return f(space, local, value)
+	})
+}
+
+// AttrNS reads a specific attribute by prefix and local part and returns the (non-decoded) value
+// An empty space matches attributes without a prefix, see Namespaces.Attr to match by namespace URI instead
+func AttrNS(attrsToken []byte, space []byte, local []byte) (attrValue []byte, err error) {
+	err = AttrsNS(attrsToken, func(s []byte, l []byte, value []byte) bool {
+		if bytes.Equal(l, local) && bytes.Equal(s, space) {
+			attrValue = value
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// AttrCount returns the number of attributes in attrsToken (stopping at the first malformed attribute)
+func AttrCount(attrsToken []byte) int {
+	count := 0
+	RawAttrs(attrsToken, func(int, int, int, int) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// AttrAt returns the ith (0-based) attribute key and (non-decoded) value in attrsToken
+func AttrAt(attrsToken []byte, i int) (key []byte, value []byte, err error) {
+	if i < 0 {
+		return nil, nil, errAttrIndex
+	}
+	idx := 0
+	err = Attrs(attrsToken, func(k []byte, v []byte) bool {
+		if idx == i {
+			key, value = k, v
+			return false
+		}
+		idx++
+		return true
+	})
+	if err == nil && key == nil {
+		err = errAttrIndex
+	}
+	return
+}
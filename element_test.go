@@ -1,6 +1,8 @@
 package fastxml
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,8 +15,26 @@ func TestIsElement(t *testing.T) {
 }
 
 func TestIsSelfClosing(t *testing.T) {
-	assert.True(t, IsSelfClosing([]byte("<text/>")))
-	assert.False(t, IsSelfClosing([]byte("<text>")))
+	testCases := map[string]bool{
+		`<text/>`:             true,
+		`<text>`:              false,
+		`<text />`:            true,
+		"<text\n\t/ >":        true,
+		`<a href="/x/"/>`:     true,
+		`<a href="/x/">`:      false,
+		`<a href="/x/" >`:     false,
+		`<a b="it's" c='"'/>`: true,
+		`<a href='/>`:         false,
+		`<a href="x" t='/ >`:  false,
+		`<a b='say "hi/"'/>`:  true,
+		`<a b='x" c="/'>`:     false,
+		`<img src=x.png/>`:    true,
+		`<a/b>`:               false,
+		`<>`:                  false,
+	}
+	for token, expected := range testCases {
+		assert.Equal(t, expected, IsSelfClosing([]byte(token)), token)
+	}
 }
 
 func TestIsEndElement(t *testing.T) {
@@ -45,6 +65,9 @@ func TestElement(t *testing.T) {
 			Token: `</end>`,
 			Name:  "end",
 		},
+		{
+			Token: `</>`,
+		},
 		{
 			Token: `<foo key="val">`,
 			Name:  "foo",
@@ -60,6 +83,36 @@ func TestElement(t *testing.T) {
 			Name:  "foo",
 			Attrs: `key="val" `,
 		},
+		{
+			Token: `<foo a="1" / >`,
+			Name:  "foo",
+			Attrs: `a="1" `,
+		},
+		{
+			Token: `<foo key="val"/ >`,
+			Name:  "foo",
+			Attrs: `key="val"`,
+		},
+		{
+			Token: `<foo key='val/>`,
+			Name:  "foo",
+			Attrs: `key='val/`,
+		},
+		{
+			Token: "<foo\n\tkey=\"val\">",
+			Name:  "foo",
+			Attrs: "\tkey=\"val\"",
+		},
+		{
+			Token: "<foo\tkey=\"val\"/>",
+			Name:  "foo",
+			Attrs: `key="val"`,
+		},
+		{
+			Token: "<foo\r\n/>",
+			Name:  "foo",
+			Attrs: "\n",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.Token, func(t *testing.T) {
@@ -96,6 +149,11 @@ func TestAttrs(t *testing.T) {
 			Key:   []string{"key", "extraspace"},
 			Value: []string{"value", " val2"},
 		},
+		{
+			Token: `b="2" a="1"`,
+			Key:   []string{"b", "a"},
+			Value: []string{"2", "1"},
+		},
 		{
 			Token: `key="value" anotherkey="val"`,
 			Limit: 1,
@@ -104,15 +162,30 @@ func TestAttrs(t *testing.T) {
 		},
 		{
 			Token: `key`,
-			Error: `expected whitespace but got "key"`,
+			Error: `expected whitespace but got "key" at offset 0`,
+		},
+		{
+			Token: `clé="é" 名前="値"`,
+			Key:   []string{"clé", "名前"},
+			Value: []string{"é", "値"},
+		},
+		{
+			// U+00A0 is not XML whitespace so it is part of the key
+			Token: "\u00a0key=\"value\"",
+			Key:   []string{"\u00a0key"},
+			Value: []string{"value"},
+		},
+		{
+			Token: "key=\"value\"\u2003",
+			Error: `expected whitespace but got "\u2003" at offset 11`,
 		},
 		{
 			Token: `key=`,
-			Error: `expected Attr to start with '"'`,
+			Error: `expected Attr to start with '"' at offset 4`,
 		},
 		{
 			Token: `key="`,
-			Error: `expected Attr to end with '"'`,
+			Error: `expected Attr to end with '"' at offset 4`,
 		},
 	}
 	for _, tc := range testCases {
@@ -137,3 +210,207 @@ func TestAttrs(t *testing.T) {
 		})
 	}
 }
+
+func TestAttrsLenient(t *testing.T) {
+	testCases := []struct {
+		Token string
+		Key   []string
+		Value []string
+		Error string
+	}{
+		{Token: ``},
+		{Token: `disabled`, Key: []string{"disabled"}, Value: []string{""}},
+		{
+			Token: `type="checkbox" checked disabled  name = "a b"`,
+			Key:   []string{"type", "checked", "disabled", "name"},
+			Value: []string{"checkbox", "", "", "a b"},
+		},
+		{Token: "\tchecked\n", Key: []string{"checked"}, Value: []string{""}},
+		{Token: `key=`, Error: `expected Attr to start with '"' at offset 4`},
+		{Token: `key=value`, Error: `expected Attr to start with '"' at offset 4`},
+		{Token: `key="`, Error: `expected Attr to end with '"' at offset 4`},
+		{Token: ` ="value"`, Error: `expected Attr to have a non-whitespace key at offset 1`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Token, func(t *testing.T) {
+			var keys []string
+			var vals []string
+			err := AttrsLenient([]byte(tc.Token), func(key, val []byte) bool {
+				keys = append(keys, string(key))
+				vals = append(vals, string(val))
+				return true
+			})
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Key, keys)
+				assert.Equal(t, tc.Value, vals)
+			}
+		})
+	}
+	// Stops when f returns false
+	count := 0
+	assert.NoError(t, RawAttrsLenient([]byte(`a b c`), func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		assert.Equal(t, keyEnd, valueStart)
+		assert.Equal(t, keyEnd, valueEnd)
+		count++
+		return count < 2
+	}))
+	assert.Equal(t, 2, count)
+}
+
+func TestEachAttr(t *testing.T) {
+	var keys []string
+	assert.NoError(t, EachAttr([]byte(`a="1" b="2"`), func(key, value []byte) error {
+		keys = append(keys, string(key)+"="+string(value))
+		return nil
+	}))
+	assert.Equal(t, []string{"a=1", "b=2"}, keys)
+
+	// The error of f is returned as-is stopping the iteration
+	stop := errors.New("stop")
+	keys = nil
+	err := EachAttr([]byte(`a="1" b="2" c`), func(key, value []byte) error {
+		keys = append(keys, string(key))
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"a"}, keys)
+
+	err = EachAttr([]byte(`a="1" c`), func(key, value []byte) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrBadAttr))
+}
+
+func TestDecodedAttrs(t *testing.T) {
+	testCases := []struct {
+		Token string
+		Key   []string
+		Value []string
+		Error string
+		Limit int
+	}{
+		{
+			Token: `a="plain" b="&lt;&amp;&gt;" c="x &quot;y&quot;"`,
+			Key:   []string{"a", "b", "c"},
+			Value: []string{"plain", "<&>", `x "y"`},
+		},
+		{
+			Token: `a="&amp;" b="&amp;"`,
+			Limit: 1,
+			Key:   []string{"a"},
+			Value: []string{"&"},
+		},
+		{
+			Token: `a="&bogus;"`,
+			Error: "unknown XML entity \"bogus\" at offset 3",
+		},
+		{
+			Token: `a="`,
+			Error: `expected Attr to end with '"' at offset 2`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Token, func(t *testing.T) {
+			var keys []string
+			var vals []string
+			err := DecodedAttrs([]byte(tc.Token), make([]byte, 0, 8), func(key, val []byte) bool {
+				keys = append(keys, string(key))
+				vals = append(vals, string(val))
+				return len(keys) != tc.Limit
+			})
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Key, keys)
+				assert.Equal(t, tc.Value, vals)
+			}
+		})
+	}
+}
+
+func TestAttrNS(t *testing.T) {
+	attrsToken := []byte(`xlink:href="#a" href="b" xml:lang="en"`)
+	value, err := AttrNS(attrsToken, []byte("xlink"), []byte("href"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#a", string(value))
+	value, err = AttrNS(attrsToken, nil, []byte("href"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(value))
+	value, err = AttrNS(attrsToken, []byte("other"), []byte("href"))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	_, err = AttrNS([]byte(`a=`), nil, []byte("a"))
+	assert.Error(t, err)
+	var names []string
+	assert.NoError(t, AttrsNS(attrsToken, func(space, local, value []byte) bool {
+		names = append(names, string(space)+"|"+string(local)+"="+string(value))
+		return true
+	}))
+	assert.Equal(t, []string{"xlink|href=#a", "|href=b", "xml|lang=en"}, names)
+}
+
+func TestCheckDuplicateAttrs(t *testing.T) {
+	testCases := map[string]string{
+		``:                             "",
+		`a="1" b="2" A="3"`:            "",
+		`x:id="1" y:id="2" id="3"`:     "",
+		`id="1" id="2"`:                `duplicate attribute "id" at offset 7`,
+		`a="1" b="id=" c="2"  b = "3"`: `duplicate attribute "b" at offset 21`,
+		`xmlns:a="u" xmlns:a="v"`:      `duplicate attribute "xmlns:a" at offset 12`,
+		`a="1" a`:                      `expected whitespace but got "a" at offset 6`,
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			err := CheckDuplicateAttrs([]byte(input))
+			if expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, expected)
+		})
+	}
+	assert.True(t, errors.Is(CheckDuplicateAttrs([]byte(`a="1" a="1"`)), ErrDuplicateAttr))
+	// Many attributes are checked in linear time, the duplicate is found before and after switching to a map
+	var many []byte
+	for i := 0; i < 100000; i++ {
+		many = append(many, fmt.Sprintf(`a%d="%d" `, i, i)...)
+	}
+	assert.NoError(t, CheckDuplicateAttrs(many))
+	for _, dup := range []string{`a3="x"`, `a99999="x"`} {
+		err := CheckDuplicateAttrs(append(many[:len(many):len(many)], dup...))
+		assert.True(t, errors.Is(err, ErrDuplicateAttr))
+		var tokenErr *TokenError
+		if assert.True(t, errors.As(err, &tokenErr)) {
+			assert.Equal(t, len(many), tokenErr.Offset)
+		}
+	}
+	assert.True(t, errors.Is(CheckDuplicateAttrs([]byte(`a="1" b="2" c="3" d="4" e="5" f="6" g="7" h="8" a="9"`)), ErrDuplicateAttr))
+	assert.False(t, errors.Is(CheckDuplicateAttrs([]byte(`a="1" b`)), ErrDuplicateAttr))
+}
+
+func TestAttrCount(t *testing.T) {
+	assert.Equal(t, 0, AttrCount(nil))
+	assert.Equal(t, 2, AttrCount([]byte(`a="1" b="2"`)))
+	assert.Equal(t, 1, AttrCount([]byte(`a="1" b=`)))
+}
+
+func TestAttrAt(t *testing.T) {
+	attrsToken := []byte(`a="1" b="&amp;"`)
+	key, value, err := AttrAt(attrsToken, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(key))
+	assert.Equal(t, "&amp;", string(value))
+	key, _, err = AttrAt(attrsToken, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(key))
+	_, _, err = AttrAt(attrsToken, 2)
+	assert.EqualError(t, err, "attribute index out of range")
+	_, _, err = AttrAt(attrsToken, -1)
+	assert.EqualError(t, err, "attribute index out of range")
+	_, _, err = AttrAt([]byte(`a=`), 0)
+	assert.Error(t, err)
+}
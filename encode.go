@@ -0,0 +1,61 @@
+package fastxml
+
+// escape appends src to dst replacing every byte with a non-empty replacement
+func escape(dst []byte, src []byte, replacements *[256]string) []byte {
+	last := 0
+	for idx, b := range src {
+		replacement := replacements[b]
+		if replacement == "" {
+			continue
+		}
+		dst = append(dst, src[last:idx]...)
+		dst = append(dst, replacement...)
+		last = idx + 1
+	}
+	return append(dst, src[last:]...)
+}
+
+// textReplacements are the bytes escaped by EscapeText
+var textReplacements = [256]string{
+	'&':  "&amp;",
+	'<':  "&lt;",
+	'>':  "&gt;",
+	'\r': "&#xD;",
+}
+
+// attrReplacements are the bytes escaped by EscapeAttr
+var attrReplacements = [256]string{
+	'&':  "&amp;",
+	'<':  "&lt;",
+	'"':  "&quot;",
+	'\t': "&#x9;",
+	'\n': "&#xA;",
+	'\r': "&#xD;",
+}
+
+// EscapeText appends src to dst escaping it for use as CharData
+// '\r' is escaped as it would otherwise be normalized to '\n' when decoded
+func EscapeText(dst []byte, src []byte) []byte {
+	return escape(dst, src, &textReplacements)
+}
+
+// EscapeAttr appends src to dst escaping it for use in a double-quoted attribute value
+// Whitespace other than ' ' is escaped as it would otherwise be normalized to ' ' when decoded
+func EscapeAttr(dst []byte, src []byte) []byte {
+	return escape(dst, src, &attrReplacements)
+}
+
+// escapeString is escape for a string src
+func escapeString(dst []byte, src string, replacements *[256]string) []byte {
+	last := 0
+	for idx := 0; idx < len(src); idx++ {
+		replacement := replacements[src[idx]]
+		if replacement == "" {
+			continue
+		}
+		dst = append(dst, src[last:idx]...)
+		dst = append(dst, replacement...)
+		last = idx + 1
+	}
+	return append(dst, src[last:]...)
+}
@@ -0,0 +1,56 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	testCases := map[string]string{
+		``:                   ``,
+		`plain`:              `plain`,
+		`a < b && c > d`:     `a &lt; b &amp;&amp; c &gt; d`,
+		"line\r\nquote\"'\t": "line&#xD;\nquote\"'\t",
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			assert.Equal(t, "prefix"+expected, string(EscapeText([]byte("prefix"), []byte(input))))
+			// The escaped text must decode to the input
+			var decoded string
+			assert.NoError(t, xml.Unmarshal([]byte("<a>"+expected+"</a>"), &decoded))
+			assert.Equal(t, input, decoded)
+		})
+	}
+}
+
+func TestEscapeAttr(t *testing.T) {
+	testCases := map[string]string{
+		``:                   ``,
+		`plain`:              `plain`,
+		`a < b && "c" > 'd'`: `a &lt; b &amp;&amp; &quot;c&quot; > 'd'`,
+		"tab\tline\r\n":      "tab&#x9;line&#xD;&#xA;",
+	}
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			assert.Equal(t, expected, string(EscapeAttr(nil, []byte(input))))
+			// The escaped value must decode to the input
+			var v struct {
+				A string `xml:"a,attr"`
+			}
+			assert.NoError(t, xml.Unmarshal([]byte(`<v a="`+expected+`"/>`), &v))
+			assert.Equal(t, input, v.A)
+		})
+	}
+}
+
+func BenchmarkEscapeText(b *testing.B) {
+	src := bytes.Repeat([]byte("some text & <markup> "), 100)
+	dst := make([]byte, 0, len(src)*2)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = EscapeText(dst[:0], src)
+	}
+}
@@ -0,0 +1,185 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Rules reported in SecurityError.Rule
+const (
+	RuleEntityCycle     = "entity-cycle"
+	RuleEntityExpansion = "entity-expansion"
+)
+
+// SecurityError is returned when a document is rejected because it appears to be malicious
+// (ex: a "billion laughs" attack) rather than because it is malformed
+type SecurityError struct {
+	Rule   string // which protection rejected the document (ex: RuleEntityCycle)
+	Offset int    // byte offset in the input where it was detected
+	Detail string
+}
+
+// Error implements the error interface
+func (e *SecurityError) Error() string {
+	return fmt.Sprintf("security: %s at offset %d: %s", e.Rule, e.Offset, e.Detail)
+}
+
+// DefaultMaxExpansion is the ratio of decoded to input size used by EntityDecoder if MaxExpansion is 0
+const DefaultMaxExpansion = 100
+
+// minExpansionInput is the smallest input size the expansion ratio is applied to
+// so that short values may still reference (reasonably) long entities
+const minExpansionInput = 1024
+
+// EntityDecoder resolves custom entities (ex: those declared in a DTD) in addition to the known ones
+// Entities are expanded recursively, a reference cycle or an expansion much larger than the input
+// is rejected with a *SecurityError instead of being expanded
+type EntityDecoder struct {
+	// Entity maps an entity name to its replacement text, which may reference other entities
+	Entity map[string]string
+	// MaxExpansion is the maximum ratio of the decoded size to the input size, the length of every custom
+	// entity reference expanded is included so entities which expand to nothing are limited as well
+	// If 0, DefaultMaxExpansion is used, if negative the size is not checked
+	MaxExpansion int
+	// Lenient passes an '&' which does not start a valid entity through literally
+	// (ex: `R&D` or `a && b`) instead of returning an error, as browsers do
+	Lenient bool
+	// PredefinedOnly only resolves Entity and the predefined XML entities (see DecodePredefinedEntities)
+	// instead of also the HTML entities (ex: `&nbsp;`) which are not well-formed XML without a declaration
+	PredefinedOnly bool
+}
+
+// entityExpansion holds the state of a single EntityDecoder call
+type entityExpansion struct {
+	d      *EntityDecoder
+	limit  int      // maximum length of the output (or -1)
+	stack  []string // names of the entities currently being expanded
+	offset int      // offset of the top-level reference being expanded
+	refs   int      // total length of the custom entity references expanded, charged against limit
+}
+
+// exceeded returns a *SecurityError if the output (and the references expanded to produce it) exceeds the limit
+// The references are counted so entities which expand to little or nothing can't be nested to do unbounded work
+func (e *entityExpansion) exceeded(out []byte) error {
+	if e.limit >= 0 && len(out)+e.refs > e.limit {
+		return &SecurityError{
+			Rule:   RuleEntityExpansion,
+			Offset: e.offset,
+			Detail: fmt.Sprintf("entity expansion exceeds %d bytes", e.limit),
+		}
+	}
+	return nil
+}
+
+// expand appends in to out resolving every entity
+func (e *entityExpansion) expand(out []byte, in []byte) ([]byte, error) {
+	for {
+		start := bytes.IndexByte(in, '&')
+		if start == -1 {
+			out = append(out, in...)
+			break
+		}
+		out = append(out, in[:start]...)
+		if len(e.stack) == 0 {
+			e.offset += start
+		}
+		end := bytes.IndexByte(in[start:], ';')
+		if end == -1 {
+			if e.d.Lenient {
+				out = append(out, in[start:]...)
+				break
+			}
+			return out, entityError(ErrBadEntity, e.offset, "expected ';' to end XML entity, not found")
+		}
+		ref := in[start : start+end+1]
+		name := String(ref[1:end])
+		if replacement, ok := e.d.Entity[name]; ok {
+			for idx, parent := range e.stack {
+				if parent == name {
+					return out, &SecurityError{
+						Rule:   RuleEntityCycle,
+						Offset: e.offset,
+						Detail: fmt.Sprintf("entity %q references itself: %s -> %s", name, strings.Join(e.stack[idx:], " -> "), name),
+					}
+				}
+			}
+			e.refs += len(ref)
+			if err := e.exceeded(out); err != nil {
+				return out, err
+			}
+			e.stack = append(e.stack, name)
+			var err error
+			out, err = e.expand(out, []byte(replacement))
+			e.stack = e.stack[:len(e.stack)-1]
+			if err != nil {
+				return out, err
+			}
+		} else {
+			decoded, err := decodeEntities(out, ref, 0, !e.d.PredefinedOnly)
+			if err != nil {
+				if !e.d.Lenient {
+					// The offset is relative to the reference, report the top-level reference instead
+					if err, ok := err.(*TokenError); ok {
+						err.Offset = e.offset
+					}
+					return out, err
+				}
+				// Not a valid entity, the '&' is passed through literally
+				out = append(out, '&')
+				if len(e.stack) == 0 {
+					e.offset++
+				}
+				in = in[start+1:]
+				continue
+			}
+			out = decoded
+		}
+		if err := e.exceeded(out); err != nil {
+			return out, err
+		}
+		if len(e.stack) == 0 {
+			e.offset += len(ref)
+		}
+		in = in[start+len(ref):]
+	}
+	return out, nil
+}
+
+// DecodeAppend appends the decoded in to out, behaves the same as DecodeEntitiesAppend
+func (d *EntityDecoder) DecodeAppend(out []byte, in []byte) ([]byte, error) {
+	if bytes.IndexByte(in, '&') == -1 {
+		return append(out, in...), nil
+	}
+	e := entityExpansion{d: d, limit: -1}
+	if ratio := d.MaxExpansion; ratio >= 0 {
+		if ratio == 0 {
+			ratio = DefaultMaxExpansion
+		}
+		size := len(in)
+		if size < minExpansionInput {
+			size = minExpansionInput
+		}
+		e.limit = len(out) + size*ratio
+	}
+	return e.expand(out, in)
+}
+
+// Decode resolves the entities in the input, behaves the same as DecodeEntities
+func (d *EntityDecoder) Decode(in []byte, scratch []byte) ([]byte, error) {
+	if bytes.IndexByte(in, '&') == -1 {
+		return in, nil
+	}
+	if scratch == nil {
+		scratch = make([]byte, 0, len(in))
+	}
+	return d.DecodeAppend(scratch, in)
+}
+
+// CharData decodes a CharData token, behaves the same as CharData
+func (d *EntityDecoder) CharData(charToken []byte, scratch []byte) ([]byte, error) {
+	if bytes.HasPrefix(charToken, prefixCDATA) && bytes.HasSuffix(charToken, suffixCDATA) {
+		return charToken[9 : len(charToken)-3], nil
+	}
+	return d.Decode(charToken, scratch)
+}
@@ -0,0 +1,146 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityDecoder(t *testing.T) {
+	d := &EntityDecoder{
+		Entity: map[string]string{
+			"company": "Fast &amp; XML",
+			"footer":  "&copy; &company;",
+			"self":    "a &self;",
+			"ping":    "&pong;",
+			"pong":    "&ping;",
+			"lol":     "lol",
+			"lol1":    "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;",
+			"lol2":    "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;",
+			"lol3":    "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;",
+			"lol4":    "&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;",
+			"lol5":    "&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;",
+			"lol6":    "&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;",
+		},
+	}
+	testCases := []struct {
+		Input    string
+		Expected string
+		Error    string
+		Rule     string
+	}{
+		{
+			Input:    `plain`,
+			Expected: `plain`,
+		}, {
+			Input:    `&lt;&footer;&gt;`,
+			Expected: `<© Fast & XML>`,
+		}, {
+			Input:    `&lol2;`,
+			Expected: strings.Repeat("lol", 100),
+		}, {
+			Input: `&unknown;`,
+			Error: `unknown XML entity "unknown" at offset 0`,
+		}, {
+			Input: `x &self;`,
+			Error: `security: entity-cycle at offset 2: entity "self" references itself: self -> self`,
+			Rule:  RuleEntityCycle,
+		}, {
+			Input: `&lt;&ping;`,
+			Error: `security: entity-cycle at offset 4: entity "ping" references itself: ping -> pong -> ping`,
+			Rule:  RuleEntityCycle,
+		}, {
+			Input: `<&lol6;`,
+			Error: `security: entity-expansion at offset 1: entity expansion exceeds 102400 bytes`,
+			Rule:  RuleEntityExpansion,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			actual, err := d.Decode([]byte(tc.Input), nil)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+				var secErr *SecurityError
+				assert.Equal(t, tc.Rule != "", errors.As(err, &secErr))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestEntityDecoder_MaxExpansion(t *testing.T) {
+	d := &EntityDecoder{
+		Entity:       map[string]string{"big": strings.Repeat("x", 4096)},
+		MaxExpansion: 2,
+	}
+	_, err := d.Decode([]byte("&big;"), nil)
+	assert.EqualError(t, err, `security: entity-expansion at offset 0: entity expansion exceeds 2048 bytes`)
+	d.MaxExpansion = -1
+	actual, err := d.DecodeAppend([]byte("prepend"), []byte("&big;"))
+	assert.NoError(t, err)
+	assert.Len(t, actual, 4096+len("prepend"))
+}
+
+func TestEntityDecoder_EmptyEntities(t *testing.T) {
+	// Entities expanding to nothing still count against the limit (otherwise this is 10^9 references)
+	var doctype strings.Builder
+	doctype.WriteString(`<!DOCTYPE a [<!ENTITY e0 "">`)
+	for level := 1; level <= 9; level++ {
+		fmt.Fprintf(&doctype, `<!ENTITY e%d "%s">`, level, strings.Repeat(fmt.Sprintf("&e%d;", level-1), 10))
+	}
+	doctype.WriteString(`]><a/>`)
+	d, err := DocumentEntities([]byte(doctype.String()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = d.Decode([]byte("&e9;"), nil)
+	var secErr *SecurityError
+	if assert.True(t, errors.As(err, &secErr)) {
+		assert.Equal(t, RuleEntityExpansion, secErr.Rule)
+	}
+	// A few empty references are fine
+	actual, err := d.Decode([]byte("x&e2;y"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "xy", string(actual))
+}
+
+func TestEntityDecoder_Lenient(t *testing.T) {
+	d := &EntityDecoder{Lenient: true}
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: `R&D`, Expected: `R&D`},
+		{Input: `a && b`, Expected: `a && b`},
+		{Input: `R&D; &amp; a &b &lt;`, Expected: `R&D; & a &b <`},
+		{Input: `&#xnothex; &#65;`, Expected: `&#xnothex; A`},
+		{Input: `&unknown;`, Expected: `&unknown;`},
+		{Input: `<![CDATA[R&D]]>`, Expected: `R&D`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			actual, err := d.CharData([]byte(tc.Input), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(actual))
+		})
+	}
+	_, err := (&EntityDecoder{}).CharData([]byte(`R&D`), nil)
+	assert.EqualError(t, err, `expected ';' to end XML entity, not found at offset 1`)
+}
+
+func TestEntityDecoder_PredefinedOnly(t *testing.T) {
+	d := &EntityDecoder{Entity: map[string]string{"custom": "value"}, PredefinedOnly: true}
+	actual, err := d.Decode([]byte(`&custom; &amp; &#65;`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "value & A", string(actual))
+	_, err = d.Decode([]byte(`a&nbsp;b`), nil)
+	assert.EqualError(t, err, `unknown XML entity "nbsp" at offset 1`)
+	actual, err = (&EntityDecoder{}).Decode([]byte(`a&nbsp;b`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a b", string(actual))
+}
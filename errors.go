@@ -0,0 +1,64 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors wrapped by the *TokenError returned for malformed input, use errors.Is to check for them
+var (
+	ErrUnterminatedElement  = errors.New("expected Token to end with '>'")
+	ErrUnterminatedCDATA    = errors.New("expected Token to end with ']]>'")
+	ErrUnterminatedComment  = errors.New("expected Token to end with '-->'")
+	ErrUnterminatedProcInst = errors.New("expected Token to end with '?>'")
+	ErrBadAttr              = errors.New("invalid attribute")
+	ErrDuplicateAttr        = errors.New("duplicate attribute")
+	ErrUnknownEntity        = errors.New("unknown XML entity")
+	ErrBadEntity            = errors.New("invalid XML entity")
+)
+
+// TokenError is returned when the input is malformed, Err is one of the sentinel errors (ex: ErrUnterminatedElement)
+// Offset is relative to the input of the function returning it, ex: the buffer of a Scanner,
+// the attributes passed to Attrs or the text passed to DecodeEntities
+type TokenError struct {
+	Msg    string // describes the problem, if empty Err is used
+	Offset int    // byte offset in the input
+	Err    error
+}
+
+// Error implements the error interface
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.message(), e.Offset)
+}
+
+// Unwrap returns the sentinel error
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// message returns the error without the offset
+func (e *TokenError) message() string {
+	if e.Msg == "" {
+		return e.Err.Error()
+	}
+	return e.Msg
+}
+
+// tokenError wraps a sentinel error returned by Scanner.next with the offset of the token
+func tokenError(err error, offset int) error {
+	switch err {
+	case ErrUnterminatedElement, ErrUnterminatedCDATA, ErrUnterminatedComment, ErrUnterminatedProcInst:
+		return &TokenError{Offset: offset, Err: err}
+	}
+	return err
+}
+
+// attrError creates a *TokenError wrapping ErrBadAttr
+func attrError(offset int, format string, args ...interface{}) error {
+	return &TokenError{Msg: fmt.Sprintf(format, args...), Offset: offset, Err: ErrBadAttr}
+}
+
+// entityError creates a *TokenError wrapping err (ErrUnknownEntity or ErrBadEntity)
+func entityError(err error, offset int, format string, args ...interface{}) error {
+	return &TokenError{Msg: fmt.Sprintf(format, args...), Offset: offset, Err: err}
+}
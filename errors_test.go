@@ -0,0 +1,75 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenError(t *testing.T) {
+	nextErr := func(input string) error {
+		s := NewScanner([]byte(input))
+		for {
+			if _, _, err := s.Next(); err != nil {
+				return err
+			}
+		}
+	}
+	testCases := []struct {
+		Name   string
+		Err    error
+		Target error
+		Offset int
+	}{
+		{"element", nextErr(`<a><b`), ErrUnterminatedElement, 3},
+		{"cdata", nextErr(`<a><![CDATA[x`), ErrUnterminatedCDATA, 3},
+		{"comment", nextErr(`<a> <!-- x`), ErrUnterminatedComment, 4},
+		{"procinst", nextErr(`<?xml`), ErrUnterminatedProcInst, 0},
+		{"attr", Attrs([]byte(`a="1" b`), func(k, v []byte) bool { return true }), ErrBadAttr, 6},
+		{"procinst attr", ProcInstAttrs([]byte(`a=1`), func(k, v []byte) bool { return true }), ErrBadAttr, 2},
+		{"unknown entity", func() error { _, err := DecodeEntities([]byte(`a &bogus;`), nil); return err }(), ErrUnknownEntity, 2},
+		{"bad entity", func() error { _, err := DecodeEntities([]byte(`a &#xzz;`), nil); return err }(), ErrBadEntity, 2},
+		{"decoded attr", DecodedAttrs([]byte(`a="x &bogus;"`), nil, func(k, v []byte) bool { return true }), ErrUnknownEntity, 5},
+		{"entity decoder", func() error { _, err := (&EntityDecoder{}).Decode([]byte(`ab &c`), nil); return err }(), ErrBadEntity, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.True(t, errors.Is(tc.Err, tc.Target), "expected %v to be %v", tc.Err, tc.Target)
+			var tokenErr *TokenError
+			if assert.True(t, errors.As(tc.Err, &tokenErr)) {
+				assert.Equal(t, tc.Offset, tokenErr.Offset)
+				assert.True(t, strings.HasSuffix(tokenErr.Error(), fmt.Sprintf(" at offset %d", tc.Offset)))
+			}
+		})
+	}
+}
+
+func TestTokenError_Wrapped(t *testing.T) {
+	// Errors from Limits are not a *TokenError
+	s := NewScanner([]byte(`<a><b><c>`))
+	s.Limits.MaxDepth = 1
+	var err error
+	for err == nil {
+		_, _, err = s.Next()
+	}
+	var tokenErr *TokenError
+	assert.False(t, errors.As(err, &tokenErr))
+	// TrackLines keeps the sentinel reachable through the *SyntaxError
+	s = NewScanner([]byte("<a>\n<b"))
+	s.TrackLines = true
+	for err = nil; err == nil; {
+		_, _, err = s.Next()
+	}
+	assert.EqualError(t, err, "syntax error at line 2, column 1: expected Token to end with '>'")
+	assert.True(t, errors.Is(err, ErrUnterminatedElement))
+	// Validate reports attribute errors at their position in the document
+	err = Validate([]byte(`<a b="1" c/>`))
+	assert.True(t, errors.Is(err, ErrBadAttr))
+	var syntaxErr *SyntaxError
+	if assert.True(t, errors.As(err, &syntaxErr)) {
+		assert.Equal(t, 9, syntaxErr.Offset)
+	}
+}
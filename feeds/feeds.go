@@ -0,0 +1,152 @@
+// Package feeds extracts the items of RSS and Atom feeds using fastxml.Scanner without building a tree
+package feeds
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Item is an RSS item or Atom entry
+type Item struct {
+	Title     string
+	Link      string // text of link (RSS) or href of the alternate link (Atom)
+	GUID      string // guid (RSS) or id (Atom)
+	Published string // pubDate (RSS) or published (Atom), falling back to dc:date or updated
+	// Content is the raw inner XML of content:encoded or description (RSS) or content or summary (Atom),
+	// it references the parsed buffer and is nil if the item has no content
+	Content []byte
+}
+
+// Content sources in increasing order of preference
+const (
+	contentNone = iota
+	contentSummary
+	contentFull
+)
+
+// Items calls f with each item (RSS 0.9x, 1.0 and 2.0) or entry (Atom) of the feed in buf
+// until f returns false, elements are matched by local name ignoring namespaces
+func Items(buf []byte, f func(item Item) bool) error {
+	s := fastxml.NewScanner(buf)
+	for {
+		token, err := s.NextElement()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fastxml.IsEndElement(token) {
+			continue
+		}
+		name, _ := fastxml.Element(token)
+		if !fastxml.MatchLocal(name, "item") && !fastxml.MatchLocal(name, "entry") {
+			continue
+		}
+		var item Item
+		if !fastxml.IsSelfClosing(token) {
+			if err := readItem(s, buf, &item); err != nil {
+				return err
+			}
+		}
+		if !f(item) {
+			return nil
+		}
+	}
+}
+
+// readItem reads the children of the item most recently returned by s
+func readItem(s *fastxml.Scanner, buf []byte, item *Item) error {
+	var updated string
+	content := contentNone
+	for {
+		token, err := s.NextElement()
+		if err != nil {
+			return err
+		}
+		if fastxml.IsEndElement(token) {
+			if item.Published == "" {
+				item.Published = updated
+			}
+			return nil
+		}
+		name, attrsToken := fastxml.Element(token)
+		_, local := fastxml.Name(name)
+		switch fastxml.String(local) {
+		case "title":
+			item.Title, err = text(s, token)
+		case "link":
+			href, attrErr := fastxml.Attr(attrsToken, []byte("href"))
+			if attrErr != nil {
+				return attrErr
+			} else if href == nil {
+				item.Link, err = text(s, token)
+				break
+			}
+			// Atom links prefer the (implicit) alternate link
+			rel, attrErr := fastxml.Attr(attrsToken, []byte("rel"))
+			if attrErr != nil {
+				return attrErr
+			}
+			if item.Link == "" || rel == nil || string(rel) == "alternate" {
+				decoded, decodeErr := fastxml.DecodeEntities(href, nil)
+				if decodeErr != nil {
+					return decodeErr
+				}
+				item.Link = string(decoded)
+			}
+			err = s.SkipElement(token)
+		case "guid", "id":
+			item.GUID, err = text(s, token)
+		case "pubDate", "published":
+			item.Published, err = text(s, token)
+		case "date", "updated":
+			updated, err = text(s, token)
+		case "encoded", "content":
+			// Empty content (ex: media:content or out-of-line Atom content) is ignored
+			if fastxml.IsSelfClosing(token) {
+				break
+			}
+			content = contentFull
+			item.Content, err = inner(s, buf, token)
+		case "description", "summary":
+			if content < contentSummary {
+				content = contentSummary
+				item.Content, err = inner(s, buf, token)
+			} else {
+				err = s.SkipElement(token)
+			}
+		default:
+			err = s.SkipElement(token)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// inner returns the inner XML of the element elemToken most recently returned by s
+func inner(s *fastxml.Scanner, buf []byte, elemToken []byte) ([]byte, error) {
+	start := s.Offset()
+	if fastxml.IsSelfClosing(elemToken) {
+		return buf[start:start], nil
+	}
+	if err := s.Skip(); err != nil {
+		return nil, err
+	}
+	end, _ := s.TokenRange()
+	return buf[start:end], nil
+}
+
+// text returns the trimmed text content of the element elemToken most recently returned by s
+func text(s *fastxml.Scanner, elemToken []byte) (string, error) {
+	if fastxml.IsSelfClosing(elemToken) {
+		return "", nil
+	}
+	text, err := s.InnerText(nil)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(text)), nil
+}
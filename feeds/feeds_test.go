@@ -0,0 +1,110 @@
+package feeds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collect returns every item of the feed in buf
+func collect(buf string) ([]Item, error) {
+	var items []Item
+	err := Items([]byte(buf), func(item Item) bool {
+		items = append(items, item)
+		return true
+	})
+	return items, err
+}
+
+func TestItems_RSS(t *testing.T) {
+	const input = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+  <title>Channel</title>
+  <link>https://example.com/</link>
+  <item>
+    <title>First &amp; best</title>
+    <link> https://example.com/1 </link>
+    <guid isPermaLink="false">1</guid>
+    <pubDate>Sat, 17 Oct 2026 00:00:00 GMT</pubDate>
+    <description>Summary</description>
+    <content:encoded><![CDATA[<p>Full</p>]]></content:encoded>
+    <media:content url="https://example.com/1.jpg"/>
+    <category><nested>skipped</nested></category>
+  </item>
+  <item>
+    <title>Second</title>
+    <description>Only &lt;b&gt;summary&lt;/b&gt;</description>
+  </item>
+  <item/>
+</channel>
+</rss>`
+	items, err := collect(input)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{
+			Title:     "First & best",
+			Link:      "https://example.com/1",
+			GUID:      "1",
+			Published: "Sat, 17 Oct 2026 00:00:00 GMT",
+			Content:   []byte(`<![CDATA[<p>Full</p>]]>`),
+		},
+		{
+			Title:   "Second",
+			Content: []byte(`Only &lt;b&gt;summary&lt;/b&gt;`),
+		},
+		{},
+	}, items)
+}
+
+func TestItems_Atom(t *testing.T) {
+	const input = `<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Feed</title>
+  <entry>
+    <title type="html">Entry</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link href="https://example.com/entry?a=1&amp;b=2"/>
+    <link rel="enclosure" href="https://example.com/file"/>
+    <id>urn:uuid:1</id>
+    <updated>2026-10-17T00:00:00Z</updated>
+    <summary>Summary</summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">Full</div></content>
+  </entry>
+  <entry>
+    <published>2026-10-16T00:00:00Z</published>
+    <updated>2026-10-17T00:00:00Z</updated>
+    <content src="https://example.com/2"/>
+    <summary>Summary</summary>
+  </entry>
+</feed>`
+	items, err := collect(input)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{
+			Title:     "Entry",
+			Link:      "https://example.com/entry?a=1&b=2",
+			GUID:      "urn:uuid:1",
+			Published: "2026-10-17T00:00:00Z",
+			Content:   []byte(`<div xmlns="http://www.w3.org/1999/xhtml">Full</div>`),
+		},
+		{
+			Published: "2026-10-16T00:00:00Z",
+			Content:   []byte(`Summary`),
+		},
+	}, items)
+}
+
+func TestItems_Stop(t *testing.T) {
+	count := 0
+	err := Items([]byte(`<rss><channel><item/><item/></channel></rss>`), func(item Item) bool {
+		count++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestItems_Error(t *testing.T) {
+	_, err := collect(`<rss><channel><item><title>unterminated`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Style configures the layout produced by Style.Format
+type Style struct {
+	// Indent is repeated once for each level of nesting (ex: "  " or "\t")
+	Indent string
+	// MaxLineWidth causes the attributes of a start element to be wrapped
+	// onto separate lines if it would otherwise exceed the width, 0 disables wrapping
+	MaxLineWidth int
+	// NewlineBeforeClose places the closing '>' or '/>' of a wrapped start element on its own line
+	NewlineBeforeClose bool
+	// SortAttrs orders the attributes of each start element in the same order as SortAttrs
+	SortAttrs bool
+}
+
+// formatter holds the state of a single Style.Format call
+type formatter struct {
+	style *Style
+	w     *bufio.Writer
+	depth int
+	attrs []Attribute // re-used between elements
+	lines bool        // if any line has been written yet
+}
+
+// newline starts a new line indented to the current depth
+func (f *formatter) newline(depth int) {
+	if f.lines {
+		f.w.WriteByte('\n')
+	}
+	f.lines = true
+	for i := 0; i < depth && f.style.Indent != ""; i++ {
+		f.w.WriteString(f.style.Indent)
+	}
+}
+
+// writeStart writes a start element (ex: `<name key="value">`) wrapping the attributes if needed
+func (f *formatter) writeStart(token []byte) error {
+	name, attrsToken := Element(token)
+	f.attrs = f.attrs[:0]
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		f.attrs = append(f.attrs, Attribute{Key: key, Value: value})
+		return true
+	}); err != nil {
+		return err
+	}
+	if f.style.SortAttrs {
+		sortRawAttrs(f.attrs)
+	}
+	closing := ">"
+	if IsSelfClosing(token) {
+		closing = "/>"
+	}
+	// Determine the width as if the element was written on a single line
+	wrap := false
+	if f.style.MaxLineWidth > 0 && len(f.attrs) > 0 {
+		width := f.depth*len(f.style.Indent) + 1 + len(name) + len(closing)
+		for _, attr := range f.attrs {
+			width += len(attr.Key) + len(attr.Value) + 4 // ` key="value"`
+		}
+		wrap = width > f.style.MaxLineWidth
+	}
+	f.w.WriteByte('<')
+	f.w.Write(name)
+	for _, attr := range f.attrs {
+		if wrap {
+			f.newline(f.depth + 1)
+		} else {
+			f.w.WriteByte(' ')
+		}
+		f.w.Write(attr.Key)
+		f.w.WriteString(`="`)
+		f.w.Write(attr.Value)
+		f.w.WriteByte('"')
+	}
+	if wrap && f.style.NewlineBeforeClose {
+		f.newline(f.depth)
+	}
+	f.w.WriteString(closing)
+	return nil
+}
+
+// formatElement is the layout of a (non self-closing) element found by layout
+type formatElement struct {
+	end          int  // offset of the end element
+	next         int  // index of the first element after its children
+	text, markup bool // if any text or markup was found in its content
+}
+
+// layout scans the document once returning the layout of every element in the order of their start elements
+// Deciding the layout of each element by scanning its children as it is formatted is O(size*depth)
+func layout(s Scanner) ([]formatElement, error) {
+	var elements []formatElement
+	var open []int // index of each open element
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(open) > 0 {
+			parent := &elements[open[len(open)-1]]
+			if chardata {
+				if len(bytes.TrimLeft(token, " \t\r\n")) > 0 {
+					parent.text = true
+				}
+			} else if !IsEndElement(token) {
+				parent.markup = true
+			}
+		}
+		if chardata || !IsElement(token) || IsSelfClosing(token) {
+			continue
+		}
+		if !IsEndElement(token) {
+			open = append(open, len(elements))
+			elements = append(elements, formatElement{})
+		} else if len(open) > 0 {
+			elements[open[len(open)-1]].end = offset
+			elements[open[len(open)-1]].next = len(elements)
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return elements, nil
+}
+
+// format writes each token from s according to the style
+func (f *formatter) format(s *Scanner) error {
+	elements, err := layout(*s)
+	if err != nil {
+		return err
+	}
+	next := 0 // index in elements of the next start element
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case chardata:
+			// Whitespace between markup is not significant outside of mixed content
+			if trimmed := bytes.Trim(token, " \t\r\n"); len(trimmed) > 0 {
+				f.newline(f.depth)
+				f.w.Write(trimmed)
+			}
+		case !IsElement(token):
+			// Directive, ProcInst and Comment are written as-is
+			f.newline(f.depth)
+			f.w.Write(token)
+		case IsEndElement(token):
+			f.depth--
+			f.newline(f.depth)
+			f.w.Write(token)
+		case IsSelfClosing(token):
+			f.newline(f.depth)
+			if err := f.writeStart(token); err != nil {
+				return err
+			}
+		default:
+			f.newline(f.depth)
+			if err := f.writeStart(token); err != nil {
+				return err
+			}
+			elem := elements[next]
+			if !elem.text && elem.markup {
+				next++
+				f.depth++
+				continue
+			}
+			next = elem.next
+			// Text (and mixed content) is significant so it is preserved exactly
+			if elem.text {
+				f.w.Write(s.buf[s.Offset():elem.end])
+			}
+			if _, err := s.Seek(int64(elem.end), io.SeekStart); err != nil {
+				return err
+			}
+			endToken, _, err := s.Next()
+			if err != nil {
+				return err
+			}
+			f.w.Write(endToken)
+		}
+	}
+	if f.lines {
+		f.w.WriteByte('\n')
+	}
+	return f.w.Flush()
+}
+
+// Format writes src to dst re-indented according to the Style
+// Elements containing text are written exactly as they appeared in src
+func (st *Style) Format(dst io.Writer, src []byte) error {
+	f := &formatter{
+		style: st,
+		w:     bufio.NewWriter(dst),
+	}
+	return f.format(NewScanner(src))
+}
+
+// Format writes src to dst re-indented with indent, it is Style.Format with only the Indent set
+// CDATA sections, comments and the content of elements containing text (mixed content) are preserved
+func Format(dst io.Writer, src []byte, indent string) error {
+	st := Style{Indent: indent}
+	return st.Format(dst, src)
+}
@@ -0,0 +1,96 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStyle_Format(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Style    Style
+		Input    string
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "indent",
+			Style:    Style{Indent: "  "},
+			Input:    `<?xml version="1.0"?><root><!--comment--><child><leaf/></child><empty></empty></root>`,
+			Expected: "<?xml version=\"1.0\"?>\n<root>\n  <!--comment-->\n  <child>\n    <leaf/>\n  </child>\n  <empty></empty>\n</root>\n",
+		},
+		{
+			Name:     "reindent",
+			Style:    Style{Indent: "\t"},
+			Input:    "<root>\n    <child   a=\"1\"  />\n</root>",
+			Expected: "<root>\n\t<child a=\"1\"/>\n</root>\n",
+		},
+		{
+			Name:     "text preserved",
+			Style:    Style{Indent: "  "},
+			Input:    "<root><p> some <b>mixed</b> content </p><c><![CDATA[ <raw> ]]></c></root>",
+			Expected: "<root>\n  <p> some <b>mixed</b> content </p>\n  <c><![CDATA[ <raw> ]]></c>\n</root>\n",
+		},
+		{
+			Name:     "sort attributes",
+			Style:    Style{SortAttrs: true},
+			Input:    `<root c="3" a="1" xmlns="urn:x" b="2"/>`,
+			Expected: "<root xmlns=\"urn:x\" a=\"1\" b=\"2\" c=\"3\"/>\n",
+		},
+		{
+			Name:     "wrap attributes",
+			Style:    Style{Indent: "  ", MaxLineWidth: 20},
+			Input:    `<root><child first="1" second="2"/><short a="1"/></root>`,
+			Expected: "<root>\n  <child\n    first=\"1\"\n    second=\"2\"/>\n  <short a=\"1\"/>\n</root>\n",
+		},
+		{
+			Name:     "newline before close",
+			Style:    Style{Indent: "  ", MaxLineWidth: 20, NewlineBeforeClose: true},
+			Input:    `<root first="1" second="2">text</root>`,
+			Expected: "<root\n  first=\"1\"\n  second=\"2\"\n>text</root>\n",
+		},
+		{
+			Name:  "unterminated",
+			Input: `<root><child>`,
+			Error: "unexpected EOF",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tc.Style.Format(&buf, []byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := Format(&buf, []byte("<root><!-- c --><a><b>text</b></a><pre>  keep\n  this </pre><c><![CDATA[x]]></c></root>"), "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "<root>\n  <!-- c -->\n  <a>\n    <b>text</b>\n  </a>\n  <pre>  keep\n  this </pre>\n  <c><![CDATA[x]]></c>\n</root>\n", buf.String())
+}
+
+func TestFormat_Deep(t *testing.T) {
+	// The document is only scanned once (not once per ancestor) so deep nesting is linear
+	const depth = 20000
+	input := strings.Repeat(`<a>`, depth) + `<b> x <c/></b><d> </d>` + strings.Repeat(`</a>`, depth)
+	var buf bytes.Buffer
+	assert.NoError(t, Format(&buf, []byte(input), ""))
+	assert.Equal(t, strings.Repeat("<a>\n", depth)+"<b> x <c/></b>\n<d></d>\n"+strings.Repeat("</a>\n", depth), buf.String())
+	// Mixed content after a nested element is still preserved exactly
+	buf.Reset()
+	assert.NoError(t, Format(&buf, []byte(`<r><p><i><b>x</b></i> y</p><q><c> z </c></q></r>`), " "))
+	assert.Equal(t, "<r>\n <p><i><b>x</b></i> y</p>\n <q>\n  <c> z </c>\n </q>\n</r>\n", buf.String())
+	buf.Reset()
+	assert.Equal(t, io.ErrUnexpectedEOF, Format(&buf, []byte(`<r><p>`), " "))
+}
@@ -0,0 +1,262 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlWriter holds the state of a single FromJSON call
+type xmlWriter struct {
+	opts    JSONOptions
+	dec     *json.Decoder
+	w       *bufio.Writer
+	scratch []byte
+	depth   int // of the element being written
+}
+
+// FromJSON writes the JSON document in src to dst as XML, it is the reverse of ToJSON with the same JSONOptions
+// Each object is an element where keys starting with AttrPrefix are attributes, TextKey is the text and any other key
+// is a child element (an array is a repeated child element), strings, numbers and booleans are the text of an element
+// and null is an empty element. The root element is named rootName or if empty, src must be an object with a single key
+// Values are escaped the same as EscapeText and EscapeAttr, attributes must appear once and before the text and child
+// elements. Objects and arrays nested deeper than MaxDepth are rejected with a *LimitError
+func FromJSON(dst io.Writer, src []byte, rootName string, opts JSONOptions) error {
+	if opts.AttrPrefix == "" {
+		opts.AttrPrefix = defaultAttrPrefix
+	}
+	if opts.TextKey == "" {
+		opts.TextKey = defaultTextKey
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultJSONMaxDepth
+	}
+	x := &xmlWriter{
+		opts: opts,
+		dec:  json.NewDecoder(bytes.NewReader(src)),
+		w:    bufio.NewWriter(dst),
+	}
+	x.dec.UseNumber()
+	if rootName != "" {
+		if err := x.value(rootName, false); err != nil {
+			return err
+		}
+	} else {
+		if err := x.delim('{'); err != nil {
+			return err
+		}
+		token, err := x.token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("expected a root element but got %v", token)
+		}
+		if err := x.value(key, false); err != nil {
+			return err
+		}
+		if x.dec.More() {
+			return fmt.Errorf("expected a single root element after %q", key)
+		}
+		if err := x.delim('}'); err != nil {
+			return err
+		}
+	}
+	if _, err := x.dec.Token(); err != io.EOF {
+		return fmt.Errorf("expected the end of the JSON document")
+	}
+	return x.w.Flush()
+}
+
+// token reads the next JSON token, the document ending is io.ErrUnexpectedEOF
+func (x *xmlWriter) token() (json.Token, error) {
+	token, err := x.dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return token, err
+}
+
+// delim reads the delimiter d
+func (x *xmlWriter) delim(d json.Delim) error {
+	token, err := x.token()
+	if err != nil {
+		return err
+	}
+	if token != d {
+		return fmt.Errorf("expected %v but got %v", d, token)
+	}
+	return nil
+}
+
+// scalar reads the next value returning its text, an object or array is an error
+// null is read as an empty string
+func (x *xmlWriter) scalar(key string) (string, error) {
+	token, err := x.token()
+	if err != nil {
+		return "", err
+	}
+	switch v := token.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return string(v), nil
+	case bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("expected %q to be a string, number, boolean or null", key)
+}
+
+// text writes an escaped value as the text of an element
+func (x *xmlWriter) text(value string) {
+	x.scratch = EscapeText(x.scratch[:0], []byte(value))
+	x.w.Write(x.scratch)
+}
+
+// value writes the next value as the element name, an array repeats the element unless already inArray
+func (x *xmlWriter) value(name string, inArray bool) error {
+	if !isName([]byte(name)) {
+		return fmt.Errorf("invalid element name %q", name)
+	}
+	token, err := x.token()
+	if err != nil {
+		return err
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		// Only objects nest, an array repeats the element at the same depth
+		if v == '{' {
+			x.depth++
+			defer func() { x.depth-- }()
+			if x.opts.MaxDepth > 0 && x.depth > x.opts.MaxDepth {
+				return &LimitError{Limit: LimitDepth, Max: x.opts.MaxDepth, Value: x.depth, Offset: int(x.dec.InputOffset()) - 1}
+			}
+		}
+		switch v {
+		case '{':
+			return x.object(name)
+		case '[':
+			if inArray {
+				return fmt.Errorf("expected %q to not be a nested array", name)
+			}
+			for x.dec.More() {
+				if err := x.value(name, true); err != nil {
+					return err
+				}
+			}
+			return x.delim(']')
+		}
+		return fmt.Errorf("unexpected %v", v)
+	case nil:
+		x.w.WriteByte('<')
+		x.w.WriteString(name)
+		x.w.WriteString("/>")
+		return nil
+	case string:
+		x.start(name)
+		x.text(v)
+	case json.Number:
+		x.start(name)
+		x.w.WriteString(string(v))
+	case bool:
+		x.start(name)
+		if v {
+			x.w.WriteString("true")
+		} else {
+			x.w.WriteString("false")
+		}
+	}
+	x.end(name)
+	return nil
+}
+
+// start writes `<name>`
+func (x *xmlWriter) start(name string) {
+	x.w.WriteByte('<')
+	x.w.WriteString(name)
+	x.w.WriteByte('>')
+}
+
+// end writes `</name>`
+func (x *xmlWriter) end(name string) {
+	x.w.WriteString("</")
+	x.w.WriteString(name)
+	x.w.WriteByte('>')
+}
+
+// object writes the object as the element name
+func (x *xmlWriter) object(name string) error {
+	x.w.WriteByte('<')
+	x.w.WriteString(name)
+	open := true // if the start element has not been closed yet
+	var attrs map[string]struct{}
+	for x.dec.More() {
+		token, err := x.token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		switch {
+		case strings.HasPrefix(key, x.opts.AttrPrefix):
+			attr := key[len(x.opts.AttrPrefix):]
+			if !open {
+				return fmt.Errorf("attribute %q of <%s> must appear before its text and child elements", attr, name)
+			}
+			if !isName([]byte(attr)) {
+				return fmt.Errorf("invalid attribute name %q", attr)
+			}
+			if _, ok := attrs[attr]; ok {
+				return fmt.Errorf("duplicate attribute %q of <%s>", attr, name)
+			} else if attrs == nil {
+				attrs = make(map[string]struct{})
+			}
+			attrs[attr] = struct{}{}
+			value, err := x.scalar(key)
+			if err != nil {
+				return err
+			}
+			x.w.WriteByte(' ')
+			x.w.WriteString(attr)
+			x.w.WriteString(`="`)
+			x.scratch = EscapeAttr(x.scratch[:0], []byte(value))
+			x.w.Write(x.scratch)
+			x.w.WriteByte('"')
+		case key == x.opts.TextKey:
+			value, err := x.scalar(key)
+			if err != nil {
+				return err
+			}
+			if open {
+				x.w.WriteByte('>')
+				open = false
+			}
+			x.text(value)
+		default:
+			if open {
+				x.w.WriteByte('>')
+				open = false
+			}
+			if err := x.value(key, false); err != nil {
+				return err
+			}
+		}
+	}
+	if err := x.delim('}'); err != nil {
+		return err
+	}
+	if open {
+		x.w.WriteString("/>")
+	} else {
+		x.end(name)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package fastxml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromJSON(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Root     string
+		Opts     JSONOptions
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "Object",
+			Input:    `{"order":{"@id":"1","item":[{"@sku":"a","#text":"first"},{"@sku":"b","#text":"<second>"}],"note":"gift & wrap","total":2.5,"paid":true,"empty":null}}`,
+			Expected: `<order id="1"><item sku="a">first</item><item sku="b">&lt;second&gt;</item><note>gift &amp; wrap</note><total>2.5</total><paid>true</paid><empty/></order>`,
+		},
+		{
+			Name:     "Root",
+			Input:    `{"@version":2,"name":"x","tags":["a","b"],"meta":{}}`,
+			Root:     "doc",
+			Expected: `<doc version="2"><name>x</name><tags>a</tags><tags>b</tags><meta/></doc>`,
+		},
+		{
+			Name:     "Scalar",
+			Input:    `"say \"hi\""`,
+			Root:     "greeting",
+			Expected: `<greeting>say "hi"</greeting>`,
+		},
+		{
+			Name:     "AttrEscaping",
+			Input:    `{"a":{"@title":"\"quoted\" & <tagged>","#text":"x"}}`,
+			Expected: `<a title="&quot;quoted&quot; &amp; &lt;tagged>">x</a>`,
+		},
+		{
+			Name:     "Options",
+			Input:    `{"a":{"-id":"1","_":"x","b":"y"}}`,
+			Opts:     JSONOptions{AttrPrefix: "-", TextKey: "_"},
+			Expected: `<a id="1">x<b>y</b></a>`,
+		},
+		{
+			Name:  "DuplicateAttr",
+			Input: `{"a":{"@x":"1","@y":"2","@x":"3"}}`,
+			Error: `duplicate attribute "x" of <a>`,
+		},
+		{
+			Name:  "MaxDepth",
+			Input: `{"a":{"b":[{"c":{}}]}}`,
+			Opts:  JSONOptions{MaxDepth: 2},
+			Error: `limit: depth of 3 exceeds 2 at offset 16`,
+		},
+		{
+			Name:  "MultipleRoots",
+			Input: `{"a":1,"b":2}`,
+			Error: `expected a single root element after "a"`,
+		},
+		{
+			Name:  "AttrAfterChild",
+			Input: `{"a":{"b":1,"@id":"x"}}`,
+			Error: `attribute "id" of <a> must appear before its text and child elements`,
+		},
+		{
+			Name:  "InvalidName",
+			Input: `{"a":{"1b":1}}`,
+			Error: `invalid element name "1b"`,
+		},
+		{
+			Name:  "ObjectAttr",
+			Input: `{"a":{"@id":{}}}`,
+			Error: `expected "@id" to be a string, number, boolean or null`,
+		},
+		{
+			Name:  "NestedArray",
+			Input: `{"a":[[1]]}`,
+			Error: `expected "a" to not be a nested array`,
+		},
+		{
+			Name:  "Trailing",
+			Input: `{"a":1} {"b":2}`,
+			Error: `expected the end of the JSON document`,
+		},
+		{
+			Name:  "Invalid",
+			Input: `{"a":`,
+			Error: `unexpected EOF`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := FromJSON(&buf, []byte(tc.Input), tc.Root, tc.Opts)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestFromJSON_RoundTrip(t *testing.T) {
+	input := `<catalog lang="en"><book id="1"><title>A &amp; B</title><tag>x</tag><tag>y</tag></book><count>1</count></catalog>`
+	for _, opts := range []JSONOptions{{}, {AttrPrefix: "-", TextKey: "$"}} {
+		var j, x bytes.Buffer
+		assert.NoError(t, ToJSON(&j, []byte(input), opts))
+		assert.NoError(t, FromJSON(&x, j.Bytes(), "", opts))
+		assert.Equal(t, input, x.String())
+	}
+}
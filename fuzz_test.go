@@ -0,0 +1,96 @@
+//go:build go1.18
+
+package fastxml
+
+import (
+	"io"
+	"testing"
+)
+
+// fuzzSeeds are the initial corpus of every fuzz test
+var fuzzSeeds = []string{
+	``, `<`, `<>`, `</>`, `<?`, `<?>`, `<?x?>`, `<!`, `<!>`, `<!-`, `<!--`, `<!-->`, `<!---->`, `<![CDATA[`, `<![CDATA[]]>`,
+	`<a>`, `<a/>`, `</a>`, `<a b="c">`, `<a b=>`, `<a b="`, `<a ="b">`, `<a:>`, `<:a>`, `&`, `&;`, `&#;`, `&#x;`, `&#xFFFFFFFF;`,
+	`<?xml version="1.0"?><!DOCTYPE a><a x="1" y="&amp;"><b/>text<![CDATA[<c>]]><!-- d --></a>`,
+}
+
+// fuzzTokens calls every helper with each token of data
+func fuzzTokens(t *testing.T, data []byte) {
+	for _, mode := range []ParseMode{ParseDefault, ParseStrict, ParseLenient, ParseHTML} {
+		s := NewScanner(data)
+		s.Mode = mode
+		s.TrackLines = true
+		for {
+			token, chardata, err := s.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				break
+			}
+			fuzzToken(token, chardata)
+		}
+	}
+	// Every helper must handle any input not just tokens produced by a Scanner
+	fuzzToken(data, false)
+	fuzzToken(data, true)
+}
+
+// fuzzToken calls every helper with token
+func fuzzToken(token []byte, chardata bool) {
+	if chardata {
+		CharData(token, nil)
+		CharDataAppend(nil, token)
+		DecodeEntities(token, nil)
+		(&EntityDecoder{Lenient: true}).CharData(token, nil)
+		return
+	}
+	IsElement(token)
+	IsSelfClosing(token)
+	IsEndElement(token)
+	IsStartElement(token)
+	IsComment(token)
+	Comment(token)
+	IsDirective(token)
+	Directive(token)
+	IsProcInst(token)
+	ProcInst(token)
+	name, attrs := Element(token)
+	Name(name)
+	Attrs(attrs, func(key, value []byte) bool {
+		Name(key)
+		DecodeEntities(value, nil)
+		return true
+	})
+	DecodedAttrs(attrs, nil, func(key, value []byte) bool { return true })
+	AttrCount(attrs)
+	AttrAt(attrs, 1)
+	var ns Namespaces
+	ns.Push(token)
+	ns.Resolve(name, false)
+}
+
+func FuzzScanner(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzTokens(t, data)
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Validate(data)
+		Parse(data)
+		NewIndex(data)
+		Split(data, []byte("a"))
+		Unmarshal(data, new(struct {
+			A string   `xml:"a,attr"`
+			B []string `xml:"b"`
+			C string   `xml:",chardata"`
+		}))
+	})
+}
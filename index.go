@@ -0,0 +1,183 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+)
+
+// indexElement is the position of an element (and its relatives) in an Index
+type indexElement struct {
+	start      int // offset of the start element
+	tagEnd     int // offset after the start element
+	end        int // offset after the end element
+	parent     int
+	depth      int
+	position   int // position in the children of the parent
+	childStart int // offset of the children in Index.children
+	childCount int
+	skip       int // the first element after the subtree
+}
+
+// Index is a structural index of every element in a document built by scanning it once
+// so that it can be navigated (ex: the nth child or the next sibling) in O(1) and queried repeatedly
+// Elements are numbered in document order, 0 is the document itself whose children are the top-level elements
+type Index struct {
+	buf      []byte
+	elems    []indexElement
+	children []int
+}
+
+// NewIndex scans buf recording the boundaries of every element
+func NewIndex(buf []byte) (*Index, error) {
+	ix := &Index{
+		buf:   buf,
+		elems: []indexElement{{end: len(buf), parent: -1}},
+	}
+	stack := []int{0}
+	s := NewScanner(buf)
+	for {
+		start := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			if len(stack) == 1 {
+				return nil, errUnexpectedEnd
+			}
+			elem := &ix.elems[stack[len(stack)-1]]
+			elem.end, elem.skip = s.Offset(), len(ix.elems)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		parent := stack[len(stack)-1]
+		ix.elems = append(ix.elems, indexElement{
+			start:    start,
+			tagEnd:   s.Offset(),
+			end:      s.Offset(),
+			parent:   parent,
+			depth:    len(stack),
+			position: ix.elems[parent].childCount,
+			skip:     len(ix.elems) + 1,
+		})
+		ix.elems[parent].childCount++
+		if !IsSelfClosing(token) {
+			stack = append(stack, len(ix.elems)-1)
+		}
+	}
+	if len(stack) > 1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	ix.elems[0].skip = len(ix.elems)
+	// Lay out the children of each element contiguously
+	ix.children = make([]int, len(ix.elems)-1)
+	offset := 0
+	for idx := range ix.elems {
+		ix.elems[idx].childStart = offset
+		offset += ix.elems[idx].childCount
+	}
+	for idx := 1; idx < len(ix.elems); idx++ {
+		elem := &ix.elems[idx]
+		ix.children[ix.elems[elem.parent].childStart+elem.position] = idx
+	}
+	return ix, nil
+}
+
+// Len returns the number of elements in the index (including the document)
+func (ix *Index) Len() int {
+	return len(ix.elems)
+}
+
+// Token returns the start element token of element i (empty for the document)
+func (ix *Index) Token(i int) []byte {
+	return ix.buf[ix.elems[i].start:ix.elems[i].tagEnd]
+}
+
+// Range returns the byte range of element i including its end element
+func (ix *Index) Range(i int) (start int, end int) {
+	return ix.elems[i].start, ix.elems[i].end
+}
+
+// Bytes returns element i including its end element
+func (ix *Index) Bytes(i int) []byte {
+	return ix.buf[ix.elems[i].start:ix.elems[i].end]
+}
+
+// Inner returns the content of element i between its start and end elements
+func (ix *Index) Inner(i int) []byte {
+	elem := &ix.elems[i]
+	if elem.tagEnd == elem.end {
+		return nil
+	}
+	inner := ix.buf[elem.tagEnd:elem.end]
+	if i == 0 {
+		return inner
+	}
+	// Remove the end element
+	return inner[:bytes.LastIndexByte(inner, '<')]
+}
+
+// Depth returns the nesting of element i (0 for the document, 1 for top-level elements)
+func (ix *Index) Depth(i int) int {
+	return ix.elems[i].depth
+}
+
+// Parent returns the parent of element i (or -1 for the document)
+func (ix *Index) Parent(i int) int {
+	return ix.elems[i].parent
+}
+
+// NumChildren returns the number of child elements of element i
+func (ix *Index) NumChildren(i int) int {
+	return ix.elems[i].childCount
+}
+
+// Child returns the nth (0-based) child element of element i (or -1)
+func (ix *Index) Child(i int, n int) int {
+	elem := &ix.elems[i]
+	if n < 0 || n >= elem.childCount {
+		return -1
+	}
+	return ix.children[elem.childStart+n]
+}
+
+// NextSibling returns the element following element i in its parent (or -1)
+func (ix *Index) NextSibling(i int) int {
+	if i == 0 {
+		return -1
+	}
+	return ix.Child(ix.elems[i].parent, ix.elems[i].position+1)
+}
+
+// PrevSibling returns the element preceding element i in its parent (or -1)
+func (ix *Index) PrevSibling(i int) int {
+	if i == 0 {
+		return -1
+	}
+	return ix.Child(ix.elems[i].parent, ix.elems[i].position-1)
+}
+
+// Skip returns the first element after the subtree of element i (or Len if there is none)
+func (ix *Index) Skip(i int) int {
+	return ix.elems[i].skip
+}
+
+// Select returns every element matching the Path without re-scanning the document
+func (ix *Index) Select(p *Path) []int {
+	var matches []int
+	m := p.Matcher()
+	for idx := 1; idx < len(ix.elems); idx++ {
+		for m.Depth() >= ix.elems[idx].depth {
+			m.Pop()
+		}
+		if m.Push(ix.Token(idx)) {
+			matches = append(matches, idx)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,56 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	buf := []byte(`<?xml version="1.0"?><root><a id="1">x<b/></a><!-- c --><a id="2"><b>y</b><b/></a><c/></root>`)
+	ix, err := NewIndex(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 8, ix.Len())
+	assert.Equal(t, 1, ix.NumChildren(0))
+	root := ix.Child(0, 0)
+	assert.Equal(t, `<root>`, string(ix.Token(root)))
+	assert.Equal(t, 0, ix.Parent(root))
+	assert.Equal(t, -1, ix.Parent(0))
+	assert.Equal(t, 3, ix.NumChildren(root))
+	first, second, third := ix.Child(root, 0), ix.Child(root, 1), ix.Child(root, 2)
+	assert.Equal(t, `<a id="1">x<b/></a>`, string(ix.Bytes(first)))
+	assert.Equal(t, `<b>y</b><b/>`, string(ix.Inner(second)))
+	assert.Equal(t, `<c/>`, string(ix.Bytes(third)))
+	assert.Nil(t, ix.Inner(third))
+	assert.Equal(t, -1, ix.Child(root, 3))
+	assert.Equal(t, second, ix.NextSibling(first))
+	assert.Equal(t, first, ix.PrevSibling(second))
+	assert.Equal(t, -1, ix.PrevSibling(first))
+	assert.Equal(t, -1, ix.NextSibling(third))
+	assert.Equal(t, -1, ix.NextSibling(0))
+	assert.Equal(t, second, ix.Skip(first))
+	assert.Equal(t, third, ix.Skip(second))
+	assert.Equal(t, ix.Len(), ix.Skip(root))
+	assert.Equal(t, 3, ix.Depth(ix.Child(second, 1)))
+	start, end := ix.Range(root)
+	assert.Equal(t, 21, start)
+	assert.Equal(t, len(buf), end)
+	var selected []string
+	for _, idx := range ix.Select(MustCompilePath("root/a/b")) {
+		selected = append(selected, string(ix.Bytes(idx)))
+	}
+	assert.Equal(t, []string{`<b/>`, `<b>y</b>`, `<b/>`}, selected)
+	assert.Equal(t, []int{second}, ix.Select(MustCompilePath("//a[@id='2']")))
+}
+
+func TestIndex_Errors(t *testing.T) {
+	_, err := NewIndex([]byte(`<a></a></b>`))
+	assert.Equal(t, errUnexpectedEnd, err)
+	_, err = NewIndex([]byte(`<a><b>`))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = NewIndex([]byte(`<a`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package fastxml
+
+// Interner returns the same string for every occurrence of the same bytes (ex: the names of repeated elements)
+// so a document with millions of identically named elements retains a single copy of each name
+// and strings returned by it can be compared by their data pointer. The zero value is ready to use
+// An Interner is not safe for concurrent use
+type Interner struct {
+	// MaxEntries limits the number of distinct strings retained, once reached any new string is
+	// copied but not retained. 0 is unlimited
+	MaxEntries int
+
+	m map[string]string
+}
+
+// Intern returns a string equal to b, the string is a copy so it does not reference b
+func (in *Interner) Intern(b []byte) string {
+	// The conversion in the map index does not allocate
+	if s, ok := in.m[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	if in.MaxEntries > 0 && len(in.m) >= in.MaxEntries {
+		return s
+	}
+	if in.m == nil {
+		in.m = make(map[string]string)
+	}
+	in.m[s] = s
+	return s
+}
+
+// InternString is Intern for a string which may reference mutable memory (ex: one returned by String)
+func (in *Interner) InternString(s string) string {
+	if interned, ok := in.m[s]; ok {
+		return interned
+	}
+	return in.Intern([]byte(s))
+}
+
+// Len returns the number of distinct strings retained
+func (in *Interner) Len() int {
+	return len(in.m)
+}
+
+// Reset removes every retained string
+func (in *Interner) Reset() {
+	in.m = nil
+}
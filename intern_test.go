@@ -0,0 +1,41 @@
+package fastxml
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stringData returns the pointer to the data of s
+func stringData(s string) uintptr {
+	return (*[2]uintptr)(unsafe.Pointer(&s))[0]
+}
+
+func TestInterner(t *testing.T) {
+	var in Interner
+	buf := []byte("<item><item>")
+	first := in.Intern(buf[1:5])
+	second := in.Intern(buf[7:11])
+	assert.Equal(t, "item", first)
+	assert.Equal(t, stringData(first), stringData(second))
+	// The string is a copy
+	buf[1] = 'x'
+	assert.Equal(t, "item", first)
+	assert.Equal(t, "", in.Intern(nil))
+	assert.Equal(t, 2, in.Len())
+	allocs := testing.AllocsPerRun(100, func() {
+		in.Intern(buf[7:11])
+	})
+	assert.Equal(t, float64(0), allocs)
+	assert.Equal(t, stringData(first), stringData(in.InternString(String(buf[7:11]))))
+	assert.Equal(t, "other", in.InternString(String([]byte("other"))))
+	in.Reset()
+	assert.Equal(t, 0, in.Len())
+
+	limited := Interner{MaxEntries: 1}
+	a := limited.Intern([]byte("a"))
+	assert.Equal(t, "b", limited.Intern([]byte("b")))
+	assert.Equal(t, 1, limited.Len())
+	assert.Equal(t, stringData(a), stringData(limited.Intern([]byte("a"))))
+}
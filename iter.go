@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package fastxml
+
+import (
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the raw bytes of each element in s matching the Path
+// The Scanner is only advanced as the iterator is consumed, stopping early leaves s after the last match
+// If s fails the error is yielded as the final value
+func (p *Path) All(s *Scanner) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		m := p.Matcher()
+		for {
+			start, end, err := m.Find(s)
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(s.buf[start:end], nil) {
+				return
+			}
+		}
+	}
+}
+
+// AllAttrs returns an iterator over each key="value" in attrsToken (see Attrs), the value will _not_ be decoded yet
+// If the attributes are malformed the error is yielded as the final value
+func AllAttrs(attrsToken []byte) iter.Seq2[Attribute, error] {
+	return func(yield func(Attribute, error) bool) {
+		stopped := false
+		err := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+			stopped = !yield(Attribute{Key: attrsToken[keyStart:keyEnd], Value: attrsToken[valueStart:valueEnd]}, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(Attribute{}, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build go1.23
+
+package fastxml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath_All(t *testing.T) {
+	s := NewScanner([]byte(`<root><item id="1"/><item id="2"/><item id="3"/></root>`))
+	var matches []string
+	for match, err := range MustCompilePath("root/item").All(s) {
+		assert.NoError(t, err)
+		matches = append(matches, string(match))
+		if len(matches) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{`<item id="1"/>`, `<item id="2"/>`}, matches)
+	// The scanner was only advanced as far as the second match
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, `<item id="3"/>`, string(token))
+
+	for _, err := range MustCompilePath("root/item").All(NewScanner([]byte(`<root><item`))) {
+		assert.EqualError(t, err, `expected Token to end with '>' at offset 6`)
+	}
+}
+
+func TestAllAttrs(t *testing.T) {
+	var keys, values []string
+	for attr, err := range AllAttrs([]byte(` a="1" b = "2"  c="&amp;"`)) {
+		assert.NoError(t, err)
+		keys = append(keys, string(attr.Key))
+		values = append(values, string(attr.Value))
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.Equal(t, []string{"1", "2", "&amp;"}, values)
+
+	// Stopping early does not yield the error of the remaining attributes
+	keys = nil
+	for attr, err := range AllAttrs([]byte(`a="1" b="2" c`)) {
+		assert.NoError(t, err)
+		keys = append(keys, string(attr.Key))
+		break
+	}
+	assert.Equal(t, []string{"a"}, keys)
+
+	keys = nil
+	var errs []error
+	for attr, err := range AllAttrs([]byte(`a="1" b="2" c`)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		keys = append(keys, string(attr.Key))
+	}
+	assert.Equal(t, []string{"a", "b"}, keys)
+	if assert.Len(t, errs, 1) {
+		assert.True(t, errors.Is(errs[0], ErrBadAttr))
+	}
+}
@@ -0,0 +1,55 @@
+package fastxml
+
+import "fmt"
+
+// Limits reported in LimitError.Limit
+const (
+	LimitTokenSize = "token-size"
+	LimitAttrCount = "attr-count"
+	LimitDepth     = "depth"
+)
+
+// LimitError is returned when a document exceeds one of the configured Limits
+type LimitError struct {
+	Limit  string // which limit was exceeded (ex: LimitDepth)
+	Max    int    // the configured limit
+	Value  int    // the size, count or depth which exceeded it
+	Offset int    // byte offset in the input of the token
+}
+
+// Error implements the error interface
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit: %s of %d exceeds %d at offset %d", e.Limit, e.Value, e.Max, e.Offset)
+}
+
+// Limits bound the work and memory spent on a (possibly malicious) document, 0 is unlimited
+// It is embedded in Scanner, Decoder and Policy, a token exceeding a limit is returned by Next as a *LimitError
+type Limits struct {
+	// MaxTokenSize rejects any token (including CharData) longer than this many bytes
+	// A Decoder also stops buffering an incomplete token once it exceeds this
+	MaxTokenSize int
+	// MaxAttrCount rejects any start element with more than this many attributes
+	MaxAttrCount int
+	// MaxDepth rejects elements nested deeper than this
+	MaxDepth int
+}
+
+// check checks the token at offset against the limits given the current element nesting depth
+func (l *Limits) check(depth int, offset int, token []byte, chardata bool) error {
+	if l.MaxTokenSize > 0 && len(token) > l.MaxTokenSize {
+		return &LimitError{Limit: LimitTokenSize, Max: l.MaxTokenSize, Value: len(token), Offset: offset}
+	}
+	if chardata || !IsElement(token) || IsEndElement(token) {
+		return nil
+	}
+	if l.MaxDepth > 0 && depth >= l.MaxDepth {
+		return &LimitError{Limit: LimitDepth, Max: l.MaxDepth, Value: depth + 1, Offset: offset}
+	}
+	if l.MaxAttrCount > 0 {
+		_, attrsToken := Element(token)
+		if count := AttrCount(attrsToken); count > l.MaxAttrCount {
+			return &LimitError{Limit: LimitAttrCount, Max: l.MaxAttrCount, Value: count, Offset: offset}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Limits Limits
+		Input  string
+		Error  string
+	}{
+		{
+			Name:   "allowed",
+			Limits: Limits{MaxTokenSize: 16, MaxAttrCount: 2, MaxDepth: 2},
+			Input:  `<a x="1" y="2"><b/><b>text</b></a>`,
+		}, {
+			Name:   "token size",
+			Limits: Limits{MaxTokenSize: 8},
+			Input:  `<a>0123456789</a>`,
+			Error:  `limit: token-size of 10 exceeds 8 at offset 3`,
+		}, {
+			Name:   "attr count",
+			Limits: Limits{MaxAttrCount: 2},
+			Input:  `<a x="1" y="2"><b x="1" y="2" z="3"/></a>`,
+			Error:  `limit: attr-count of 3 exceeds 2 at offset 15`,
+		}, {
+			Name:   "depth",
+			Limits: Limits{MaxDepth: 2},
+			Input:  `<a><b></b><b><c/></b></a>`,
+			Error:  `limit: depth of 3 exceeds 2 at offset 13`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			s := NewScanner([]byte(tc.Input))
+			s.Limits = tc.Limits
+			var err error
+			for err == nil {
+				_, _, err = s.Next()
+			}
+			d := NewDecoderReaderSize(strings.NewReader(tc.Input), 16)
+			d.Limits = tc.Limits
+			_, decoderErr := decoderTokens(d)
+			if tc.Error == "" {
+				assert.Equal(t, io.EOF, err)
+				assert.NoError(t, decoderErr)
+				return
+			}
+			assert.EqualError(t, err, tc.Error)
+			assert.EqualError(t, decoderErr, tc.Error)
+			assert.IsType(t, &LimitError{}, err)
+		})
+	}
+}
+
+func TestLimits_Policy(t *testing.T) {
+	// The depth is shared with the Policy and the lower limit takes precedence
+	for _, limits := range [][2]int{{3, 2}, {2, 3}, {2, 0}, {0, 2}} {
+		s := NewScanner([]byte(`<a><b><c></c></b></a>`))
+		var audited []*SecurityError
+		s.Policy = &Policy{Limits: Limits{MaxDepth: limits[0]}, Audit: func(err *SecurityError) {
+			audited = append(audited, err)
+		}}
+		s.MaxDepth = limits[1]
+		var err error
+		for err == nil {
+			_, _, err = s.Next()
+		}
+		assert.EqualError(t, err, `limit: depth of 3 exceeds 2 at offset 6`)
+		assert.Equal(t, []*SecurityError{{Rule: RuleDepth, Offset: 6, Detail: "3 exceeds 2"}}, audited)
+	}
+	var err error
+	// TrackLines reports the offset of the token
+	s := NewScanner([]byte("<a>\n  <b/>\n</a>"))
+	s.MaxDepth = 1
+	s.TrackLines = true
+	for err = nil; err == nil; {
+		_, _, err = s.Next()
+	}
+	assert.EqualError(t, err, "syntax error at line 2, column 3: limit: depth of 2 exceeds 1 at offset 6")
+}
+
+func TestDecoder_LimitsBuffering(t *testing.T) {
+	// An unterminated token is not buffered past MaxTokenSize
+	input := `<a>` + strings.Repeat("x", 1<<20)
+	r := strings.NewReader(input)
+	d := NewDecoderReaderSize(r, 16)
+	d.MaxTokenSize = 64
+	tokens, err := decoderTokens(d)
+	assert.Equal(t, []string{"<a>"}, tokens)
+	assert.EqualError(t, err, "limit: token-size of 128 exceeds 64 at offset 3")
+	assert.True(t, r.Len() > 1<<19)
+}
@@ -0,0 +1,208 @@
+// Package lint implements a fast, configurable linter for XML documents built on fastxml.Scanner
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Names of the rules reported in Diagnostic.Rule
+const (
+	RuleIndent        = "indent"
+	RuleMixedIndent   = "mixed-indent"
+	RuleTrailingSpace = "trailing-whitespace"
+	RuleAttrOrder     = "attr-order"
+	RuleDeprecated    = "deprecated"
+)
+
+// Diagnostic is a single problem found in a document
+type Diagnostic struct {
+	Offset  int // byte offset in the input
+	Line    int // 1-based line number
+	Column  int // 1-based column (in bytes)
+	Rule    string
+	Message string
+}
+
+// String formats the Diagnostic as `line:col: rule: message`
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Rule, d.Message)
+}
+
+// Config selects which checks are performed by Lint
+type Config struct {
+	// Indent is the expected indentation unit (ex: "  " or "\t")
+	// If empty it is inferred from the first indented line
+	Indent string
+	// CheckIndent reports lines whose indentation does not match the nesting depth
+	CheckIndent bool
+	// CheckMixedIndent reports indentation containing both tabs and spaces
+	CheckMixedIndent bool
+	// CheckTrailingSpace reports lines ending in spaces or tabs
+	CheckTrailingSpace bool
+	// CheckAttrOrder reports elements whose attributes are not in lexicographic order
+	CheckAttrOrder bool
+	// Deprecated is a list of element names which should no longer be used
+	Deprecated []string
+}
+
+// DefaultConfig enables every check which doesn't require additional configuration
+func DefaultConfig() Config {
+	return Config{
+		CheckIndent:        true,
+		CheckMixedIndent:   true,
+		CheckTrailingSpace: true,
+		CheckAttrOrder:     true,
+	}
+}
+
+// linter holds the state of a single Lint call
+type linter struct {
+	cfg        Config
+	buf        []byte
+	lines      []int // offset of the start of each line
+	diags      []Diagnostic
+	deprecated map[string]struct{}
+}
+
+// report appends a Diagnostic for the given offset
+func (l *linter) report(offset int, rule string, format string, args ...interface{}) {
+	line := sort.Search(len(l.lines), func(i int) bool { return l.lines[i] > offset })
+	l.diags = append(l.diags, Diagnostic{
+		Offset:  offset,
+		Line:    line,
+		Column:  offset - l.lines[line-1] + 1,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkTrailingSpace reports every line ending in a space or tab
+func (l *linter) checkTrailingSpace() {
+	for idx, start := range l.lines {
+		end := len(l.buf)
+		if idx+1 < len(l.lines) {
+			end = l.lines[idx+1] - 1 // exclude '\n'
+		}
+		if end > start && l.buf[end-1] == '\r' {
+			end--
+		}
+		trimmed := bytes.TrimRight(l.buf[start:end], " \t")
+		if len(trimmed) != end-start {
+			l.report(start+len(trimmed), RuleTrailingSpace, "trailing whitespace")
+		}
+	}
+}
+
+// checkIndent verifies indent (the whitespace after the last newline) at offset for the given depth
+func (l *linter) checkIndent(offset int, indent []byte, depth int) {
+	if l.cfg.CheckMixedIndent && bytes.IndexByte(indent, ' ') != -1 && bytes.IndexByte(indent, '\t') != -1 {
+		l.report(offset, RuleMixedIndent, "indentation mixes tabs and spaces")
+	}
+	if !l.cfg.CheckIndent {
+		return
+	}
+	// Infer the indentation unit from the first indented line
+	if l.cfg.Indent == "" {
+		if depth == 0 || len(indent) == 0 || len(indent)%depth != 0 {
+			return
+		}
+		l.cfg.Indent = string(indent[:len(indent)/depth])
+	}
+	if want := strings.Repeat(l.cfg.Indent, depth); string(indent) != want {
+		l.report(offset, RuleIndent, "expected indentation %q but got %q", want, indent)
+	}
+}
+
+// checkElement runs the per-element checks on a start element
+func (l *linter) checkElement(offset int, token []byte) error {
+	name, attrs := fastxml.Element(token)
+	if _, ok := l.deprecated[fastxml.String(name)]; ok {
+		l.report(offset, RuleDeprecated, "element %q is deprecated", name)
+	}
+	if !l.cfg.CheckAttrOrder || attrs == nil {
+		return nil
+	}
+	var prev []byte
+	return fastxml.Attrs(attrs, func(key, _ []byte) bool {
+		if prev != nil && bytes.Compare(prev, key) > 0 {
+			l.report(offset, RuleAttrOrder, "attribute %q should be before %q", key, prev)
+			return false
+		}
+		prev = key
+		return true
+	})
+}
+
+// Lint checks buf according to cfg returning all the diagnostics found
+// If the document cannot be scanned the diagnostics found so far are returned with the error
+func Lint(buf []byte, cfg Config) ([]Diagnostic, error) {
+	l := &linter{
+		cfg:   cfg,
+		buf:   buf,
+		lines: []int{0},
+	}
+	for idx, b := range buf {
+		if b == '\n' {
+			l.lines = append(l.lines, idx+1)
+		}
+	}
+	if len(cfg.Deprecated) > 0 {
+		l.deprecated = make(map[string]struct{}, len(cfg.Deprecated))
+		for _, name := range cfg.Deprecated {
+			l.deprecated[name] = struct{}{}
+		}
+	}
+	if cfg.CheckTrailingSpace {
+		l.checkTrailingSpace()
+	}
+	s := fastxml.NewScanner(buf)
+	depth := 0
+	var indent []byte // indentation preceding the current token (if on a new line)
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return l.diags, err
+		}
+		if chardata {
+			// Only whitespace containing a newline determines the indentation of the next token
+			indent = nil
+			if idx := bytes.LastIndexByte(token, '\n'); idx != -1 && len(bytes.TrimLeft(token, " \t\r\n")) == 0 {
+				indent = token[idx+1:]
+			}
+			continue
+		}
+		isElement := fastxml.IsElement(token)
+		isEnd := isElement && fastxml.IsEndElement(token)
+		if isEnd {
+			depth--
+		}
+		if indent != nil {
+			l.checkIndent(offset, indent, depth)
+			indent = nil
+		}
+		if !isElement || isEnd {
+			continue
+		}
+		if err := l.checkElement(offset, token); err != nil {
+			return l.diags, err
+		}
+		if !fastxml.IsSelfClosing(token) {
+			depth++
+		}
+	}
+	// Trailing whitespace is found before the token based checks, keep the output ordered
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		return l.diags[i].Offset < l.diags[j].Offset
+	})
+	return l.diags, nil
+}
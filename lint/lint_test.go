@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Config   Config
+		Expected []string
+	}{
+		{
+			Name:   "clean",
+			Input:  "<root>\n  <child a=\"1\" b=\"2\"/>\n</root>\n",
+			Config: DefaultConfig(),
+		},
+		{
+			Name:     "indent",
+			Input:    "<root>\n  <child>\n     <leaf/>\n  </child>\n</root>",
+			Config:   DefaultConfig(),
+			Expected: []string{`3:6: indent: expected indentation "    " but got "     "`},
+		},
+		{
+			Name:     "configured indent",
+			Input:    "<root>\n  <child/>\n</root>",
+			Config:   Config{Indent: "\t", CheckIndent: true},
+			Expected: []string{`2:3: indent: expected indentation "\t" but got "  "`},
+		},
+		{
+			Name:     "mixed indent",
+			Input:    "<root>\n \t<child/>\n</root>",
+			Config:   Config{CheckMixedIndent: true},
+			Expected: []string{`2:3: mixed-indent: indentation mixes tabs and spaces`},
+		},
+		{
+			Name:     "trailing whitespace",
+			Input:    "<root> \r\n<child/>\t\n</root>",
+			Config:   Config{CheckTrailingSpace: true},
+			Expected: []string{`1:7: trailing-whitespace: trailing whitespace`, `2:9: trailing-whitespace: trailing whitespace`},
+		},
+		{
+			Name:     "attr order",
+			Input:    `<root b="2" a="1"/>`,
+			Config:   Config{CheckAttrOrder: true},
+			Expected: []string{`1:1: attr-order: attribute "a" should be before "b"`},
+		},
+		{
+			Name:     "deprecated",
+			Input:    `<root><old/><new/></root>`,
+			Config:   Config{Deprecated: []string{"old"}},
+			Expected: []string{`1:7: deprecated: element "old" is deprecated`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			diags, err := Lint([]byte(tc.Input), tc.Config)
+			assert.NoError(t, err)
+			var actual []string
+			for _, diag := range diags {
+				actual = append(actual, diag.String())
+			}
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestLint_Error(t *testing.T) {
+	_, err := Lint([]byte(`<root><unterminated`), DefaultConfig())
+	assert.EqualError(t, err, `expected Token to end with '>' at offset 6`)
+}
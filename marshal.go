@@ -0,0 +1,475 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// UnsupportedError is returned by Marshal for a type implementing an encoding/xml interface which
+// requires an *xml.Encoder (ex: xml.Marshaler) or using a feature Marshal does not implement
+// stdxml.Marshal falls back to encoding/xml when it occurs
+type UnsupportedError struct {
+	Type    reflect.Type
+	Feature string // the method (ex: "MarshalXML") or feature which is not supported
+}
+
+// Error implements the error interface
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("fastxml: %s uses %s which is not supported by Marshal", e.Type, e.Feature)
+}
+
+// errCommentDashes is returned when a comment field contains "--"
+var errCommentDashes = errors.New(`xml: comments must not contain "--"`)
+
+// Allocate once instead of on each append call
+var (
+	endCDATA     = []byte("]]>")
+	escapedCDATA = []byte("]]]]><![CDATA[>")
+)
+
+// marshalPlan is the (cached) way values of a type are marshalled
+// The encoding/xml interfaces are detected by method name so they are supported without importing encoding/xml
+type marshalPlan struct {
+	marshalXML, ptrMarshalXML         bool // implements xml.Marshaler
+	marshalXMLAttr, ptrMarshalXMLAttr bool // implements xml.MarshalerAttr
+	text, ptrText                     bool // implements encoding.TextMarshaler
+	tinfo                             *typeInfo
+}
+
+// planMap caches the *marshalPlan for each reflect.Type
+var planMap sync.Map
+
+// getMarshalPlan returns the (cached) marshalPlan for typ
+func getMarshalPlan(typ reflect.Type) (*marshalPlan, error) {
+	if plan, ok := planMap.Load(typ); ok {
+		return plan.(*marshalPlan), nil
+	}
+	tinfo, err := getTypeInfo(typ)
+	if err != nil {
+		return nil, err
+	}
+	ptr := reflect.PtrTo(typ)
+	_, marshalXML := typ.MethodByName("MarshalXML")
+	_, ptrMarshalXML := ptr.MethodByName("MarshalXML")
+	_, marshalXMLAttr := typ.MethodByName("MarshalXMLAttr")
+	_, ptrMarshalXMLAttr := ptr.MethodByName("MarshalXMLAttr")
+	plan := &marshalPlan{
+		marshalXML:        marshalXML,
+		ptrMarshalXML:     ptrMarshalXML,
+		marshalXMLAttr:    marshalXMLAttr,
+		ptrMarshalXMLAttr: ptrMarshalXMLAttr,
+		text:              typ.Implements(textMarshalerType),
+		ptrText:           ptr.Implements(textMarshalerType),
+		tinfo:             tinfo,
+	}
+	actual, _ := planMap.LoadOrStore(typ, plan)
+	return actual.(*marshalPlan), nil
+}
+
+// textMarshaler returns v as an encoding.TextMarshaler if implemented
+func (plan *marshalPlan) textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if plan.text && v.CanInterface() {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if plan.ptrText && v.CanAddr() {
+		if pv := v.Addr(); pv.CanInterface() {
+			return pv.Interface().(encoding.TextMarshaler), true
+		}
+	}
+	return nil, false
+}
+
+// isEmptyValue determines if v is empty for omitempty, the same as encoding/xml
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// indirect follows pointers and interfaces stopping at a nil value
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldByIndex returns the field of v, ok is false if an embedded pointer is nil
+func fieldByIndex(v reflect.Value, idx []int) (reflect.Value, bool) {
+	for i, x := range idx {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// appendSimple appends the text of a basic value (number, bool, string or []byte) escaped with replacements
+// ok is false if v is not a basic value
+func appendSimple(dst []byte, v reflect.Value, replacements *[256]string) (out []byte, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(dst, v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(dst, v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(dst, v.Float(), 'g', -1, v.Type().Bits()), true
+	case reflect.Bool:
+		return strconv.AppendBool(dst, v.Bool()), true
+	case reflect.String:
+		return escapeString(dst, v.String(), replacements), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return escape(dst, v.Bytes(), replacements), true
+		}
+	}
+	return dst, false
+}
+
+// appendCDATA appends data as a CDATA section splitting any "]]>"
+func appendCDATA(dst []byte, data []byte) []byte {
+	if len(data) == 0 {
+		return dst
+	}
+	dst = append(dst, prefixCDATA...)
+	for {
+		idx := bytes.Index(data, endCDATA)
+		if idx == -1 {
+			break
+		}
+		dst = append(dst, data[:idx]...)
+		dst = append(dst, escapedCDATA...)
+		data = data[idx+len(endCDATA):]
+	}
+	dst = append(dst, data...)
+	return append(dst, suffixCDATA...)
+}
+
+// encodeState holds the state of a single Marshal
+type encodeState struct {
+	out []byte
+}
+
+// writeParents closes the elements of stack not shared with parents and opens the rest of parents
+func (e *encodeState) writeParents(stack []string, parents []string) []string {
+	common := 0
+	for common < len(stack) && common < len(parents) && stack[common] == parents[common] {
+		common++
+	}
+	for idx := len(stack) - 1; idx >= common; idx-- {
+		e.out = append(e.out, '<', '/')
+		e.out = append(e.out, stack[idx]...)
+		e.out = append(e.out, '>')
+	}
+	stack = stack[:common]
+	for _, parent := range parents[common:] {
+		e.out = append(e.out, '<')
+		e.out = append(e.out, parent...)
+		e.out = append(e.out, '>')
+		stack = append(stack, parent)
+	}
+	return stack
+}
+
+// startName determines the element name of val following the same rules as encoding/xml:
+// the XMLName tag, the XMLName value, the field name (or tag) and finally the type name
+func startName(val reflect.Value, tinfo *typeInfo, finfo *fieldInfo) (space string, local string, err error) {
+	if xmlname := tinfo.xmlname; xmlname != nil {
+		if xmlname.name != "" {
+			return xmlname.xmlns, xmlname.name, nil
+		}
+		if v, ok := fieldByIndex(val, xmlname.idx); ok && isXMLType(v.Type(), "Name") {
+			if local := v.FieldByName("Local").String(); local != "" {
+				return v.FieldByName("Space").String(), local, nil
+			}
+		}
+	}
+	if finfo != nil && finfo.name != "" {
+		return finfo.xmlns, finfo.name, nil
+	}
+	if name := val.Type().Name(); name != "" {
+		if idx := strings.IndexByte(name, '['); idx != -1 {
+			name = name[:idx]
+		}
+		return "", name, nil
+	}
+	return "", "", fmt.Errorf("xml: unsupported type: %s", val.Type())
+}
+
+// marshalValue appends the element for val
+func (e *encodeState) marshalValue(val reflect.Value, finfo *fieldInfo) error {
+	if !val.IsValid() {
+		return nil
+	}
+	if finfo != nil && finfo.flags&fOmitEmpty != 0 && isEmptyValue(val) {
+		return nil
+	}
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	typ := val.Type()
+	plan, err := getMarshalPlan(typ)
+	if err != nil {
+		return err
+	}
+	if plan.marshalXML || (plan.ptrMarshalXML && val.CanAddr()) {
+		return &UnsupportedError{Type: typ, Feature: "MarshalXML"}
+	}
+	tm, isText := plan.textMarshaler(val)
+	// Slices and arrays (other than []byte) are a sequence of elements
+	if !isText && (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && typ.Elem().Kind() != reflect.Uint8 {
+		for idx := 0; idx < val.Len(); idx++ {
+			if err := e.marshalValue(val.Index(idx), finfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	space, local, err := startName(val, plan.tinfo, finfo)
+	if err != nil {
+		return err
+	}
+	e.out = append(e.out, '<')
+	e.out = append(e.out, local...)
+	if space != "" {
+		e.out = append(e.out, ` xmlns="`...)
+		e.out = escapeString(e.out, space, &attrReplacements)
+		e.out = append(e.out, '"')
+	}
+	if !isText && val.Kind() == reflect.Struct {
+		if err := e.marshalAttrs(val, plan.tinfo); err != nil {
+			return err
+		}
+	}
+	e.out = append(e.out, '>')
+	switch {
+	case isText:
+		text, err := tm.MarshalText()
+		if err != nil {
+			return err
+		}
+		e.out = EscapeText(e.out, text)
+	case val.Kind() == reflect.Struct:
+		if err := e.marshalStruct(val, plan.tinfo); err != nil {
+			return err
+		}
+	default:
+		var ok bool
+		if e.out, ok = appendSimple(e.out, val, &textReplacements); !ok {
+			return fmt.Errorf("xml: unsupported type: %s", typ)
+		}
+	}
+	e.out = append(e.out, '<', '/')
+	e.out = append(e.out, local...)
+	e.out = append(e.out, '>')
+	return nil
+}
+
+// marshalAttrs appends the attribute fields of the struct val
+func (e *encodeState) marshalAttrs(val reflect.Value, tinfo *typeInfo) error {
+	for idx := range tinfo.fields {
+		finfo := &tinfo.fields[idx]
+		if finfo.flags&fAttr == 0 {
+			continue
+		}
+		fv, ok := fieldByIndex(val, finfo.idx)
+		if !ok {
+			continue
+		}
+		if err := e.marshalAttr(fv, finfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAttr appends an attribute with an (unescaped) value
+func (e *encodeState) writeAttr(name string, value []byte) {
+	e.out = append(e.out, ' ')
+	e.out = append(e.out, name...)
+	e.out = append(e.out, '=', '"')
+	e.out = EscapeAttr(e.out, value)
+	e.out = append(e.out, '"')
+}
+
+// marshalAttr appends the attribute for the field val
+func (e *encodeState) marshalAttr(val reflect.Value, finfo *fieldInfo) error {
+	if finfo.flags&fOmitEmpty != 0 && isEmptyValue(val) {
+		return nil
+	}
+	if val = indirect(val); val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		return nil
+	}
+	typ := val.Type()
+	plan, err := getMarshalPlan(typ)
+	if err != nil {
+		return err
+	}
+	if plan.marshalXMLAttr || (plan.ptrMarshalXMLAttr && val.CanAddr()) {
+		return &UnsupportedError{Type: typ, Feature: "MarshalXMLAttr"}
+	}
+	if isXMLType(typ, "Attr") {
+		name := val.FieldByName("Name")
+		if name.FieldByName("Space").String() != "" {
+			return &UnsupportedError{Type: typ, Feature: "namespaced attributes"}
+		}
+		e.writeAttr(name.FieldByName("Local").String(), []byte(val.FieldByName("Value").String()))
+		return nil
+	}
+	if finfo.xmlns != "" {
+		return &UnsupportedError{Type: typ, Feature: "namespaced attributes"}
+	}
+	if tm, ok := plan.textMarshaler(val); ok {
+		text, err := tm.MarshalText()
+		if err != nil {
+			return err
+		}
+		e.writeAttr(finfo.name, text)
+		return nil
+	}
+	if val.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 {
+		for idx := 0; idx < val.Len(); idx++ {
+			if err := e.marshalAttr(val.Index(idx), finfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	e.out = append(e.out, ' ')
+	e.out = append(e.out, finfo.name...)
+	e.out = append(e.out, '=', '"')
+	var ok bool
+	if e.out, ok = appendSimple(e.out, val, &attrReplacements); !ok {
+		return fmt.Errorf("xml: unsupported type: %s", typ)
+	}
+	e.out = append(e.out, '"')
+	return nil
+}
+
+// marshalStruct appends the content (everything but the attributes) of the struct val
+func (e *encodeState) marshalStruct(val reflect.Value, tinfo *typeInfo) error {
+	var stack []string
+	for idx := range tinfo.fields {
+		finfo := &tinfo.fields[idx]
+		if finfo.flags&fAttr != 0 {
+			continue
+		}
+		fv, ok := fieldByIndex(val, finfo.idx)
+		if !ok {
+			continue
+		}
+		switch finfo.flags & fMode {
+		case fCDATA, fCharData:
+			plan, err := getMarshalPlan(fv.Type())
+			if err != nil {
+				return err
+			}
+			var text []byte
+			if tm, ok := plan.textMarshaler(fv); ok {
+				if text, err = tm.MarshalText(); err != nil {
+					return err
+				}
+			} else if text, ok = appendSimple(nil, indirect(fv), &emptyReplacements); !ok {
+				continue
+			}
+			if finfo.flags&fMode == fCDATA {
+				e.out = appendCDATA(e.out, text)
+			} else {
+				e.out = EscapeText(e.out, text)
+			}
+		case fComment:
+			fv = indirect(fv)
+			if fv.Kind() != reflect.String && !(fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8) {
+				return fmt.Errorf("xml: bad type for comment field of %s", val.Type())
+			}
+			if fv.Len() == 0 {
+				continue
+			}
+			comment, _ := appendSimple(nil, fv, &emptyReplacements)
+			if bytes.Contains(comment, dashDash) {
+				return errCommentDashes
+			}
+			e.out = append(e.out, prefixComment...)
+			e.out = append(e.out, comment...)
+			if comment[len(comment)-1] == '-' {
+				e.out = append(e.out, ' ')
+			}
+			e.out = append(e.out, suffixComment...)
+		case fInnerXML:
+			fv = indirect(fv)
+			if fv.Kind() == reflect.String || (fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8) {
+				e.out, _ = appendSimple(e.out, fv, &emptyReplacements)
+			}
+		case fElement, fElement | fAny:
+			stack = e.writeParents(stack, finfo.parents[:commonPrefix(stack, finfo.parents)])
+			if len(finfo.parents) > len(stack) {
+				if (fv.Kind() != reflect.Ptr && fv.Kind() != reflect.Interface) || !fv.IsNil() {
+					stack = e.writeParents(stack, finfo.parents)
+				}
+			}
+			if err := e.marshalValue(fv, finfo); err != nil {
+				return err
+			}
+		}
+	}
+	e.writeParents(stack, nil)
+	return nil
+}
+
+// commonPrefix returns the number of leading elements shared by a and b
+func commonPrefix(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// emptyReplacements copies values without escaping them
+var emptyReplacements [256]string
+
+// MarshalAppend appends the XML encoding of v to dst following the same rules as xml.Marshal
+// (struct tags such as `xml:"name,attr"`, `xml:",chardata"`, `xml:",innerxml"` and `xml:"a>b"`)
+// Types implementing xml.Marshaler or xml.MarshalerAttr and namespaced attributes return an *UnsupportedError
+func MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	e := encodeState{out: dst}
+	if err := e.marshalValue(reflect.ValueOf(v), nil); err != nil {
+		return dst, err
+	}
+	return e.out, nil
+}
+
+// Marshal returns the XML encoding of v, see MarshalAppend
+func Marshal(v interface{}) ([]byte, error) {
+	return MarshalAppend(nil, v)
+}
@@ -0,0 +1,159 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type marshalInner struct {
+	Value string `xml:",chardata"`
+	Lang  string `xml:"lang,attr,omitempty"`
+}
+
+type marshalEmbedded struct {
+	Embedded int `xml:"embedded"`
+}
+
+type marshalPerson struct {
+	XMLName xml.Name `xml:"person"`
+	marshalEmbedded
+	ID       int            `xml:"id,attr"`
+	Active   bool           `xml:"active,attr"`
+	Name     string         `xml:"name"`
+	Email    []string       `xml:"contact>email"`
+	Phone    string         `xml:"contact>phone"`
+	Nick     *marshalInner  `xml:"nick"`
+	Missing  *marshalInner  `xml:"missing"`
+	Aliases  []marshalInner `xml:"alias"`
+	Score    float64        `xml:"score"`
+	Created  time.Time      `xml:"created"`
+	Note     string         `xml:",comment"`
+	Empty    string         `xml:"empty,omitempty"`
+	Raw      string         `xml:",innerxml"`
+	Data     []byte         `xml:"data"`
+	Ignored  string         `xml:"-"`
+	Any      interface{}    `xml:"any"`
+	unexport string
+}
+
+type marshalCDATA struct {
+	Text string `xml:",cdata"`
+}
+
+type marshalName struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Count   uint8      `xml:",chardata"`
+}
+
+type marshalMarshaler struct{}
+
+func (marshalMarshaler) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement("custom", start)
+}
+
+type marshalNamespaced struct {
+	Attr string `xml:"urn:x attr,attr"`
+}
+
+func TestMarshal(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Value interface{}
+	}{
+		{
+			Name: "struct",
+			Value: &marshalPerson{
+				marshalEmbedded: marshalEmbedded{Embedded: 7},
+				ID:              42,
+				Active:          true,
+				Name:            `a < b & c`,
+				Email:           []string{"a@example.com", "b@example.com"},
+				Phone:           "555",
+				Nick:            &marshalInner{Value: "nick", Lang: "en"},
+				Aliases:         []marshalInner{{Value: "x"}, {Value: "y", Lang: "fr"}},
+				Score:           1.5,
+				Created:         time.Date(2026, 10, 17, 1, 2, 3, 0, time.UTC),
+				Note:            "comment-",
+				Raw:             "<raw/>",
+				Data:            []byte("bytes"),
+				Ignored:         "ignored",
+				Any:             marshalInner{Value: "iface"},
+			},
+		},
+		{Name: "zero", Value: marshalPerson{}},
+		{Name: "cdata", Value: marshalCDATA{Text: "a]]>b"}},
+		{Name: "cdata empty", Value: marshalCDATA{}},
+		{
+			Name: "xml.Name",
+			Value: marshalName{
+				XMLName: xml.Name{Space: "urn:x", Local: "named"},
+				Attrs:   []xml.Attr{{Name: xml.Name{Local: "a"}, Value: "1\t2"}},
+				Count:   3,
+			},
+		},
+		{Name: "slice", Value: []marshalCDATA{{Text: "1"}, {Text: "2"}}},
+		{Name: "nil", Value: nil},
+		{Name: "nil pointer", Value: (*marshalPerson)(nil)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			expected, err := xml.Marshal(tc.Value)
+			assert.NoError(t, err)
+			actual, err := Marshal(tc.Value)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	var unsupported *UnsupportedError
+	_, err := Marshal(marshalMarshaler{})
+	assert.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, "MarshalXML", unsupported.Feature)
+	_, err = Marshal(marshalNamespaced{Attr: "x"})
+	assert.True(t, errors.As(err, &unsupported))
+	_, err = Marshal(map[string]string{})
+	assert.EqualError(t, err, "xml: unsupported type: map[string]string")
+	_, err = Marshal(marshalPerson{Note: "a--b"})
+	assert.Equal(t, errCommentDashes, err)
+}
+
+func TestMarshal_Escaping(t *testing.T) {
+	out, err := Marshal(marshalInner{Value: "\"a\" & <b>\n", Lang: "\"x\"\t<"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<marshalInner lang=\"&quot;x&quot;&#x9;&lt;\">\"a\" &amp; &lt;b&gt;\n</marshalInner>", string(out))
+}
+
+func TestMarshalAppend(t *testing.T) {
+	out, err := MarshalAppend([]byte("prefix:"), marshalCDATA{Text: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "prefix:<marshalCDATA><![CDATA[x]]></marshalCDATA>", string(out))
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	v := &marshalPerson{Name: "name", Email: []string{"a", "b"}, Aliases: []marshalInner{{Value: "x", Lang: "en"}}}
+	b.ReportAllocs()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = MarshalAppend(buf[:0], v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodingXMLMarshal(b *testing.B) {
+	v := &marshalPerson{Name: "name", Email: []string{"a", "b"}, Aliases: []marshalInner{{Value: "x", Lang: "en"}}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := xml.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Conflict is a node (or attribute) which was changed differently in both versions during Merge
+type Conflict struct {
+	// Path is the XPath of the conflicting node, attribute or (for conflicting
+	// insertions/removals amongst children) the parent element
+	Path string
+	// Base, Ours and Theirs are the XML (or the raw attribute value) of each version, nil if absent
+	Base   []byte
+	Ours   []byte
+	Theirs []byte
+}
+
+// String describes the conflict
+func (c Conflict) String() string {
+	return fmt.Sprintf("conflict at %s: base %q, ours %q, theirs %q", c.Path, c.Base, c.Ours, c.Theirs)
+}
+
+// merger accumulates the conflicts of a Merge
+type merger struct {
+	conflicts []Conflict
+}
+
+// appendNodes appends the XML of nodes to dst
+func appendNodes(dst []byte, nodes []*Node) []byte {
+	for _, n := range nodes {
+		dst = n.appendXML(dst)
+	}
+	return dst
+}
+
+// equalNodes determines if two lists of nodes are identical
+func equalNodes(a, b []*Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !a[idx].equal(b[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeAttrs performs a three-way merge of the attributes of matched elements
+func (m *merger) mergeAttrs(path string, base, ours, theirs *Node) []Attribute {
+	merged := make([]Attribute, 0, len(ours.Attrs))
+	value := func(n *Node, key []byte) ([]byte, bool) {
+		if idx := n.attr(key); idx != -1 {
+			return n.Attrs[idx].Value, true
+		}
+		return nil, false
+	}
+	resolve := func(key []byte) {
+		bv, inBase := value(base, key)
+		ov, inOurs := value(ours, key)
+		tv, inTheirs := value(theirs, key)
+		switch {
+		case inOurs == inTheirs && bytes.Equal(ov, tv), inBase == inTheirs && bytes.Equal(bv, tv):
+			if inOurs {
+				merged = append(merged, Attribute{Key: key, Value: ov})
+			}
+		case inBase == inOurs && bytes.Equal(bv, ov):
+			if inTheirs {
+				merged = append(merged, Attribute{Key: key, Value: tv})
+			}
+		default:
+			m.conflicts = append(m.conflicts, Conflict{Path: path + "/@" + string(key), Base: bv, Ours: ov, Theirs: tv})
+			if inOurs {
+				merged = append(merged, Attribute{Key: key, Value: ov})
+			}
+		}
+	}
+	// Keep the attribute order of ours, followed by any only in theirs or base
+	for _, attr := range ours.Attrs {
+		resolve(attr.Key)
+	}
+	for _, attr := range theirs.Attrs {
+		if ours.attr(attr.Key) == -1 {
+			resolve(attr.Key)
+		}
+	}
+	for _, attr := range base.Attrs {
+		if ours.attr(attr.Key) == -1 && theirs.attr(attr.Key) == -1 {
+			resolve(attr.Key)
+		}
+	}
+	return merged
+}
+
+// mergeNode performs a three-way merge of three matched nodes
+func (m *merger) mergeNode(path string, base, ours, theirs *Node) *Node {
+	switch {
+	case ours.equal(theirs), theirs.equal(base):
+		return ours
+	case ours.equal(base):
+		return theirs
+	case ours.Kind != ElementNode || !bytes.Equal(base.Name, ours.Name) || !bytes.Equal(base.Name, theirs.Name):
+		m.conflicts = append(m.conflicts, Conflict{
+			Path:   path,
+			Base:   base.appendXML(nil),
+			Ours:   ours.appendXML(nil),
+			Theirs: theirs.appendXML(nil),
+		})
+		return ours
+	}
+	merged := *ours
+	merged.Attrs = m.mergeAttrs(path, base, ours, theirs)
+	merged.Children = m.mergeChildren(path, base.Children, ours.Children, theirs.Children, (*Node).same)
+	return &merged
+}
+
+// mergeChildren performs a three-way merge (diff3) of the children of three matched nodes
+func (m *merger) mergeChildren(path string, base, ours, theirs []*Node, same func(a, b *Node) bool) []*Node {
+	// Find the base children which were kept (as the same node) in both versions
+	inOurs := make([]int, len(base))
+	inTheirs := make([]int, len(base))
+	for idx := range base {
+		inOurs[idx], inTheirs[idx] = -1, -1
+	}
+	for _, pair := range matchChildren(base, ours, same) {
+		inOurs[pair[0]] = pair[1]
+	}
+	for _, pair := range matchChildren(base, theirs, same) {
+		inTheirs[pair[0]] = pair[1]
+	}
+	var merged []*Node
+	lastBase, lastOurs, lastTheirs := 0, 0, 0
+	// region merges the children between the previous and next stable child
+	region := func(nextBase, nextOurs, nextTheirs int) {
+		b, o, t := base[lastBase:nextBase], ours[lastOurs:nextOurs], theirs[lastTheirs:nextTheirs]
+		switch {
+		case equalNodes(o, t), equalNodes(t, b):
+			merged = append(merged, o...)
+		case equalNodes(o, b):
+			merged = append(merged, t...)
+		default:
+			conflict := Conflict{
+				Path:   path,
+				Base:   appendNodes(nil, b),
+				Ours:   appendNodes(nil, o),
+				Theirs: appendNodes(nil, t),
+			}
+			if conflict.Path == "" {
+				conflict.Path = "/"
+			}
+			m.conflicts = append(m.conflicts, conflict)
+			merged = append(merged, o...)
+		}
+	}
+	for idx, child := range base {
+		oIdx, tIdx := inOurs[idx], inTheirs[idx]
+		if oIdx == -1 || tIdx == -1 {
+			continue
+		}
+		region(idx, oIdx, tIdx)
+		// The stable child is merged recursively, its step is based on the position in ours
+		step := path + "/" + ours[oIdx].step(position(ours, oIdx))
+		merged = append(merged, m.mergeNode(step, child, ours[oIdx], theirs[tIdx]))
+		lastBase, lastOurs, lastTheirs = idx+1, oIdx+1, tIdx+1
+	}
+	region(len(base), len(ours), len(theirs))
+	return merged
+}
+
+// Merge performs a three-way merge of two versions (ours and theirs) of the document base
+// Changes made by only one side are applied, changes made identically by both sides are applied once
+// If both sides changed the same node, attribute or range of children differently it is reported
+// as a Conflict and the version from ours is used in the merged document
+func Merge(base, ours, theirs []byte) ([]byte, []Conflict, error) {
+	var trees [3]*Node
+	for idx, doc := range [][]byte{base, ours, theirs} {
+		tree, err := Parse(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		trees[idx] = tree
+	}
+	var m merger
+	merged := Node{
+		Kind:     DocumentNode,
+		Children: m.mergeChildren("", trees[0].Children, trees[1].Children, trees[2].Children, sameKind),
+	}
+	return merged.appendXML(nil), m.conflicts, nil
+}
@@ -0,0 +1,86 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Base      string
+		Ours      string
+		Theirs    string
+		Expected  string
+		Conflicts []string
+	}{
+		{
+			Name:     "independent attributes",
+			Base:     `<config a="1" b="2" c="3"/>`,
+			Ours:     `<config a="10" b="2"/>`,
+			Theirs:   `<config a="1" b="2" c="3" d="4"/>`,
+			Expected: `<config a="10" b="2" d="4"/>`,
+		},
+		{
+			Name:     "independent children",
+			Base:     `<config><a>1</a><b>2</b><c>3</c></config>`,
+			Ours:     `<config><new/><a>1</a><b>changed</b><c>3</c></config>`,
+			Theirs:   `<config><a>1</a><b>2</b><added/></config>`,
+			Expected: `<config><new/><a>1</a><b>changed</b><added/></config>`,
+		},
+		{
+			Name:     "same change",
+			Base:     `<config><a>1</a></config>`,
+			Ours:     `<config><a>2</a></config>`,
+			Theirs:   `<config><a>2</a></config>`,
+			Expected: `<config><a>2</a></config>`,
+		},
+		{
+			Name:      "conflicting attribute",
+			Base:      `<config><item id="1" v="a"/><item id="2" v="b"/></config>`,
+			Ours:      `<config><item id="1" v="a"/><item id="2" v="ours"/></config>`,
+			Theirs:    `<config><item id="1" v="a"/><item id="2"/></config>`,
+			Expected:  `<config><item id="1" v="a"/><item id="2" v="ours"/></config>`,
+			Conflicts: []string{`conflict at /config[1]/item[2]/@v: base "b", ours "ours", theirs ""`},
+		},
+		{
+			Name:      "conflicting text",
+			Base:      `<config><a>1</a></config>`,
+			Ours:      `<config><a>2</a></config>`,
+			Theirs:    `<config><a>3</a></config>`,
+			Expected:  `<config><a>2</a></config>`,
+			Conflicts: []string{`conflict at /config[1]/a[1]/text()[1]: base "1", ours "2", theirs "3"`},
+		},
+		{
+			Name:      "modify and delete",
+			Base:      `<config><a>1</a><b/></config>`,
+			Ours:      `<config><b/></config>`,
+			Theirs:    `<config><a>2</a><b/></config>`,
+			Expected:  `<config><b/></config>`,
+			Conflicts: []string{`conflict at /config[1]: base "<a>1</a>", ours "", theirs "<a>2</a>"`},
+		},
+		{
+			Name:      "renamed root",
+			Base:      `<a/>`,
+			Ours:      `<b/>`,
+			Theirs:    `<c/>`,
+			Expected:  `<b/>`,
+			Conflicts: []string{`conflict at /b[1]: base "<a/>", ours "<b/>", theirs "<c/>"`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			merged, conflicts, err := Merge([]byte(tc.Base), []byte(tc.Ours), []byte(tc.Theirs))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(merged))
+			var actual []string
+			for _, conflict := range conflicts {
+				actual = append(actual, conflict.String())
+			}
+			assert.Equal(t, tc.Conflicts, actual)
+		})
+	}
+	_, _, err := Merge([]byte(`<a/>`), []byte(`<a>`), []byte(`<a/>`))
+	assert.Error(t, err)
+}
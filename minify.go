@@ -0,0 +1,60 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+)
+
+// Allocate once instead of on each Attr call
+var attrXMLSpace = []byte("xml:space")
+
+// redundantXMLDecl determines if the XML declaration inst (ex: `version="1.0" encoding="UTF-8"`)
+// only states the defaults (version 1.0 and UTF-8) and can be removed without changing the document
+func redundantXMLDecl(inst []byte) bool {
+	version, encoding, standalone, err := ParseXMLDecl(inst)
+	return err == nil && String(version) == "1.0" && (encoding == nil || strings.EqualFold(String(encoding), "UTF-8")) && standalone == nil
+}
+
+// Minify appends src to dst with comments, whitespace-only CharData between markup and redundant
+// XML declarations (those stating only the defaults or appearing after the first token) removed
+// Whitespace within an element with xml:space="preserve" is kept and all other tokens are copied unchanged
+func Minify(dst, src []byte) ([]byte, error) {
+	depth, preserve := 0, 0 // preserve is the depth of the outermost xml:space="preserve" element
+	s := NewScanner(src)
+	for first := true; ; first = false {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return dst, nil
+		} else if err != nil {
+			return dst, err
+		}
+		switch {
+		case chardata:
+			if preserve == 0 && IsWhitespace(token) {
+				continue
+			}
+		case IsComment(token):
+			continue
+		case IsProcInst(token):
+			if target, inst := ProcInst(token); String(target) == "xml" && (!first || redundantXMLDecl(inst)) {
+				continue
+			}
+		case IsEndElement(token):
+			if depth == preserve {
+				preserve = 0
+			}
+			depth--
+		case IsElement(token) && !IsSelfClosing(token):
+			depth++
+			if preserve == 0 {
+				_, attrsToken := Element(token)
+				if value, err := Attr(attrsToken, attrXMLSpace); err != nil {
+					return dst, err
+				} else if String(value) == "preserve" {
+					preserve = depth
+				}
+			}
+		}
+		dst = append(dst, token...)
+	}
+}
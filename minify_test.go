@@ -0,0 +1,50 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinify(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "whitespace and comments",
+			Input:    "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<!-- c -->\n<root>\n  <a  x=\"1\" >text <b>bold</b></a>\n  <!-- c -->\n  <c><![CDATA[ ]]></c>\n</root>\n",
+			Expected: `<root><a  x="1" >text <b>bold</b></a><c><![CDATA[ ]]></c></root>`,
+		},
+		{
+			Name:     "declaration kept",
+			Input:    "<?xml version='1.0' standalone='yes'?>\n<root/>",
+			Expected: `<?xml version='1.0' standalone='yes'?><root/>`,
+		},
+		{
+			Name:     "declaration other encoding",
+			Input:    `<?xml version="1.0" encoding="ISO-8859-1"?><root/>`,
+			Expected: `<?xml version="1.0" encoding="ISO-8859-1"?><root/>`,
+		},
+		{
+			Name:     "repeated declaration",
+			Input:    `<?xml version="1.1"?><root/><?xml version="1.1"?><?pi x?>`,
+			Expected: `<?xml version="1.1"?><root/><?pi x?>`,
+		},
+		{
+			Name:     "preserve",
+			Input:    "<root>\n <pre xml:space=\"preserve\">\n  <a> </a>\n </pre>\n <a> </a>\n</root>",
+			Expected: "<root><pre xml:space=\"preserve\">\n  <a> </a>\n </pre><a></a></root>",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := Minify([]byte("prefix:"), []byte(tc.Input))
+			assert.NoError(t, err)
+			assert.Equal(t, "prefix:"+tc.Expected, string(actual))
+		})
+	}
+	_, err := Minify(nil, []byte(`<root><a`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package fastxml
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenMmap memory-maps the file at path read-only and returns a *Scanner over its contents
+// The returned function unmaps the file and must be called once the Scanner (and any token
+// or string referencing it) is no longer used. Platforms without mmap support read the file instead
+func OpenMmap(path string) (*Scanner, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return NewScanner(nil), func() error { return nil }, nil
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("%s: file too large to map (%d bytes)", path, size)
+	}
+	buf, unmap, err := mmap(f, int(size))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: mmap: %w", path, err)
+	}
+	return NewScanner(buf), unmap, nil
+}
@@ -0,0 +1,17 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && (!windows || !go1.17 || fastxml_safe)
+
+package fastxml
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the first size bytes of f as memory mapping is not supported
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error { return nil }, nil
+}
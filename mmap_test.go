@@ -0,0 +1,47 @@
+package fastxml
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenMmap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastxml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "doc.xml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`<a><b>text</b></a>`), 0600))
+	s, closer, err := OpenMmap(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var tokens []string
+	for {
+		token, _, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		tokens = append(tokens, string(token))
+	}
+	assert.Equal(t, []string{"<a>", "<b>", "text", "</b>", "</a>"}, tokens)
+	assert.NoError(t, closer())
+
+	empty := filepath.Join(dir, "empty.xml")
+	assert.NoError(t, ioutil.WriteFile(empty, nil, 0600))
+	s, closer, err = OpenMmap(empty)
+	assert.NoError(t, err)
+	_, _, err = s.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.NoError(t, closer())
+
+	_, _, err = OpenMmap(filepath.Join(dir, "missing.xml"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package fastxml
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of f read-only
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	buf, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error {
+		return syscall.Munmap(buf)
+	}, nil
+}
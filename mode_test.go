@@ -0,0 +1,176 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// modeTokens reads every token from input in mode, checking a Decoder produces the same tokens
+func modeTokens(t *testing.T, mode ParseMode, input string) ([]string, error) {
+	s := NewScanner([]byte(input))
+	s.Mode = mode
+	var tokens []string
+	var err error
+	for {
+		var token []byte
+		if token, _, err = s.Next(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			break
+		}
+		tokens = append(tokens, string(token))
+	}
+	decoders := map[string]*Decoder{
+		"bytes":   NewDecoder([]byte(input)),
+		"onebyte": NewDecoderReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16),
+	}
+	for name, d := range decoders {
+		d.Mode = mode
+		actual, actualErr := decoderTokens(d)
+		assert.Equal(t, tokens, actual, name)
+		if err == nil {
+			assert.NoError(t, actualErr, name)
+		} else {
+			assert.EqualError(t, actualErr, err.Error(), name)
+		}
+	}
+	return tokens, err
+}
+
+func TestParseMode(t *testing.T) {
+	testCases := []struct {
+		Input   string
+		Default []string
+		Strict  string
+		Lenient []string
+		Valid   bool
+	}{
+		{
+			Input:   `<a href="x">text</a>`,
+			Valid:   true,
+			Default: []string{`<a href="x">`, `text`, `</a>`},
+			Lenient: []string{`<a href="x">`, `text`, `</a>`},
+		}, {
+			Input:   `<p>a < b and c > d</p>`,
+			Default: []string{`<p>`, `a `, `< b and c >`, ` d`, `</p>`},
+			Strict:  `syntax error at line 1, column 6: invalid token "< b and c >"`,
+			Lenient: []string{`<p>`, `a `, `< b and c > d`, `</p>`},
+		}, {
+			Input:  `<p>if a <b then`,
+			Strict: `expected Token to end with '>' at offset 8`,
+		}, {
+			Input:   `<p>1 < 2`,
+			Lenient: []string{`<p>`, `1 `, `< 2`},
+		}, {
+			Input:   `<a href=x title='it"s > x' alt="y">text</a>`,
+			Default: []string{`<a href=x title='it"s > x' alt="y">`, `text`, `</a>`},
+			Strict:  `syntax error at line 1, column 33: expected whitespace but got "y\""`,
+			Lenient: []string{`<a href="x" title="it&quot;s > x" alt="y">`, `text`, `</a>`},
+		}, {
+			Input:   `<a href=/?a<b&c=1 title='x &amp; y' alt=R&D&#38;>text</a>`,
+			Lenient: []string{`<a href="/?a&lt;b&amp;c=1" title="x &amp; y" alt="R&amp;D&#38;">`, `text`, `</a>`},
+		}, {
+			Input:   `<img src=x.png/>`,
+			Lenient: []string{`<img src="x.png"/>`},
+		}, {
+			Input:   `<a>R&D</a>`,
+			Default: []string{`<a>`, `R&D`, `</a>`},
+			Strict:  `syntax error at line 1, column 5: invalid character or entity reference`,
+		}, {
+			Input:  `<a x="1" x="2"/>`,
+			Strict: `syntax error at line 1, column 10: duplicate attribute "x"`,
+		}, {
+			Input:   `<a><!-- x > y --></a>`,
+			Valid:   true,
+			Default: []string{`<a>`, `<!-- x > y -->`, `</a>`},
+		}, {
+			Input:   `<a><!-- x -- y --></a>`,
+			Default: []string{`<a>`, `<!-- x -- y -->`, `</a>`},
+			Strict:  `syntax error at line 1, column 4: invalid sequence "--" not allowed in comments`,
+		}, {
+			Input:  `<a><!-- x ---></a>`,
+			Strict: `syntax error at line 1, column 4: invalid sequence "--" not allowed in comments`,
+		}, {
+			Input:  `<a></b>`,
+			Strict: `element <a> at offset 0 closed by </b> at offset 3`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			if tc.Default != nil {
+				tokens, err := modeTokens(t, ParseDefault, tc.Input)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Default, tokens)
+			}
+			_, err := modeTokens(t, ParseStrict, tc.Input)
+			if tc.Valid {
+				assert.NoError(t, err)
+			} else if tc.Strict != "" {
+				assert.EqualError(t, err, tc.Strict)
+			} else {
+				assert.Error(t, err)
+			}
+			if tc.Lenient != nil {
+				tokens, err := modeTokens(t, ParseLenient, tc.Input)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Lenient, tokens)
+			}
+		})
+	}
+	assert.Equal(t, "strict", ParseStrict.String())
+	assert.Equal(t, "lenient", ParseLenient.String())
+	assert.Equal(t, "html", ParseHTML.String())
+	assert.Equal(t, "default", ParseDefault.String())
+}
+
+func TestParseHTML(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected []string
+	}{
+		{
+			Input:    `<p>line<br>next<BR/><img alt="a > b"><img src=x.png></p>`,
+			Expected: []string{`<p>`, `line`, `<br>`, `next`, `<BR/>`, `<img alt="a > b">`, `<img src="x.png">`, `</p>`},
+		},
+		{
+			Input:    `<p>R&D &amp; Q&A &nbsp; &#169;<a href="/?a=1&b=2">x</a><![CDATA[&]]> 1 < 2 & 3</p>`,
+			Expected: []string{`<p>`, `R&amp;D &amp; Q&amp;A &nbsp; &#169;`, `<a href="/?a=1&amp;b=2">`, `x`, `</a>`, `<![CDATA[&]]>`, ` 1 `, `< 2 &amp; 3`, `</p>`},
+		},
+		{
+			Input:    `<DIV><Span>x</SPAN></div>`,
+			Expected: []string{`<DIV>`, `<Span>`, `x`, `</Span>`, `</DIV>`},
+		},
+		{
+			Input:    `<ul><li>one<li>two</ul></br><p>unclosed`,
+			Expected: []string{`<ul>`, `<li>`, `one`, `<li>`, `two`, `</li>`, `</li>`, `</ul>`, `<p>`, `unclosed`, `</p>`},
+		},
+		{
+			Input:    `<b>bold</i></b></p>`,
+			Expected: []string{`<b>`, `bold`, `</b>`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			tokens, err := modeTokens(t, ParseHTML, tc.Input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, tokens)
+		})
+	}
+	// Void elements are skipped as self-closing and the generated end elements keep Skip balanced
+	s := NewScanner([]byte(`<div><p>a<br>b<div>c</div></DIV><next>`))
+	s.Mode = ParseHTML
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Skip())
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "<next>", string(token))
+	assert.True(t, s.SelfClosing([]byte("<Img src=\"x\">")))
+	s.Mode = ParseDefault
+	assert.False(t, s.SelfClosing([]byte("<img>")))
+}
@@ -0,0 +1,121 @@
+package fastxml
+
+import "io"
+
+// muxRoute is a single Path registered with a Mux
+type muxRoute struct {
+	matcher *PathMatcher
+	handler func(subtree []byte) error
+	open    []muxMatch // matched elements which have not ended yet
+}
+
+// muxMatch is the start offset and depth of a matched element
+type muxMatch struct {
+	start int
+	depth int
+}
+
+// Mux dispatches the subtrees of elements matching a Path to the registered handlers
+// Every handler is served from a single pass over the document
+type Mux struct {
+	routes []*muxRoute
+}
+
+// HandlePath registers f to be called with the raw bytes of every element matching p
+// (from the start element through the end element). The subtree is a slice of the buffer given to the Scanner
+func (mx *Mux) HandlePath(p *Path, f func(subtree []byte) error) {
+	mx.routes = append(mx.routes, &muxRoute{
+		matcher: p.Matcher(),
+		handler: f,
+	})
+}
+
+// Handle compiles expr and registers f using HandlePath
+func (mx *Mux) Handle(expr string, f func(subtree []byte) error) error {
+	p, err := CompilePath(expr)
+	if err != nil {
+		return err
+	}
+	mx.HandlePath(p, f)
+	return nil
+}
+
+// push enters a start element for every route returning if any route could match within it
+func (mx *Mux) push(start int, token []byte, selfClosing bool) (possible bool, err error) {
+	for _, r := range mx.routes {
+		if r.matcher.Push(token) {
+			if selfClosing {
+				if err := r.handler(token); err != nil {
+					return false, err
+				}
+			} else {
+				r.open = append(r.open, muxMatch{start: start, depth: r.matcher.Depth()})
+			}
+		}
+		if len(r.open) > 0 || r.matcher.Possible() {
+			possible = true
+		}
+	}
+	return possible, nil
+}
+
+// pop leaves an element (which ended at end) for every route calling the handler of any route it matched
+func (mx *Mux) pop(buf []byte, end int) error {
+	for _, r := range mx.routes {
+		if n := len(r.open); n > 0 && r.open[n-1].depth == r.matcher.Depth() {
+			start := r.open[n-1].start
+			r.open = r.open[:n-1]
+			if err := r.handler(buf[start:end]); err != nil {
+				return err
+			}
+		}
+		if err := r.matcher.Pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan reads every token from s dispatching matching elements to the registered handlers
+// Elements in which no Path can match are skipped. A handler error stops the Scan and is returned
+func (mx *Mux) Scan(s *Scanner) error {
+	for _, r := range mx.routes {
+		r.matcher.Reset()
+		r.open = r.open[:0]
+	}
+	for {
+		start := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			if err := mx.pop(s.buf, s.Offset()); err != nil {
+				return err
+			}
+			continue
+		}
+		selfClosing := s.SelfClosing(token)
+		possible, err := mx.push(start, token, selfClosing)
+		if err != nil {
+			return err
+		}
+		if selfClosing {
+			if err := mx.pop(s.buf, s.Offset()); err != nil {
+				return err
+			}
+		} else if !possible {
+			if err := s.Skip(); err != nil {
+				return err
+			}
+			if err := mx.pop(s.buf, s.Offset()); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package fastxml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMux(t *testing.T) {
+	var ids, names, items []string
+	var mx Mux
+	assert.Error(t, mx.Handle("root/item[1]", nil))
+	assert.NoError(t, mx.Handle("root/item/id", func(subtree []byte) error {
+		ids = append(ids, string(subtree))
+		return nil
+	}))
+	assert.NoError(t, mx.Handle("//name", func(subtree []byte) error {
+		names = append(names, string(subtree))
+		return nil
+	}))
+	assert.NoError(t, mx.Handle("root/item", func(subtree []byte) error {
+		items = append(items, string(subtree))
+		return nil
+	}))
+	doc := `<root><item><id>1</id><name>first</name></item><skipped><id>2</id></skipped><item><name/></item></root>`
+	assert.NoError(t, mx.Scan(NewScanner([]byte(doc))))
+	assert.Equal(t, []string{"<id>1</id>"}, ids)
+	assert.Equal(t, []string{"<name>first</name>", "<name/>"}, names)
+	assert.Equal(t, []string{"<item><id>1</id><name>first</name></item>", "<item><name/></item>"}, items)
+}
+
+func TestMux_Error(t *testing.T) {
+	var mx Mux
+	errStop := errors.New("stop")
+	mx.HandlePath(MustCompilePath("root/item"), func(subtree []byte) error {
+		return errStop
+	})
+	assert.Equal(t, errStop, mx.Scan(NewScanner([]byte(`<root><item/></root>`))))
+	assert.Equal(t, errStop, mx.Scan(NewScanner([]byte(`<root><item></item></root>`))))
+	assert.EqualError(t, mx.Scan(NewScanner([]byte(`<root><item`))), `expected Token to end with '>' at offset 6`)
+}
@@ -9,3 +9,158 @@ func Name(token []byte) (space []byte, local []byte) {
 	}
 	return nil, token
 }
+
+// NameEqual determines if two names (ex: `foo:bar`) have the same prefix and local part
+func NameEqual(a, b []byte) bool {
+	aSpace, aLocal := Name(a)
+	bSpace, bLocal := Name(b)
+	return bytes.Equal(aLocal, bLocal) && bytes.Equal(aSpace, bSpace)
+}
+
+// MatchLocal determines if the local part of a name is local, ignoring any prefix
+// (ex: `item` and `ns:item` both match "item")
+func MatchLocal(name []byte, local string) bool {
+	_, l := Name(name)
+	return String(l) == local
+}
+
+// tokenName returns the name of an element token (ex: `<ns:item a="1">` -> `ns:item`) or token if it is a name
+func tokenName(token []byte) []byte {
+	if len(token) == 0 || token[0] != '<' {
+		return token
+	}
+	start := 1
+	if len(token) > 1 && token[1] == '/' {
+		start = 2
+	}
+	end := start
+	for end < len(token) && !isNameEnd(token[end]) {
+		end++
+	}
+	return token[start:end]
+}
+
+// equalFold determines if b and s are equal ignoring ASCII case
+func equalFold(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for idx := 0; idx < len(b); idx++ {
+		x, y := b[idx], s[idx]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// nameEquals compares the name of token to space and local using equal
+func nameEquals(token []byte, space, local string, equal func([]byte, string) bool) bool {
+	name := tokenName(token)
+	if space == "" {
+		return bytes.IndexByte(name, ':') == -1 && equal(name, local)
+	}
+	if len(name) != len(space)+1+len(local) || name[len(space)] != ':' {
+		return false
+	}
+	return equal(name[:len(space)], space) && equal(name[len(space)+1:], local)
+}
+
+// bytesEqual compares b and s without allocating
+func bytesEqual(b []byte, s string) bool {
+	return String(b) == s
+}
+
+// NameEquals determines if the name of an element token (or a name) has the prefix space and local part
+// without allocating, an empty space only matches names without a prefix
+// (ex: `<ns:item a="1">` matches ("ns", "item") and `</item>` matches ("", "item"))
+func NameEquals(token []byte, space, local string) bool {
+	return nameEquals(token, space, local, bytesEqual)
+}
+
+// NameEqualsFold is NameEquals ignoring ASCII case
+func NameEqualsFold(token []byte, space, local string) bool {
+	return nameEquals(token, space, local, equalFold)
+}
+
+// ElementIs determines if the name of an element token (or a name) is name including any prefix
+// without allocating (ex: `<ns:item/>` is "ns:item")
+func ElementIs(token []byte, name string) bool {
+	return String(tokenName(token)) == name
+}
+
+// ElementIsFold is ElementIs ignoring ASCII case (ex: `<TD>` is "td")
+func ElementIsFold(token []byte, name string) bool {
+	return equalFold(tokenName(token), name)
+}
+
+// NamePattern matches names against a pattern, see ParseNamePattern
+type NamePattern struct {
+	space    []byte
+	local    []byte
+	anySpace bool // `*:local` or `*`
+	anyLocal bool // `prefix:*` or `*`
+}
+
+// ParseNamePattern creates a NamePattern from pattern which is one of:
+// `*` any name, `prefix:*` any name with the prefix, `*:local` the local part with any (or no) prefix,
+// `local` the local part without a prefix or `prefix:local` exactly
+func ParseNamePattern(pattern string) NamePattern {
+	if pattern == "*" {
+		return NamePattern{anySpace: true, anyLocal: true}
+	}
+	var p NamePattern
+	space, local := Name([]byte(pattern))
+	if String(space) == "*" {
+		p.anySpace = true
+	} else {
+		p.space = space
+	}
+	if String(local) == "*" {
+		p.anyLocal = true
+	} else {
+		p.local = local
+	}
+	return p
+}
+
+// Exact returns the name matched by the pattern if it does not contain a wildcard
+func (p NamePattern) Exact() (name []byte, ok bool) {
+	if p.anySpace || p.anyLocal {
+		return nil, false
+	}
+	if p.space == nil {
+		return p.local, true
+	}
+	name = append(append(append([]byte{}, p.space...), ':'), p.local...)
+	return name, true
+}
+
+// Match determines if name matches the pattern
+func (p NamePattern) Match(name []byte) bool {
+	space, local := Name(name)
+	return (p.anyLocal || bytes.Equal(p.local, local)) && (p.anySpace || bytes.Equal(p.space, space))
+}
+
+// String returns the pattern
+func (p NamePattern) String() string {
+	space, local := string(p.space), string(p.local)
+	if p.anySpace {
+		space = "*"
+	}
+	if p.anyLocal {
+		local = "*"
+	}
+	if p.anySpace && p.anyLocal {
+		return "*"
+	} else if space == "" {
+		return local
+	}
+	return space + ":" + local
+}
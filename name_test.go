@@ -14,3 +14,92 @@ func TestName(t *testing.T) {
 	assert.Equal(t, []byte("space"), space)
 	assert.Equal(t, []byte("local"), local)
 }
+
+func TestNameEqual(t *testing.T) {
+	assert.True(t, NameEqual([]byte("a:b"), []byte("a:b")))
+	assert.True(t, NameEqual([]byte("b"), []byte("b")))
+	assert.False(t, NameEqual([]byte("a:b"), []byte("b")))
+	assert.False(t, NameEqual([]byte("a:b"), []byte("c:b")))
+}
+
+func TestMatchLocal(t *testing.T) {
+	assert.True(t, MatchLocal([]byte("item"), "item"))
+	assert.True(t, MatchLocal([]byte("ns:item"), "item"))
+	assert.False(t, MatchLocal([]byte("item:ns"), "item"))
+}
+
+func TestNameEquals(t *testing.T) {
+	testCases := []struct {
+		Token string
+		Space string
+		Local string
+		Equal bool
+		Fold  bool
+	}{
+		{Token: `<item>`, Local: "item", Equal: true, Fold: true},
+		{Token: `</item>`, Local: "item", Equal: true, Fold: true},
+		{Token: `<item a="1"/>`, Local: "item", Equal: true, Fold: true},
+		{Token: `<item/>`, Local: "item", Equal: true, Fold: true},
+		{Token: `item`, Local: "item", Equal: true, Fold: true},
+		{Token: `<ns:item>`, Space: "ns", Local: "item", Equal: true, Fold: true},
+		{Token: `<NS:Item>`, Space: "ns", Local: "item", Fold: true},
+		{Token: `<ns:item>`, Local: "item"},
+		{Token: `<item>`, Space: "ns", Local: "item"},
+		{Token: `<items>`, Local: "item"},
+		{Token: `<ns:item>`, Space: "n", Local: "s:item"},
+		{Token: `<ITEM>`, Local: "item", Fold: true},
+	}
+	for _, tc := range testCases {
+		token := []byte(tc.Token)
+		assert.Equal(t, tc.Equal, NameEquals(token, tc.Space, tc.Local), tc.Token)
+		assert.Equal(t, tc.Fold, NameEqualsFold(token, tc.Space, tc.Local), tc.Token)
+	}
+}
+
+func TestElementIs(t *testing.T) {
+	assert.True(t, ElementIs([]byte(`<ns:item a="1">`), "ns:item"))
+	assert.True(t, ElementIs([]byte(`</item>`), "item"))
+	assert.True(t, ElementIs([]byte(`<item/>`), "item"))
+	assert.False(t, ElementIs([]byte(`<ns:item>`), "item"))
+	assert.False(t, ElementIs([]byte(`<Item>`), "item"))
+	assert.True(t, ElementIsFold([]byte(`<TD class="x">`), "td"))
+	assert.False(t, ElementIsFold([]byte(`<TR>`), "td"))
+	token := []byte(`<ns:item a="1">`)
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		NameEquals(token, "ns", "item")
+		NameEqualsFold(token, "NS", "ITEM")
+		ElementIs(token, "ns:item")
+		ElementIsFold(token, "NS:ITEM")
+	}))
+}
+
+func TestNamePattern(t *testing.T) {
+	testCases := []struct {
+		Pattern string
+		Matches []string
+		Exact   string
+	}{
+		{Pattern: "*", Matches: []string{"item", "a:item", "b:item", "a:other", "other"}},
+		{Pattern: "item", Matches: []string{"item"}, Exact: "item"},
+		{Pattern: "a:item", Matches: []string{"a:item"}, Exact: "a:item"},
+		{Pattern: "a:*", Matches: []string{"a:item", "a:other"}},
+		{Pattern: "*:item", Matches: []string{"item", "a:item", "b:item"}},
+	}
+	names := []string{"item", "a:item", "b:item", "a:other", "other"}
+	for _, tc := range testCases {
+		t.Run(tc.Pattern, func(t *testing.T) {
+			p := ParseNamePattern(tc.Pattern)
+			assert.Equal(t, tc.Pattern, p.String())
+			var matches []string
+			for _, name := range names {
+				if p.Match([]byte(name)) {
+					matches = append(matches, name)
+				}
+			}
+			assert.ElementsMatch(t, tc.Matches, matches)
+			exact, ok := p.Exact()
+			assert.Equal(t, tc.Exact != "", ok)
+			assert.Equal(t, tc.Exact, string(exact))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+)
+
+// nsBinding is a namespace prefix declared by an xmlns attribute
+type nsBinding struct {
+	prefix, url string
+}
+
+// Namespaces tracks the xmlns declarations in scope to resolve prefixes to namespace URIs
+// The zero value is ready to use
+type Namespaces struct {
+	bindings []nsBinding
+	marks    []int // len(bindings) before each pushed element
+}
+
+// errNamespacesUnbalanced is returned when Pop is called more times than Push
+var errNamespacesUnbalanced = errors.New("unbalanced Namespaces.Pop")
+
+// Push enters the start element elemToken declaring any xmlns attributes it contains
+// Every call to Push (including for self-closing elements) must be paired with a call to Pop
+// URIs without entities reference elemToken instead of being copied
+func (ns *Namespaces) Push(elemToken []byte) error {
+	ns.marks = append(ns.marks, len(ns.bindings))
+	_, attrsToken := Element(elemToken)
+	var declErr error
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		space, local := Name(key)
+		var prefix []byte
+		if String(space) == "xmlns" {
+			prefix = local
+		} else if space != nil || String(local) != "xmlns" {
+			return true
+		}
+		url, err := DecodeEntities(value, nil)
+		if err != nil {
+			declErr = err
+			return false
+		}
+		ns.bindings = append(ns.bindings, nsBinding{prefix: String(prefix), url: String(url)})
+		return true
+	}); err != nil {
+		return err
+	}
+	return declErr
+}
+
+// Pop leaves the most recently pushed element removing its declarations
+func (ns *Namespaces) Pop() error {
+	if len(ns.marks) == 0 {
+		return errNamespacesUnbalanced
+	}
+	ns.bindings = ns.bindings[:ns.marks[len(ns.marks)-1]]
+	ns.marks = ns.marks[:len(ns.marks)-1]
+	return nil
+}
+
+// Reset removes every declaration
+func (ns *Namespaces) Reset() {
+	ns.bindings = ns.bindings[:0]
+	ns.marks = ns.marks[:0]
+}
+
+// Clone returns a copy of the declarations in scope which is not affected by later calls to Push and Pop
+func (ns *Namespaces) Clone() Namespaces {
+	return Namespaces{
+		bindings: append([]nsBinding(nil), ns.bindings...),
+		marks:    append([]int(nil), ns.marks...),
+	}
+}
+
+// Lookup returns the URI bound to prefix in the current scope (the default namespace if prefix is empty)
+func (ns *Namespaces) Lookup(prefix []byte) (url string, ok bool) {
+	for idx := len(ns.bindings) - 1; idx >= 0; idx-- {
+		if ns.bindings[idx].prefix == String(prefix) {
+			return ns.bindings[idx].url, true
+		}
+	}
+	return "", false
+}
+
+// Resolve returns the namespace URI and local part of an element (or attribute) name following the
+// same rules as encoding/xml: unprefixed attributes have no namespace, the xml prefix is always bound,
+// xmlns declarations keep "xmlns" as the space and an undeclared prefix is returned as-is
+func (ns *Namespaces) Resolve(name []byte, attr bool) (space string, local []byte) {
+	prefix, local := Name(name)
+	switch {
+	case String(prefix) == "xmlns":
+		return "xmlns", local
+	case prefix == nil && attr:
+		return "", local
+	case String(prefix) == "xml":
+		return xmlURL, local
+	case prefix == nil && String(local) == "xmlns":
+		return "", local
+	}
+	if url, ok := ns.Lookup(prefix); ok {
+		return url, local
+	}
+	return String(prefix), local
+}
+
+// Attr reads a specific attribute by namespace URI and local part and returns the (non-decoded) value
+// so the attribute matches regardless of the prefix the document bound to the namespace
+// The element of attrsToken must have been pushed for any declarations it contains to be in scope
+func (ns *Namespaces) Attr(attrsToken []byte, space string, local []byte) (attrValue []byte, err error) {
+	err = Attrs(attrsToken, func(key []byte, value []byte) bool {
+		s, l := ns.Resolve(key, true)
+		if bytes.Equal(l, local) && s == space {
+			attrValue = value
+			return false
+		}
+		return true
+	})
+	return
+}
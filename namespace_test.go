@@ -0,0 +1,82 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaces(t *testing.T) {
+	var ns Namespaces
+	assert.NoError(t, ns.Push([]byte(`<root xmlns="urn:default" xmlns:a="urn:a&amp;b">`)))
+	assert.NoError(t, ns.Push([]byte(`<a:child xmlns:a="urn:inner" xmlns:b="urn:b"/>`)))
+	testCases := []struct {
+		Name  string
+		Attr  bool
+		Space string
+	}{
+		{Name: "item", Space: "urn:default"},
+		{Name: "item", Attr: true, Space: ""},
+		{Name: "a:item", Space: "urn:inner"},
+		{Name: "a:item", Attr: true, Space: "urn:inner"},
+		{Name: "b:item", Space: "urn:b"},
+		{Name: "xml:lang", Attr: true, Space: "http://www.w3.org/XML/1998/namespace"},
+		{Name: "xmlns:a", Attr: true, Space: "xmlns"},
+		{Name: "xmlns", Attr: true, Space: ""},
+		{Name: "unknown:item", Space: "unknown"},
+	}
+	for _, tc := range testCases {
+		space, local := ns.Resolve([]byte(tc.Name), tc.Attr)
+		assert.Equal(t, tc.Space, space, tc.Name)
+		_, expected := Name([]byte(tc.Name))
+		assert.Equal(t, expected, local)
+	}
+	assert.NoError(t, ns.Pop())
+	url, ok := ns.Lookup([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "urn:a&b", url)
+	_, ok = ns.Lookup([]byte("b"))
+	assert.False(t, ok)
+	assert.NoError(t, ns.Pop())
+	assert.Error(t, ns.Pop())
+	_, ok = ns.Lookup(nil)
+	assert.False(t, ok)
+	assert.NoError(t, ns.Push([]byte(`<root xmlns="urn:x">`)))
+	ns.Reset()
+	_, ok = ns.Lookup(nil)
+	assert.False(t, ok)
+}
+
+func TestNamespaces_Attr(t *testing.T) {
+	const xlink = "http://www.w3.org/1999/xlink"
+	for _, token := range []string{
+		`<svg xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="#a" href="b">`,
+		`<svg xmlns:l="http://www.w3.org/1999/xlink" l:href="#a" href="b">`,
+	} {
+		var ns Namespaces
+		assert.NoError(t, ns.Push([]byte(token)))
+		_, attrsToken := Element([]byte(token))
+		value, err := ns.Attr(attrsToken, xlink, []byte("href"))
+		assert.NoError(t, err)
+		assert.Equal(t, "#a", string(value))
+		value, err = ns.Attr(attrsToken, "", []byte("href"))
+		assert.NoError(t, err)
+		assert.Equal(t, "b", string(value))
+		value, err = ns.Attr(attrsToken, "urn:other", []byte("href"))
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+	}
+}
+
+func TestNamespaces_Clone(t *testing.T) {
+	var ns Namespaces
+	assert.NoError(t, ns.Push([]byte(`<a xmlns:x="urn:1">`)))
+	clone := ns.Clone()
+	assert.NoError(t, ns.Pop())
+	assert.NoError(t, ns.Push([]byte(`<a xmlns:x="urn:2">`)))
+	url, ok := clone.Lookup([]byte("x"))
+	assert.True(t, ok)
+	assert.Equal(t, "urn:1", url)
+	url, _ = ns.Lookup([]byte("x"))
+	assert.Equal(t, "urn:2", url)
+}
@@ -0,0 +1,440 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// editOp is the type of an edit in a structural diff
+type editOp uint8
+
+const (
+	editAdd editOp = iota
+	editRemove
+	editReplace
+)
+
+// edit is a single operation transforming one document into another
+// The selectors are only valid after every preceding edit has been applied (as in RFC 5261)
+type edit struct {
+	op       editOp
+	sel      string // XPath selecting the target node (or attribute)
+	path     string // XPath of the node (or attribute) in the document it appears in, for display
+	pos      string // for editAdd of a node: "prepend", "after" or "" to append
+	attr     []byte // for edits of an attribute: the attribute key
+	old      *Node  // the removed or replaced node
+	new      *Node  // the added or replacement node
+	oldValue []byte // the removed or replaced attribute value
+	newValue []byte // the added or replacement attribute value
+}
+
+// errDiffDirective is returned when the Directives of two documents differ (they cannot be selected by XPath)
+var errDiffDirective = errors.New("cannot diff documents with different Directives")
+
+// maxLCS limits the size of the table used to match children, larger lists are matched greedily
+const maxLCS = 1 << 20
+
+// matchChildren pairs the children of a and b which are the same node (in increasing order of both)
+func matchChildren(as, bs []*Node, same func(a, b *Node) bool) (pairs [][2]int) {
+	// Greedy in-order matching if the lists are too large for the LCS table
+	if len(as)*len(bs) > maxLCS {
+		for i, j := 0, 0; i < len(as) && j < len(bs); j++ {
+			if same(as[i], bs[j]) {
+				pairs = append(pairs, [2]int{i, j})
+				i++
+			}
+		}
+		return pairs
+	}
+	// lcs[i][j] is the longest common subsequence of as[i:] and bs[j:]
+	width := len(bs) + 1
+	lcs := make([]int, (len(as)+1)*width)
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if same(as[i], bs[j]) {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if lcs[(i+1)*width+j] >= lcs[i*width+j+1] {
+				lcs[i*width+j] = lcs[(i+1)*width+j]
+			} else {
+				lcs[i*width+j] = lcs[i*width+j+1]
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(as) && j < len(bs); {
+		switch {
+		case same(as[i], bs[j]):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// sameKind is used to match the children of the document, there can only be a single root element
+func sameKind(a, b *Node) bool {
+	return a.Kind == b.Kind
+}
+
+// differ accumulates the edits between two trees
+type differ struct {
+	edits []edit
+}
+
+// diffNode compares two nodes selected by sel (located at path in b) which were matched to each other
+func (d *differ) diffNode(sel string, path string, a, b *Node) error {
+	if a.Kind == DirectiveNode && !a.equal(b) {
+		return errDiffDirective
+	}
+	if a.Kind != ElementNode || !bytes.Equal(a.Name, b.Name) {
+		if !a.equal(b) {
+			d.edits = append(d.edits, edit{op: editReplace, sel: sel, path: path, old: a, new: b})
+		}
+		return nil
+	}
+	for _, attr := range a.Attrs {
+		if idx := b.attr(attr.Key); idx == -1 {
+			d.edits = append(d.edits, edit{op: editRemove, sel: sel + "/@" + string(attr.Key), path: path + "/@" + string(attr.Key), attr: attr.Key, oldValue: attr.Value})
+		} else if !bytes.Equal(attr.Value, b.Attrs[idx].Value) {
+			d.edits = append(d.edits, edit{op: editReplace, sel: sel + "/@" + string(attr.Key), path: path + "/@" + string(attr.Key), attr: attr.Key, oldValue: attr.Value, newValue: b.Attrs[idx].Value})
+		}
+	}
+	for _, attr := range b.Attrs {
+		if a.attr(attr.Key) == -1 {
+			d.edits = append(d.edits, edit{op: editAdd, sel: sel, path: path + "/@" + string(attr.Key), attr: attr.Key, newValue: attr.Value})
+		}
+	}
+	return d.diffChildren(sel, path, a.Children, b.Children, (*Node).same)
+}
+
+// diffChildren compares the children of two matched nodes selected by sel (located at path in b)
+func (d *differ) diffChildren(sel string, path string, as, bs []*Node, same func(a, b *Node) bool) error {
+	pairs := matchChildren(as, bs, same)
+	matchedA := make([]bool, len(as))
+	matchedB := make([]bool, len(bs))
+	kept := make([]*Node, 0, len(pairs))
+	for _, pair := range pairs {
+		matchedA[pair[0]], matchedB[pair[1]] = true, true
+		kept = append(kept, as[pair[0]])
+	}
+	// Remove in reverse order so the positions of the preceding siblings are not changed
+	for i := len(as) - 1; i >= 0; i-- {
+		if matchedA[i] {
+			continue
+		} else if as[i].Kind == DirectiveNode {
+			return errDiffDirective
+		}
+		step := "/" + as[i].step(position(as, i))
+		d.edits = append(d.edits, edit{op: editRemove, sel: sel + step, path: path + step, old: as[i]})
+	}
+	// After the removals only the matched children remain
+	for k, pair := range pairs {
+		childPath := path + "/" + bs[pair[1]].step(position(bs, pair[1]))
+		if err := d.diffNode(sel+"/"+kept[k].step(position(kept, k)), childPath, as[pair[0]], bs[pair[1]]); err != nil {
+			return err
+		}
+	}
+	// Insert in order, so the preceding siblings are identical to those in b
+	for j, child := range bs {
+		if matchedB[j] {
+			continue
+		} else if child.Kind == DirectiveNode {
+			return errDiffDirective
+		}
+		childPath := path + "/" + child.step(position(bs, j))
+		if j == 0 {
+			parent := sel
+			if parent == "" {
+				parent = "/"
+			}
+			d.edits = append(d.edits, edit{op: editAdd, sel: parent, path: childPath, pos: "prepend", new: child})
+		} else {
+			d.edits = append(d.edits, edit{op: editAdd, sel: sel + "/" + bs[j-1].step(position(bs, j-1)), path: childPath, pos: "after", new: child})
+		}
+	}
+	return nil
+}
+
+// diffTrees computes the edits which transform the document a into b
+func diffTrees(a, b []byte) ([]edit, error) {
+	treeA, err := Parse(a)
+	if err != nil {
+		return nil, err
+	}
+	treeB, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	var d differ
+	if err := d.diffChildren("", "", treeA.Children, treeB.Children, sameKind); err != nil {
+		return nil, err
+	}
+	return d.edits, nil
+}
+
+// appendPatch appends an RFC 5261 patch document containing edits to dst
+func appendPatch(dst []byte, edits []edit) []byte {
+	dst = append(dst, "<diff>\n"...)
+	for _, e := range edits {
+		var name string
+		switch e.op {
+		case editAdd:
+			name = "add"
+		case editRemove:
+			name = "remove"
+		case editReplace:
+			name = "replace"
+		}
+		dst = append(dst, '<')
+		dst = append(dst, name...)
+		dst = append(dst, ` sel="`...)
+		dst = EscapeAttr(dst, []byte(e.sel))
+		dst = append(dst, '"')
+		if e.pos != "" {
+			dst = append(dst, ` pos="`...)
+			dst = append(dst, e.pos...)
+			dst = append(dst, '"')
+		}
+		if e.op == editAdd && e.attr != nil {
+			dst = append(dst, ` type="@`...)
+			dst = append(dst, e.attr...)
+			dst = append(dst, '"')
+		}
+		switch {
+		case e.op == editRemove:
+			dst = append(dst, "/>\n"...)
+			continue
+		case e.attr != nil:
+			// The raw attribute value is already escaped for use as CharData
+			dst = append(dst, '>')
+			dst = append(dst, e.newValue...)
+		default:
+			dst = append(dst, '>')
+			dst = e.new.appendXML(dst)
+		}
+		dst = append(dst, "</"...)
+		dst = append(dst, name...)
+		dst = append(dst, ">\n"...)
+	}
+	return append(dst, "</diff>\n"...)
+}
+
+// DiffPatch produces an RFC 5261 patch document (`<diff>...</diff>`) which transforms the document a into b
+// Nodes are selected by position (ex: `/root[1]/item[2]/@id`) and namespace prefixes are used as-is
+func DiffPatch(a, b []byte) ([]byte, error) {
+	edits, err := diffTrees(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return appendPatch(nil, edits), nil
+}
+
+// selectNode evaluates an XPath selector of the form produced by DiffPatch against doc
+// returning the parent and index of the selected node, or the element and key of a selected attribute
+// If the selector is "/" the parent is nil and the document itself is selected
+func selectNode(doc *Node, sel string) (parent *Node, idx int, attr []byte, err error) {
+	if !strings.HasPrefix(sel, "/") {
+		return nil, -1, nil, fmt.Errorf("unsupported selector %q: must be absolute", sel)
+	}
+	if sel == "/" {
+		return nil, -1, nil, nil
+	}
+	current := doc
+	steps := strings.Split(sel[1:], "/")
+	for stepIdx, step := range steps {
+		if strings.HasPrefix(step, "@") && stepIdx == len(steps)-1 {
+			if current.Kind != ElementNode {
+				return nil, -1, nil, fmt.Errorf("unsupported selector %q: attribute of a non-element", sel)
+			}
+			return current, -1, []byte(step[1:]), nil
+		}
+		// Parse the optional position predicate
+		pos := 1
+		if idx := strings.IndexByte(step, '['); idx != -1 && strings.HasSuffix(step, "]") {
+			if pos, err = strconv.Atoi(step[idx+1 : len(step)-1]); err != nil || pos < 1 {
+				return nil, -1, nil, fmt.Errorf("unsupported selector %q: invalid position in %q", sel, step)
+			}
+			step = step[:idx]
+		}
+		want := &Node{Kind: ElementNode}
+		switch step {
+		case "text()":
+			want.Kind = TextNode
+		case "comment()":
+			want.Kind = CommentNode
+		case "processing-instruction()":
+			want.Kind = ProcInstNode
+		default:
+			want.Name = []byte(step)
+		}
+		found := -1
+		for childIdx, child := range current.Children {
+			if child.same(want) {
+				if pos--; pos == 0 {
+					found = childIdx
+					break
+				}
+			}
+		}
+		if found == -1 {
+			return nil, -1, nil, fmt.Errorf("selector %q did not match a node", sel)
+		}
+		if stepIdx == len(steps)-1 {
+			return current, found, nil, nil
+		}
+		current = current.Children[found]
+	}
+	return nil, -1, nil, fmt.Errorf("unsupported selector %q", sel)
+}
+
+// textValue decodes the text content of a patch operation for use as an attribute value
+func textValue(op *Node) ([]byte, error) {
+	var value []byte
+	for _, child := range op.Children {
+		if child.Kind != TextNode {
+			return nil, errors.New("expected attribute value to only contain text")
+		}
+		var err error
+		if value, err = CharDataAppend(value, child.Raw); err != nil {
+			return nil, err
+		}
+	}
+	return EscapeAttr(nil, value), nil
+}
+
+// insert adds nodes into the children of parent at idx
+func (n *Node) insert(idx int, nodes []*Node) {
+	children := make([]*Node, 0, len(n.Children)+len(nodes))
+	children = append(children, n.Children[:idx]...)
+	children = append(children, nodes...)
+	n.Children = append(children, n.Children[idx:]...)
+}
+
+// applyEdit applies a single patch operation (add, replace or remove) to doc
+func applyEdit(doc *Node, op *Node) error {
+	_, local := Name(op.Name)
+	var sel, pos, typ []byte
+	for _, attr := range op.Attrs {
+		switch string(attr.Key) {
+		case "sel":
+			sel = attr.Value
+		case "pos":
+			pos = attr.Value
+		case "type":
+			typ = attr.Value
+		}
+	}
+	decoded, err := DecodeEntities(sel, nil)
+	if err != nil {
+		return err
+	}
+	parent, idx, attr, err := selectNode(doc, string(decoded))
+	if err != nil {
+		return err
+	}
+	target := doc
+	if parent != nil && idx != -1 {
+		target = parent.Children[idx]
+	} else if parent != nil {
+		target = parent
+	}
+	switch string(local) {
+	case "add":
+		if len(typ) > 0 && typ[0] == '@' {
+			if attr != nil || target.Kind != ElementNode || target.attr(typ[1:]) != -1 {
+				return fmt.Errorf("cannot add attribute %q at %q", typ[1:], sel)
+			}
+			value, err := textValue(op)
+			if err != nil {
+				return err
+			}
+			target.Attrs = append(target.Attrs, Attribute{Key: typ[1:], Value: value})
+			return nil
+		}
+		switch string(pos) {
+		case "":
+			target.insert(len(target.Children), op.Children)
+		case "prepend":
+			target.insert(0, op.Children)
+		case "before", "after":
+			if parent == nil || idx == -1 {
+				return fmt.Errorf("cannot add a sibling at %q", sel)
+			}
+			if string(pos) == "after" {
+				idx++
+			}
+			parent.insert(idx, op.Children)
+		default:
+			return fmt.Errorf("unsupported pos %q", pos)
+		}
+	case "replace":
+		if attr != nil {
+			attrIdx := target.attr(attr)
+			if attrIdx == -1 {
+				return fmt.Errorf("selector %q did not match an attribute", sel)
+			}
+			value, err := textValue(op)
+			if err != nil {
+				return err
+			}
+			target.Attrs[attrIdx].Value = value
+			return nil
+		}
+		if parent == nil {
+			return fmt.Errorf("cannot replace %q", sel)
+		}
+		parent.Children = append(parent.Children[:idx], parent.Children[idx+1:]...)
+		parent.insert(idx, op.Children)
+	case "remove":
+		if attr != nil {
+			attrIdx := target.attr(attr)
+			if attrIdx == -1 {
+				return fmt.Errorf("selector %q did not match an attribute", sel)
+			}
+			target.Attrs = append(target.Attrs[:attrIdx], target.Attrs[attrIdx+1:]...)
+			return nil
+		}
+		if parent == nil {
+			return fmt.Errorf("cannot remove %q", sel)
+		}
+		parent.Children = append(parent.Children[:idx], parent.Children[idx+1:]...)
+	default:
+		return fmt.Errorf("unsupported patch operation %q", op.Name)
+	}
+	return nil
+}
+
+// ApplyPatch applies an RFC 5261 patch document to doc returning the patched document
+// Only the XPath subset produced by DiffPatch is supported: absolute paths of element names,
+// text(), comment() and processing-instruction() steps with optional positions, and a final @attribute step
+func ApplyPatch(doc, patch []byte) ([]byte, error) {
+	tree, err := Parse(doc)
+	if err != nil {
+		return nil, err
+	}
+	patchTree, err := Parse(patch)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range patchTree.Children {
+		if root.Kind != ElementNode {
+			continue
+		}
+		for _, op := range root.Children {
+			if op.Kind != ElementNode {
+				continue
+			}
+			if err := applyEdit(tree, op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tree.appendXML(nil), nil
+}
@@ -0,0 +1,102 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPatch(t *testing.T) {
+	patch, err := DiffPatch(
+		[]byte(`<root a="1" b="2"><item>one</item><old/></root>`),
+		[]byte(`<root a="1" b="3" c="4"><new/><item>two</item></root>`),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, `<diff>
+<replace sel="/root[1]/@b">3</replace>
+<add sel="/root[1]" type="@c">4</add>
+<remove sel="/root[1]/old[1]"/>
+<replace sel="/root[1]/item[1]/text()[1]">two</replace>
+<add sel="/root[1]" pos="prepend"><new/></add>
+</diff>
+`, string(patch))
+}
+
+func TestApplyPatch(t *testing.T) {
+	testCases := []struct {
+		Name string
+		A    string
+		B    string
+	}{
+		{
+			Name: "identical",
+			A:    `<?xml version="1.0"?><root><a/></root>`,
+			B:    `<?xml version="1.0"?><root><a/></root>`,
+		},
+		{
+			Name: "attributes",
+			A:    `<root a="1" b="&amp;"><child x="y"/></root>`,
+			B:    `<root b="&lt;" c="&quot;"><child/></root>`,
+		},
+		{
+			Name: "children",
+			A:    `<root><a>1</a><b>2</b><a>3</a><c/><!--x--></root>`,
+			B:    `<root><b>2</b><a>3</a><d><e/></d><a>4</a><!--y--><c/></root>`,
+		},
+		{
+			Name: "whitespace",
+			A:    "<root>\n  <item id=\"1\"/>\n  <item id=\"2\"/>\n</root>",
+			B:    "<root>\n  <item id=\"2\"/>\n  <item id=\"3\">text</item>\n  <item id=\"1\"/>\n</root>",
+		},
+		{
+			Name: "root renamed",
+			A:    `<!--head--><a><b/></a>`,
+			B:    `<?pi?><z><b/></z>`,
+		},
+		{
+			Name: "cdata",
+			A:    `<root><![CDATA[<raw>]]></root>`,
+			B:    `<root><![CDATA[<changed>]]><?target inst?></root>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			patch, err := DiffPatch([]byte(tc.A), []byte(tc.B))
+			assert.NoError(t, err)
+			actual, err := ApplyPatch([]byte(tc.A), patch)
+			assert.NoError(t, err, string(patch))
+			expected, err := Parse([]byte(tc.B))
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected.appendXML(nil)), string(actual), string(patch))
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	testCases := []struct {
+		Patch string
+		Error string
+	}{
+		{Patch: `<diff><remove sel="root"/></diff>`, Error: `unsupported selector "root": must be absolute`},
+		{Patch: `<diff><remove sel="/root/missing"/></diff>`, Error: `selector "/root/missing" did not match a node`},
+		{Patch: `<diff><remove sel="/root/a[0]"/></diff>`, Error: `unsupported selector "/root/a[0]": invalid position in "a[0]"`},
+		{Patch: `<diff><remove sel="/root/@missing"/></diff>`, Error: `selector "/root/@missing" did not match an attribute`},
+		{Patch: `<diff><add sel="/root" type="@id">2</add></diff>`, Error: `cannot add attribute "id" at "/root"`},
+		{Patch: `<diff><add sel="/root" pos="middle"/></diff>`, Error: `unsupported pos "middle"`},
+		{Patch: `<diff><move sel="/root"/></diff>`, Error: `unsupported patch operation "move"`},
+		{Patch: `<diff><replace sel="/root/@id"><a/></replace></diff>`, Error: `expected attribute value to only contain text`},
+		{Patch: `<diff><remove sel="/"/></diff>`, Error: `cannot remove "/"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Patch, func(t *testing.T) {
+			_, err := ApplyPatch([]byte(`<root id="1"><a/></root>`), []byte(tc.Patch))
+			assert.EqualError(t, err, tc.Error)
+		})
+	}
+	_, err := DiffPatch([]byte(`<!DOCTYPE a><a/>`), []byte(`<!DOCTYPE b><a/>`))
+	assert.Equal(t, errDiffDirective, err)
+	_, err = DiffPatch([]byte(`<a></a></a>`), []byte(`<a/>`))
+	assert.Error(t, err)
+	_, err = ApplyPatch([]byte(`<a>`), []byte(`<diff/>`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,288 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxPathSteps is the number of steps which fit in the uint64 state set of a PathMatcher
+const maxPathSteps = 63
+
+// pathAttr is an attribute predicate of a pathStep (ex: `[@id]` or `[@id='x']`)
+type pathAttr struct {
+	key      []byte
+	value    string
+	hasValue bool // if false only the presence of the attribute is checked
+}
+
+// pathStep is a single element name in a Path
+type pathStep struct {
+	name       NamePattern
+	attrs      []pathAttr
+	descendant bool // if preceded by `//` any number of elements may appear before it
+}
+
+// match determines if an element matches the step
+func (step *pathStep) match(name []byte, attrsToken []byte) bool {
+	if !step.name.Match(name) {
+		return false
+	}
+	for _, attr := range step.attrs {
+		start, stop, err := RawAttr(attrsToken, attr.key)
+		if err != nil || start == -1 {
+			return false
+		}
+		if !attr.hasValue {
+			continue
+		}
+		value, err := DecodeEntities(attrsToken[start:stop], nil)
+		if err != nil || string(value) != attr.value {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidPathChars may not appear in the name of a step (or the key of a predicate)
+const invalidPathChars = " \t\r\n<>[]\"'=@/"
+
+// splitStep returns the first step of the path expression (up to the first '/' outside of a predicate)
+func splitStep(expr string) (step string, rest string) {
+	var quote byte
+	brackets := 0
+	for idx := 0; idx < len(expr); idx++ {
+		switch c := expr[idx]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case brackets > 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == '[':
+			brackets++
+		case c == ']':
+			brackets--
+		case c == '/' && brackets == 0:
+			return expr[:idx], expr[idx+1:]
+		}
+	}
+	return expr, ""
+}
+
+// parseStep parses a NamePattern followed by any number of `[@key]` or `[@key='value']` predicates
+func parseStep(step string) (pathStep, bool) {
+	name, preds := step, ""
+	if idx := strings.IndexByte(step, '['); idx != -1 {
+		name, preds = step[:idx], step[idx:]
+	}
+	if name == "" || strings.ContainsAny(name, invalidPathChars) {
+		return pathStep{}, false
+	}
+	parsed := pathStep{name: ParseNamePattern(name)}
+	for preds != "" {
+		if !strings.HasPrefix(preds, "[@") {
+			return pathStep{}, false
+		}
+		preds = preds[2:]
+		end := strings.IndexAny(preds, "=]")
+		if end <= 0 || strings.ContainsAny(preds[:end], invalidPathChars) {
+			return pathStep{}, false
+		}
+		attr := pathAttr{key: []byte(preds[:end])}
+		if preds[end] == '=' {
+			preds = preds[end+1:]
+			if preds == "" || (preds[0] != '\'' && preds[0] != '"') {
+				return pathStep{}, false
+			}
+			closing := strings.IndexByte(preds[1:], preds[0])
+			if closing == -1 {
+				return pathStep{}, false
+			}
+			attr.value, attr.hasValue = preds[1:closing+1], true
+			preds = preds[closing+2:]
+			end = 0
+			if !strings.HasPrefix(preds, "]") {
+				return pathStep{}, false
+			}
+		}
+		preds = preds[end+1:]
+		parsed.attrs = append(parsed.attrs, attr)
+	}
+	return parsed, true
+}
+
+// Path is a compiled path expression which matches elements based on their ancestors
+// Steps are separated by '/' and are a NamePattern (ex: `item`, `ns:*`, `*:item` or `*` to match any element)
+// followed by any number of attribute predicates (ex: `item[@id]` or `item[@id='x'][@type="y"]`)
+// A step preceded by '//' may be nested at any depth below the previous step
+// Paths always start at the root element, a leading '/' is optional (ex: `root/item`, `//item`, `*/id`)
+type Path struct {
+	expr   string
+	steps  []pathStep
+	needle []byte // `<name` of the final step (if not a wildcard) used to quickly reject documents
+}
+
+// CompilePath parses a path expression into a Path which can be used to match elements
+func CompilePath(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	rest := expr
+	descendant := false
+	if strings.HasPrefix(rest, "//") {
+		rest, descendant = rest[2:], true
+	} else if strings.HasPrefix(rest, "/") {
+		rest = rest[1:]
+	}
+	for {
+		var step string
+		step, rest = splitStep(rest)
+		if step == "" {
+			return nil, fmt.Errorf("invalid path %q: empty step", expr)
+		}
+		parsed, ok := parseStep(step)
+		if !ok {
+			return nil, fmt.Errorf("invalid path %q: invalid step %q", expr, step)
+		}
+		parsed.descendant = descendant
+		p.steps = append(p.steps, parsed)
+		if rest == "" {
+			break
+		}
+		// A second '/' makes the next step a descendant
+		descendant = false
+		if rest[0] == '/' {
+			rest, descendant = rest[1:], true
+		}
+	}
+	if len(p.steps) > maxPathSteps {
+		return nil, fmt.Errorf("invalid path %q: more than %d steps", expr, maxPathSteps)
+	}
+	if name, ok := p.steps[len(p.steps)-1].name.Exact(); ok {
+		p.needle = append([]byte{'<'}, name...)
+	}
+	return p, nil
+}
+
+// MustCompilePath is like CompilePath but panics if the expression cannot be parsed
+func MustCompilePath(expr string) *Path {
+	p, err := CompilePath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source expression of the Path
+func (p *Path) String() string {
+	return p.expr
+}
+
+// next computes the state set after entering an element
+// Bit i of a state set is set when the first i steps have been matched
+func (p *Path) next(state uint64, name []byte, attrsToken []byte) uint64 {
+	var next uint64
+	for idx := range p.steps {
+		step := &p.steps[idx]
+		if state&(1<<uint(idx)) == 0 {
+			continue
+		}
+		if step.descendant {
+			next |= 1 << uint(idx)
+		}
+		if step.match(name, attrsToken) {
+			next |= 1 << uint(idx+1)
+		}
+	}
+	return next
+}
+
+// PathMatcher tracks the element stack of a document to match elements against a Path
+type PathMatcher struct {
+	path  *Path
+	stack []uint64 // state set for each open element, the first is the document itself
+}
+
+// Matcher creates a new *PathMatcher for the Path
+func (p *Path) Matcher() *PathMatcher {
+	return &PathMatcher{
+		path:  p,
+		stack: []uint64{1},
+	}
+}
+
+// errPathUnbalanced is returned when Pop is called more times than Push
+var errPathUnbalanced = errors.New("unbalanced PathMatcher.Pop")
+
+// Push enters the start element elemToken returning true if it matches the Path
+// Every call to Push (including for self-closing elements) must be paired with a call to Pop
+func (m *PathMatcher) Push(elemToken []byte) bool {
+	name, attrsToken := Element(elemToken)
+	state := m.path.next(m.stack[len(m.stack)-1], name, attrsToken)
+	m.stack = append(m.stack, state)
+	return state&(1<<uint(len(m.path.steps))) != 0
+}
+
+// Pop leaves the most recently pushed element
+func (m *PathMatcher) Pop() error {
+	if len(m.stack) == 1 {
+		return errPathUnbalanced
+	}
+	m.stack = m.stack[:len(m.stack)-1]
+	return nil
+}
+
+// Possible returns false if no descendant of the current element can match the Path
+// which allows callers to skip the remainder of the element
+func (m *PathMatcher) Possible() bool {
+	return m.stack[len(m.stack)-1]&^(1<<uint(len(m.path.steps))) != 0
+}
+
+// Depth returns the number of elements currently pushed
+func (m *PathMatcher) Depth() int {
+	return len(m.stack) - 1
+}
+
+// Reset clears the element stack so the PathMatcher can be used for another document
+func (m *PathMatcher) Reset() {
+	m.stack = m.stack[:1]
+}
+
+// Find advances s to the next element matching the Path returning its byte range in the Scanner's buffer
+// The matching element is consumed (including any children) so matches nested within it are not reported
+// Elements in which the Path cannot match are skipped. io.EOF is returned when no more elements match
+func (m *PathMatcher) Find(s *Scanner) (start int, end int, err error) {
+	for {
+		start = s.Offset()
+		token, chardata, err := s.Next()
+		if err != nil {
+			return -1, -1, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			if err := m.Pop(); err != nil {
+				return -1, -1, err
+			}
+			continue
+		}
+		matched := m.Push(token)
+		if !s.SelfClosing(token) {
+			if !matched && m.Possible() {
+				continue // descend into the element
+			}
+			if err := s.Skip(); err == io.EOF {
+				return -1, -1, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return -1, -1, err
+			}
+		}
+		if err := m.Pop(); err != nil {
+			return -1, -1, err
+		}
+		if matched {
+			return start, s.Offset(), nil
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// matchPath returns the offsets of every element in input matching expr
+func matchPath(t *testing.T, expr string, input string) []int {
+	m := MustCompilePath(expr).Matcher()
+	s := NewScanner([]byte(input))
+	var offsets []int
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return offsets
+		}
+		assert.NoError(t, err)
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			assert.NoError(t, m.Pop())
+			continue
+		}
+		if m.Push(token) {
+			offsets = append(offsets, offset)
+		}
+		if IsSelfClosing(token) {
+			assert.NoError(t, m.Pop())
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	const doc = `<root><a><b/><c><b/></c></a><other><b/></other><b/></root>`
+	testCases := []struct {
+		Path     string
+		Expected []int
+	}{
+		{Path: "root", Expected: []int{0}},
+		{Path: "/root/a/b", Expected: []int{9}},
+		{Path: "root/*/b", Expected: []int{9, 35}},
+		{Path: "*/b", Expected: []int{47}},
+		{Path: "root//b", Expected: []int{9, 16, 35, 47}},
+		{Path: "//b", Expected: []int{9, 16, 35, 47}},
+		{Path: "//c/b", Expected: []int{16}},
+		{Path: "root/a//c//b", Expected: []int{16}},
+		{Path: "//*", Expected: []int{0, 6, 9, 13, 16, 28, 35, 47}},
+		{Path: "missing//b"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Path, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, matchPath(t, tc.Path, doc))
+		})
+	}
+}
+
+func TestPath_Namespaces(t *testing.T) {
+	const doc = `<a:root><a:item/><b:item/><item/><a:other/></a:root>`
+	testCases := []struct {
+		Path     string
+		Expected []int
+	}{
+		{Path: "a:root/a:item", Expected: []int{8}},
+		{Path: "a:root/item", Expected: []int{26}},
+		{Path: "*:root/*:item", Expected: []int{8, 17, 26}},
+		{Path: "a:*/a:*", Expected: []int{8, 33}},
+		{Path: "root"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Path, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, matchPath(t, tc.Path, doc))
+		})
+	}
+}
+
+func TestPath_Attrs(t *testing.T) {
+	const doc = `<root><item id="x"><name/></item><item id="y" type="a/b"><name/></item><item/><item id="&lt;"/></root>`
+	testCases := []struct {
+		Path     string
+		Expected []int
+	}{
+		{Path: "root/item[@id]", Expected: []int{6, 33, 78}},
+		{Path: "/root/item[@id='x']/name", Expected: []int{19}},
+		{Path: `root/item[@id="y"][@type='a/b']/name`, Expected: []int{57}},
+		{Path: "root/item[@id='x'][@type]"},
+		{Path: "//item[@id='<']", Expected: []int{78}},
+		{Path: "//*[@type='a/b']", Expected: []int{33}},
+		{Path: "root/item[@id='z']"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Path, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, matchPath(t, tc.Path, doc))
+		})
+	}
+}
+
+func TestCompilePath(t *testing.T) {
+	for expr, errMsg := range map[string]string{
+		"":          `invalid path "": empty step`,
+		"a//":       `invalid path "a//": empty step`,
+		"a///b":     `invalid path "a///b": empty step`,
+		"a/b[1]":    `invalid path "a/b[1]": invalid step "b[1]"`,
+		"root/b c":  `invalid path "root/b c": invalid step "b c"`,
+		"a[@]":      `invalid path "a[@]": invalid step "a[@]"`,
+		"a[@id=x]":  `invalid path "a[@id=x]": invalid step "a[@id=x]"`,
+		"a[@id='x":  `invalid path "a[@id='x": invalid step "a[@id='x"`,
+		"a[@id='x'": `invalid path "a[@id='x'": invalid step "a[@id='x'"`,
+		"[@id]":     `invalid path "[@id]": invalid step "[@id]"`,
+	} {
+		_, err := CompilePath(expr)
+		assert.EqualError(t, err, errMsg)
+	}
+	assert.Panics(t, func() { MustCompilePath("") })
+	assert.Equal(t, "root//b", MustCompilePath("root//b").String())
+}
+
+func TestPathMatcher(t *testing.T) {
+	m := MustCompilePath("root/a").Matcher()
+	assert.True(t, m.Possible())
+	assert.False(t, m.Push([]byte("<other>")))
+	assert.False(t, m.Possible())
+	assert.NoError(t, m.Pop())
+	assert.False(t, m.Push([]byte("<root>")))
+	assert.True(t, m.Push([]byte(`<a key="val">`)))
+	assert.Equal(t, 2, m.Depth())
+	m.Reset()
+	assert.Equal(t, 0, m.Depth())
+	assert.Error(t, m.Pop())
+}
+
+func TestPathMatcher_Find(t *testing.T) {
+	buf := []byte(`<root><item>1<item>nested</item></item><skip><item/></skip><item/></root>`)
+	s := NewScanner(buf)
+	m := MustCompilePath("root//item").Matcher()
+	var matches []string
+	for {
+		start, end, err := m.Find(s)
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		matches = append(matches, string(buf[start:end]))
+	}
+	assert.Equal(t, []string{"<item>1<item>nested</item></item>", "<item/>", "<item/>"}, matches)
+	_, _, err := MustCompilePath("root").Matcher().Find(NewScanner([]byte(`</root>`)))
+	assert.Error(t, err)
+}
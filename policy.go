@@ -0,0 +1,235 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Rules reported in SecurityError.Rule by a Policy
+// RuleTokenSize, RuleAttrCount and RuleDepth are only reported to Audit, the token is rejected with a *LimitError
+const (
+	RuleDoctype           = "doctype"
+	RuleExternalEntity    = "external-entity"
+	RuleTokenSize         = LimitTokenSize
+	RuleAttrCount         = LimitAttrCount
+	RuleDepth             = LimitDepth
+	RuleElementNotAllowed = "element-not-allowed"
+	RuleAttrNotAllowed    = "attr-not-allowed"
+	RuleDuplicateAttr     = "duplicate-attr"
+)
+
+// Allocate these once instead of on each bytes.Index/HasPrefix call
+var (
+	prefixDoctype = []byte("DOCTYPE")
+	prefixEntity  = []byte("<!ENTITY")
+)
+
+// Policy restricts which constructs a Scanner accepts from an untrusted document
+// A rejected token is returned by Scanner.Next as a *SecurityError
+type Policy struct {
+	// DisallowDoctype rejects any DOCTYPE declaration
+	DisallowDoctype bool
+	// DisallowExternalEntities rejects entity declarations (and DOCTYPEs) referencing
+	// an external resource via SYSTEM or PUBLIC
+	DisallowExternalEntities bool
+	// Limits are checked the same as (and after) the Limits of the Scanner, so the lower of each takes precedence
+	// A token exceeding either is rejected with a *LimitError which is also reported to Audit
+	Limits
+	// AllowElements (if non-nil) is the list of element names permitted in the document
+	AllowElements []string
+	// AllowAttrs (if non-nil) is the list of attribute names permitted on any element
+	// Namespace declarations (xmlns and xmlns:prefix) are always permitted
+	AllowAttrs []string
+	// DisallowDuplicateAttrs rejects start elements repeating an attribute name (see CheckDuplicateAttrs)
+	// as parsers disagree on which value wins (ex: signature wrapping attacks), Strip does not apply to it
+	DisallowDuplicateAttrs bool
+	// Strip removes elements (and their children) and attributes which are not allowed
+	// instead of rejecting the document, a start element with stripped attributes is
+	// re-written into a new slice instead of referencing the input
+	Strip bool
+	// Audit (if set) is called with every rejection (or stripped element or attribute)
+	// so that attack attempts can be logged with structured details
+	Audit func(err *SecurityError)
+}
+
+// contains checks if name is in list
+func contains(list []string, name []byte) bool {
+	for _, allowed := range list {
+		if allowed == String(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedElement determines if the element name is permitted by AllowElements
+func (p *Policy) AllowedElement(name []byte) bool {
+	return p.AllowElements == nil || contains(p.AllowElements, name)
+}
+
+// AllowedAttr determines if the attribute key is permitted by AllowAttrs
+func (p *Policy) AllowedAttr(key []byte) bool {
+	return p.AllowAttrs == nil || isXMLNS(key) || contains(p.AllowAttrs, key)
+}
+
+// externalID determines if a declaration (ex: `<!ENTITY name SYSTEM "uri">`) has an external ID
+// Only the words before the first quoted literal are considered
+func externalID(decl []byte) bool {
+	if idx := bytes.IndexAny(decl, `"'`); idx != -1 {
+		decl = decl[:idx]
+	}
+	for _, field := range bytes.Fields(decl) {
+		if String(field) == "SYSTEM" || String(field) == "PUBLIC" {
+			return true
+		}
+	}
+	return false
+}
+
+// declEnd returns the index of the '>' ending the declaration at the start of buf (or -1)
+// A '>' within a quoted literal does not end it
+func declEnd(buf []byte) int {
+	for idx := 0; idx < len(buf); idx++ {
+		switch c := buf[idx]; c {
+		case '"', '\'':
+			end := bytes.IndexByte(buf[idx+1:], c)
+			if end == -1 {
+				return -1
+			}
+			idx += end + 1
+		case '>':
+			return idx
+		}
+	}
+	return -1
+}
+
+// reject reports a violation of the policy
+func (p *Policy) reject(rule string, offset int, format string, args ...interface{}) *SecurityError {
+	err := &SecurityError{
+		Rule:   rule,
+		Offset: offset,
+		Detail: fmt.Sprintf(format, args...),
+	}
+	if p.Audit != nil {
+		p.Audit(err)
+	}
+	return err
+}
+
+// exceeded reports a *LimitError of the Scanner (or the Policy) to Audit
+func (p *Policy) exceeded(err error) {
+	if limitErr, ok := err.(*LimitError); ok && p.Audit != nil {
+		p.reject(limitErr.Limit, limitErr.Offset, "%d exceeds %d", limitErr.Value, limitErr.Max)
+	}
+}
+
+// allowAttrs checks the attributes of a start element against AllowAttrs
+// returning the start element re-written without them if stripping
+func (s *Scanner) allowAttrs(offset int, token []byte) ([]byte, error) {
+	p := s.Policy
+	name, attrsToken := Element(token)
+	var rejected *SecurityError
+	if err := Attrs(attrsToken, func(key, _ []byte) bool {
+		if !p.AllowedAttr(key) {
+			rejected = p.reject(RuleAttrNotAllowed, offset, "attribute %q on element %q is not allowed", key, name)
+			return p.Strip
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if rejected == nil {
+		return token, nil
+	} else if !p.Strip {
+		return nil, rejected
+	}
+	out := make([]byte, 0, len(token))
+	out = append(out, '<')
+	out = append(out, name...)
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		if p.AllowedAttr(key) {
+			out = append(out, ' ')
+			out = append(out, key...)
+			out = append(out, '=', '"')
+			out = append(out, value...)
+			out = append(out, '"')
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if IsSelfClosing(token) {
+		out = append(out, '/')
+	}
+	return append(out, '>'), nil
+}
+
+// enforce checks the token read by the Scanner at offset against the Policy
+// A nil token (and error) is returned if the token was stripped
+func (s *Scanner) enforce(offset int, token []byte, chardata bool) ([]byte, error) {
+	p := s.Policy
+	if chardata {
+		return token, nil
+	}
+	switch {
+	case IsDirective(token):
+		dir := Directive(token)
+		if bytes.HasPrefix(dir, prefixDoctype) {
+			if p.DisallowDoctype {
+				return nil, p.reject(RuleDoctype, offset, "DOCTYPE is not allowed")
+			}
+			// The internal subset (if any) is checked below
+			head := dir
+			if idx := bytes.IndexByte(head, '['); idx != -1 {
+				head = head[:idx]
+			}
+			if p.DisallowExternalEntities && externalID(head) {
+				return nil, p.reject(RuleExternalEntity, offset, "DOCTYPE references an external DTD")
+			}
+		}
+		if p.DisallowExternalEntities {
+			// Every entity declaration of the internal subset is checked
+			for rest, base := token, offset; ; {
+				idx := bytes.Index(rest, prefixEntity)
+				if idx == -1 {
+					break
+				}
+				decl := rest[idx:]
+				if end := declEnd(decl); end != -1 {
+					decl = decl[:end+1]
+				}
+				if externalID(decl[len(prefixEntity):]) {
+					return nil, p.reject(RuleExternalEntity, base+idx, "external entity %q is not allowed", decl)
+				}
+				rest, base = rest[idx+len(decl):], base+idx+len(decl)
+			}
+		}
+	case IsElement(token) && !IsEndElement(token):
+		if name, _ := Element(token); !p.AllowedElement(name) {
+			err := p.reject(RuleElementNotAllowed, offset, "element %q is not allowed", name)
+			if !p.Strip {
+				return nil, err
+			}
+			if s.SelfClosing(token) {
+				return nil, nil
+			}
+			return nil, s.skipRaw()
+		}
+		if p.DisallowDuplicateAttrs {
+			name, attrsToken := Element(token)
+			var dupErr *TokenError
+			if err := CheckDuplicateAttrs(attrsToken); errors.As(err, &dupErr) && dupErr.Err == ErrDuplicateAttr {
+				return nil, p.reject(RuleDuplicateAttr, offset, "%s on element %q", dupErr.message(), name)
+			}
+		}
+		if p.AllowAttrs != nil {
+			var err error
+			if token, err = s.allowAttrs(offset, token); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return token, nil
+}
@@ -0,0 +1,131 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Policy Policy
+		Input  string
+		Error  string
+	}{
+		{
+			Name:   "allowed",
+			Policy: Policy{DisallowDoctype: true, DisallowExternalEntities: true, Limits: Limits{MaxTokenSize: 16, MaxDepth: 2}},
+			Input:  `<a><b/><b>text</b></a>`,
+		}, {
+			Name:   "doctype",
+			Policy: Policy{DisallowDoctype: true},
+			Input:  `<?xml version="1.0"?><!DOCTYPE a><a/>`,
+			Error:  `security: doctype at offset 21: DOCTYPE is not allowed`,
+		}, {
+			Name:   "internal entity",
+			Policy: Policy{DisallowExternalEntities: true},
+			Input:  `<!DOCTYPE a [<!ENTITY e "SYSTEM">]><a>&e;</a>`,
+		}, {
+			Name:   "external entity",
+			Policy: Policy{DisallowExternalEntities: true},
+			Input:  `<!DOCTYPE a [<!ENTITY e "x"><!ENTITY xxe SYSTEM "file:///etc/passwd">]><a>&xxe;</a>`,
+			Error:  `security: external-entity at offset 28: external entity "<!ENTITY xxe SYSTEM \"file:///etc/passwd\">" is not allowed`,
+		}, {
+			Name:   "external dtd",
+			Policy: Policy{DisallowExternalEntities: true},
+			Input:  `<!DOCTYPE a PUBLIC "-//x" "http://example.com/a.dtd"><a/>`,
+			Error:  `security: external-entity at offset 0: DOCTYPE references an external DTD`,
+		}, {
+			Name:   "token size",
+			Policy: Policy{Limits: Limits{MaxTokenSize: 8}},
+			Input:  `<a>0123456789</a>`,
+			Error:  `limit: token-size of 10 exceeds 8 at offset 3`,
+		}, {
+			Name:   "depth",
+			Policy: Policy{Limits: Limits{MaxDepth: 2}},
+			Input:  `<a><b></b><b><c/></b></a>`,
+			Error:  `limit: depth of 3 exceeds 2 at offset 13`,
+		}, {
+			Name:   "distinct attrs",
+			Policy: Policy{DisallowDuplicateAttrs: true},
+			Input:  `<a id="1" a:id="2" b:id="3"><b id="1"/></a>`,
+		}, {
+			Name:   "duplicate attr",
+			Policy: Policy{DisallowDuplicateAttrs: true, Strip: true},
+			Input:  `<a><b ID="1" id="x" ID="2"/></a>`,
+			Error:  `security: duplicate-attr at offset 3: duplicate attribute "ID" on element "b"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var audited []*SecurityError
+			policy := tc.Policy
+			policy.Audit = func(err *SecurityError) {
+				audited = append(audited, err)
+			}
+			s := NewScanner([]byte(tc.Input))
+			s.Policy = &policy
+			var err error
+			for err == nil {
+				_, _, err = s.Next()
+			}
+			if tc.Error == "" {
+				assert.Equal(t, io.EOF, err)
+				assert.Empty(t, audited)
+				return
+			}
+			assert.EqualError(t, err, tc.Error)
+			if !assert.Len(t, audited, 1) {
+				return
+			}
+			// A *LimitError is reported to Audit as a *SecurityError with the same offset
+			if limitErr, ok := err.(*LimitError); ok {
+				assert.Equal(t, limitErr.Limit, audited[0].Rule)
+				assert.Equal(t, limitErr.Offset, audited[0].Offset)
+			} else {
+				assert.Equal(t, err, audited[0])
+			}
+		})
+	}
+}
+
+func TestPolicy_Allowlist(t *testing.T) {
+	input := []byte(`<a xmlns="urn:x" id="1" onclick="evil()"><b>keep</b><script src="x">alert(1)<b/></script><br/></a>`)
+	policy := &Policy{
+		AllowElements: []string{"a", "b", "br"},
+		AllowAttrs:    []string{"id"},
+	}
+	s := NewScanner(input)
+	s.Policy = policy
+	_, _, err := s.Next()
+	assert.EqualError(t, err, `security: attr-not-allowed at offset 0: attribute "onclick" on element "a" is not allowed`)
+
+	policy.AllowAttrs = append(policy.AllowAttrs, "onclick")
+	s.Reset(input)
+	err = nil
+	for err == nil {
+		_, _, err = s.Next()
+	}
+	assert.EqualError(t, err, `security: element-not-allowed at offset 52: element "script" is not allowed`)
+
+	var audited []string
+	policy.AllowAttrs = []string{"id"}
+	policy.Strip = true
+	policy.Audit = func(err *SecurityError) {
+		audited = append(audited, err.Rule)
+	}
+	s.Reset(input)
+	var out []byte
+	for {
+		token, _, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		out = append(out, token...)
+	}
+	assert.Equal(t, `<a xmlns="urn:x" id="1"><b>keep</b><br/></a>`, string(out))
+	assert.Equal(t, []string{RuleAttrNotAllowed, RuleElementNotAllowed}, audited)
+}
@@ -0,0 +1,40 @@
+package fastxml
+
+import "sync"
+
+// ScannerPool reuses Scanners to avoid an allocation per document, it is safe for concurrent use
+// The zero value is ready to use
+type ScannerPool struct {
+	pool sync.Pool
+}
+
+// Get returns a Scanner for buf with the default options
+func (p *ScannerPool) Get(buf []byte) *Scanner {
+	s, ok := p.pool.Get().(*Scanner)
+	if !ok {
+		return NewScanner(buf)
+	}
+	s.Reset(buf)
+	return s
+}
+
+// Put returns s to the pool clearing its options and buf
+// Neither s nor any token it returned may be used afterwards
+func (p *ScannerPool) Put(s *Scanner) {
+	s.Reset(nil)
+	*s = Scanner{open: s.open}
+	p.pool.Put(s)
+}
+
+// scannerPool is used by GetScanner and PutScanner
+var scannerPool ScannerPool
+
+// GetScanner returns a Scanner for buf with the default options from a package-level ScannerPool
+func GetScanner(buf []byte) *Scanner {
+	return scannerPool.Get(buf)
+}
+
+// PutScanner returns a Scanner obtained from GetScanner to the package-level ScannerPool
+func PutScanner(s *Scanner) {
+	scannerPool.Put(s)
+}
@@ -0,0 +1,68 @@
+package fastxml
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScannerPool(t *testing.T) {
+	var p ScannerPool
+	s := p.Get([]byte(`<a><b>`))
+	s.Mode = ParseStrict
+	s.Policy = &Policy{}
+	s.TrimCharData = true
+	s.MaxDepth = 1
+	_, _, _ = s.Next()
+	p.Put(s)
+	assert.Nil(t, s.buf)
+	assert.Equal(t, Scanner{open: s.open}, *s)
+
+	s = p.Get([]byte(`<a>text</a>`))
+	defer p.Put(s)
+	assert.Equal(t, ParseMode(0), s.Mode)
+	assert.Nil(t, s.Policy)
+	assert.Equal(t, 0, s.Offset())
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, `<a>`, string(token))
+}
+
+func TestGetScanner(t *testing.T) {
+	var wg sync.WaitGroup
+	for idx := 0; idx < 8; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				s := GetScanner([]byte(`<a><b>x</b></a>`))
+				count := 0
+				for {
+					_, _, err := s.Next()
+					if err != nil {
+						break
+					}
+					count++
+				}
+				PutScanner(s)
+				assert.Equal(t, 5, count)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkGetScanner(b *testing.B) {
+	buf := []byte(`<a><b>x</b></a>`)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		s := GetScanner(buf)
+		for {
+			if _, _, err := s.Next(); err != nil {
+				break
+			}
+		}
+		PutScanner(s)
+	}
+}
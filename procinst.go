@@ -1,16 +1,132 @@
 package fastxml
 
-import "bytes"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
 
 // IsProcInst determines if a []byte is proc inst (ex: <?target inst>)
 func IsProcInst(b []byte) bool {
-	return len(b) >= 2 && b[1] == '?'
+	return len(b) >= 2 && b[0] == '<' && b[1] == '?'
 }
 
 // ProcInst extracts the target and inst from a ProcInst (ex: `<?target inst>` -> (`target`, `inst`))
 func ProcInst(b []byte) (target []byte, inst []byte) {
-	if idx := bytes.IndexByte(b, ' '); idx != -1 {
-		return b[2:idx], b[idx+1 : len(b)-2]
+	if len(b) < 4 {
+		return nil, nil
 	}
-	return b[2 : len(b)-2], nil
+	b = b[2 : len(b)-2]
+	if idx := indexSpace(b); idx != -1 {
+		return b[:idx], b[idx+1:]
+	}
+	return b, nil
+}
+
+// skipSpace returns the index of the first byte at or after pos in b which is not XML whitespace (or len(b))
+func skipSpace(b []byte, pos int) int {
+	for pos < len(b) && isSpace(b[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// ProcInstAttrs calls f for each pseudo-attribute in inst (ex: `href="style.xsl" type='text/xsl'` of an
+// xml-stylesheet ProcInst), stopping if f returns false. Unlike Attrs the value may be quoted with either
+// `"` or `'` and whitespace is allowed around the '=', the value will _not_ be decoded
+func ProcInstAttrs(inst []byte, f func(key []byte, value []byte) bool) error {
+	for pos := 0; ; {
+		pos = skipSpace(inst, pos)
+		if pos == len(inst) {
+			return nil
+		}
+		keyStart := pos
+		for pos < len(inst) && inst[pos] != '=' && !isSpace(inst[pos]) {
+			pos++
+		}
+		key := inst[keyStart:pos]
+		pos = skipSpace(inst, pos)
+		if pos == len(inst) || inst[pos] != '=' {
+			return attrError(pos, "expected '=' after pseudo-attribute %q", key)
+		}
+		pos++
+		pos = skipSpace(inst, pos)
+		if pos == len(inst) || (inst[pos] != '"' && inst[pos] != '\'') {
+			return attrError(pos, "expected pseudo-attribute %q value to be quoted", key)
+		}
+		end := bytes.IndexByte(inst[pos+1:], inst[pos])
+		if end == -1 {
+			return attrError(pos, "expected pseudo-attribute %q value to end with %c", key, inst[pos])
+		}
+		value := inst[pos+1 : pos+1+end]
+		pos += end + 2
+		if pos < len(inst) && !isSpace(inst[pos]) {
+			return attrError(pos, "expected whitespace after pseudo-attribute %q", key)
+		}
+		if !f(key, value) {
+			return nil
+		}
+	}
+}
+
+// ParseXMLDecl parses the pseudo-attributes of an XML declaration (ex: `version="1.0" encoding="UTF-8"`) which
+// may also be the entire `<?xml ...?>` token. version is required and must be first, encoding and standalone
+// are optional (nil if not present) but must be in that order, standalone must be "yes" or "no"
+func ParseXMLDecl(inst []byte) (version, encoding, standalone []byte, err error) {
+	if IsProcInst(inst) {
+		var target []byte
+		if target, inst = ProcInst(inst); String(target) != "xml" {
+			return nil, nil, nil, fmt.Errorf("invalid XML declaration: target is %q", target)
+		}
+	}
+	idx := 0
+	if attrsErr := ProcInstAttrs(inst, func(key []byte, value []byte) bool {
+		switch {
+		case idx == 0 && String(key) == "version":
+			if len(value) < 3 || value[0] != '1' || value[1] != '.' || bytes.IndexFunc(value[2:], func(r rune) bool {
+				return r < '0' || r > '9'
+			}) != -1 {
+				err = fmt.Errorf("invalid XML declaration: version %q", value)
+			}
+			version = value
+		case idx == 0:
+			err = errors.New("invalid XML declaration: version must be first")
+		case encoding == nil && standalone == nil && String(key) == "encoding":
+			if !isEncodingName(value) {
+				err = fmt.Errorf("invalid XML declaration: encoding %q", value)
+			}
+			encoding = value
+		case standalone == nil && String(key) == "standalone":
+			if String(value) != "yes" && String(value) != "no" {
+				err = fmt.Errorf("invalid XML declaration: standalone %q", value)
+			}
+			standalone = value
+		default:
+			err = fmt.Errorf("invalid XML declaration: unexpected %q", key)
+		}
+		idx++
+		return err == nil
+	}); attrsErr != nil {
+		return nil, nil, nil, fmt.Errorf("invalid XML declaration: %w", attrsErr)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if version == nil {
+		return nil, nil, nil, errors.New("invalid XML declaration: missing version")
+	}
+	return version, encoding, standalone, nil
+}
+
+// isEncodingName checks value is an EncName (ex: `UTF-8` or `ISO-8859-1`)
+func isEncodingName(value []byte) bool {
+	if len(value) == 0 || !(value[0] >= 'A' && value[0] <= 'Z' || value[0] >= 'a' && value[0] <= 'z') {
+		return false
+	}
+	for _, b := range value[1:] {
+		if !(b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '.' || b == '_' || b == '-') {
+			return false
+		}
+	}
+	return true
 }
@@ -9,12 +9,83 @@ import (
 func TestIsProcInst(t *testing.T) {
 	assert.True(t, IsProcInst([]byte("<?target inst?>")))
 	assert.False(t, IsProcInst([]byte("<element>")))
+	assert.False(t, IsProcInst([]byte("x?")))
 }
 func TestProcInst(t *testing.T) {
 	target, inst := ProcInst([]byte("<?target inst?>"))
 	assert.Equal(t, "target", string(target))
 	assert.Equal(t, "inst", string(inst))
+	target, inst = ProcInst([]byte("<?target\ninst?>"))
+	assert.Equal(t, "target", string(target))
+	assert.Equal(t, "inst", string(inst))
 	target, inst = ProcInst([]byte("<?invalid?>"))
 	assert.Equal(t, "invalid", string(target))
 	assert.Nil(t, inst)
+	target, inst = ProcInst([]byte("<?>"))
+	assert.Nil(t, target)
+	assert.Nil(t, inst)
+}
+
+func TestProcInstAttrs(t *testing.T) {
+	var pairs []string
+	err := ProcInstAttrs([]byte(` href = "style.xsl"	type='text/xsl' title="a 'b'" `), func(key, value []byte) bool {
+		pairs = append(pairs, string(key)+"="+string(value))
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"href=style.xsl", "type=text/xsl", "title=a 'b'"}, pairs)
+
+	pairs = nil
+	assert.NoError(t, ProcInstAttrs([]byte(`a="1" b="2"`), func(key, value []byte) bool {
+		pairs = append(pairs, string(key))
+		return false
+	}))
+	assert.Equal(t, []string{"a"}, pairs)
+
+	for input, expected := range map[string]string{
+		`a`:          `expected '=' after pseudo-attribute "a" at offset 1`,
+		`a=1`:        `expected pseudo-attribute "a" value to be quoted at offset 2`,
+		`a="1`:       `expected pseudo-attribute "a" value to end with " at offset 2`,
+		`a='1'b='2'`: `expected whitespace after pseudo-attribute "a" at offset 5`,
+	} {
+		assert.EqualError(t, ProcInstAttrs([]byte(input), func(key, value []byte) bool { return true }), expected, input)
+	}
+}
+
+func TestParseXMLDecl(t *testing.T) {
+	testCases := []struct {
+		Input      string
+		Version    string
+		Encoding   string
+		Standalone string
+		Error      string
+	}{
+		{Input: `version="1.0"`, Version: "1.0"},
+		{Input: `<?xml version='1.1' encoding="ISO-8859-1" standalone='yes'?>`, Version: "1.1", Encoding: "ISO-8859-1", Standalone: "yes"},
+		{Input: ` version = "1.0" standalone="no" `, Version: "1.0", Standalone: "no"},
+		{Input: ``, Error: "invalid XML declaration: missing version"},
+		{Input: `<?xml-stylesheet href="a"?>`, Error: `invalid XML declaration: target is "xml-stylesheet"`},
+		{Input: `encoding="UTF-8" version="1.0"`, Error: "invalid XML declaration: version must be first"},
+		{Input: `version="2.0"`, Error: `invalid XML declaration: version "2.0"`},
+		{Input: `version="1."`, Error: `invalid XML declaration: version "1."`},
+		{Input: `version="1.0" encoding="8bit"`, Error: `invalid XML declaration: encoding "8bit"`},
+		{Input: `version="1.0" standalone="true"`, Error: `invalid XML declaration: standalone "true"`},
+		{Input: `version="1.0" standalone="yes" encoding="UTF-8"`, Error: `invalid XML declaration: unexpected "encoding"`},
+		{Input: `version="1.0" version="1.0"`, Error: `invalid XML declaration: unexpected "version"`},
+		{Input: `version="1.0`, Error: `invalid XML declaration: expected pseudo-attribute "version" value to end with " at offset 8`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			version, encoding, standalone, err := ParseXMLDecl([]byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.Version, string(version))
+				assert.Equal(t, tc.Encoding, string(encoding))
+				assert.Equal(t, tc.Standalone, string(standalone))
+			}
+		})
+	}
 }
@@ -0,0 +1,148 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// mayContain performs a raw byte search returning false if buf cannot contain a match for the Path
+func (p *Path) mayContain(buf []byte) bool {
+	if p.needle == nil {
+		return true
+	}
+	for offset := 0; ; {
+		idx := bytes.Index(buf[offset:], p.needle)
+		if idx == -1 {
+			return false
+		}
+		offset += idx + len(p.needle)
+		// The name must not just be the prefix of a longer name
+		if offset == len(buf) {
+			return false
+		}
+		if isNameEnd(buf[offset]) {
+			return true
+		}
+	}
+}
+
+// Count returns the number of elements in buf matching the path expression (including nested matches)
+// Documents which cannot contain the final element of the path are rejected without being scanned
+func Count(buf []byte, path string) (int, error) {
+	p, err := CompilePath(path)
+	if err != nil {
+		return 0, err
+	}
+	if !p.mayContain(buf) {
+		return 0, nil
+	}
+	m := p.Matcher()
+	s := NewScanner(buf)
+	count := 0
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			if err := m.Pop(); err != nil {
+				return count, err
+			}
+			continue
+		}
+		if m.Push(token) {
+			count++
+		}
+		if IsSelfClosing(token) {
+			if err := m.Pop(); err != nil {
+				return count, err
+			}
+		} else if !m.Possible() {
+			if err := s.Skip(); err != nil {
+				return count, err
+			}
+			if err := m.Pop(); err != nil {
+				return count, err
+			}
+		}
+	}
+}
+
+// Exists determines if any element in buf matches the path expression, stopping at the first match
+// Documents which cannot contain the final element of the path are rejected without being scanned
+func Exists(buf []byte, path string) (bool, error) {
+	p, err := CompilePath(path)
+	if err != nil {
+		return false, err
+	}
+	if !p.mayContain(buf) {
+		return false, nil
+	}
+	if _, _, err := p.Matcher().Find(NewScanner(buf)); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// errEmptyPath is returned by FindFirst when no names are given
+var errEmptyPath = errors.New("expected at least one name in the path")
+
+// FindFirst returns the byte range of the first element in buf at the path of names starting at the root element
+// (ex: `feed`, `entry`, `title`), a name may be `*:local` to match the local part with any prefix
+// Elements which are not on the path are skipped and scanning stops at the first match
+// io.EOF is returned if no element matches
+func FindFirst(buf []byte, path ...[]byte) (start int, end int, err error) {
+	if len(path) == 0 {
+		return -1, -1, errEmptyPath
+	}
+	s := NewScanner(buf)
+	depth := 0 // number of names in path matched by the open elements
+	for {
+		start = s.Offset()
+		token, chardata, err := s.Next()
+		if err != nil {
+			return -1, -1, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		// Only the elements on the path are descended into so the end of one ends the search within it
+		if IsEndElement(token) {
+			if depth <= 1 {
+				return -1, -1, io.EOF
+			}
+			depth--
+			continue
+		}
+		matched := startNamed(token, path[depth])
+		if !matched && depth == 0 {
+			return -1, -1, io.EOF
+		}
+		if s.SelfClosing(token) {
+			if matched && depth == len(path)-1 {
+				return start, s.Offset(), nil
+			}
+			continue
+		}
+		if matched && depth < len(path)-1 {
+			depth++
+			continue
+		}
+		if err := s.Skip(); err == io.EOF {
+			return -1, -1, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return -1, -1, err
+		}
+		if matched {
+			return start, s.Offset(), nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCount(t *testing.T) {
+	doc := []byte(`<Envelope><Body><item><item/></item><items/><item>x</item></Body></Envelope>`)
+	testCases := []struct {
+		Path     string
+		Expected int
+		Error    string
+	}{
+		{Path: "Envelope/Body/item", Expected: 2},
+		{Path: "//item", Expected: 3},
+		{Path: "Envelope/*/*", Expected: 3},
+		{Path: "//Fault", Expected: 0},
+		{Path: "//ite", Expected: 0},
+		{Path: "", Error: `invalid path "": empty step`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Path, func(t *testing.T) {
+			count, err := Count(doc, tc.Path)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, count)
+			}
+		})
+	}
+	_, err := Count([]byte(`<a><item/><item`), "//item")
+	assert.EqualError(t, err, `expected Token to end with '>' at offset 10`)
+}
+
+func TestExists(t *testing.T) {
+	doc := []byte(`<Envelope><Body><Fault><code>1</code></Fault></Body></Envelope>`)
+	exists, err := Exists(doc, "Envelope/Body/Fault")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = Exists(doc, "//Faul")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = Exists(doc, "Envelope/Fault")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	// The raw byte search allows an invalid document to be rejected without an error
+	exists, err = Exists([]byte(`<Envelope><Body`), "//Fault")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	_, err = Exists(doc, "a//")
+	assert.Error(t, err)
+}
+
+func TestFindFirst(t *testing.T) {
+	doc := `<?xml version="1.0"?><feed><entry><id>1</id></entry><entry><title>a</title><ns:title/></entry><title>b</title></feed>`
+	testCases := []struct {
+		Path   []string
+		Output string
+		Err    error
+	}{
+		{Path: []string{"feed", "entry", "title"}, Output: `<title>a</title>`},
+		{Path: []string{"feed", "entry", "id"}, Output: `<id>1</id>`},
+		{Path: []string{"feed", "entry"}, Output: `<entry><id>1</id></entry>`},
+		{Path: []string{"feed", "title"}, Output: `<title>b</title>`},
+		{Path: []string{"feed", "entry", "ns:title"}, Output: `<ns:title/>`},
+		{Path: []string{"feed", "entry", "*:title"}, Output: `<title>a</title>`},
+		{Path: []string{"feed"}, Output: doc[21:]},
+		{Path: []string{"feed", "id"}, Err: io.EOF},
+		{Path: []string{"entry"}, Err: io.EOF},
+		{Path: []string{"feed", "entry", "id", "x"}, Err: io.EOF},
+		{Err: errEmptyPath},
+	}
+	for _, tc := range testCases {
+		path := make([][]byte, len(tc.Path))
+		for idx, name := range tc.Path {
+			path[idx] = []byte(name)
+		}
+		start, end, err := FindFirst([]byte(doc), path...)
+		if tc.Err != nil {
+			assert.Equal(t, tc.Err, err, tc.Path)
+			continue
+		}
+		if assert.NoError(t, err, tc.Path) {
+			assert.Equal(t, tc.Output, doc[start:end], tc.Path)
+		}
+	}
+	// Scanning stops at the match so the rest of the document is never read
+	start, end, err := FindFirst([]byte(`<a><b>x</b><c`), []byte("a"), []byte("b"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, start)
+	assert.Equal(t, 11, end)
+	_, _, err = FindFirst([]byte(`<a><c><b>`), []byte("a"), []byte("b"))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
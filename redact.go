@@ -0,0 +1,125 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// redactTarget is a compiled path passed to Redact
+type redactTarget struct {
+	matcher *PathMatcher
+	attr    []byte // if nil the text content of the element is redacted
+}
+
+// redactAttrs appends the start element token to dst with the value of each attribute in keys replaced by value
+func redactAttrs(dst []byte, token []byte, keys [][]byte, value []byte) ([]byte, error) {
+	name, attrsToken := Element(token)
+	// Offset of attrsToken within token (ex: `<name ` for start elements)
+	offset := len(name) + 2
+	last := 0
+	err := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		for _, key := range keys {
+			if bytes.Equal(attrsToken[keyStart:keyEnd], key) {
+				dst = append(dst, token[last:offset+valueStart]...)
+				dst = append(dst, value...)
+				last = offset + valueEnd
+				break
+			}
+		}
+		return true
+	})
+	return append(dst, token[last:]...), err
+}
+
+// Redact returns a copy of buf with the text content or attribute values at the given paths replaced by replacement
+// Paths are path expressions (see CompilePath) matching elements whose text content (including that of any
+// descendants) is redacted, or if the path ends in `/@name` the attribute of each matching element is redacted
+// Each run of non-whitespace CharData is replaced once and all other bytes in the document are copied unchanged
+func Redact(buf []byte, paths []string, replacement []byte) ([]byte, error) {
+	targets := make([]redactTarget, 0, len(paths))
+	possible := false
+	for _, path := range paths {
+		var attr []byte
+		if idx := strings.LastIndex(path, "/@"); idx != -1 {
+			path, attr = path[:idx], []byte(path[idx+2:])
+		}
+		p, err := CompilePath(path)
+		if err != nil {
+			return nil, err
+		}
+		possible = possible || p.mayContain(buf)
+		targets = append(targets, redactTarget{matcher: p.Matcher(), attr: attr})
+	}
+	dst := make([]byte, 0, len(buf))
+	// Documents which cannot contain a match are copied as-is
+	if !possible {
+		return append(dst, buf...), nil
+	}
+	text := EscapeText(nil, replacement)
+	value := EscapeAttr(nil, replacement)
+	var keys [][]byte
+	depth, redacting := 0, 0 // redacting is the depth of the outermost element whose text is redacted
+	replaced := false        // if the previous token was replaced with text
+	s := NewScanner(buf)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return dst, nil
+		} else if err != nil {
+			return dst, err
+		}
+		switch {
+		case chardata:
+			if redacting != 0 && !IsWhitespace(token) {
+				if !replaced {
+					dst = append(dst, text...)
+				}
+				replaced = true
+				continue
+			}
+		case IsEndElement(token):
+			for _, target := range targets {
+				if err := target.matcher.Pop(); err != nil {
+					return dst, err
+				}
+			}
+			if depth == redacting {
+				redacting = 0
+			}
+			depth--
+		case IsElement(token):
+			depth++
+			keys = keys[:0]
+			for _, target := range targets {
+				if !target.matcher.Push(token) {
+					continue
+				}
+				if target.attr != nil {
+					keys = append(keys, target.attr)
+				} else if redacting == 0 {
+					redacting = depth
+				}
+			}
+			if IsSelfClosing(token) {
+				for _, target := range targets {
+					if err := target.matcher.Pop(); err != nil {
+						return dst, err
+					}
+				}
+				if depth == redacting {
+					redacting = 0
+				}
+				depth--
+			}
+			if len(keys) > 0 {
+				if dst, err = redactAttrs(dst, token, keys, value); err != nil {
+					return dst, err
+				}
+				token = nil
+			}
+		}
+		replaced = false
+		dst = append(dst, token...)
+	}
+}
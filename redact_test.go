@@ -0,0 +1,82 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Paths    []string
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "Text",
+			Input:    `<Envelope><Body><ssn>123-45-6789</ssn><name>Bob</name></Body></Envelope>`,
+			Paths:    []string{"//ssn"},
+			Expected: `<Envelope><Body><ssn>***</ssn><name>Bob</name></Body></Envelope>`,
+		},
+		{
+			Name:     "Attribute",
+			Input:    `<Envelope><Header><Auth  token = "s&amp;cret" user="bob"/></Header></Envelope>`,
+			Paths:    []string{"Envelope/Header/Auth/@token"},
+			Expected: `<Envelope><Header><Auth  token = "***" user="bob"/></Header></Envelope>`,
+		},
+		{
+			Name:     "Multiple",
+			Input:    "<a>\n\t<b id=\"1\" key=\"x\">one</b>\n\t<c>two</c>\n</a>",
+			Paths:    []string{"a/b/@key", "a/b/@id", "//c"},
+			Expected: "<a>\n\t<b id=\"***\" key=\"***\">one</b>\n\t<c>***</c>\n</a>",
+		},
+		{
+			Name:     "Descendants",
+			Input:    "<a><card>\n  <number>4111</number>\n  <cvv>123<![CDATA[4]]>5</cvv>\n</card></a>",
+			Paths:    []string{"a/card"},
+			Expected: "<a><card>\n  <number>***</number>\n  <cvv>***</cvv>\n</card></a>",
+		},
+		{
+			Name:     "SelfClosing",
+			Input:    `<a><ssn/><b>keep</b><ssn></ssn></a>`,
+			Paths:    []string{"//ssn"},
+			Expected: `<a><ssn/><b>keep</b><ssn></ssn></a>`,
+		},
+		{
+			Name:     "NoMatch",
+			Input:    `<?xml version="1.0"?><!-- x --><a><b>keep</b></a>`,
+			Paths:    []string{"//ssn", "a/b/@id"},
+			Expected: `<?xml version="1.0"?><!-- x --><a><b>keep</b></a>`,
+		},
+		{
+			Name:  "InvalidPath",
+			Input: `<a/>`,
+			Paths: []string{"a//"},
+			Error: `invalid path "a//": empty step`,
+		},
+		{
+			Name:     "Invalid",
+			Input:    `<a><ssn>1</ssn><b`,
+			Paths:    []string{"//ssn"},
+			Expected: `<a><ssn>***</ssn>`,
+			Error:    `expected Token to end with '>' at offset 15`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			out, err := Redact([]byte(tc.Input), tc.Paths, []byte("***"))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.Expected, string(out))
+		})
+	}
+	// The replacement is escaped
+	out, err := Redact([]byte(`<a k="v">x</a>`), []string{"a", "a/@k"}, []byte(`<"&">`))
+	assert.NoError(t, err)
+	assert.Equal(t, `<a k="&lt;&quot;&amp;&quot;>">&lt;"&amp;"&gt;</a>`, string(out))
+}
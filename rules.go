@@ -0,0 +1,170 @@
+package fastxml
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Rule is a contract checked by ValidateRules for every element matching Path
+type Rule struct {
+	// Path is a path expression (see CompilePath) selecting the elements the rule applies to
+	Path string
+	// RequiredAttrs are the names of the attributes each element must have (ex: `id` or `xml:lang`)
+	RequiredAttrs []string
+	// RequiredChildren are NamePatterns (see ParseNamePattern) each of which must match a direct child element
+	RequiredChildren []string
+}
+
+// RuleViolation is an element which does not satisfy a Rule
+type RuleViolation struct {
+	Rule   *Rule
+	Msg    string
+	Offset int // byte offset of the start element
+	Line   int // 1-based line number
+	Column int // 1-based column (in bytes)
+}
+
+// String formats the violation (ex: `line 3, column 5: <item> matching "root/item" is missing attribute "id"`)
+func (v RuleViolation) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", v.Line, v.Column, v.Msg)
+}
+
+// compiledRule is a Rule with its Path and children compiled
+type compiledRule struct {
+	rule     *Rule
+	matcher  *PathMatcher
+	attrs    [][]byte
+	children []NamePattern
+}
+
+// ruleFrame is an open element matched by a Rule with RequiredChildren
+type ruleFrame struct {
+	rule   *compiledRule
+	name   []byte
+	offset int
+	depth  int
+	seen   []bool // if a child matched each of the RequiredChildren
+}
+
+// ValidateRules checks every element of buf against the rules in a single pass returning each violation in document order
+// The document is not otherwise validated (see Validate), an error is only returned if a rule is invalid or buf
+// cannot be tokenized in which case the violations found before the error are also returned
+func ValidateRules(buf []byte, rules []Rule) ([]RuleViolation, error) {
+	compiled := make([]*compiledRule, len(rules))
+	for idx := range rules {
+		p, err := CompilePath(rules[idx].Path)
+		if err != nil {
+			return nil, err
+		}
+		cr := &compiledRule{rule: &rules[idx], matcher: p.Matcher()}
+		for _, attr := range rules[idx].RequiredAttrs {
+			cr.attrs = append(cr.attrs, []byte(attr))
+		}
+		for _, child := range rules[idx].RequiredChildren {
+			cr.children = append(cr.children, ParseNamePattern(child))
+		}
+		compiled[idx] = cr
+	}
+	var violations []RuleViolation
+	violate := func(cr *compiledRule, name []byte, offset int, format string, args ...interface{}) {
+		line, column := lineColumn(buf, offset)
+		violations = append(violations, RuleViolation{
+			Rule:   cr.rule,
+			Msg:    fmt.Sprintf("<%s> matching %q ", name, cr.rule.Path) + fmt.Sprintf(format, args...),
+			Offset: offset,
+			Line:   line,
+			Column: column,
+		})
+	}
+	// end reports the RequiredChildren missing from the frames of the element ending at depth
+	var frames []ruleFrame
+	end := func(depth int) {
+		first := len(frames)
+		for first > 0 && frames[first-1].depth == depth {
+			first--
+		}
+		for _, frame := range frames[first:] {
+			for idx, seen := range frame.seen {
+				if !seen {
+					violate(frame.rule, frame.name, frame.offset, "is missing child element <%s>", frame.rule.children[idx])
+				}
+			}
+		}
+		frames = frames[:first]
+	}
+	s := NewScanner(buf)
+	depth := 0
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF && depth > 0 {
+			return violations, io.ErrUnexpectedEOF
+		} else if err == io.EOF {
+			// Missing children are only known at the end element so the violations are re-ordered by offset
+			sort.SliceStable(violations, func(i, j int) bool {
+				return violations[i].Offset < violations[j].Offset
+			})
+			return violations, nil
+		} else if err != nil {
+			return violations, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			end(depth)
+			for _, cr := range compiled {
+				if err := cr.matcher.Pop(); err != nil {
+					return violations, err
+				}
+			}
+			depth--
+			continue
+		}
+		depth++
+		name, attrsToken := Element(token)
+		// Mark the RequiredChildren of the parent this element satisfies
+		for idx := len(frames) - 1; idx >= 0 && frames[idx].depth == depth-1; idx-- {
+			for child, pattern := range frames[idx].rule.children {
+				if pattern.Match(name) {
+					frames[idx].seen[child] = true
+				}
+			}
+		}
+		possible, children := false, false
+		for _, cr := range compiled {
+			if cr.matcher.Push(token) {
+				for _, attr := range cr.attrs {
+					if start, _, err := RawAttr(attrsToken, attr); err != nil {
+						return violations, err
+					} else if start == -1 {
+						violate(cr, name, offset, "is missing attribute %q", attr)
+					}
+				}
+				if len(cr.children) > 0 {
+					frames = append(frames, ruleFrame{rule: cr, name: name, offset: offset, depth: depth, seen: make([]bool, len(cr.children))})
+					children = true
+				}
+			}
+			possible = possible || cr.matcher.Possible()
+		}
+		if !s.SelfClosing(token) {
+			if possible || children {
+				continue // descend into the element
+			}
+			if err := s.Skip(); err == io.EOF {
+				return violations, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return violations, err
+			}
+		}
+		end(depth)
+		for _, cr := range compiled {
+			if err := cr.matcher.Pop(); err != nil {
+				return violations, err
+			}
+		}
+		depth--
+	}
+}
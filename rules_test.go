@@ -0,0 +1,53 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRules(t *testing.T) {
+	rules := []Rule{
+		{Path: "feed", RequiredAttrs: []string{"version"}, RequiredChildren: []string{"title"}},
+		{Path: "feed/entry", RequiredAttrs: []string{"id", "xml:lang"}, RequiredChildren: []string{"title", "*:link"}},
+	}
+	doc := `<feed version="1">
+	<title>t</title>
+	<entry id="1" xml:lang="en"><title>a</title><atom:link/></entry>
+	<entry id="2"><link/><nested><title/></nested></entry>
+	<entry xml:lang="en"/>
+</feed>`
+	violations, err := ValidateRules([]byte(doc), rules)
+	assert.NoError(t, err)
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.String())
+		assert.Same(t, &rules[1], v.Rule)
+	}
+	assert.Equal(t, []string{
+		`line 4, column 2: <entry> matching "feed/entry" is missing attribute "xml:lang"`,
+		`line 4, column 2: <entry> matching "feed/entry" is missing child element <title>`,
+		`line 5, column 2: <entry> matching "feed/entry" is missing attribute "id"`,
+		`line 5, column 2: <entry> matching "feed/entry" is missing child element <title>`,
+		`line 5, column 2: <entry> matching "feed/entry" is missing child element <*:link>`,
+	}, messages)
+	assert.Equal(t, strings.Index(doc, `<entry id="2"`), violations[0].Offset)
+
+	violations, err = ValidateRules([]byte(`<feed><entry id="1"/></feed>`), rules)
+	assert.NoError(t, err)
+	assert.Len(t, violations, 5)
+	assert.Equal(t, `<feed> matching "feed" is missing attribute "version"`, violations[0].Msg)
+	assert.Equal(t, `<feed> matching "feed" is missing child element <title>`, violations[1].Msg)
+
+	// Elements no rule can apply to are skipped
+	violations, err = ValidateRules([]byte(`<other><entry/></other>`), rules)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	_, err = ValidateRules([]byte(`<feed version="1"><title>`), rules)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = ValidateRules([]byte(`<feed/>`), []Rule{{Path: "a//"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,13 @@
+//go:build fastxml_safe
+
+package fastxml
+
+// zeroCopy is true when String references buf instead of copying it
+const zeroCopy = false
+
+// String copies buf into a new string
+// This is used instead of the no-copy conversion in unsafe.go when built with the fastxml_safe tag
+// for environments where the unsafe package is prohibited, at the cost of an allocation per call
+func String(buf []byte) string {
+	return string(buf)
+}
@@ -0,0 +1,252 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// prefixXMLDecl is the start of the XML declaration
+var prefixXMLDecl = []byte("<?xml ")
+
+// Sanitizer removes any element, attribute or URL which was not explicitly allowed from a document
+// It is configured with a builder API similar to bluemonday:
+//
+//	NewSanitizer().
+//		AllowElements("p", "a").
+//		AllowAttrs("href").OnElements("a").
+//		AllowURLSchemes("https", "mailto")
+type Sanitizer struct {
+	elements  map[string]struct{}
+	skip      map[string]struct{}
+	global    map[string]struct{}            // attributes allowed on every element
+	attrs     map[string]map[string]struct{} // element -> attributes allowed on it
+	schemes   map[string]struct{}
+	urlAttrs  map[string]struct{}
+	keepOther bool
+}
+
+// AttrPolicy is returned by Sanitizer.AllowAttrs to select where the attributes are allowed
+type AttrPolicy struct {
+	sz    *Sanitizer
+	attrs []string
+}
+
+// NewSanitizer creates a *Sanitizer which allows nothing
+// The attributes href, src, action and xlink:href are treated as URLs by default
+func NewSanitizer() *Sanitizer {
+	return &Sanitizer{
+		elements: make(map[string]struct{}),
+		skip:     make(map[string]struct{}),
+		global:   make(map[string]struct{}),
+		attrs:    make(map[string]map[string]struct{}),
+		schemes:  make(map[string]struct{}),
+		urlAttrs: map[string]struct{}{
+			"href":       {},
+			"src":        {},
+			"action":     {},
+			"xlink:href": {},
+		},
+	}
+}
+
+// addAll adds each name to set
+func addAll(set map[string]struct{}, names []string) {
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+}
+
+// AllowElements allows the elements, any other element is removed (but not its content)
+func (sz *Sanitizer) AllowElements(names ...string) *Sanitizer {
+	addAll(sz.elements, names)
+	return sz
+}
+
+// SkipElementsContent removes the elements along with their content (ex: "script", "style")
+func (sz *Sanitizer) SkipElementsContent(names ...string) *Sanitizer {
+	addAll(sz.skip, names)
+	return sz
+}
+
+// AllowAttrs allows the attributes on the elements given to OnElements (or Globally)
+func (sz *Sanitizer) AllowAttrs(attrs ...string) *AttrPolicy {
+	return &AttrPolicy{sz: sz, attrs: attrs}
+}
+
+// OnElements allows the attributes only on the named elements
+func (ap *AttrPolicy) OnElements(names ...string) *Sanitizer {
+	for _, name := range names {
+		allowed, ok := ap.sz.attrs[name]
+		if !ok {
+			allowed = make(map[string]struct{})
+			ap.sz.attrs[name] = allowed
+		}
+		addAll(allowed, ap.attrs)
+	}
+	return ap.sz
+}
+
+// Globally allows the attributes on any allowed element
+func (ap *AttrPolicy) Globally() *Sanitizer {
+	addAll(ap.sz.global, ap.attrs)
+	return ap.sz
+}
+
+// AllowURLSchemes allows absolute URLs with the schemes (ex: "https") in URL attributes
+// Relative URLs are always allowed
+func (sz *Sanitizer) AllowURLSchemes(schemes ...string) *Sanitizer {
+	for _, scheme := range schemes {
+		sz.schemes[strings.ToLower(scheme)] = struct{}{}
+	}
+	return sz
+}
+
+// URLAttrs adds attributes which contain a URL and should have the scheme checked
+func (sz *Sanitizer) URLAttrs(attrs ...string) *Sanitizer {
+	addAll(sz.urlAttrs, attrs)
+	return sz
+}
+
+// AllowComments keeps comments, processing instructions and directives which are removed by default
+// The XML declaration (<?xml ...?>) is always kept
+func (sz *Sanitizer) AllowComments() *Sanitizer {
+	sz.keepOther = true
+	return sz
+}
+
+// allowedURL determines if the (raw) attribute value is a relative URL or has an allowed scheme
+func (sz *Sanitizer) allowedURL(value []byte) bool {
+	decoded, err := DecodeEntities(value, nil)
+	if err != nil {
+		return false
+	}
+	// Browsers ignore whitespace and control characters within the scheme
+	var scheme []byte
+	for _, b := range decoded {
+		if b <= ' ' {
+			continue
+		}
+		if b == ':' {
+			_, ok := sz.schemes[strings.ToLower(String(scheme))]
+			return ok
+		}
+		if b == '/' || b == '?' || b == '#' {
+			break
+		}
+		scheme = append(scheme, b)
+	}
+	return true
+}
+
+// allowedAttr determines if the attribute is allowed on the element
+func (sz *Sanitizer) allowedAttr(name, key, value []byte) bool {
+	if _, ok := sz.global[String(key)]; !ok {
+		if _, ok := sz.attrs[String(name)][String(key)]; !ok {
+			return false
+		}
+	}
+	if _, ok := sz.urlAttrs[String(key)]; ok {
+		return sz.allowedURL(value)
+	}
+	return true
+}
+
+// appendStart appends the start element with only the allowed attributes
+func (sz *Sanitizer) appendStart(dst []byte, token []byte) ([]byte, error) {
+	name, attrsToken := Element(token)
+	dst = append(dst, '<')
+	dst = append(dst, name...)
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		if sz.allowedAttr(name, key, value) {
+			dst = append(dst, ' ')
+			dst = append(dst, key...)
+			dst = append(dst, '=', '"')
+			dst = append(dst, value...)
+			dst = append(dst, '"')
+		}
+		return true
+	}); err != nil {
+		return dst, err
+	}
+	if IsSelfClosing(token) {
+		dst = append(dst, '/')
+	}
+	return append(dst, '>'), nil
+}
+
+// sanitizedElement is an open element in Sanitize
+type sanitizedElement struct {
+	openElement
+	kept bool
+}
+
+// Sanitize appends the sanitized src to dst
+// Text (including CDATA sections) is decoded and escaped again so it can't contain markup
+// An end element which does not match the open element is rejected with a *MismatchError
+func (sz *Sanitizer) Sanitize(dst, src []byte) ([]byte, error) {
+	s := NewScanner(src)
+	var open []sanitizedElement
+	var scratch []byte
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return dst, err
+		}
+		switch {
+		case chardata:
+			if scratch, err = CharData(token, scratch[:0]); err != nil {
+				return dst, err
+			}
+			dst = EscapeText(dst, scratch)
+		case !IsElement(token):
+			if sz.keepOther || (IsProcInst(token) && bytes.HasPrefix(token, prefixXMLDecl)) {
+				dst = append(dst, token...)
+			}
+		case IsEndElement(token):
+			if len(open) == 0 {
+				return dst, errUnexpectedEnd
+			}
+			start := open[len(open)-1]
+			name, _ := Element(token)
+			if !bytes.Equal(start.name, name) {
+				return dst, &MismatchError{
+					StartName:   string(start.name),
+					StartOffset: start.offset,
+					EndName:     string(name),
+					EndOffset:   offset,
+				}
+			}
+			if start.kept {
+				dst = append(dst, '<', '/')
+				dst = append(dst, start.name...)
+				dst = append(dst, '>')
+			}
+			open = open[:len(open)-1]
+		default:
+			name, _ := Element(token)
+			if _, ok := sz.skip[String(name)]; ok {
+				if err := s.SkipElement(token); err != nil {
+					return dst, err
+				}
+				continue
+			}
+			_, ok := sz.elements[String(name)]
+			if ok {
+				if dst, err = sz.appendStart(dst, token); err != nil {
+					return dst, err
+				}
+			}
+			if !IsSelfClosing(token) {
+				open = append(open, sanitizedElement{openElement{offset: offset, name: name}, ok})
+			}
+		}
+	}
+	if len(open) > 0 {
+		return dst, io.ErrUnexpectedEOF
+	}
+	return dst, nil
+}
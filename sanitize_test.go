@@ -0,0 +1,71 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizer(t *testing.T) {
+	sz := NewSanitizer().
+		AllowElements("p", "a", "svg", "use").
+		SkipElementsContent("script").
+		AllowAttrs("href").OnElements("a").
+		AllowAttrs("xlink:href").OnElements("use").
+		AllowAttrs("class").Globally().
+		AllowURLSchemes("https", "MailTo")
+	testCases := []struct {
+		Input    string
+		Expected string
+		Error    string
+	}{
+		{
+			Input:    `<?xml version="1.0"?><!-- hi --><p class="x" style="y">text &amp; <b>bold</b></p>`,
+			Expected: `<?xml version="1.0"?><p class="x">text &amp; bold</p>`,
+		}, {
+			Input:    `<p><script>alert(1)<p/></script>after</p>`,
+			Expected: `<p>after</p>`,
+		}, {
+			Input:    `<a href="https://example.com" onclick="x()">a</a><a href="mailto:a@example.com">b</a><a href="/relative?x=y:z">c</a>`,
+			Expected: `<a href="https://example.com">a</a><a href="mailto:a@example.com">b</a><a href="/relative?x=y:z">c</a>`,
+		}, {
+			Input:    `<a href="javascript:alert(1)">a</a><a href=" jav&#x61;script:x">b</a><a href="java&#9;script:x" class="c">c</a>`,
+			Expected: `<a>a</a><a>b</a><a class="c">c</a>`,
+		}, {
+			Input:    `<svg><use xlink:href="data:image/svg+xml,x" href="y"/><use xlink:href="#icon"/></svg>`,
+			Expected: `<svg><use/><use xlink:href="#icon"/></svg>`,
+		}, {
+			Input:    `<p><![CDATA[<script>alert(1)</script>]]> &lt;b&gt; &amp; 1 > 0</p>`,
+			Expected: `<p>&lt;script&gt;alert(1)&lt;/script&gt; &lt;b&gt; &amp; 1 &gt; 0</p>`,
+		}, {
+			Input:    `<p>a</p ><p>b</p	>`,
+			Expected: `<p>a</p><p>b</p>`,
+		}, {
+			Input: `<p><script></p>alert(1)</script>`,
+			Error: `element <p> at offset 0 closed by </script> at offset 23`,
+		}, {
+			Input: `<p><b></p></b>`,
+			Error: `element <b> at offset 3 closed by </p> at offset 6`,
+		}, {
+			Input: `<p></p></a>`,
+			Error: `unexpected end element`,
+		}, {
+			Input: `<p><a>`,
+			Error: `unexpected EOF`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			actual, err := sz.Sanitize(nil, []byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(actual))
+			}
+		})
+	}
+	actual, err := NewSanitizer().AllowComments().Sanitize([]byte("prefix:"), []byte(`<!-- c --><p>text</p>`))
+	assert.NoError(t, err)
+	assert.Equal(t, `prefix:<!-- c -->text`, string(actual))
+}
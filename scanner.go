@@ -3,25 +3,131 @@ package fastxml
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 )
 
-// Allocate the errors once and return the same structs
-var (
-	errCDATASuffix   = errors.New("expected Token to end with ']]>'")
-	errElementSuffix = errors.New("expected Token to end with '>'")
-)
-
 // Allocate these once instead of on each bytes.Index/HasPrefix/HasSuffix call
 var (
-	prefixCDATA = []byte("<![CDATA[")
-	suffixCDATA = []byte("]]>")
+	prefixCDATA    = []byte("<![CDATA[")
+	suffixCDATA    = []byte("]]>")
+	prefixComment  = []byte("<!--")
+	suffixComment  = []byte("-->")
+	prefixProcInst = []byte("<?")
+	suffixProcInst = []byte("?>")
 )
 
 // Scanner reads a []byte emitting each "token" as a slice
 type Scanner struct {
-	buf []byte // immutable slice of data
-	pos int    // pos is the current offset in buf
+	// AutoClose is a list of element names which are treated as self-closing
+	// even without a trailing '/' (ex: xml.HTMLAutoClose), any end element for them is dropped
+	AutoClose []string
+	// Policy (if set) restricts which constructs are accepted, see Policy
+	Policy *Policy
+	// Mode controls how much malformed input is tolerated, see ParseMode
+	// In ParseStrict mode every end element must close the most recent open start element
+	// (or a *MismatchError is returned), the open elements are not updated by Seek, only by Next and Reset
+	Mode ParseMode
+	// TrackLines wraps every error returned by Next in a *SyntaxError with the line and column it occurred at
+	// The position is only computed when an error occurs
+	TrackLines bool
+	// SkipWhitespaceCharData suppresses CharData tokens consisting solely of whitespace (see IsWhitespace)
+	// such as the indentation between the elements of a pretty-printed document
+	SkipWhitespaceCharData bool
+	// TrimCharData removes the leading and trailing whitespace of each CharData token (see TrimWhitespace)
+	// suppressing any which are then empty, by default all whitespace is preserved
+	TrimCharData bool
+	// Limits (if any are set) reject tokens exceeding them with a *LimitError, see Limits
+	Limits
+	// Progress (if set) is called by Next with the offset of the next token each time another ProgressInterval
+	// bytes have been scanned and once more when the end of the input is reached (ex: to render a progress bar)
+	Progress func(offset int)
+	// ProgressInterval is the minimum number of bytes between calls to Progress, DefaultProgressInterval if 0
+	ProgressInterval int
+
+	buf      []byte        // immutable slice of data
+	pos      int           // pos is the current offset in buf
+	start    int           // start is the offset in buf of the most recent token
+	depth    int           // depth is the current element nesting, only tracked for Policy and Limits
+	open     []openElement // open start elements, only tracked in ParseStrict and ParseHTML modes
+	progress int           // offset at which Progress is next called, -1 once the end has been reported
+	partial  bool          // buf may be followed by more input, set by a Decoder (see errPartial)
+}
+
+// errPartial is returned by filter if the token may continue past the end of buf and partial is set
+var errPartial = errors.New("partial token")
+
+// DefaultProgressInterval is the number of bytes between calls to Progress if the ProgressInterval is 0
+const DefaultProgressInterval = 1 << 20
+
+// openElement is a start element which has not been closed yet
+type openElement struct {
+	offset int
+	name   []byte
+}
+
+// MismatchError is returned in ParseStrict mode when an end element does not match the open start element
+type MismatchError struct {
+	StartName   string
+	StartOffset int // offset of the start element, -1 if no element is open
+	EndName     string
+	EndOffset   int // offset of the end element
+}
+
+// Error implements the error interface
+func (e *MismatchError) Error() string {
+	if e.StartOffset == -1 {
+		return fmt.Sprintf("unexpected end element </%s> at offset %d", e.EndName, e.EndOffset)
+	}
+	return fmt.Sprintf("element <%s> at offset %d closed by </%s> at offset %d", e.StartName, e.StartOffset, e.EndName, e.EndOffset)
+}
+
+// balance tracks the open start elements returning a *MismatchError for an unexpected end element
+func (s *Scanner) balance(offset int, elemToken []byte) error {
+	if !IsEndElement(elemToken) {
+		if !s.SelfClosing(elemToken) {
+			name, _ := Element(elemToken)
+			s.open = append(s.open, openElement{offset: offset, name: name})
+		}
+		return nil
+	}
+	name, _ := Element(elemToken)
+	if len(s.open) == 0 {
+		return &MismatchError{StartOffset: -1, EndName: string(name), EndOffset: offset}
+	}
+	start := s.open[len(s.open)-1]
+	if !bytes.Equal(start.name, name) {
+		return &MismatchError{
+			StartName:   string(start.name),
+			StartOffset: start.offset,
+			EndName:     string(name),
+			EndOffset:   offset,
+		}
+	}
+	s.open = s.open[:len(s.open)-1]
+	return nil
+}
+
+// isAutoClose checks if the element is in the AutoClose list
+func (s *Scanner) isAutoClose(elemToken []byte) bool {
+	name, _ := Element(elemToken)
+	for _, autoClose := range s.AutoClose {
+		if autoClose == String(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfClosing is IsSelfClosing but also considers elements in AutoClose (and void elements in ParseHTML mode) as self-closing
+func (s *Scanner) SelfClosing(elemToken []byte) bool {
+	if IsSelfClosing(elemToken) {
+		return true
+	}
+	if len(s.AutoClose) > 0 && IsStartElement(elemToken) && s.isAutoClose(elemToken) {
+		return true
+	}
+	return s.Mode == ParseHTML && IsStartElement(elemToken) && isVoidElement(elemToken)
 }
 
 // Offset outputs the internal position the Scanner is at
@@ -29,6 +135,36 @@ func (s *Scanner) Offset() int {
 	return s.pos
 }
 
+// Remaining returns the number of bytes after Offset which have not been scanned yet
+func (s *Scanner) Remaining() int {
+	return len(s.buf) - s.pos
+}
+
+// reportProgress calls Progress if another ProgressInterval bytes have been scanned or the end was reached
+func (s *Scanner) reportProgress() {
+	switch {
+	case s.progress < 0:
+		return
+	case s.pos == len(s.buf):
+		s.progress = -1
+	case s.pos < s.progress:
+		return
+	default:
+		interval := s.ProgressInterval
+		if interval <= 0 {
+			interval = DefaultProgressInterval
+		}
+		s.progress = s.pos + interval
+	}
+	s.Progress(s.pos)
+}
+
+// TokenRange returns the byte range in the buffer of the token most recently returned by Next
+// If the token was rewritten (ex: by a Policy) the range is of the original token
+func (s *Scanner) TokenRange() (start int, end int) {
+	return s.start, s.pos
+}
+
 // Seek implements the io.Seeker interface
 func (s *Scanner) Seek(offset int64, whence int) (int64, error) {
 	var abs int
@@ -51,9 +187,253 @@ func (s *Scanner) Seek(offset int64, whence int) (int64, error) {
 	return int64(s.pos), nil
 }
 
+// Checkpoint is the state of a Scanner captured by Scanner.Checkpoint
+type Checkpoint struct {
+	pos, start, depth int
+	open              []openElement
+}
+
+// Checkpoint captures the state of the Scanner so that it can be returned to with Restore
+// (ex: to backtrack after speculatively parsing), unlike Seek it includes the open elements and depth
+func (s *Scanner) Checkpoint() Checkpoint {
+	cp := Checkpoint{pos: s.pos, start: s.start, depth: s.depth}
+	if len(s.open) > 0 {
+		cp.open = append([]openElement(nil), s.open...)
+	}
+	return cp
+}
+
+// Restore returns the Scanner to the state captured by Checkpoint, a Checkpoint may be restored multiple times
+// The Scanner must not have been Reset since the Checkpoint was captured
+func (s *Scanner) Restore(cp Checkpoint) {
+	s.pos, s.start, s.depth = cp.pos, cp.start, cp.depth
+	s.open = append(s.open[:0], cp.open...)
+}
+
+// Position returns the 1-based line and column (in bytes) of an offset in the buffer
+func (s *Scanner) Position(offset int) (line int, column int) {
+	return lineColumn(s.buf, offset)
+}
+
 // Next produces the next token from the scanner
 // When no more tokens are available io.EOF is returned AND the trailing token (if any)
 func (s *Scanner) Next() (token []byte, chardata bool, err error) {
+	if len(s.AutoClose) == 0 && s.Policy == nil && s.Mode == ParseDefault && !s.TrackLines && !s.SkipWhitespaceCharData && !s.TrimCharData && s.Limits == (Limits{}) && s.Progress == nil {
+		token, chardata, err = s.next()
+		if err != nil && err != io.EOF {
+			err = tokenError(err, s.pos)
+		}
+		return
+	}
+	token, chardata, err = s.filter()
+	err = tokenError(err, s.pos)
+	if _, ok := err.(*SyntaxError); s.TrackLines && err != nil && err != io.EOF && !ok {
+		offset := errorOffset(err, s.pos)
+		line, column := s.Position(offset)
+		err = &SyntaxError{Msg: errorMessage(err), Offset: offset, Line: line, Column: column, Err: err}
+	}
+	if s.Progress != nil {
+		s.reportProgress()
+	}
+	return
+}
+
+// NextKind is Next but returns the Kind of the token (see TokenKind) instead of if it is CharData
+// so callers can switch on it instead of classifying the token again with IsElement, IsComment, etc
+func (s *Scanner) NextKind() (token []byte, kind Kind, err error) {
+	token, chardata, err := s.Next()
+	if err != nil {
+		return token, 0, err
+	}
+	return token, TokenKind(token, chardata), nil
+}
+
+// filter implements Next with the AutoClose, Policy, Limits, SkipWhitespaceCharData, TrimCharData and Mode handling
+func (s *Scanner) filter() (token []byte, chardata bool, err error) {
+	for {
+		offset := s.pos
+		token, chardata, err = s.next()
+		// Nothing is checked (or any state updated) until the token is complete
+		if s.partial && s.incomplete(chardata, err) {
+			return nil, false, errPartial
+		}
+		lenient := s.Mode == ParseLenient || s.Mode == ParseHTML
+		if lenient && !chardata && (err == nil || err == ErrUnterminatedElement) {
+			if text, ok := s.lenient(offset, token); ok {
+				if s.partial && s.pos == len(s.buf) {
+					return nil, false, errPartial
+				}
+				if s.Mode == ParseHTML {
+					text = escapeBareAmps(text)
+				}
+				return text, true, nil
+			}
+		}
+		// Close any elements left open at the end of the input
+		if err == io.EOF && s.Mode == ParseHTML && len(s.open) > 0 {
+			top := s.open[len(s.open)-1]
+			s.open = s.open[:len(s.open)-1]
+			return endTag(top.name), false, nil
+		}
+		if err != nil {
+			return
+		}
+		if s.SkipWhitespaceCharData && chardata && IsWhitespace(token) {
+			continue
+		}
+		if s.TrimCharData && chardata {
+			if token = TrimWhitespace(token); len(token) == 0 {
+				continue
+			}
+		}
+		if err = s.Limits.check(s.depth, offset, token, chardata); err == nil && s.Policy != nil {
+			err = s.Policy.Limits.check(s.depth, offset, token, chardata)
+		}
+		if err != nil {
+			if s.Policy != nil {
+				s.Policy.exceeded(err)
+			}
+			return nil, false, err
+		}
+		switch {
+		case s.Mode == ParseStrict:
+			if err = s.strict(offset, token, chardata); err != nil {
+				return nil, false, err
+			}
+		case lenient && !chardata && IsElement(token) && !IsEndElement(token):
+			token = lenientAttrs(token)
+			if s.Mode == ParseHTML {
+				token = escapeBareAmps(token)
+			}
+		case s.Mode == ParseHTML && chardata:
+			token = escapeBareAmps(token)
+		}
+		// Drop the end element of any auto-closed element
+		if len(s.AutoClose) > 0 && !chardata && IsEndElement(token) && s.isAutoClose(token) {
+			continue
+		}
+		if s.Policy != nil {
+			if token, err = s.enforce(offset, token, chardata); err != nil {
+				return nil, false, err
+			} else if token == nil {
+				continue
+			}
+		}
+		if s.Mode == ParseStrict && !chardata && IsElement(token) {
+			if err = s.balance(offset, token); err != nil {
+				return nil, false, err
+			}
+		}
+		if s.Mode == ParseHTML && !chardata && IsElement(token) {
+			if token = s.html(offset, token); token == nil {
+				continue
+			}
+		}
+		if (s.Policy != nil || s.MaxDepth > 0) && !chardata && IsElement(token) {
+			if !IsEndElement(token) {
+				if !s.SelfClosing(token) {
+					s.depth++
+				}
+			} else if s.depth > 0 {
+				s.depth--
+			}
+		}
+		return
+	}
+}
+
+// incomplete determines if the token produced by next may continue past the end of buf
+func (s *Scanner) incomplete(chardata bool, err error) bool {
+	switch err {
+	case nil:
+		// CharData continues until the next '<'
+		return chardata && s.pos == len(s.buf)
+	case io.EOF, ErrUnterminatedElement, ErrUnterminatedCDATA, ErrUnterminatedComment, ErrUnterminatedProcInst:
+		return true
+	}
+	return false
+}
+
+// quotes are the characters an attribute value may be quoted with
+var quotes = [2]byte{'"', '\''}
+
+// unquotedEnd determines if the '>' at end of a start element without single quotes can't be within a quoted
+// attribute value as it follows a closing quote (ex: `key="val">` or `key="val"/>`) or there are no attributes
+// (ex: `<name>`), the opening quote of a value is always preceded by '=' (or whitespace)
+func unquotedEnd(buf []byte, end int) bool {
+	if c := buf[end-1]; c == '"' || (c == '/' && buf[end-2] == '"') {
+		if c == '/' {
+			end--
+		}
+		return end > 1 && buf[end-2] != '=' && !isSpace(buf[end-2])
+	}
+	idx := 1
+	for idx < end && !isSpace(buf[idx]) && buf[idx] != '"' {
+		idx++
+	}
+	return idx == end
+}
+
+// elementEnd returns the index of the '>' ending the element at the start of buf (or -1) given the first '>' at end
+// A '>' within a (single or double) quoted attribute value (ex: `<a title='x > y'>`) does not end the element
+func elementEnd(buf []byte, end int) int {
+	// Most elements only use double quotes, which can be checked without finding every value
+	if end > 1 && bytes.IndexByte(buf[:end], '\'') == -1 && unquotedEnd(buf, end) {
+		return end
+	}
+	// The index of the next quote of each kind (or end if there is none before it), kept so each kind is only
+	// searched for again once passed instead of for every value
+	next := [2]int{-1, -1}
+	for offset := 0; ; {
+		for k, quote := range quotes {
+			if next[k] < offset {
+				if next[k] = bytes.IndexByte(buf[offset:end], quote); next[k] == -1 {
+					next[k] = end
+				} else {
+					next[k] += offset
+				}
+			}
+		}
+		// The first quote opens a value, the other kind of quote is part of it
+		open := next[0]
+		if next[1] < open {
+			open = next[1]
+		}
+		if open == end {
+			return end
+		}
+		closing := bytes.IndexByte(buf[open+1:], buf[open])
+		if closing == -1 {
+			return -1
+		}
+		offset = open + closing + 2
+		if offset > end {
+			// The '>' was within the value, continue with the next '>' after it
+			idx := bytes.IndexByte(buf[offset:], '>')
+			if idx == -1 {
+				return -1
+			}
+			end = offset + idx
+			next[0], next[1] = -1, -1
+		}
+	}
+}
+
+// until produces the token at pos ending with suffix which is searched for after the prefix
+func (s *Scanner) until(prefix int, suffix []byte, suffixErr error) (token []byte, chardata bool, err error) {
+	end := bytes.Index(s.buf[s.pos+prefix:], suffix)
+	if end == -1 {
+		return s.buf[s.pos:], false, suffixErr
+	}
+	end += prefix + len(suffix)
+	token = s.buf[s.pos : s.pos+end]
+	s.pos += end
+	return token, false, nil
+}
+
+// next implements Next without any AutoClose, Policy, Limits, SkipWhitespaceCharData, Mode or TrackLines handling
+func (s *Scanner) next() (token []byte, chardata bool, err error) {
+	s.start = s.pos
 	// EOF, no more data
 	if s.pos == len(s.buf) {
 		err = io.EOF
@@ -89,7 +469,7 @@ func (s *Scanner) Next() (token []byte, chardata bool, err error) {
 		end := bytes.Index(s.buf[s.pos+8:], suffixCDATA)
 		if end == -1 {
 			token = s.buf[s.pos:]
-			err = errCDATASuffix
+			err = ErrUnterminatedCDATA
 			return
 		}
 		end += 11 // len(prefixCDATA) + len(suffixCDATA)
@@ -97,11 +477,38 @@ func (s *Scanner) Next() (token []byte, chardata bool, err error) {
 		s.pos += end
 		return
 	}
+	// Comments and ProcInsts end at '-->' and '?>' so their contents may contain '>'
+	if len(s.buf)-s.pos > 1 {
+		switch s.buf[s.pos+1] {
+		case '!':
+			if bytes.HasPrefix(s.buf[s.pos:], prefixComment) {
+				return s.until(len(prefixComment), suffixComment, ErrUnterminatedComment)
+			}
+			// The internal subset of a DOCTYPE contains declarations ending with '>'
+			if bytes.HasPrefix(s.buf[s.pos+2:], prefixDoctype) {
+				end := doctypeEnd(s.buf[s.pos:])
+				if end == -1 {
+					token = s.buf[s.pos:]
+					err = ErrUnterminatedElement
+					return
+				}
+				token = s.buf[s.pos : s.pos+end+1]
+				s.pos += end + 1
+				return
+			}
+		case '?':
+			return s.until(len(prefixProcInst), suffixProcInst, ErrUnterminatedProcInst)
+		}
+	}
 	// Find the end of the element
 	end := bytes.IndexByte(s.buf[s.pos:], '>')
+	// A '>' within a quoted attribute value of a start element does not end it
+	if end > 1 && s.buf[s.pos+1] != '/' && s.buf[s.pos+1] != '!' && s.buf[s.pos+1] != '?' {
+		end = elementEnd(s.buf[s.pos:], end)
+	}
 	if end == -1 {
 		token = s.buf[s.pos:]
-		err = errElementSuffix
+		err = ErrUnterminatedElement
 		return
 	}
 	end++ // len('>')
@@ -110,6 +517,19 @@ func (s *Scanner) Next() (token []byte, chardata bool, err error) {
 	return
 }
 
+// Peek returns the Kind and length in bytes of the next token without advancing the Scanner
+// The token is as it appears in the buffer, before any AutoClose, Policy, Limits or Mode handling
+// When no more tokens are available io.EOF is returned
+func (s *Scanner) Peek() (kind Kind, length int, err error) {
+	pos, start := s.pos, s.start
+	token, chardata, err := s.next()
+	s.pos, s.start = pos, start
+	if err != nil {
+		return 0, 0, tokenError(err, pos)
+	}
+	return TokenKind(token, chardata), len(token), nil
+}
+
 // NextElement calls Next until a Element is reached
 func (s *Scanner) NextElement() (elemToken []byte, err error) {
 	for {
@@ -123,7 +543,38 @@ func (s *Scanner) NextElement() (elemToken []byte, err error) {
 	}
 }
 
+// anyPrefix is the start of a name passed to NextStart which matches any (or no) prefix
+var anyPrefix = []byte("*:")
+
+// NextStart calls Next until a start (or self-closing) element named name is reached skipping all other tokens
+// If name starts with `*:` the local part is matched ignoring any prefix (ex: `*:item` matches `item` and `ns:item`)
+// When no more matching elements are available io.EOF is returned
+func (s *Scanner) NextStart(name []byte) (elemToken []byte, err error) {
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return nil, err
+		} else if chardata || !IsElement(token) || IsEndElement(token) {
+			continue
+		}
+		if startNamed(token, name) {
+			return token, nil
+		}
+	}
+}
+
+// startNamed determines if the name of the start element elemToken is name, see NextStart
+func startNamed(elemToken []byte, name []byte) bool {
+	elemName, _ := Element(elemToken)
+	if bytes.HasPrefix(name, anyPrefix) {
+		_, elemName = Name(elemName)
+		name = name[len(anyPrefix):]
+	}
+	return bytes.Equal(elemName, name)
+}
+
 // Skip will skip until the end of the most recently processed element
+// In ParseStrict mode an error is returned if any end element does not match its start element
 func (s *Scanner) Skip() error {
 	for depth := 1; depth > 0; {
 		// Grab the next token, bail on error
@@ -136,7 +587,7 @@ func (s *Scanner) Skip() error {
 			continue
 		}
 		// If self-closing, has no impact on depth
-		if IsSelfClosing(token) {
+		if s.SelfClosing(token) {
 			continue
 		}
 		// Increment the depth based on an element start/stop
@@ -149,10 +600,84 @@ func (s *Scanner) Skip() error {
 	return nil
 }
 
+// InnerText appends the decoded CharData (including CDATA sections) within the most recently processed
+// (non self-closing) element to scratch skipping any markup, equivalent to the DOM textContent
+func (s *Scanner) InnerText(scratch []byte) ([]byte, error) {
+	for depth := 1; depth > 0; {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return scratch, err
+		}
+		if chardata {
+			if scratch, err = CharDataAppend(scratch, token); err != nil {
+				return scratch, err
+			}
+			continue
+		}
+		if !IsElement(token) || s.SelfClosing(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			depth--
+		} else {
+			depth++
+		}
+	}
+	return scratch, nil
+}
+
+// ElementText appends the decoded CharData (including CDATA sections) directly within the most recently
+// processed (non self-closing) element to scratch skipping any child elements, the same as encoding/xml
+// decodes the text of an element
+func (s *Scanner) ElementText(scratch []byte) ([]byte, error) {
+	for depth := 1; depth > 0; {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return scratch, err
+		}
+		if chardata {
+			if depth == 1 {
+				if scratch, err = CharDataAppend(scratch, token); err != nil {
+					return scratch, err
+				}
+			}
+			continue
+		}
+		if !IsElement(token) || s.SelfClosing(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			depth--
+		} else {
+			depth++
+		}
+	}
+	return scratch, nil
+}
+
+// skipRaw is Skip without any Policy or Mode handling
+func (s *Scanner) skipRaw() error {
+	for depth := 1; depth > 0; {
+		token, chardata, err := s.next()
+		if err != nil {
+			return tokenError(err, s.pos)
+		}
+		if chardata || !IsElement(token) || s.SelfClosing(token) {
+			continue
+		}
+		if !IsEndElement(token) {
+			depth++
+		} else if len(s.AutoClose) == 0 || !s.isAutoClose(token) {
+			depth--
+		}
+	}
+	return nil
+}
+
 // SkipElement extends Skip with a helper for self-closed elements
 // It is faster than SkipToken as it assumes the token is an element
 func (s *Scanner) SkipElement(elemToken []byte) error {
-	if elemToken != nil && IsSelfClosing(elemToken) {
+	if elemToken != nil && s.SelfClosing(elemToken) {
 		return nil
 	}
 	return s.Skip()
@@ -162,16 +687,25 @@ func (s *Scanner) SkipElement(elemToken []byte) error {
 // token is an _optional_ parameter, if present it will check if the
 // element was a self-closed element in which case it will exit immediately
 func (s *Scanner) SkipToken(token []byte) error {
-	if token != nil && IsElement(token) && IsSelfClosing(token) {
+	if token != nil && IsElement(token) && s.SelfClosing(token) {
 		return nil
 	}
 	return s.Skip()
 }
 
-// Reset replaces the buf in scanner to a new slice
+// Reset replaces the buf in scanner to a new slice discarding all state from the previous buf
+// The options (ex: Mode, Policy, Limits) are unchanged
 func (s *Scanner) Reset(buf []byte) {
 	s.buf = buf
 	s.pos = 0
+	s.start = 0
+	s.depth = 0
+	s.progress = 0
+	// Drop the references into the previous buf so it can be garbage collected
+	for idx := range s.open {
+		s.open[idx] = openElement{}
+	}
+	s.open = s.open[:0]
 }
 
 // NewScanner creates a *Scanner for a given byte slice
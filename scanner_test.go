@@ -1,14 +1,16 @@
 package fastxml
 
 import (
+	"errors"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestScanner_Skip(t *testing.T) {
-	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me></nested>more`))
+	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me?></nested>more`))
 	// Read <nested>
 	token, chardata, err := s.Next()
 	assert.NoError(t, err)
@@ -32,7 +34,7 @@ func TestScanner_Skip(t *testing.T) {
 }
 
 func TestScanner_SkipToken(t *testing.T) {
-	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me></nested>more`))
+	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me?></nested>more`))
 	// Skip nothing
 	err := s.SkipToken([]byte("<foo />"))
 	assert.NoError(t, err)
@@ -58,8 +60,63 @@ func TestScanner_SkipToken(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestScanner_AutoClose(t *testing.T) {
+	s := NewScanner([]byte(`<p>line<br>break<img src="x"></img></p>after`))
+	s.AutoClose = []string{"br", "img"}
+	assert.True(t, s.SelfClosing([]byte("<br>")))
+	assert.False(t, s.SelfClosing([]byte("</br>")))
+	assert.False(t, s.SelfClosing([]byte("<p>")))
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	err = s.Skip()
+	assert.NoError(t, err)
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("after"), token)
+	// The end element of an auto-closed element is dropped
+	s.Reset([]byte(`<img></img><p>`))
+	token, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("<img>"), token)
+	token, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("<p>"), token)
+}
+
+func TestScanner_Strict(t *testing.T) {
+	s := NewScanner([]byte(`<a><b/><c></c></a>`))
+	s.Mode = ParseStrict
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Skip())
+	// Without ParseStrict a mismatched end element is silently accepted
+	s = NewScanner([]byte(`<a><b>text</c></a>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Skip())
+	s.Reset([]byte(`<a><b>text</c></a>`))
+	s.Mode = ParseStrict
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	err = s.Skip()
+	assert.Equal(t, &MismatchError{StartName: "b", StartOffset: 3, EndName: "c", EndOffset: 10}, err)
+	assert.EqualError(t, err, "element <b> at offset 3 closed by </c> at offset 10")
+	s.Reset([]byte(`<a></a></b>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Skip())
+	_, _, err = s.Next()
+	assert.EqualError(t, err, "unexpected end element </b> at offset 7")
+	// AutoClose elements are never open
+	s.Reset([]byte(`<p><br></br></p>`))
+	s.AutoClose = []string{"br"}
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Skip())
+}
+
 func TestScanner_Seek(t *testing.T) {
-	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me></nested>more`))
+	s := NewScanner([]byte(`<nested><element>with data</element><closing/><?skip me?></nested>more`))
 	// Read <nested>
 	token, chardata, err := s.Next()
 	assert.NoError(t, err)
@@ -103,7 +160,7 @@ func TestScanner(t *testing.T) {
 	}
 	testCases := []struct {
 		Input    string
-		Error    string
+		Error    error
 		Expected []result
 	}{
 		{
@@ -147,12 +204,70 @@ func TestScanner(t *testing.T) {
 					CharData: true,
 				},
 			},
+		}, {
+			Input: `<a title="x > y" b=">"/><b c = ">"></b>`,
+			Expected: []result{
+				{
+					Token: []byte(`<a title="x > y" b=">"/>`),
+				}, {
+					Offset: 24,
+					Token:  []byte(`<b c = ">">`),
+				}, {
+					Offset: 35,
+					Token:  []byte(`</b>`),
+				},
+			},
+		}, {
+			Input: `<a title='x > y'>t</a><b c='say "hi" >' d="it's >"/>`,
+			Expected: []result{
+				{
+					Token: []byte(`<a title='x > y'>`),
+				}, {
+					Offset:   17,
+					Token:    []byte(`t`),
+					CharData: true,
+				}, {
+					Offset: 18,
+					Token:  []byte(`</a>`),
+				}, {
+					Offset: 22,
+					Token:  []byte(`<b c='say "hi" >' d="it's >"/>`),
+				},
+			},
+		}, {
+			Input: `<!-- a > b --><!----><?pi a > b?>`,
+			Expected: []result{
+				{
+					Token: []byte(`<!-- a > b -->`),
+				}, {
+					Offset: 14,
+					Token:  []byte(`<!---->`),
+				}, {
+					Offset: 21,
+					Token:  []byte(`<?pi a > b?>`),
+				},
+			},
+		}, {
+			Input: `<!-- unterminated > comment`,
+			Error: ErrUnterminatedComment,
+		}, {
+			Input: `<!-->`,
+			Error: ErrUnterminatedComment,
+		}, {
+			Input: `<?pi unterminated>`,
+			Error: ErrUnterminatedProcInst,
 		}, {
 			Input: `<unterminated`,
-			Error: `expected Token to end with '>'`,
+			Error: ErrUnterminatedElement,
+		}, {
+			Input: `<unterminated a="x>`,
+			Error: ErrUnterminatedElement,
+		}, {
+			Input: `<unterminated a='x" b="y>`,
+			Error: ErrUnterminatedElement,
 		}, {
 			Input: `<![CDATA[unterminated`,
-			Error: `expected Token to end with ']]>'`,
+			Error: ErrUnterminatedCDATA,
 		},
 	}
 	for _, tc := range testCases {
@@ -177,8 +292,8 @@ func TestScanner(t *testing.T) {
 					break
 				}
 			}
-			if tc.Error != "" {
-				assert.EqualError(t, err, tc.Error)
+			if tc.Error != nil {
+				assert.True(t, errors.Is(err, tc.Error), "expected %v to be %v", err, tc.Error)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.Expected, actual)
@@ -201,3 +316,295 @@ func BenchmarkScanner(b *testing.B) {
 		}
 	}
 }
+
+func TestScanner_TrackLines(t *testing.T) {
+	s := NewScanner([]byte("<a>\n  <b>\n  <c"))
+	line, column := s.Position(6)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, 3, column)
+	s.TrackLines = true
+	for i := 0; i < 4; i++ {
+		_, _, err := s.Next()
+		assert.NoError(t, err)
+	}
+	_, _, err := s.Next()
+	assert.EqualError(t, err, "syntax error at line 3, column 3: expected Token to end with '>'")
+	assert.True(t, errors.Is(err, ErrUnterminatedElement))
+	// The offset of a mismatched end element is reported
+	s.Reset([]byte("<a>\n</b>"))
+	s.Mode = ParseStrict
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	_, _, err = s.Next()
+	assert.EqualError(t, err, "syntax error at line 2, column 1: element <a> at offset 0 closed by </b> at offset 4")
+	var mismatch *MismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	// io.EOF is never wrapped
+	_, _, err = s.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestScanner_TokenRange(t *testing.T) {
+	buf := []byte(`<a x="1">text<!-- c --></a>`)
+	s := NewScanner(buf)
+	var tokens []string
+	for {
+		token, _, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		start, end := s.TokenRange()
+		assert.Equal(t, string(token), string(buf[start:end]))
+		tokens = append(tokens, string(buf[start:end]))
+	}
+	assert.Equal(t, []string{`<a x="1">`, `text`, `<!-- c -->`, `</a>`}, tokens)
+	// The range of a rewritten token is of the original token
+	s.Reset(buf)
+	s.Policy = &Policy{AllowAttrs: []string{"y"}, Strip: true}
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, `<a>`, string(token))
+	start, end := s.TokenRange()
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 9, end)
+}
+
+func TestScanner_InnerText(t *testing.T) {
+	s := NewScanner([]byte(`<item><title>A &amp; B</title><!-- c --><desc><![CDATA[<b>bold</b>]]> text<br/></desc></item><next>`))
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	text, err := s.InnerText(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "A & B<b>bold</b> text", string(text))
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "<next>", string(token))
+	_, err = s.InnerText(nil)
+	assert.Equal(t, io.EOF, err)
+	s.Reset([]byte(`<a>&bogus;</a>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	_, err = s.InnerText(nil)
+	assert.Error(t, err)
+}
+
+func TestScanner_ElementText(t *testing.T) {
+	s := NewScanner([]byte(`<item>A &amp; <b>bold <i>x</i></b><!-- c --><![CDATA[<B>]]><br/></item><next>`))
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	text, err := s.ElementText([]byte("prefix:"))
+	assert.NoError(t, err)
+	assert.Equal(t, "prefix:A & <B>", string(text))
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "<next>", string(token))
+	_, err = s.ElementText(nil)
+	assert.Equal(t, io.EOF, err)
+	s.Reset([]byte(`<a>&bogus;</a>`))
+	_, _, err = s.Next()
+	assert.NoError(t, err)
+	_, err = s.ElementText(nil)
+	assert.Error(t, err)
+}
+
+func TestScanner_NextStart(t *testing.T) {
+	s := NewScanner([]byte(`<feed><item>1</item><!-- <item> --><ns:item id="2"/><items/></feed><ns:item/>`))
+	token, err := s.NextStart([]byte("item"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<item>", string(token))
+	token, err = s.NextStart([]byte("ns:item"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<ns:item id="2"/>`, string(token))
+	_, err = s.NextStart([]byte("item"))
+	assert.Equal(t, io.EOF, err)
+	s.Reset(s.buf)
+	var names []string
+	for {
+		token, err := s.NextStart([]byte("*:item"))
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+		names = append(names, string(token))
+	}
+	assert.Equal(t, []string{"<item>", `<ns:item id="2"/>`, "<ns:item/>"}, names)
+	s.Reset([]byte(`<a><item`))
+	_, err = s.NextStart([]byte("item"))
+	assert.EqualError(t, err, "expected Token to end with '>' at offset 3")
+}
+
+func TestScanner_NextKind(t *testing.T) {
+	s := NewScanner([]byte(`<?xml version="1.0"?><!DOCTYPE a><a>text<![CDATA[x]]><b/><!-- c --></a>`))
+	var kinds []Kind
+	for {
+		_, kind, err := s.NextKind()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		kinds = append(kinds, kind)
+	}
+	assert.Equal(t, []Kind{
+		KindProcInst, KindDirective, KindStartElement, KindCharData, KindCDATA,
+		KindSelfClosing, KindComment, KindEndElement,
+	}, kinds)
+	s.Reset([]byte(`<a`))
+	_, _, err := s.NextKind()
+	assert.True(t, errors.Is(err, ErrUnterminatedElement))
+}
+
+func TestScanner_Peek(t *testing.T) {
+	s := NewScanner([]byte(`<?xml version="1.0"?><a>text<![CDATA[x]]><b/><!-- c --></a><x`))
+	expected := []struct {
+		Kind   Kind
+		Length int
+	}{
+		{KindProcInst, 21}, {KindStartElement, 3}, {KindCharData, 4}, {KindCDATA, 13},
+		{KindSelfClosing, 4}, {KindComment, 10}, {KindEndElement, 4},
+	}
+	for _, e := range expected {
+		kind, length, err := s.Peek()
+		assert.NoError(t, err)
+		assert.Equal(t, e.Kind, kind)
+		assert.Equal(t, e.Length, length)
+		// Peeking again does not advance
+		offset := s.Offset()
+		kind, _, _ = s.Peek()
+		assert.Equal(t, e.Kind, kind)
+		assert.Equal(t, offset, s.Offset())
+		token, _, err := s.Next()
+		assert.NoError(t, err)
+		assert.Len(t, token, e.Length)
+	}
+	_, _, err := s.Peek()
+	assert.Equal(t, &TokenError{Offset: 59, Err: ErrUnterminatedElement}, err)
+	s.Reset(nil)
+	_, _, err = s.Peek()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestScanner_Checkpoint(t *testing.T) {
+	s := NewScanner([]byte(`<a><b>x</b><c/></a>`))
+	s.Mode = ParseStrict
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	cp := s.Checkpoint()
+	for i := 0; i < 2; i++ {
+		var tokens []string
+		for {
+			token, _, err := s.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			tokens = append(tokens, string(token))
+		}
+		assert.Equal(t, []string{`<b>`, `x`, `</b>`, `<c/>`, `</a>`}, tokens)
+		// The open elements are restored so </a> still balances
+		s.Restore(cp)
+	}
+	assert.Equal(t, 3, s.Offset())
+}
+
+func TestScanner_TrimCharData(t *testing.T) {
+	input := []byte("<a>\n  <b> text\n</b>\n  <c><![CDATA[ ]]></c>\n</a>\n")
+	s := NewScanner(input)
+	s.TrimCharData = true
+	var tokens []string
+	for {
+		token, _, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		tokens = append(tokens, string(token))
+	}
+	assert.Equal(t, []string{`<a>`, `<b>`, `text`, `</b>`, `<c>`, `<![CDATA[ ]]>`, `</c>`, `</a>`}, tokens)
+	// The input is not modified
+	assert.Equal(t, "<a>\n  <b> text\n</b>\n  <c><![CDATA[ ]]></c>\n</a>\n", string(input))
+}
+
+func TestScanner_SkipWhitespaceCharData(t *testing.T) {
+	s := NewScanner([]byte("<a>\n  <b> text </b>\n  <c><![CDATA[ ]]></c>\n</a>\n"))
+	s.SkipWhitespaceCharData = true
+	var tokens []string
+	for {
+		token, _, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		tokens = append(tokens, string(token))
+	}
+	assert.Equal(t, []string{`<a>`, `<b>`, ` text `, `</b>`, `<c>`, `<![CDATA[ ]]>`, `</c>`, `</a>`}, tokens)
+}
+
+func TestScanner_ResetOpen(t *testing.T) {
+	s := NewScanner([]byte(`<a><b>`))
+	s.Mode = ParseStrict
+	_, _, _ = s.Next()
+	_, _, _ = s.Next()
+	open := s.open[:2]
+	s.Reset([]byte(`<c/>`))
+	assert.Empty(t, s.open)
+	assert.Equal(t, []openElement{{}, {}}, open)
+	assert.Equal(t, ParseStrict, s.Mode)
+}
+
+func TestScanner_Doctype(t *testing.T) {
+	s := NewScanner([]byte(`<!DOCTYPE a [<!ENTITY e "b>c"><!-- ] > --><?pi ]>?><!ATTLIST a x CDATA '>'>]><a>&e;</a>`))
+	var tokens []string
+	for {
+		token, _, err := s.Next()
+		if err != nil {
+			break
+		}
+		tokens = append(tokens, string(token))
+	}
+	assert.Equal(t, []string{`<!DOCTYPE a [<!ENTITY e "b>c"><!-- ] > --><?pi ]>?><!ATTLIST a x CDATA '>'>]>`, `<a>`, `&e;`, `</a>`}, tokens)
+
+	s = NewScanner([]byte(`<!DOCTYPE a [<!ENTITY e "b>c">`))
+	_, _, err := s.Next()
+	assert.True(t, errors.Is(err, ErrUnterminatedElement))
+}
+
+func TestScanner_Progress(t *testing.T) {
+	input := []byte(`<a>` + strings.Repeat(`<b>text</b>`, 10) + `</a>`)
+	s := NewScanner(input)
+	s.ProgressInterval = 30
+	var offsets []int
+	s.Progress = func(offset int) {
+		assert.Equal(t, offset, s.Offset())
+		offsets = append(offsets, offset)
+	}
+	for {
+		if _, _, err := s.Next(); err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+	}
+	assert.Equal(t, []int{3, 36, 69, 102, 117}, offsets)
+	assert.Equal(t, 0, s.Remaining())
+	s.Seek(100, io.SeekStart)
+	assert.Equal(t, 17, s.Remaining())
+	// Reset starts reporting again
+	offsets = nil
+	s.Reset([]byte(`<a/>`))
+	_, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4}, offsets)
+}
+
+func TestScanner_SelfClosingWhitespace(t *testing.T) {
+	s := NewScanner([]byte(`<a><b / ><c href='/'>x</c><c href="/" title='>'>y</c></a><d/>`))
+	token, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, `<a>`, string(token))
+	assert.NoError(t, s.Skip())
+	token, _, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, `<d/>`, string(token))
+}
@@ -0,0 +1,290 @@
+// Package soap locates the parts of a SOAP 1.1 or 1.2 envelope using fastxml.Scanner without unmarshaling
+package soap
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Namespaces of the SOAP envelope
+const (
+	Namespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	Namespace12 = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// Errors returned by Parse
+var (
+	ErrNotEnvelope = errors.New("soap: document element is not a SOAP Envelope")
+	ErrNoBody      = errors.New("soap: Envelope has no Body")
+)
+
+// Envelope is a parsed SOAP envelope, the byte slices reference the parsed buffer
+type Envelope struct {
+	Namespace string // Namespace11 or Namespace12
+	Header    []byte // inner XML of the Header, nil if there is no Header
+	Body      []byte // inner XML of the Body
+	Payload   []byte // the first element of the Body (including its content), nil if the Body is empty
+	// Namespace URI and local name of the Payload element, used to route on the request
+	PayloadSpace string
+	PayloadLocal []byte
+}
+
+// Parse locates the Header, Body and the payload of the SOAP envelope in buf
+func Parse(buf []byte) (*Envelope, error) {
+	var ns fastxml.Namespaces
+	s := fastxml.NewScanner(buf)
+	token, err := s.NextElement()
+	if err == io.EOF || (err == nil && fastxml.IsEndElement(token)) {
+		return nil, ErrNotEnvelope
+	} else if err != nil {
+		return nil, err
+	}
+	if err := ns.Push(token); err != nil {
+		return nil, err
+	}
+	name, _ := fastxml.Element(token)
+	space, local := ns.Resolve(name, false)
+	if (space != Namespace11 && space != Namespace12) || fastxml.String(local) != "Envelope" {
+		return nil, ErrNotEnvelope
+	}
+	if fastxml.IsSelfClosing(token) {
+		return nil, ErrNoBody
+	}
+	env := &Envelope{Namespace: space}
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return nil, ErrNoBody
+		} else if err != nil {
+			return nil, err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		} else if fastxml.IsEndElement(token) {
+			return nil, ErrNoBody
+		}
+		if err := ns.Push(token); err != nil {
+			return nil, err
+		}
+		name, _ := fastxml.Element(token)
+		space, local := ns.Resolve(name, false)
+		switch {
+		case space != env.Namespace:
+			if err := s.SkipElement(token); err != nil {
+				return nil, err
+			}
+		case fastxml.String(local) == "Header":
+			if env.Header, err = inner(s, buf, token); err != nil {
+				return nil, err
+			}
+		case fastxml.String(local) == "Body":
+			if err := env.body(s, &ns, buf, token); err != nil {
+				return nil, err
+			}
+			return env, nil
+		default:
+			if err := s.SkipElement(token); err != nil {
+				return nil, err
+			}
+		}
+		if err := ns.Pop(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// body reads the Body element bodyToken locating the payload
+func (env *Envelope) body(s *fastxml.Scanner, ns *fastxml.Namespaces, buf []byte, bodyToken []byte) error {
+	start := s.Offset()
+	if fastxml.IsSelfClosing(bodyToken) {
+		env.Body = buf[start:start]
+		return nil
+	}
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		} else if fastxml.IsEndElement(token) {
+			end, _ := s.TokenRange()
+			env.Body = buf[start:end]
+			return nil
+		}
+		payloadStart, _ := s.TokenRange()
+		if env.Payload == nil {
+			if err := ns.Push(token); err != nil {
+				return err
+			}
+			name, _ := fastxml.Element(token)
+			env.PayloadSpace, env.PayloadLocal = ns.Resolve(name, false)
+			if err := ns.Pop(); err != nil {
+				return err
+			}
+		}
+		if err := s.SkipElement(token); err != nil {
+			return err
+		}
+		if env.Payload == nil {
+			_, payloadEnd := s.TokenRange()
+			env.Payload = buf[payloadStart:payloadEnd]
+		}
+	}
+}
+
+// inner returns the inner XML of the element elemToken most recently returned by s
+func inner(s *fastxml.Scanner, buf []byte, elemToken []byte) ([]byte, error) {
+	start := s.Offset()
+	if fastxml.IsSelfClosing(elemToken) {
+		return buf[start:start], nil
+	}
+	if err := s.Skip(); err != nil {
+		return nil, err
+	}
+	end, _ := s.TokenRange()
+	return buf[start:end], nil
+}
+
+// text returns the trimmed text content of the element elemToken most recently returned by s
+func text(s *fastxml.Scanner, elemToken []byte) (string, error) {
+	if fastxml.IsSelfClosing(elemToken) {
+		return "", nil
+	}
+	text, err := s.InnerText(nil)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(text)), nil
+}
+
+// IsFault determines if the payload of the envelope is a SOAP Fault
+func (env *Envelope) IsFault() bool {
+	return env.PayloadSpace == env.Namespace && fastxml.String(env.PayloadLocal) == "Fault"
+}
+
+// Fault is a SOAP Fault, the fields are named after SOAP 1.1 with the SOAP 1.2 equivalent noted
+type Fault struct {
+	Code    string // faultcode or Code/Value (ex: "soap:Server")
+	Subcode string // Code/Subcode/Value (SOAP 1.2 only)
+	String  string // faultstring or the first Reason/Text
+	Actor   string // faultactor or Role
+	Node    string // Node (SOAP 1.2 only)
+	Detail  []byte // inner XML of detail or Detail, nil if absent
+}
+
+// Fault parses the payload of the envelope as a SOAP Fault, returning nil if it is not a Fault
+func (env *Envelope) Fault() (*Fault, error) {
+	if !env.IsFault() {
+		return nil, nil
+	}
+	var fault Fault
+	s := fastxml.NewScanner(env.Payload)
+	faultToken, err := s.NextElement()
+	if err != nil {
+		return nil, err
+	} else if fastxml.IsSelfClosing(faultToken) {
+		return &fault, nil
+	}
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return nil, err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		} else if fastxml.IsEndElement(token) {
+			return &fault, nil
+		}
+		name, _ := fastxml.Element(token)
+		_, local := fastxml.Name(name)
+		switch fastxml.String(local) {
+		case "faultcode":
+			fault.Code, err = text(s, token)
+		case "faultstring":
+			fault.String, err = text(s, token)
+		case "faultactor", "Role":
+			fault.Actor, err = text(s, token)
+		case "Node":
+			fault.Node, err = text(s, token)
+		case "detail", "Detail":
+			fault.Detail, err = inner(s, env.Payload, token)
+		case "Code":
+			fault.Code, fault.Subcode, err = code(s, token)
+		case "Reason":
+			fault.String, err = reason(s, token)
+		default:
+			err = s.SkipElement(token)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// code reads the Value and Subcode/Value of the SOAP 1.2 Code (or Subcode) element codeToken
+func code(s *fastxml.Scanner, codeToken []byte) (value string, subcode string, err error) {
+	if fastxml.IsSelfClosing(codeToken) {
+		return "", "", nil
+	}
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return "", "", err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		} else if fastxml.IsEndElement(token) {
+			return value, subcode, nil
+		}
+		name, _ := fastxml.Element(token)
+		switch {
+		case fastxml.MatchLocal(name, "Value"):
+			value, err = text(s, token)
+		case fastxml.MatchLocal(name, "Subcode") && subcode == "":
+			subcode, _, err = code(s, token)
+		default:
+			err = s.SkipElement(token)
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+}
+
+// reason reads the first Text of the SOAP 1.2 Reason element reasonToken
+func reason(s *fastxml.Scanner, reasonToken []byte) (string, error) {
+	if fastxml.IsSelfClosing(reasonToken) {
+		return "", nil
+	}
+	var result string
+	found := false
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return "", err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		} else if fastxml.IsEndElement(token) {
+			return result, nil
+		}
+		name, _ := fastxml.Element(token)
+		if !found && fastxml.MatchLocal(name, "Text") {
+			found = true
+			if fastxml.IsSelfClosing(token) {
+				continue
+			}
+			value, err := s.InnerText(nil)
+			if err != nil {
+				return "", err
+			}
+			result = string(bytes.TrimSpace(value))
+		} else if err := s.SkipElement(token); err != nil {
+			return "", err
+		}
+	}
+}
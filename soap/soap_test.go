@@ -0,0 +1,116 @@
+package soap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	const input = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Header><auth>token</auth></soap:Header>
+  <soap:Body xmlns:m="urn:stock">
+    <m:GetPrice><m:Item>Apples</m:Item></m:GetPrice>
+  </soap:Body>
+</soap:Envelope>`
+	env, err := Parse([]byte(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, Namespace11, env.Namespace)
+	assert.Equal(t, `<auth>token</auth>`, string(env.Header))
+	assert.Equal(t, "\n    <m:GetPrice><m:Item>Apples</m:Item></m:GetPrice>\n  ", string(env.Body))
+	assert.Equal(t, `<m:GetPrice><m:Item>Apples</m:Item></m:GetPrice>`, string(env.Payload))
+	assert.Equal(t, "urn:stock", env.PayloadSpace)
+	assert.Equal(t, "GetPrice", string(env.PayloadLocal))
+	assert.False(t, env.IsFault())
+	fault, err := env.Fault()
+	assert.NoError(t, err)
+	assert.Nil(t, fault)
+}
+
+func TestParse_Errors(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Input string
+		Error error
+	}{
+		{Name: "Empty", Input: ``, Error: ErrNotEnvelope},
+		{Name: "NotSOAP", Input: `<Envelope><Body/></Envelope>`, Error: ErrNotEnvelope},
+		{Name: "SelfClosing", Input: `<e:Envelope xmlns:e="http://www.w3.org/2003/05/soap-envelope"/>`, Error: ErrNoBody},
+		{Name: "NoBody", Input: `<e:Envelope xmlns:e="http://www.w3.org/2003/05/soap-envelope"><e:Header/></e:Envelope>`, Error: ErrNoBody},
+		{Name: "OtherBody", Input: `<e:Envelope xmlns:e="http://www.w3.org/2003/05/soap-envelope"><Body/></e:Envelope>`, Error: ErrNoBody},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.Input))
+			assert.Equal(t, tc.Error, err)
+		})
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	env, err := Parse([]byte(`<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Body/></Envelope>`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, Namespace12, env.Namespace)
+		assert.Nil(t, env.Header)
+		assert.Equal(t, "", string(env.Body))
+		assert.Nil(t, env.Payload)
+	}
+}
+
+func TestEnvelope_Fault(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected Fault
+	}{
+		{
+			Name: "SOAP11",
+			Input: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>
+<faultcode>soap:Server</faultcode>
+<faultstring> Price &amp; stock unavailable </faultstring>
+<faultactor>urn:backend</faultactor>
+<detail><e:code xmlns:e="urn:err">42</e:code></detail>
+</soap:Fault></soap:Body></soap:Envelope>`,
+			Expected: Fault{
+				Code:   "soap:Server",
+				String: "Price & stock unavailable",
+				Actor:  "urn:backend",
+				Detail: []byte(`<e:code xmlns:e="urn:err">42</e:code>`),
+			},
+		},
+		{
+			Name: "SOAP12",
+			Input: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>
+<env:Code><env:Value>env:Sender</env:Value><env:Subcode><env:Value>m:MessageTimeout</env:Value></env:Subcode></env:Code>
+<env:Reason><env:Text xml:lang="en">Sender Timeout</env:Text><env:Text xml:lang="nl">Time-out</env:Text></env:Reason>
+<env:Node>urn:node</env:Node>
+<env:Role>urn:role</env:Role>
+<env:Detail/>
+</env:Fault></env:Body></env:Envelope>`,
+			Expected: Fault{
+				Code:    "env:Sender",
+				Subcode: "m:MessageTimeout",
+				String:  "Sender Timeout",
+				Actor:   "urn:role",
+				Node:    "urn:node",
+				Detail:  []byte{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			env, err := Parse([]byte(tc.Input))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, env.IsFault())
+			fault, err := env.Fault()
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.Expected, *fault)
+			}
+		})
+	}
+}
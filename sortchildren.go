@@ -0,0 +1,60 @@
+package fastxml
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SortKey extracts the value sibling elements are ordered by from a start element token
+type SortKey func(elemToken []byte) []byte
+
+// SortByName orders sibling elements by their name
+func SortByName() SortKey {
+	return func(elemToken []byte) []byte {
+		name, _ := Element(elemToken)
+		return name
+	}
+}
+
+// SortByAttr orders sibling elements by the raw value of the attribute key
+// Elements without the attribute (or with malformed attributes) are ordered first
+func SortByAttr(key string) SortKey {
+	attrKey := []byte(key)
+	return func(elemToken []byte) []byte {
+		_, attrs := Element(elemToken)
+		value, _ := Attr(attrs, attrKey)
+		return value
+	}
+}
+
+// sortChildren recursively orders the child elements of n
+func sortChildren(n *Node, key SortKey) {
+	var elems []*Node
+	for _, child := range n.Children {
+		if child.Kind == ElementNode {
+			elems = append(elems, child)
+			sortChildren(child, key)
+		}
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		return bytes.Compare(key(elems[i].Token), key(elems[j].Token)) < 0
+	})
+	// Only the element positions are re-used, text, comments etc. are not moved
+	for idx, child := range n.Children {
+		if child.Kind == ElementNode {
+			n.Children[idx], elems = elems[0], elems[1:]
+		}
+	}
+}
+
+// SortChildren appends src to dst with the child elements of every element ordered (stably) by key
+// This normalizes documents where the order of siblings is not significant before diffing or serializing
+// Text, comments and other nodes keep their position, start elements are re-serialized
+func SortChildren(dst, src []byte, key SortKey) ([]byte, error) {
+	tree, err := Parse(src)
+	if err != nil {
+		return dst, err
+	}
+	sortChildren(tree, key)
+	return tree.appendXML(dst), nil
+}
@@ -0,0 +1,48 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortChildren(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Key      SortKey
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "name",
+			Key:      SortByName(),
+			Input:    "<root>\n<c/>\n<a><z/><y/></a>\n<!--b--><b/></root>",
+			Expected: "<root>\n<a><y/><z/></a>\n<b/>\n<!--b--><c/></root>",
+		},
+		{
+			Name:     "attr",
+			Key:      SortByAttr("id"),
+			Input:    `<root><item id="2">two</item><item id="1">one</item><item>none</item></root>`,
+			Expected: `<root><item>none</item><item id="1">one</item><item id="2">two</item></root>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := SortChildren(nil, []byte(tc.Input), tc.Key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(actual))
+		})
+	}
+	_, err := SortChildren(nil, []byte(`<root>`), SortByName())
+	assert.Error(t, err)
+}
+
+func TestSortChildren_Diff(t *testing.T) {
+	a, err := SortChildren(nil, []byte(`<root><b/><a/></root>`), SortByName())
+	assert.NoError(t, err)
+	b, err := SortChildren(nil, []byte(`<root><a/><b/></root>`), SortByName())
+	assert.NoError(t, err)
+	report, err := DiffText(a, b)
+	assert.NoError(t, err)
+	assert.Empty(t, report)
+}
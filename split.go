@@ -0,0 +1,117 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+)
+
+// splitter locates records using raw byte searches instead of tokenizing
+type splitter struct {
+	buf   []byte
+	open  []byte // `<name`
+	close []byte // `</name`
+}
+
+// isNameEnd checks if the byte following an element name in a token ends the name
+func isNameEnd(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '/', '>':
+		return true
+	}
+	return false
+}
+
+// find returns the offset of the next needle at or after offset which is followed by the end of the name (or -1)
+func (sp *splitter) find(offset int, needle []byte) int {
+	for {
+		idx := bytes.Index(sp.buf[offset:], needle)
+		if idx == -1 {
+			return -1
+		}
+		offset += idx + len(needle)
+		if offset < len(sp.buf) && isNameEnd(sp.buf[offset]) {
+			return offset - len(needle)
+		}
+	}
+}
+
+// tagEnd returns the offset after the '>' ending the tag at offset (or -1)
+func (sp *splitter) tagEnd(offset int) int {
+	idx := bytes.IndexByte(sp.buf[offset:], '>')
+	if idx > 0 {
+		idx = elementEnd(sp.buf[offset:], idx)
+	}
+	if idx == -1 {
+		return -1
+	}
+	return offset + idx + 1
+}
+
+// record returns the byte range of the record starting at start
+func (sp *splitter) record(start int) (int, error) {
+	end := sp.tagEnd(start)
+	if end == -1 {
+		return -1, io.ErrUnexpectedEOF
+	} else if IsSelfClosing(sp.buf[start:end]) {
+		return end, nil
+	}
+	nextOpen := sp.find(end, sp.open)
+	for depth := 1; ; {
+		nextClose := sp.find(end, sp.close)
+		if nextClose == -1 {
+			return -1, io.ErrUnexpectedEOF
+		}
+		// A nested element of the same name
+		if nextOpen != -1 && nextOpen < nextClose {
+			if end = sp.tagEnd(nextOpen); end == -1 {
+				return -1, io.ErrUnexpectedEOF
+			}
+			if !IsSelfClosing(sp.buf[nextOpen:end]) {
+				depth++
+			}
+			nextOpen = sp.find(end, sp.open)
+			continue
+		}
+		if end = sp.tagEnd(nextClose); end == -1 {
+			return -1, io.ErrUnexpectedEOF
+		}
+		if depth--; depth == 0 {
+			return end, nil
+		}
+	}
+}
+
+// SplitFunc calls f with every (outermost) element named elementName in buf, stopping if f returns false
+// Records are located with raw byte searches instead of tokenizing the document so that the records
+// can be fanned out for parallel processing, the name must not appear in comments or CDATA sections
+func SplitFunc(buf []byte, elementName []byte, f func(record []byte) bool) error {
+	sp := &splitter{
+		buf:   buf,
+		open:  append([]byte{'<'}, elementName...),
+		close: append([]byte{'<', '/'}, elementName...),
+	}
+	for offset := 0; ; {
+		start := sp.find(offset, sp.open)
+		if start == -1 {
+			return nil
+		}
+		end, err := sp.record(start)
+		if err != nil {
+			return err
+		}
+		if !f(buf[start:end]) {
+			return nil
+		}
+		offset = end
+	}
+}
+
+// Split returns every (outermost) element named elementName in buf, see SplitFunc
+func Split(buf []byte, elementName []byte) ([][]byte, error) {
+	var records [][]byte
+	err := SplitFunc(buf, elementName, func(record []byte) bool {
+		records = append(records, record)
+		return true
+	})
+	return records, err
+}
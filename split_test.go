@@ -0,0 +1,70 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected []string
+		Error    error
+	}{
+		{
+			Name:     "records",
+			Input:    `<feed><entry id="1">a</entry><entryx/><entry/>` + "\n" + `<entry` + "\n" + `id="3"><b/></entry></feed>`,
+			Expected: []string{`<entry id="1">a</entry>`, `<entry/>`, "<entry\nid=\"3\"><b/></entry>"},
+		}, {
+			Name:     "nested",
+			Input:    `<entry><entry>a</entry><entry/></entry ><entry>b</entry>`,
+			Expected: []string{`<entry><entry>a</entry><entry/></entry >`, `<entry>b</entry>`},
+		}, {
+			Name:     "quoted",
+			Input:    `<entry title="a > b">x</entry><entry b=">"/><entry c='it"s >'/>`,
+			Expected: []string{`<entry title="a > b">x</entry>`, `<entry b=">"/>`, `<entry c='it"s >'/>`},
+		}, {
+			Name:     "whitespace",
+			Input:    `<entry / ><entry><entry /></entry>`,
+			Expected: []string{`<entry / >`, `<entry><entry /></entry>`},
+		}, {
+			Name:  "none",
+			Input: `<feed><other/></feed>`,
+		}, {
+			Name:  "unclosed",
+			Input: `<entry>a</entry><entry><entry>b</entry>`,
+			Error: io.ErrUnexpectedEOF,
+		}, {
+			Name:  "unterminated",
+			Input: `<entry id="1"`,
+			Error: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			records, err := Split([]byte(tc.Input), []byte("entry"))
+			if tc.Error != nil {
+				assert.Equal(t, tc.Error, err)
+				return
+			}
+			assert.NoError(t, err)
+			var actual []string
+			for _, record := range records {
+				actual = append(actual, string(record))
+			}
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestSplitFunc(t *testing.T) {
+	var records []string
+	assert.NoError(t, SplitFunc([]byte(`<a>1</a><a>2</a>`), []byte("a"), func(record []byte) bool {
+		records = append(records, string(record))
+		return false
+	}))
+	assert.Equal(t, []string{`<a>1</a>`}, records)
+}
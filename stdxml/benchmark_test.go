@@ -0,0 +1,111 @@
+package stdxml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+var benchCache struct {
+	sync.Once
+	b   []byte
+	err error
+}
+
+func benchData(b *testing.B) []byte {
+	benchCache.Do(func() {
+		f, err := os.Open("../benchmark_SwissProt.xml.gz")
+		if err != nil {
+			benchCache.err = err
+			return
+		}
+		defer f.Close()
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			benchCache.err = err
+			return
+		}
+		defer gr.Close()
+		b, err := ioutil.ReadAll(gr)
+		if err != nil {
+			benchCache.err = err
+			return
+		}
+		benchCache.b = b
+	})
+	if benchCache.err != nil {
+		b.Fatalf("failed to load benchmark data: %v", benchCache.err)
+	}
+	return benchCache.b
+}
+
+func BenchmarkEncodingXMLDecoder(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			_, err := d.RawToken()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkXMLTokenReader(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		d := NewTokenReader(fastxml.NewScanner(data))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkXMLTokenReader_Release(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		d := NewTokenReader(fastxml.NewScanner(data))
+		for {
+			token, err := d.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+			if start, ok := token.(xml.StartElement); ok {
+				ReleaseStartElement(start)
+			}
+		}
+	}
+}
+
+func BenchmarkXMLTokenReader_Reuse(b *testing.B) {
+	data := benchData(b)
+	for n := 0; n < b.N; n++ {
+		d := NewTokenReader(fastxml.NewScanner(data))
+		d.Reuse = true
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
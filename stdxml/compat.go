@@ -0,0 +1,94 @@
+package stdxml
+
+import (
+	"encoding/xml"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// The XML prefixed names were previously exported by the fastxml package itself,
+// they are kept so migrating only requires changing the import path
+
+// XMLCharData produces a xml.CharData given a token
+//
+// Deprecated: use CharData
+func XMLCharData(token []byte, scratch []byte) (xml.CharData, error) {
+	return CharData(token, scratch)
+}
+
+// XMLDirective produces a xml.Directive given a token
+//
+// Deprecated: use Directive
+func XMLDirective(token []byte) xml.Directive {
+	return Directive(token)
+}
+
+// XMLComment produces a xml.Comment given a token
+//
+// Deprecated: use Comment
+func XMLComment(token []byte) xml.Comment {
+	return Comment(token)
+}
+
+// XMLProcInst produces a xml.ProcInst given a token
+//
+// Deprecated: use ProcInst
+func XMLProcInst(token []byte) xml.ProcInst {
+	return ProcInst(token)
+}
+
+// XMLName produces a xml.Name given a token
+//
+// Deprecated: use Name
+func XMLName(token []byte) xml.Name {
+	return Name(token)
+}
+
+// XMLAttr produces a xml.Attr given a key, value
+//
+// Deprecated: use Attr
+func XMLAttr(key []byte, value []byte) (xml.Attr, error) {
+	return Attr(key, value)
+}
+
+// XMLAttrs produces a []xml.Attr given attributes slice
+//
+// Deprecated: use Attrs
+func XMLAttrs(token []byte) ([]xml.Attr, error) {
+	return Attrs(token)
+}
+
+// XMLStartElement produces a xml.StartElement given a token
+//
+// Deprecated: use StartElement
+func XMLStartElement(token []byte) (xml.StartElement, error) {
+	return StartElement(token)
+}
+
+// XMLEndElement produces a xml.EndElement given a token
+//
+// Deprecated: use EndElement
+func XMLEndElement(token []byte) xml.EndElement {
+	return EndElement(token)
+}
+
+// XMLElement produces a xml.EndElement or xml.StartElement depending on IsEndElement
+//
+// Deprecated: use Element
+func XMLElement(token []byte) (xml.Token, error) {
+	return Element(token)
+}
+
+// XMLToken produces a xml.Token given a piece of data
+//
+// Deprecated: use Token
+func XMLToken(token []byte, chardata bool) (xml.Token, error) {
+	return Token(token, chardata)
+}
+
+// NewXMLTokenReader creates a xml.TokenReader given a scanner
+//
+// Deprecated: use NewTokenReader
+func NewXMLTokenReader(s *fastxml.Scanner) xml.TokenReader {
+	return NewTokenReader(s)
+}
@@ -0,0 +1,40 @@
+package stdxml
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// NewDecoder creates a *xml.Decoder reading the tokens of s
+// so any type supported by encoding/xml (including xml.Unmarshaler) can be decoded
+func NewDecoder(s *fastxml.Scanner) *xml.Decoder {
+	return xml.NewTokenDecoder(NewTokenReader(s))
+}
+
+// DecodeElement decodes the element whose start element token was most recently read from s into v
+// mirroring xml.Decoder.DecodeElement, s is left after the end of the element
+// The xmlns declarations of the ancestors of the element are not in scope
+func DecodeElement(s *fastxml.Scanner, v interface{}, startToken []byte) error {
+	if !fastxml.IsElement(startToken) || fastxml.IsEndElement(startToken) {
+		return fmt.Errorf("stdxml: %q is not a start element", startToken)
+	}
+	start, err := StartElement(startToken)
+	if err != nil {
+		return err
+	}
+	// The start element is read by the xml.Decoder so it declares any namespaces
+	tr := NewTokenReader(s)
+	tr.pending = append(tr.pending, start)
+	if s.SelfClosing(startToken) {
+		tr.pending = append(tr.pending, start.End())
+	}
+	d := xml.NewTokenDecoder(tr)
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	start = token.(xml.StartElement)
+	return d.DecodeElement(v, &start)
+}
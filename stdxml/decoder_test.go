@@ -0,0 +1,60 @@
+package stdxml
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/bored-engineer/fastxml"
+	"github.com/stretchr/testify/assert"
+)
+
+// upperText implements xml.Unmarshaler decoding the upper-cased text of an element
+type upperText struct {
+	Name xml.Name
+	Text string
+}
+
+func (u *upperText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	u.Name = start.Name
+	u.Text = strings.ToUpper(text)
+	return nil
+}
+
+func TestNewDecoder(t *testing.T) {
+	var v struct {
+		Items []upperText `xml:"item"`
+	}
+	s := fastxml.NewScanner([]byte(`<root><item>a</item><item>b<!-- c --></item></root>`))
+	assert.NoError(t, NewDecoder(s).Decode(&v))
+	assert.Equal(t, []upperText{{Name: xml.Name{Local: "item"}, Text: "A"}, {Name: xml.Name{Local: "item"}, Text: "B"}}, v.Items)
+}
+
+func TestDecodeElement(t *testing.T) {
+	s := fastxml.NewScanner([]byte(`<feed xmlns:a="urn:a"><skip>x</skip><a:entry xmlns:b="urn:b">one</a:entry><b/><a:entry xmlns:a="urn:other"/></feed>`))
+	var decoded []upperText
+	for {
+		token, err := s.NextElement()
+		if err != nil {
+			break
+		}
+		name, _ := fastxml.Element(token)
+		if fastxml.IsEndElement(token) || !fastxml.MatchLocal(name, "entry") {
+			continue
+		}
+		var u upperText
+		assert.NoError(t, DecodeElement(s, &u, token))
+		decoded = append(decoded, u)
+	}
+	assert.Equal(t, []upperText{
+		// The declarations of the ancestors are not in scope
+		{Name: xml.Name{Space: "a", Local: "entry"}, Text: "ONE"},
+		{Name: xml.Name{Space: "urn:other", Local: "entry"}},
+	}, decoded)
+	assert.Error(t, DecodeElement(s, &upperText{}, []byte(`</a>`)))
+	assert.Error(t, DecodeElement(fastxml.NewScanner([]byte(`text`)), &upperText{}, []byte(`<a>`)))
+}
@@ -0,0 +1,102 @@
+package stdxml
+
+import (
+	"encoding/xml"
+)
+
+// TokenFilter transforms the tokens of a ChainReader
+// Filter returns the (possibly modified) token or nil to drop it
+// A filter which drops a xml.StartElement must also drop the matching xml.EndElement
+type TokenFilter interface {
+	Filter(token xml.Token) (xml.Token, error)
+}
+
+// TokenFilterFunc adapts a function to a TokenFilter
+type TokenFilterFunc func(token xml.Token) (xml.Token, error)
+
+// Filter implements TokenFilter
+func (f TokenFilterFunc) Filter(token xml.Token) (xml.Token, error) {
+	return f(token)
+}
+
+// ChainReader implements xml.TokenReader applying each TokenFilter in order to the tokens of another xml.TokenReader
+type ChainReader struct {
+	r       xml.TokenReader
+	filters []TokenFilter
+}
+
+// NewChainReader creates a *ChainReader given a xml.TokenReader (ex: a *TokenReader) and filters
+func NewChainReader(r xml.TokenReader, filters ...TokenFilter) *ChainReader {
+	return &ChainReader{r: r, filters: filters}
+}
+
+// Token implements xml.TokenReader
+func (c *ChainReader) Token() (xml.Token, error) {
+	for {
+		token, err := c.r.Token()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range c.filters {
+			if token, err = f.Filter(token); err != nil {
+				return nil, err
+			} else if token == nil {
+				break
+			}
+		}
+		if token != nil {
+			return token, nil
+		}
+	}
+}
+
+// DropComments is a TokenFilter which drops every xml.Comment
+var DropComments TokenFilter = TokenFilterFunc(func(token xml.Token) (xml.Token, error) {
+	if _, ok := token.(xml.Comment); ok {
+		return nil, nil
+	}
+	return token, nil
+})
+
+// DropProcInsts is a TokenFilter which drops every xml.ProcInst (including the XML declaration)
+var DropProcInsts TokenFilter = TokenFilterFunc(func(token xml.Token) (xml.Token, error) {
+	if _, ok := token.(xml.ProcInst); ok {
+		return nil, nil
+	}
+	return token, nil
+})
+
+// StripNamespaces is a TokenFilter which clears the Space of element and attribute names
+// and removes any xmlns declarations
+var StripNamespaces TokenFilter = TokenFilterFunc(func(token xml.Token) (xml.Token, error) {
+	switch t := token.(type) {
+	case xml.StartElement:
+		t.Name.Space = ""
+		attrs := t.Attr[:0]
+		for _, attr := range t.Attr {
+			if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+				continue
+			}
+			attr.Name.Space = ""
+			attrs = append(attrs, attr)
+		}
+		t.Attr = attrs
+		return t, nil
+	case xml.EndElement:
+		t.Name.Space = ""
+		return t, nil
+	}
+	return token, nil
+})
+
+// RewriteAttrs creates a TokenFilter which replaces the value of every attribute with the result of f
+func RewriteAttrs(f func(elem xml.Name, attr xml.Attr) string) TokenFilter {
+	return TokenFilterFunc(func(token xml.Token) (xml.Token, error) {
+		if t, ok := token.(xml.StartElement); ok {
+			for idx, attr := range t.Attr {
+				t.Attr[idx].Value = f(t.Name, attr)
+			}
+		}
+		return token, nil
+	})
+}
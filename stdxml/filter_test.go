@@ -0,0 +1,56 @@
+package stdxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bored-engineer/fastxml"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeTokens writes every token of r with xml.Encoder
+func encodeTokens(r xml.TokenReader) (string, error) {
+	var sb strings.Builder
+	e := xml.NewEncoder(&sb)
+	for {
+		token, err := r.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if err := e.EncodeToken(token); err != nil {
+			return "", err
+		}
+	}
+	if err := e.Flush(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func TestChainReader(t *testing.T) {
+	const input = `<?xml version="1.0"?><a:root xmlns:a="urn:a" xmlns="urn:b" a:id="1"><!-- secret --><item href="http://example.com">text</item></a:root>`
+	https := RewriteAttrs(func(elem xml.Name, attr xml.Attr) string {
+		if elem.Local == "item" && attr.Name.Local == "href" {
+			return strings.Replace(attr.Value, "http:", "https:", 1)
+		}
+		return attr.Value
+	})
+	actual, err := encodeTokens(NewChainReader(
+		NewTokenReader(fastxml.NewScanner([]byte(input))),
+		DropComments, DropProcInsts, StripNamespaces, https,
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, `<root id="1"><item href="https://example.com">text</item></root>`, actual)
+	// Errors from a filter are returned
+	filterErr := errors.New("rejected")
+	_, err = encodeTokens(NewChainReader(
+		NewTokenReader(fastxml.NewScanner([]byte(input))),
+		TokenFilterFunc(func(token xml.Token) (xml.Token, error) { return nil, filterErr }),
+	))
+	assert.Equal(t, filterErr, err)
+}
@@ -0,0 +1,19 @@
+package stdxml
+
+import (
+	"encoding/xml"
+	"errors"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Marshal returns the XML encoding of v using fastxml.Marshal, falling back to xml.Marshal
+// for types which need an *xml.Encoder (ex: xml.Marshaler) so any type supported by encoding/xml can be encoded
+func Marshal(v interface{}) ([]byte, error) {
+	out, err := fastxml.Marshal(v)
+	var unsupported *fastxml.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return xml.Marshal(v)
+	}
+	return out, err
+}
@@ -0,0 +1,31 @@
+package stdxml
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type marshaler struct{}
+
+func (marshaler) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement("custom", start)
+}
+
+func TestMarshal(t *testing.T) {
+	out, err := Marshal(struct {
+		XMLName xml.Name  `xml:"root"`
+		Child   marshaler `xml:"child"`
+	}{})
+	assert.NoError(t, err)
+	assert.Equal(t, "<root><child>custom</child></root>", string(out))
+	out, err = Marshal(struct {
+		XMLName xml.Name `xml:"root"`
+		Value   string   `xml:"value,attr"`
+	}{Value: "v"})
+	assert.NoError(t, err)
+	assert.Equal(t, `<root value="v"></root>`, string(out))
+	_, err = Marshal(map[string]string{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,399 @@
+// Package stdxml converts the tokens of a fastxml.Scanner to the encoding/xml types
+package stdxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// CharData produces a xml.CharData given a token
+func CharData(token []byte, scratch []byte) (xml.CharData, error) {
+	cd, err := fastxml.CharData(token, scratch)
+	if err != nil {
+		return nil, err
+	}
+	return xml.CharData(cd), nil
+}
+
+// Directive produces a xml.Directive given a token
+func Directive(token []byte) xml.Directive {
+	return xml.Directive(fastxml.Directive(token))
+}
+
+// Comment produces a xml.Comment given a token
+func Comment(token []byte) xml.Comment {
+	return xml.Comment(fastxml.Comment(token))
+}
+
+// ProcInst produces a xml.ProcInst given a token
+func ProcInst(token []byte) xml.ProcInst {
+	target, inst := fastxml.ProcInst(token)
+	return xml.ProcInst{
+		Target: fastxml.String(target),
+		Inst:   inst,
+	}
+}
+
+// Name produces a xml.Name given a token
+func Name(token []byte) xml.Name {
+	space, local := fastxml.Name(token)
+	return xml.Name{
+		Space: fastxml.String(space),
+		Local: fastxml.String(local),
+	}
+}
+
+// Attr produces a xml.Attr given a key, value
+func Attr(key []byte, value []byte) (attr xml.Attr, err error) {
+	value, err = fastxml.DecodeEntities(value, nil)
+	if err != nil {
+		return
+	}
+	attr.Name = Name(key)
+	attr.Value = fastxml.String(value)
+	return
+}
+
+// reduce allocations when casting many attributes, slices are only returned by ReleaseAttrs
+var attrsPool = &sync.Pool{
+	New: func() interface{} {
+		// pre-allocate a few elements to avoid repeated growth of slices
+		return make([]xml.Attr, 0, 3)
+	},
+}
+
+// maxPooledAttrs is the largest capacity ReleaseAttrs returns to the pool so a single
+// element with many attributes does not pin a large slice for the life of the pool
+const maxPooledAttrs = 64
+
+// appendAttrs appends the attributes in token to attrs
+func appendAttrs(attrs []xml.Attr, token []byte) ([]xml.Attr, error) {
+	var attrErr error
+	if err := fastxml.Attrs(token, func(key []byte, value []byte) bool {
+		var attr xml.Attr
+		attr, attrErr = Attr(key, value)
+		if attrErr != nil {
+			return false
+		}
+		attrs = append(attrs, attr)
+		return true
+	}); err != nil {
+		return attrs, err
+	} else if attrErr != nil {
+		return attrs, attrErr
+	}
+	return attrs, nil
+}
+
+// Attrs produces a []xml.Attr given attributes slice
+// The slice may come from a pool, see ReleaseAttrs
+func Attrs(token []byte) ([]xml.Attr, error) {
+	attrs, err := appendAttrs(attrsPool.Get().([]xml.Attr), token)
+	if err != nil {
+		ReleaseAttrs(attrs)
+		return nil, err
+	}
+	// If no attributes
+	if len(attrs) == 0 {
+		attrsPool.Put(attrs)
+		// Use nil so gc can cleanup attrs slice
+		return nil, nil
+	}
+	return attrs, nil
+}
+
+// ReleaseAttrs returns a slice produced by Attrs to the pool so it can be re-used by a later call
+// The attrs (and any copy of the slice) must not be used after it is released as the memory is shared
+// It is optional, a slice that is never released is collected by the gc as usual
+// The Attr of a xml.StartElement from a TokenReader with Reuse set must not be released, the TokenReader owns it
+func ReleaseAttrs(attrs []xml.Attr) {
+	if cap(attrs) == 0 || cap(attrs) > maxPooledAttrs {
+		return
+	}
+	// Clear the names and values so the pool does not keep the input alive
+	attrs = attrs[:cap(attrs)]
+	for idx := range attrs {
+		attrs[idx] = xml.Attr{}
+	}
+	attrsPool.Put(attrs[:0])
+}
+
+// ReleaseStartElement releases the Attr of a xml.StartElement produced by StartElement (or Token), see ReleaseAttrs
+// Use start.Copy() first if the attributes are needed after the start element is released
+// Only release a xml.StartElement from a TokenReader if Reuse is not set, otherwise its Attr is re-used by both
+func ReleaseStartElement(start xml.StartElement) {
+	ReleaseAttrs(start.Attr)
+}
+
+// StartElement produces a xml.StartElement given a token
+func StartElement(token []byte) (xml.StartElement, error) {
+	name, attrToken := fastxml.Element(token)
+	attrs, err := Attrs(attrToken)
+	if err != nil {
+		return xml.StartElement{}, err
+	}
+	return xml.StartElement{
+		Name: Name(name),
+		Attr: attrs,
+	}, nil
+}
+
+// EndElement produces a xml.EndElement given a token
+func EndElement(token []byte) xml.EndElement {
+	name, _ := fastxml.Element(token)
+	return xml.EndElement{
+		Name: Name(name),
+	}
+}
+
+// Element produces a xml.EndElement or xml.StartElement depending on IsEndElement
+func Element(token []byte) (xml.Token, error) {
+	if fastxml.IsEndElement(token) {
+		return EndElement(token), nil
+	}
+	return StartElement(token)
+}
+
+// Token produces a xml.Token given a piece of data
+func Token(token []byte, chardata bool) (xml.Token, error) {
+	switch {
+	case chardata:
+		return CharData(token, nil)
+	case fastxml.IsDirective(token):
+		return Directive(token), nil
+	case fastxml.IsComment(token):
+		return Comment(token), nil
+	case fastxml.IsProcInst(token):
+		return ProcInst(token), nil
+	default:
+		return Element(token)
+	}
+}
+
+// TokenReader implements xml.TokenReader given a *fastxml.Scanner
+type TokenReader struct {
+	// ResolveNamespaces sets the Space of element and attribute names to the namespace URI
+	// declared by the xmlns attributes in scope (as xml.Decoder does) instead of the literal prefix
+	ResolveNamespaces bool
+	// Reuse re-uses the memory of the previous token (the []xml.Attr of a xml.StartElement and
+	// the buffer of decoded xml.CharData) so a token is only valid until the next call to Token
+	// which is the same guarantee as xml.Decoder.Token
+	// The tokens must not be released (see ReleaseStartElement) as the memory is owned by the TokenReader
+	Reuse bool
+	// Interner (if set) interns the Space and Local of element and attribute names (including the namespace URIs
+	// of ResolveNamespaces) so repeated names share a single copy instead of referencing the Scanner's buffer
+	Interner *fastxml.Interner
+
+	s       *fastxml.Scanner
+	d       *fastxml.Decoder // set instead of s by NewTokenReaderFromReader
+	pending []xml.Token      // tokens to return before reading from s (ex: the end of a self-closing element)
+	ns      fastxml.Namespaces
+	attrs   []xml.Attr // re-used if Reuse is set
+	scratch []byte     // re-used if Reuse is set
+}
+
+// reuse is Token re-using the memory of the previous token
+func (tr *TokenReader) reuse(rawToken []byte, chardata bool) (xml.Token, error) {
+	switch {
+	case chardata:
+		if bytes.IndexByte(rawToken, '&') == -1 {
+			return CharData(rawToken, nil)
+		}
+		cd, err := fastxml.CharDataAppend(tr.scratch[:0], rawToken)
+		if err != nil {
+			return nil, err
+		}
+		tr.scratch = cd
+		return xml.CharData(cd), nil
+	case fastxml.IsElement(rawToken) && !fastxml.IsEndElement(rawToken):
+		name, attrsToken := fastxml.Element(rawToken)
+		attrs, err := appendAttrs(tr.attrs[:0], attrsToken)
+		if err != nil {
+			return nil, err
+		}
+		tr.attrs = attrs
+		start := xml.StartElement{Name: Name(name)}
+		if len(attrs) > 0 {
+			start.Attr = attrs
+		}
+		return start, nil
+	}
+	return Token(rawToken, chardata)
+}
+
+// intern replaces the names in start with strings from the Interner
+func (tr *TokenReader) intern(rawToken []byte, start *xml.StartElement) error {
+	name, attrToken := fastxml.Element(rawToken)
+	start.Name = tr.internName(name)
+	idx := 0
+	return fastxml.Attrs(attrToken, func(key []byte, _ []byte) bool {
+		start.Attr[idx].Name = tr.internName(key)
+		idx++
+		return true
+	})
+}
+
+// internName converts a name to an xml.Name using the Interner
+func (tr *TokenReader) internName(name []byte) xml.Name {
+	space, local := fastxml.Name(name)
+	return xml.Name{Space: tr.Interner.Intern(space), Local: tr.Interner.Intern(local)}
+}
+
+// resolve replaces the prefixes of the names in start with their namespace URI
+func (tr *TokenReader) resolve(rawToken []byte, start *xml.StartElement) error {
+	if err := tr.ns.Push(rawToken); err != nil {
+		return err
+	}
+	name, attrToken := fastxml.Element(rawToken)
+	start.Name.Space = tr.resolveName(name, false)
+	idx := 0
+	return fastxml.Attrs(attrToken, func(key []byte, _ []byte) bool {
+		start.Attr[idx].Name.Space = tr.resolveName(key, true)
+		idx++
+		return true
+	})
+}
+
+// resolveName returns the namespace URI of name using the Interner (if set)
+func (tr *TokenReader) resolveName(name []byte, attr bool) string {
+	space, _ := tr.ns.Resolve(name, attr)
+	if tr.Interner != nil {
+		return tr.Interner.InternString(space)
+	}
+	return space
+}
+
+// next reads the next raw token from the Scanner or Decoder
+func (tr *TokenReader) next() (rawToken []byte, chardata bool, err error) {
+	if tr.d == nil {
+		return tr.s.Next()
+	}
+	if rawToken, chardata, err = tr.d.Next(); err != nil {
+		return nil, false, err
+	}
+	// The Decoder re-uses its buffer but the returned xml.Token (and the namespace declarations) reference the token
+	return append([]byte(nil), rawToken...), chardata, nil
+}
+
+// Token implements xml.TokenReader
+func (tr *TokenReader) Token() (_ xml.Token, err error) {
+	// Just in case that data was not well-formed or some other error
+	defer func() {
+		if rErr := recover(); rErr != nil {
+			err = fmt.Errorf("unexpected panic: %v", rErr)
+		}
+	}()
+	// If we have a pending token use that
+	if len(tr.pending) > 0 {
+		token := tr.pending[0]
+		tr.pending = tr.pending[1:]
+		return token, nil
+	}
+	// Get the next token, convert to XML interface
+	rawToken, chardata, sErr := tr.next()
+	if sErr != nil {
+		return nil, sErr
+	}
+	var token xml.Token
+	var tErr error
+	if tr.Reuse {
+		token, tErr = tr.reuse(rawToken, chardata)
+	} else {
+		token, tErr = Token(rawToken, chardata)
+	}
+	if tErr != nil {
+		return nil, tErr
+	}
+	switch t := token.(type) {
+	case xml.StartElement:
+		selfClosing := fastxml.IsSelfClosing(rawToken)
+		if tr.s != nil {
+			selfClosing = tr.s.SelfClosing(rawToken)
+		}
+		if tr.Interner != nil {
+			if err := tr.intern(rawToken, &t); err != nil {
+				return nil, err
+			}
+			token = t
+		}
+		if tr.ResolveNamespaces {
+			if err := tr.resolve(rawToken, &t); err != nil {
+				return nil, err
+			}
+			token = t
+			if selfClosing {
+				tr.ns.Pop()
+			}
+		}
+		// If it's self closing, next token is it's end element
+		if selfClosing {
+			tr.pending = append(tr.pending, t.End())
+		}
+	case xml.EndElement:
+		if tr.Interner != nil {
+			name, _ := fastxml.Element(rawToken)
+			t.Name = tr.internName(name)
+			token = t
+		}
+		if tr.ResolveNamespaces {
+			name, _ := fastxml.Element(rawToken)
+			t.Name.Space = tr.resolveName(name, false)
+			token = t
+			if err := tr.ns.Pop(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return token, nil
+}
+
+// ErrCheckpointUnsupported is returned by TokenReader.Checkpoint for a TokenReader created by NewTokenReaderFromReader
+var ErrCheckpointUnsupported = errors.New("stdxml: Checkpoint is not supported reading from an io.Reader")
+
+// Checkpoint is the state of a TokenReader captured by TokenReader.Checkpoint
+type Checkpoint struct {
+	scanner fastxml.Checkpoint
+	pending []xml.Token
+	ns      fastxml.Namespaces
+}
+
+// Checkpoint captures the state of the TokenReader (and its Scanner) so that it can be returned to with Restore
+// It includes the synthetic xml.EndElement of a self-closing element which has not been returned yet
+// A TokenReader created by NewTokenReaderFromReader can't go back to a previous token, ErrCheckpointUnsupported is returned
+func (tr *TokenReader) Checkpoint() (Checkpoint, error) {
+	if tr.s == nil {
+		return Checkpoint{}, ErrCheckpointUnsupported
+	}
+	return Checkpoint{
+		scanner: tr.s.Checkpoint(),
+		pending: append([]xml.Token(nil), tr.pending...),
+		ns:      tr.ns.Clone(),
+	}, nil
+}
+
+// Restore returns the TokenReader to the state captured by Checkpoint, a Checkpoint may be restored multiple times
+func (tr *TokenReader) Restore(cp Checkpoint) {
+	tr.s.Restore(cp.scanner)
+	tr.pending = append([]xml.Token(nil), cp.pending...)
+	tr.ns = cp.ns.Clone()
+}
+
+// NewTokenReader creates a *TokenReader given a scanner
+func NewTokenReader(s *fastxml.Scanner) *TokenReader {
+	return &TokenReader{s: s}
+}
+
+// NewTokenReaderFromReader creates a *TokenReader which reads from r as tokens are requested (ex: an HTTP body)
+// using a fastxml.Decoder, only enough of r is buffered to produce the next token
+// Unlike NewTokenReader, where the tokens reference the input without copying, each token is copied out of the
+// re-used buffer (an allocation per token) so the xml.Token remains valid, reading the entire input into a []byte
+// first is faster if its size is known to be reasonable
+func NewTokenReaderFromReader(r io.Reader) *TokenReader {
+	return &TokenReader{d: fastxml.NewDecoderReader(r)}
+}
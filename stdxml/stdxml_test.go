@@ -0,0 +1,394 @@
+package stdxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bored-engineer/fastxml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	testCases := []struct {
+		Token    string
+		Chardata bool
+		Error    string
+		Expected xml.Token
+	}{
+		{
+			Token:    `Hello World`,
+			Chardata: true,
+			Expected: xml.CharData([]byte(`Hello World`)),
+		},
+		{
+			Token: `<true>`,
+			Expected: xml.StartElement{
+				Name: xml.Name{Local: "true"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Token, func(t *testing.T) {
+			actual, err := Token([]byte(tc.Token), tc.Chardata)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestReleaseStartElement(t *testing.T) {
+	start, err := StartElement([]byte(`<a x="1" y="2">`))
+	assert.NoError(t, err)
+	attrs := start.Attr
+	assert.Equal(t, []xml.Attr{{Name: xml.Name{Local: "x"}, Value: "1"}, {Name: xml.Name{Local: "y"}, Value: "2"}}, attrs)
+	ReleaseStartElement(start)
+	// The released memory no longer references the input
+	assert.Equal(t, []xml.Attr{{}, {}}, attrs)
+	// Released slices are re-used (or a new one is allocated) but never aliased by two live elements
+	first, err := StartElement([]byte(`<b z="3">`))
+	assert.NoError(t, err)
+	second, err := StartElement([]byte(`<c w="4">`))
+	assert.NoError(t, err)
+	assert.Equal(t, "3", first.Attr[0].Value)
+	assert.Equal(t, "4", second.Attr[0].Value)
+	ReleaseStartElement(first)
+	ReleaseStartElement(second)
+	// Nothing to release
+	ReleaseStartElement(xml.StartElement{Name: xml.Name{Local: "d"}})
+	ReleaseAttrs(nil)
+	// The slice is released when an attribute fails to decode
+	_, err = StartElement([]byte(`<e x="1" y="&bogus;">`))
+	assert.Error(t, err)
+}
+
+func TestTokenReader(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Error    string
+		Expected []xml.Token
+	}{
+		{
+			Input: `<hello key="v&amp;lue">world</hello>`,
+			Expected: []xml.Token{
+				xml.StartElement{
+					Name: xml.Name{Local: "hello"},
+					Attr: []xml.Attr{
+						xml.Attr{
+							Name:  xml.Name{Local: "key"},
+							Value: "v&lue",
+						},
+					},
+				},
+				xml.CharData("world"),
+				xml.EndElement{
+					Name: xml.Name{Local: "hello"},
+				},
+			},
+		},
+		{
+			Input: `<ns:café clé="é">ü</ns:café>`,
+			Expected: []xml.Token{
+				xml.StartElement{
+					Name: xml.Name{Space: "ns", Local: "café"},
+					Attr: []xml.Attr{
+						xml.Attr{
+							Name:  xml.Name{Local: "clé"},
+							Value: "é",
+						},
+					},
+				},
+				xml.CharData("ü"),
+				xml.EndElement{
+					Name: xml.Name{Space: "ns", Local: "café"},
+				},
+			},
+		},
+		{
+			Input: "<parent><child/></parent>",
+			Expected: []xml.Token{
+				xml.StartElement{
+					Name: xml.Name{Local: "parent"},
+				},
+				xml.StartElement{
+					Name: xml.Name{Local: "child"},
+				},
+				xml.EndElement{
+					Name: xml.Name{Local: "child"},
+				},
+				xml.EndElement{
+					Name: xml.Name{Local: "parent"},
+				},
+			},
+		},
+		{
+			Input: "<?proc inst?>then<!directive>with<![CDATA[some data]]><!--comment-->",
+			Expected: []xml.Token{
+				xml.ProcInst{
+					Target: "proc",
+					Inst:   []byte("inst"),
+				},
+				xml.CharData("then"),
+				xml.Directive("directive"),
+				xml.CharData("with"),
+				xml.CharData("some data"),
+				xml.Comment("comment"),
+			},
+		},
+		{
+			Input: "<?invalid",
+			Error: "expected Token to end with '?>' at offset 0",
+		},
+		{
+			Input: "&invalid;",
+			Error: `unknown XML entity "invalid" at offset 0`,
+		},
+		{
+			Input: `<element key="&invalid;">`,
+			Error: `unknown XML entity "invalid" at offset 0`,
+		},
+		{
+			Input: `<element key="invalid>`,
+			Error: `expected Token to end with '>' at offset 0`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			r := NewTokenReader(fastxml.NewScanner([]byte(tc.Input)))
+			var err error
+			var tokens []xml.Token
+			for {
+				var token xml.Token
+				token, err = r.Token()
+				if token != nil {
+					tokens = append(tokens, token)
+				}
+				if err != nil {
+					if err == io.EOF {
+						err = nil
+					}
+					break
+				}
+			}
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Expected, tokens)
+			}
+		})
+	}
+}
+
+func TestTokenReader_AutoClose(t *testing.T) {
+	s := fastxml.NewScanner([]byte(`<p>a<br>b<br></br></p>`))
+	s.AutoClose = xml.HTMLAutoClose
+	r := NewTokenReader(s)
+	var tokens []xml.Token
+	for {
+		token, err := r.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "p"}},
+		xml.CharData("a"),
+		xml.StartElement{Name: xml.Name{Local: "br"}},
+		xml.EndElement{Name: xml.Name{Local: "br"}},
+		xml.CharData("b"),
+		xml.StartElement{Name: xml.Name{Local: "br"}},
+		xml.EndElement{Name: xml.Name{Local: "br"}},
+		xml.EndElement{Name: xml.Name{Local: "p"}},
+	}, tokens)
+}
+
+func TestTokenReader_ResolveNamespaces(t *testing.T) {
+	input := []byte(`<root xmlns="urn:default" xmlns:a="urn:a" a:key="1" key="2"><a:child xml:lang="en"/><child xmlns="urn:other"><a:x/></child><unknown:y/></root>`)
+	var expected []xml.Token
+	d := xml.NewDecoder(bytes.NewReader(input))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		token = xml.CopyToken(token)
+		// CopyToken makes an empty (non-nil) slice of attributes
+		if start, ok := token.(xml.StartElement); ok && len(start.Attr) == 0 {
+			start.Attr = nil
+			token = start
+		}
+		expected = append(expected, token)
+	}
+	r := NewTokenReader(fastxml.NewScanner(input))
+	r.ResolveNamespaces = true
+	var actual []xml.Token
+	for {
+		token, err := r.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		actual = append(actual, token)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestTokenReader_Reuse(t *testing.T) {
+	const input = `<root a="1" b="&lt;"><item c="2">x &amp; y</item><empty/>z &gt; w</root>`
+	expected, err := readTokens(NewTokenReader(fastxml.NewScanner([]byte(input))))
+	assert.NoError(t, err)
+	tr := NewTokenReader(fastxml.NewScanner([]byte(input)))
+	tr.Reuse = true
+	var attrs *xml.Attr
+	for idx := 0; ; idx++ {
+		token, err := tr.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		// Compare each token before the next call invalidates it
+		assert.Equal(t, expected[idx], xml.CopyToken(token))
+		if start, ok := token.(xml.StartElement); ok && len(start.Attr) > 0 {
+			if attrs == nil {
+				attrs = &start.Attr[0]
+			} else {
+				assert.True(t, attrs == &start.Attr[0], "expected the attributes to be re-used")
+			}
+		}
+	}
+}
+
+func TestTokenReader_Release(t *testing.T) {
+	// Without Reuse each xml.StartElement has its own Attr which can be released as soon as it is used
+	const input = `<root a="1" b="2"><item c="3"/><item d="4" e="5">x</item></root>`
+	expected, err := readTokens(NewTokenReader(fastxml.NewScanner([]byte(input))))
+	assert.NoError(t, err)
+	tr := NewTokenReader(fastxml.NewScanner([]byte(input)))
+	var previous xml.StartElement
+	for idx := 0; ; idx++ {
+		token, err := tr.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected[idx], token)
+		if start, ok := token.(xml.StartElement); ok {
+			// The previous attributes are returned to the pool but never shared with the current element
+			ReleaseStartElement(previous)
+			assert.Equal(t, expected[idx], start)
+			previous = start
+		}
+	}
+}
+
+func TestTokenReader_Interner(t *testing.T) {
+	input := []byte(`<root xmlns:a="urn:a"><a:item a:id="1"/><a:item a:id="2">x</a:item></root>`)
+	expected, err := readTokens(NewTokenReader(fastxml.NewScanner(input)))
+	assert.NoError(t, err)
+	in := &fastxml.Interner{}
+	tr := NewTokenReader(fastxml.NewScanner(input))
+	tr.Interner = in
+	actual, err := readTokens(tr)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	// root, xmlns, a, item, id and the empty space
+	assert.Equal(t, 6, in.Len())
+	// The names no longer reference the input
+	tr = NewTokenReader(fastxml.NewScanner(input))
+	tr.Interner = in
+	tr.ResolveNamespaces = true
+	_, err = tr.Token()
+	assert.NoError(t, err)
+	token, err := tr.Token()
+	assert.NoError(t, err)
+	copy(input, bytes.Repeat([]byte("x"), len(input)))
+	// The Value is not interned so only the names are compared
+	start := token.(xml.StartElement)
+	assert.Equal(t, xml.Name{Space: "urn:a", Local: "item"}, start.Name)
+	if assert.Len(t, start.Attr, 1) {
+		assert.Equal(t, xml.Name{Space: "urn:a", Local: "id"}, start.Attr[0].Name)
+	}
+}
+
+// readTokens reads (a copy of) every token from r
+func readTokens(r xml.TokenReader) ([]xml.Token, error) {
+	var tokens []xml.Token
+	for {
+		token, err := r.Token()
+		if err == io.EOF {
+			return tokens, nil
+		} else if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+}
+
+func TestTokenReader_Checkpoint(t *testing.T) {
+	tr := NewTokenReader(fastxml.NewScanner([]byte(`<x:a xmlns:x="urn:x"><x:b/><x:c/></x:a>`)))
+	tr.ResolveNamespaces = true
+	_, err := tr.Token()
+	assert.NoError(t, err)
+	token, err := tr.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, xml.StartElement{Name: xml.Name{Space: "urn:x", Local: "b"}}, token)
+	// The pending end of the self-closing <x:b/> is part of the checkpoint
+	cp, err := tr.Checkpoint()
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		var tokens []xml.Token
+		for {
+			token, err := tr.Token()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			tokens = append(tokens, token)
+		}
+		assert.Equal(t, []xml.Token{
+			xml.EndElement{Name: xml.Name{Space: "urn:x", Local: "b"}},
+			xml.StartElement{Name: xml.Name{Space: "urn:x", Local: "c"}},
+			xml.EndElement{Name: xml.Name{Space: "urn:x", Local: "c"}},
+			xml.EndElement{Name: xml.Name{Space: "urn:x", Local: "a"}},
+		}, tokens)
+		tr.Restore(cp)
+	}
+}
+
+func TestNewTokenReaderFromReader(t *testing.T) {
+	input := `<?xml version="1.0"?><root xmlns="urn:default" xmlns:a="urn:a" a:key="1"><a:child>x &amp; y</a:child><![CDATA[<z>]]><!-- c --><empty/></root>`
+	expected, err := readTokens(NewTokenReader(fastxml.NewScanner([]byte(input))))
+	assert.NoError(t, err)
+	// One byte at a time so every token is split across reads and the buffer is re-used
+	actual, err := readTokens(NewTokenReaderFromReader(iotest.OneByteReader(strings.NewReader(input))))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	tr := NewTokenReaderFromReader(iotest.OneByteReader(strings.NewReader(input)))
+	tr.ResolveNamespaces = true
+	var v struct {
+		XMLName xml.Name `xml:"urn:default root"`
+		Key     string   `xml:"urn:a key,attr"`
+		Child   string   `xml:"urn:a child"`
+	}
+	if assert.NoError(t, xml.NewTokenDecoder(tr).Decode(&v)) {
+		assert.Equal(t, "1", v.Key)
+		assert.Equal(t, "x & y", v.Child)
+	}
+
+	_, err = readTokens(NewTokenReaderFromReader(strings.NewReader(`<root><a`)))
+	assert.EqualError(t, err, "expected Token to end with '>' at offset 6")
+	_, err = NewTokenReaderFromReader(strings.NewReader(input)).Checkpoint()
+	assert.Equal(t, ErrCheckpointUnsupported, err)
+}
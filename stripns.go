@@ -0,0 +1,58 @@
+package fastxml
+
+import "io"
+
+// stripName appends the local part of name to dst (the reserved xml prefix is kept)
+func stripName(dst []byte, name []byte) []byte {
+	if space, local := Name(name); space != nil && String(space) != "xml" {
+		return append(dst, local...)
+	}
+	return append(dst, name...)
+}
+
+// StripNamespaces appends src to dst with the xmlns declarations removed and the namespace prefix removed
+// from each element and attribute name (ex: `<s:Body xmlns:s="urn:x" s:id="1">` -> `<Body id="1">`)
+// The reserved xml prefix (ex: xml:lang) is kept, prefixes within attribute values or CharData are not
+// modified and all other bytes (including whitespace between attributes) are copied unchanged
+// Attributes in different namespaces with the same local name will be duplicates once stripped
+func StripNamespaces(dst, src []byte) ([]byte, error) {
+	s := NewScanner(src)
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return dst, nil
+		} else if err != nil {
+			return dst, err
+		}
+		if chardata || !IsElement(token) {
+			dst = append(dst, token...)
+			continue
+		}
+		name, attrsToken := Element(token)
+		if IsEndElement(token) {
+			dst = append(dst, '<', '/')
+			dst = stripName(dst, name)
+			dst = append(dst, token[len(name)+2:]...)
+			continue
+		}
+		dst = append(dst, '<')
+		dst = stripName(dst, name)
+		// Offset of attrsToken within token, the whitespace before each attribute is
+		// copied (or removed) with it so last starts at the separator after the name
+		offset := len(name) + 2
+		last := len(name) + 1
+		if err := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+			key := attrsToken[keyStart:keyEnd]
+			if !isXMLNS(key) {
+				dst = append(dst, token[last:offset+keyStart]...)
+				dst = stripName(dst, key)
+				dst = append(dst, token[offset+keyEnd:offset+valueEnd+1]...)
+			}
+			last = offset + valueEnd + 1
+			return true
+		}); err != nil {
+			return dst, err
+		}
+		dst = append(dst, token[last:]...)
+	}
+}
@@ -0,0 +1,54 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripNamespaces(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "SOAP",
+			Input:    `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><m:Get xmlns:m="urn:m" m:id="1">x &amp; y</m:Get></s:Body></s:Envelope>`,
+			Expected: `<?xml version="1.0"?><Envelope><Body><Get id="1">x &amp; y</Get></Body></Envelope>`,
+		},
+		{
+			Name:     "Default",
+			Input:    `<feed xmlns="http://www.w3.org/2005/Atom"><title>t</title></feed>`,
+			Expected: `<feed><title>t</title></feed>`,
+		},
+		{
+			Name:     "Formatting",
+			Input:    "<a:root\n\txmlns:a=\"urn:a\"\n\ta:key = \"v\"\n\tother=\"o\" >\n  <a:empty\txmlns=\"urn:b\" />\n</a:root >",
+			Expected: "<root\n\tkey = \"v\"\n\tother=\"o\" >\n  <empty />\n</root >",
+		},
+		{
+			Name:     "XMLPrefix",
+			Input:    `<p xml:lang="en" xsi:type="ns:T"><!-- ns:c --><![CDATA[<ns:x/>]]></p>`,
+			Expected: `<p xml:lang="en" type="ns:T"><!-- ns:c --><![CDATA[<ns:x/>]]></p>`,
+		},
+		{
+			Name:     "Invalid",
+			Input:    `<a:b c></a:b>`,
+			Expected: `<b`,
+			Error:    `expected whitespace but got "c" at offset 0`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			out, err := StripNamespaces(nil, []byte(tc.Input))
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.Expected, string(out))
+		})
+	}
+}
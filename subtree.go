@@ -0,0 +1,59 @@
+package fastxml
+
+import (
+	"fmt"
+	"io"
+)
+
+// CopySubtree returns a copy of the element most recently returned by s (which must be a start element) with
+// the root element replaced by newRoot and every byte within it copied unchanged, s is advanced past the element
+// newRoot is either a start element token (ex: `<ns:item xmlns:ns="urn:x">`) replacing the name and attributes
+// or a name (ex: `ns:item`) replacing only the name and keeping the original attributes
+// Namespace declarations of ancestors are not copied, any prefixes used within the subtree must be declared by newRoot
+func CopySubtree(s *Scanner, newRoot []byte) ([]byte, error) {
+	start, end := s.TokenRange()
+	token := s.buf[start:end]
+	if !IsElement(token) || IsEndElement(token) {
+		return nil, errNotStartElement
+	}
+	var name, root []byte
+	if len(newRoot) > 0 && newRoot[0] == '<' {
+		if !IsElement(newRoot) || IsEndElement(newRoot) || IsSelfClosing(newRoot) {
+			return nil, errNotStartElement
+		}
+		name, _ = Element(newRoot)
+		root = newRoot
+	} else {
+		name = newRoot
+	}
+	if !isName(name) {
+		return nil, fmt.Errorf("invalid element name %q", name)
+	}
+	oldName, _ := Element(token)
+	selfClosing := s.SelfClosing(token)
+	inner := end
+	if !selfClosing {
+		if err := s.Skip(); err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		inner, _ = s.TokenRange()
+	}
+	dst := make([]byte, 0, len(root)+len(name)+inner-start+3)
+	if root == nil {
+		// Only the name is replaced, the rest of the token (ex: ` id="1">` or `/>`) is unchanged
+		dst = append(dst, '<')
+		dst = append(dst, name...)
+		dst = append(dst, token[len(oldName)+1:]...)
+		if selfClosing {
+			return dst, nil
+		}
+	} else {
+		dst = append(dst, root...)
+	}
+	dst = append(dst, s.buf[end:inner]...)
+	dst = append(dst, '<', '/')
+	dst = append(dst, name...)
+	return append(dst, '>'), nil
+}
@@ -0,0 +1,67 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopySubtree(t *testing.T) {
+	testCases := []struct {
+		Input  string
+		Root   string
+		Output string
+		Err    string
+	}{
+		{
+			Input:  `<feed><entry id="1"><a:title>x &amp; <![CDATA[<y>]]></a:title><!-- c --></entry></feed>`,
+			Root:   `<item xmlns:a="urn:a">`,
+			Output: `<item xmlns:a="urn:a"><a:title>x &amp; <![CDATA[<y>]]></a:title><!-- c --></item>`,
+		},
+		{
+			Input:  `<feed><entry  id="1" ><b/></entry></feed>`,
+			Root:   `ns:item`,
+			Output: `<ns:item  id="1" ><b/></ns:item>`,
+		},
+		{
+			Input:  `<feed><entry id="1"/></feed>`,
+			Root:   `item`,
+			Output: `<item id="1"/>`,
+		},
+		{
+			Input:  `<feed><entry id="1"/></feed>`,
+			Root:   `<item>`,
+			Output: `<item></item>`,
+		},
+		{
+			Input:  `<feed><entry><entry>x</entry></entry></feed>`,
+			Root:   `item`,
+			Output: `<item><entry>x</entry></item>`,
+		},
+		{Input: `<feed><entry>`, Root: `item`, Err: io.ErrUnexpectedEOF.Error()},
+		{Input: `<feed><entry/></feed>`, Root: `<item/>`, Err: errNotStartElement.Error()},
+		{Input: `<feed><entry/></feed>`, Root: `1item`, Err: `invalid element name "1item"`},
+	}
+	for _, tc := range testCases {
+		s := NewScanner([]byte(tc.Input))
+		_, err := s.NextStart([]byte("entry"))
+		assert.NoError(t, err)
+		output, err := CopySubtree(s, []byte(tc.Root))
+		if tc.Err != "" {
+			assert.EqualError(t, err, tc.Err, tc.Input)
+			continue
+		}
+		if assert.NoError(t, err, tc.Input) {
+			assert.Equal(t, tc.Output, string(output), tc.Input)
+		}
+		token, _, err := s.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, `</feed>`, string(token), tc.Input)
+	}
+	s := NewScanner([]byte(`<feed></feed>`))
+	_, _, _ = s.Next()
+	_, _, _ = s.Next()
+	_, err := CopySubtree(s, []byte("item"))
+	assert.Equal(t, errNotStartElement, err)
+}
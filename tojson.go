@@ -0,0 +1,295 @@
+package fastxml
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// JSONOptions configures the representation produced by ToJSON, the zero value uses the defaults
+type JSONOptions struct {
+	// AttrPrefix is prepended to the name of each attribute, "@" if empty
+	AttrPrefix string
+	// TextKey is the key of the text of an element with attributes or child elements, "#text" if empty
+	TextKey string
+	// MaxDepth rejects elements nested deeper than this with a *LimitError, DefaultJSONMaxDepth if 0
+	// and unlimited if negative. As each element is tokenized once per ancestor the time ToJSON takes
+	// grows with the size of the document times its depth, only disable it for trusted input
+	MaxDepth int
+}
+
+// DefaultJSONMaxDepth is the MaxDepth of ToJSON if JSONOptions.MaxDepth is 0
+const DefaultJSONMaxDepth = 256
+
+// Defaults of JSONOptions
+const (
+	defaultAttrPrefix = "@"
+	defaultTextKey    = "#text"
+)
+
+// errNoRoot is returned by ToJSON when the document does not contain an element
+var errNoRoot = errors.New("expected a root element")
+
+// jsonChild is the byte range of a child element
+type jsonChild struct {
+	name       []byte
+	start, end int
+	next       int  // index of the next child with the same name (or -1)
+	done       bool // if already written as part of an array
+}
+
+// jsonLevel is re-used for every element at a depth
+type jsonLevel struct {
+	s        Scanner
+	children []jsonChild
+	text     []byte
+	last     map[string]int // name -> index of the last child with that name
+}
+
+// jsonConverter holds the state of a single ToJSON call
+type jsonConverter struct {
+	opts       JSONOptions
+	attrPrefix []byte
+	textKey    []byte
+	w          *bufio.Writer
+	src        []byte
+	levels     []*jsonLevel
+}
+
+// ToJSON writes the document in src to dst as JSON without building a tree
+// The root element becomes an object with a single key, each element is a string of its text if it has
+// no attributes or child elements, otherwise an object with a key for each attribute (prefixed with
+// AttrPrefix), its text (as TextKey) and each child element where repeated elements become an array
+// Text is decoded with leading and trailing whitespace removed, whitespace-only text is omitted from
+// objects and comments, ProcInsts and Directives are ignored (ex: `<a id="1"><b>x</b><b>y</b></a>` ->
+// `{"a":{"@id":"1","b":["x","y"]}}`)
+// Each element's children are tokenized once for every level of nesting above it, see JSONOptions.MaxDepth
+func ToJSON(dst io.Writer, src []byte, opts JSONOptions) error {
+	if opts.AttrPrefix == "" {
+		opts.AttrPrefix = defaultAttrPrefix
+	}
+	if opts.TextKey == "" {
+		opts.TextKey = defaultTextKey
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultJSONMaxDepth
+	}
+	c := &jsonConverter{
+		opts:       opts,
+		attrPrefix: []byte(opts.AttrPrefix),
+		textKey:    []byte(opts.TextKey),
+		w:          bufio.NewWriter(dst),
+		src:        src,
+	}
+	s := NewScanner(src)
+	for {
+		start := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return errNoRoot
+		} else if err != nil {
+			return err
+		}
+		if chardata || !IsElement(token) || IsEndElement(token) {
+			continue
+		}
+		if !IsSelfClosing(token) {
+			if err := s.Skip(); err == io.EOF {
+				return io.ErrUnexpectedEOF
+			} else if err != nil {
+				return err
+			}
+		}
+		name, _ := Element(token)
+		c.w.WriteByte('{')
+		writeJSONString(c.w, nil, name)
+		c.w.WriteByte(':')
+		if err := c.element(start, s.Offset(), 1); err != nil {
+			return err
+		}
+		c.w.WriteByte('}')
+		return c.w.Flush()
+	}
+}
+
+// level returns the re-used state for elements at depth
+func (c *jsonConverter) level(depth int) *jsonLevel {
+	for len(c.levels) < depth {
+		c.levels = append(c.levels, &jsonLevel{last: make(map[string]int)})
+	}
+	return c.levels[depth-1]
+}
+
+// content reads the child elements and text of the element most recently read from s
+func (lvl *jsonLevel) content(offset int) error {
+	s := &lvl.s
+	for {
+		start := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case chardata:
+			if lvl.text, err = CharDataAppend(lvl.text, token); err != nil {
+				return err
+			}
+		case !IsElement(token):
+			continue
+		case IsEndElement(token):
+			return nil
+		default:
+			if !IsSelfClosing(token) {
+				if err := s.Skip(); err == io.EOF {
+					return io.ErrUnexpectedEOF
+				} else if err != nil {
+					return err
+				}
+			}
+			name, _ := Element(token)
+			child := jsonChild{name: name, start: offset + start, end: offset + s.Offset(), next: -1}
+			if prev, ok := lvl.last[String(name)]; ok {
+				lvl.children[prev].next = len(lvl.children)
+			}
+			lvl.last[String(name)] = len(lvl.children)
+			lvl.children = append(lvl.children, child)
+		}
+	}
+}
+
+// element writes the JSON value of the element src[start:end] nested at depth
+func (c *jsonConverter) element(start, end int, depth int) error {
+	if c.opts.MaxDepth > 0 && depth > c.opts.MaxDepth {
+		return &LimitError{Limit: LimitDepth, Max: c.opts.MaxDepth, Value: depth, Offset: start}
+	}
+	lvl := c.level(depth)
+	lvl.s.Reset(c.src[start:end])
+	lvl.children, lvl.text = lvl.children[:0], lvl.text[:0]
+	for name := range lvl.last {
+		delete(lvl.last, name)
+	}
+	token, _, err := lvl.s.Next()
+	if err != nil {
+		return err
+	}
+	if !IsSelfClosing(token) {
+		if err := lvl.content(start); err != nil {
+			return err
+		}
+	}
+	text := trimSpace(lvl.text)
+	_, attrsToken := Element(token)
+	if len(lvl.children) == 0 && AttrCount(attrsToken) == 0 {
+		writeJSONString(c.w, nil, text)
+		return nil
+	}
+	c.w.WriteByte('{')
+	first := true
+	if err := DecodedAttrs(attrsToken, nil, func(key, value []byte) bool {
+		if !first {
+			c.w.WriteByte(',')
+		}
+		first = false
+		writeJSONString(c.w, c.attrPrefix, key)
+		c.w.WriteByte(':')
+		writeJSONString(c.w, nil, value)
+		return true
+	}); err != nil {
+		return err
+	}
+	if len(text) > 0 {
+		if !first {
+			c.w.WriteByte(',')
+		}
+		first = false
+		writeJSONString(c.w, c.textKey, nil)
+		c.w.WriteByte(':')
+		writeJSONString(c.w, nil, text)
+	}
+	// The children are read from the level before recursing as the level of each child is depth+1
+	for idx := range lvl.children {
+		child := lvl.children[idx]
+		if child.done {
+			continue
+		}
+		if !first {
+			c.w.WriteByte(',')
+		}
+		first = false
+		writeJSONString(c.w, nil, child.name)
+		c.w.WriteByte(':')
+		if child.next == -1 {
+			if err := c.element(child.start, child.end, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		c.w.WriteByte('[')
+		for next := idx; next != -1; next = lvl.children[next].next {
+			if next != idx {
+				c.w.WriteByte(',')
+			}
+			lvl.children[next].done = true
+			if err := c.element(lvl.children[next].start, lvl.children[next].end, depth+1); err != nil {
+				return err
+			}
+		}
+		c.w.WriteByte(']')
+	}
+	c.w.WriteByte('}')
+	return nil
+}
+
+// jsonHex is used to escape control characters
+const jsonHex = "0123456789abcdef"
+
+// writeJSONString writes prefix followed by value as a quoted JSON string
+// Invalid UTF-8 is replaced with U+FFFD the same as encoding/json
+func writeJSONString(w *bufio.Writer, prefix []byte, value []byte) {
+	w.WriteByte('"')
+	for _, b := range [2][]byte{prefix, value} {
+		last := 0
+		for idx := 0; idx < len(b); {
+			c := b[idx]
+			if c >= utf8.RuneSelf {
+				r, size := utf8.DecodeRune(b[idx:])
+				if r == utf8.RuneError && size == 1 {
+					w.Write(b[last:idx])
+					w.WriteString("�")
+					idx += size
+					last = idx
+					continue
+				}
+				idx += size
+				continue
+			}
+			if c >= 0x20 && c != '"' && c != '\\' {
+				idx++
+				continue
+			}
+			w.Write(b[last:idx])
+			switch c {
+			case '"', '\\':
+				w.WriteByte('\\')
+				w.WriteByte(c)
+			case '\n':
+				w.WriteString(`\n`)
+			case '\r':
+				w.WriteString(`\r`)
+			case '\t':
+				w.WriteString(`\t`)
+			default:
+				w.WriteString(`\u00`)
+				w.WriteByte(jsonHex[c>>4])
+				w.WriteByte(jsonHex[c&0xf])
+			}
+			idx++
+			last = idx
+		}
+		w.Write(b[last:])
+	}
+	w.WriteByte('"')
+}
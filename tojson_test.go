@@ -0,0 +1,149 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSON(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		Options  JSONOptions
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "Text",
+			Input:    `<?xml version="1.0"?><!-- c --><name>Fast &amp; "XML"</name>`,
+			Expected: `{"name":"Fast & \"XML\""}`,
+		},
+		{
+			Name:     "Empty",
+			Input:    `<a/>`,
+			Expected: `{"a":""}`,
+		},
+		{
+			Name: "Nested",
+			Input: `<order id="1" xmlns:x="urn:x">
+  <item sku="a">first</item>
+  <note>gift</note>
+  <item sku="b"><![CDATA[<second>]]></item>
+  <x:total>2</x:total>
+</order>`,
+			Expected: `{"order":{"@id":"1","@xmlns:x":"urn:x","item":[{"@sku":"a","#text":"first"},{"@sku":"b","#text":"<second>"}],"note":"gift","x:total":"2"}}`,
+		},
+		{
+			Name:     "Mixed",
+			Input:    "<p>\n  Hello <b>world</b>!\n</p>",
+			Expected: `{"p":{"#text":"Hello !","b":"world"}}`,
+		},
+		{
+			Name:     "Options",
+			Input:    `<a k="v">text<b/></a>`,
+			Options:  JSONOptions{AttrPrefix: "-", TextKey: "$"},
+			Expected: `{"a":{"-k":"v","$":"text","b":""}}`,
+		},
+		{
+			Name:     "Escaping",
+			Input:    "<a>tab\there &#1;\\</a>",
+			Expected: `{"a":"tab\there \u0001\\"}`,
+		},
+		{
+			Name:     "MaxDepth",
+			Input:    `<a><b><c/></b></a>`,
+			Options:  JSONOptions{MaxDepth: 3},
+			Expected: `{"a":{"b":{"c":""}}}`,
+		},
+		{
+			Name:    "MaxDepthExceeded",
+			Input:   `<a><b><c/></b></a>`,
+			Options: JSONOptions{MaxDepth: 2},
+			Error:   "limit: depth of 3 exceeds 2 at offset 6",
+		},
+		{
+			Name:  "NoRoot",
+			Input: `<!-- nothing -->`,
+			Error: "expected a root element",
+		},
+		{
+			Name:  "Unclosed",
+			Input: `<a><b>`,
+			Error: "unexpected EOF",
+		},
+		{
+			Name:  "Entity",
+			Input: `<a><b>&bogus;</b></a>`,
+			Error: `unknown XML entity "bogus" at offset 0`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := ToJSON(&buf, []byte(tc.Input), tc.Options)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+				return
+			}
+			assert.NoError(t, err)
+			// Compare the decoded values as encoding/json escapes differently
+			var expected, actual interface{}
+			assert.NoError(t, json.Unmarshal([]byte(tc.Expected), &expected))
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual), buf.String())
+			assert.Equal(t, expected, actual)
+		})
+	}
+	var limitErr *LimitError
+	err := ToJSON(&bytes.Buffer{}, []byte(`<a><b/></a>`), JSONOptions{MaxDepth: 1})
+	assert.True(t, errors.As(err, &limitErr))
+}
+
+func TestToJSON_DefaultMaxDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		return []byte(strings.Repeat(`<a>`, depth) + strings.Repeat(`</a>`, depth))
+	}
+	// Deeply nested input is rejected by default instead of taking time quadratic in its depth
+	var limitErr *LimitError
+	err := ToJSON(&bytes.Buffer{}, nested(20000), JSONOptions{})
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, DefaultJSONMaxDepth, limitErr.Max)
+		assert.Equal(t, DefaultJSONMaxDepth+1, limitErr.Value)
+	}
+	assert.NoError(t, ToJSON(&bytes.Buffer{}, nested(DefaultJSONMaxDepth), JSONOptions{}))
+	// A negative MaxDepth is unlimited
+	var buf bytes.Buffer
+	assert.NoError(t, ToJSON(&buf, nested(DefaultJSONMaxDepth+1), JSONOptions{MaxDepth: -1}))
+	assert.Equal(t, DefaultJSONMaxDepth+1, strings.Count(buf.String(), `"a"`))
+}
+
+func TestToJSON_Order(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, ToJSON(&buf, []byte(`<a z="1"><y>1</y><x>2</x><y>3</y></a>`), JSONOptions{}))
+	assert.Equal(t, `{"a":{"@z":"1","y":["1","3"],"x":"2"}}`, buf.String())
+	buf.Reset()
+	assert.NoError(t, ToJSON(&buf, []byte("<a>\xff\x01</a>"), JSONOptions{}))
+	assert.Equal(t, "{\"a\":\"�\\u0001\"}", buf.String())
+}
+
+func BenchmarkToJSON(b *testing.B) {
+	var doc bytes.Buffer
+	doc.WriteString(`<catalog>`)
+	for i := 0; i < 1000; i++ {
+		doc.WriteString(`<book id="bk101"><author>Gambardella, Matthew</author><title>XML Developer's Guide</title><price>44.95</price></book>`)
+	}
+	doc.WriteString(`</catalog>`)
+	var out bytes.Buffer
+	b.SetBytes(int64(doc.Len()))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out.Reset()
+		if err := ToJSON(&out, doc.Bytes(), JSONOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package fastxml
+
+import (
+	"bytes"
+	"io"
+)
+
+// Kind is the type of a token
+type Kind uint8
+
+// Kinds of token
+const (
+	KindStartElement Kind = iota
+	KindEndElement
+	KindSelfClosing
+	KindCharData
+	KindCDATA
+	KindComment
+	KindProcInst
+	KindDirective
+)
+
+// kindNames are returned by Kind.String
+var kindNames = [...]string{
+	KindStartElement: "StartElement",
+	KindEndElement:   "EndElement",
+	KindSelfClosing:  "SelfClosing",
+	KindCharData:     "CharData",
+	KindCDATA:        "CDATA",
+	KindComment:      "Comment",
+	KindProcInst:     "ProcInst",
+	KindDirective:    "Directive",
+}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Unknown"
+}
+
+// TokenKind determines the Kind of a token produced by Scanner.Next
+func TokenKind(token []byte, chardata bool) Kind {
+	switch {
+	case chardata && bytes.HasPrefix(token, prefixCDATA):
+		return KindCDATA
+	case chardata:
+		return KindCharData
+	case IsComment(token):
+		return KindComment
+	case IsProcInst(token):
+		return KindProcInst
+	case IsDirective(token):
+		return KindDirective
+	case IsEndElement(token):
+		return KindEndElement
+	case IsSelfClosing(token):
+		return KindSelfClosing
+	}
+	return KindStartElement
+}
+
+// Token is a token of a document which can be modified and rendered back to XML with WriteToken
+type Token struct {
+	Kind   Kind
+	Offset int    // offset of Raw in the parsed buffer
+	Raw    []byte // the token as it appeared in the input, rendered verbatim unless Attrs is set
+	// Name and Attrs (non-decoded) of an element, if Attrs is not nil the element is rendered from
+	// Name and Attrs instead of Raw (ex: after calling SetAttr)
+	Name  []byte
+	Attrs []Attribute
+}
+
+// Tokenize splits buf into a Token for each token produced by a Scanner
+func Tokenize(buf []byte) ([]Token, error) {
+	var tokens []Token
+	s := NewScanner(buf)
+	for {
+		offset := s.Offset()
+		raw, chardata, err := s.Next()
+		if err == io.EOF {
+			return tokens, nil
+		} else if err != nil {
+			return tokens, err
+		}
+		t := Token{Kind: TokenKind(raw, chardata), Offset: offset, Raw: raw}
+		switch t.Kind {
+		case KindStartElement, KindSelfClosing, KindEndElement:
+			t.Name, _ = Element(raw)
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+// loadAttrs populates Attrs from Raw so they can be modified
+func (t *Token) loadAttrs() error {
+	if t.Attrs != nil {
+		return nil
+	}
+	_, attrsToken := Element(t.Raw)
+	t.Attrs = make([]Attribute, 0, AttrCount(attrsToken)+1)
+	return Attrs(attrsToken, func(key []byte, value []byte) bool {
+		t.Attrs = append(t.Attrs, Attribute{Key: key, Value: value})
+		return true
+	})
+}
+
+// SetAttr sets the (decoded) value of the attribute key of an element, adding it if not present
+// The order of the existing attributes is preserved
+func (t *Token) SetAttr(key []byte, value []byte) error {
+	if err := t.loadAttrs(); err != nil {
+		return err
+	}
+	escaped := EscapeAttr(nil, value)
+	for idx := range t.Attrs {
+		if bytes.Equal(t.Attrs[idx].Key, key) {
+			t.Attrs[idx].Value = escaped
+			return nil
+		}
+	}
+	t.Attrs = append(t.Attrs, Attribute{Key: key, Value: escaped})
+	return nil
+}
+
+// RemoveAttr removes the attribute key of an element (if present)
+func (t *Token) RemoveAttr(key []byte) error {
+	if err := t.loadAttrs(); err != nil {
+		return err
+	}
+	for idx := range t.Attrs {
+		if bytes.Equal(t.Attrs[idx].Key, key) {
+			t.Attrs = append(t.Attrs[:idx], t.Attrs[idx+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// WriteToken writes the XML representation of t to w
+// Unmodified tokens are written verbatim, elements with Attrs set are written with double-quoted attributes
+func WriteToken(w *bytes.Buffer, t Token) {
+	switch {
+	case t.Kind == KindEndElement && t.Raw == nil:
+		w.WriteString("</")
+		w.Write(t.Name)
+		w.WriteByte('>')
+	case (t.Kind == KindStartElement || t.Kind == KindSelfClosing) && (t.Attrs != nil || t.Raw == nil):
+		name := t.Name
+		if name == nil {
+			name, _ = Element(t.Raw)
+		}
+		w.WriteByte('<')
+		w.Write(name)
+		for _, attr := range t.Attrs {
+			w.WriteByte(' ')
+			w.Write(attr.Key)
+			w.WriteString(`="`)
+			w.Write(attr.Value)
+			w.WriteByte('"')
+		}
+		if t.Kind == KindSelfClosing {
+			w.WriteString("/>")
+		} else {
+			w.WriteByte('>')
+		}
+	default:
+		w.Write(t.Raw)
+	}
+}
+
+// RenderTokens returns the XML representation of tokens, see WriteToken
+func RenderTokens(tokens []Token) []byte {
+	var w bytes.Buffer
+	for _, t := range tokens {
+		WriteToken(&w, t)
+	}
+	return w.Bytes()
+}
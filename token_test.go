@@ -0,0 +1,62 @@
+package fastxml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenKind(t *testing.T) {
+	testCases := []struct {
+		Token    string
+		Chardata bool
+		Expected Kind
+	}{
+		{Token: `<a>`, Expected: KindStartElement},
+		{Token: `</a>`, Expected: KindEndElement},
+		{Token: `<a/>`, Expected: KindSelfClosing},
+		{Token: `text`, Chardata: true, Expected: KindCharData},
+		{Token: `<![CDATA[x]]>`, Chardata: true, Expected: KindCDATA},
+		{Token: `<!-- c -->`, Expected: KindComment},
+		{Token: `<?xml version="1.0"?>`, Expected: KindProcInst},
+		{Token: `<!DOCTYPE a>`, Expected: KindDirective},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Token, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, TokenKind([]byte(tc.Token), tc.Chardata))
+		})
+	}
+	assert.Equal(t, "SelfClosing", KindSelfClosing.String())
+	assert.Equal(t, "Unknown", Kind(100).String())
+}
+
+func TestRenderTokens(t *testing.T) {
+	const input = `<?xml version="1.0"?>
+<!-- c --><root  xmlns="urn:x"><item id="1"   version="1">a &amp; b</item><img src="x"/><![CDATA[<raw>]]></root>`
+	tokens, err := Tokenize([]byte(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	// Unmodified tokens are rendered verbatim
+	assert.Equal(t, input, string(RenderTokens(tokens)))
+	assert.Equal(t, 32, tokens[3].Offset)
+	assert.Equal(t, "root", string(tokens[3].Name))
+	// Only the modified elements are re-written
+	assert.NoError(t, tokens[4].SetAttr([]byte("version"), []byte(`2 "new"`)))
+	assert.NoError(t, tokens[4].SetAttr([]byte("lang"), []byte("en")))
+	assert.NoError(t, tokens[7].RemoveAttr([]byte("src")))
+	assert.NoError(t, tokens[7].RemoveAttr([]byte("missing")))
+	assert.Equal(t, `<?xml version="1.0"?>
+<!-- c --><root  xmlns="urn:x"><item id="1" version="2 &quot;new&quot;" lang="en">a &amp; b</item><img/><![CDATA[<raw>]]></root>`, string(RenderTokens(tokens)))
+	// Tokens may be created without Raw
+	var w bytes.Buffer
+	WriteToken(&w, Token{Kind: KindStartElement, Name: []byte("a"), Attrs: []Attribute{{Key: []byte("k"), Value: []byte("v")}}})
+	WriteToken(&w, Token{Kind: KindSelfClosing, Name: []byte("b")})
+	WriteToken(&w, Token{Kind: KindCharData, Raw: []byte("text")})
+	WriteToken(&w, Token{Kind: KindEndElement, Name: []byte("a")})
+	assert.Equal(t, `<a k="v"><b/>text</a>`, w.String())
+	// Malformed attributes can not be modified
+	bad := Token{Kind: KindStartElement, Raw: []byte(`<a b=>`)}
+	assert.Error(t, bad.SetAttr([]byte("c"), nil))
+}
@@ -0,0 +1,248 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NodeKind is the type of a Node
+type NodeKind uint8
+
+// Kinds of Node
+const (
+	DocumentNode NodeKind = iota
+	ElementNode
+	TextNode
+	CommentNode
+	ProcInstNode
+	DirectiveNode
+)
+
+// Attribute is a (non-decoded) attribute key and value
+type Attribute struct {
+	Key   []byte
+	Value []byte
+}
+
+// Node is a zero-copy tree representation of a document, every slice references the parsed buffer
+type Node struct {
+	Kind        NodeKind
+	Name        []byte      // element name
+	Token       []byte      // the start element token it was parsed from
+	Attrs       []Attribute // element attributes (not decoded)
+	Raw         []byte      // the token of any non-element node (CharData is not decoded)
+	SelfClosing bool        // if an element without children was self-closing
+	Children    []*Node
+	// Parent and the offsets of the node in the parsed buffer (including any end element)
+	// are only set by Parse, the Parent of the DocumentNode is nil
+	Parent     *Node
+	Start, End int
+
+	idx int // position of the node in the Children of its Parent when last found, see index
+}
+
+// errUnexpectedEnd is returned when an end element does not have a matching start element
+var errUnexpectedEnd = errors.New("unexpected end element")
+
+// Parse builds a DocumentNode containing every token in buf, allowing random access to parents and siblings
+// An end element which does not match the open element is rejected with a *MismatchError
+func Parse(buf []byte) (*Node, error) {
+	doc := &Node{Kind: DocumentNode, End: len(buf)}
+	stack := []*Node{doc}
+	s := NewScanner(buf)
+	for {
+		start := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		n := &Node{Raw: token, Parent: parent, Start: start, End: s.Offset()}
+		switch {
+		case chardata:
+			n.Kind = TextNode
+		case IsComment(token):
+			n.Kind = CommentNode
+		case IsDirective(token):
+			n.Kind = DirectiveNode
+		case IsProcInst(token):
+			n.Kind = ProcInstNode
+		case IsEndElement(token):
+			if len(stack) == 1 {
+				return nil, errUnexpectedEnd
+			}
+			if name, _ := Element(token); !bytes.Equal(parent.Name, name) {
+				return nil, &MismatchError{
+					StartName:   string(parent.Name),
+					StartOffset: parent.Start,
+					EndName:     string(name),
+					EndOffset:   start,
+				}
+			}
+			parent.End = s.Offset()
+			stack = stack[:len(stack)-1]
+			continue
+		default:
+			n.Kind = ElementNode
+			n.Raw = nil
+			n.Token = token
+			var attrsToken []byte
+			n.Name, attrsToken = Element(token)
+			if err := Attrs(attrsToken, func(key, value []byte) bool {
+				n.Attrs = append(n.Attrs, Attribute{Key: key, Value: value})
+				return true
+			}); err != nil {
+				return nil, err
+			}
+			if IsSelfClosing(token) {
+				n.SelfClosing = true
+			} else {
+				stack = append(stack, n)
+			}
+		}
+		n.idx = len(parent.Children)
+		parent.Children = append(parent.Children, n)
+	}
+	if len(stack) > 1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return doc, nil
+}
+
+// AttrValue returns the (non-decoded) value of the attribute key
+func (n *Node) AttrValue(key string) ([]byte, bool) {
+	for _, attr := range n.Attrs {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return nil, false
+}
+
+// index returns the index of the node in the children of its parent (or -1 if it has no parent)
+// The position recorded by Parse is checked first so only a node moved since (ex: by Patch) is searched for
+func (n *Node) index() int {
+	if n.Parent == nil {
+		return -1
+	} else if n.idx < len(n.Parent.Children) && n.Parent.Children[n.idx] == n {
+		return n.idx
+	}
+	for idx, sibling := range n.Parent.Children {
+		if sibling == n {
+			n.idx = idx
+			return idx
+		}
+	}
+	return -1
+}
+
+// NextSibling returns the node following n in its parent (or nil)
+func (n *Node) NextSibling() *Node {
+	if idx := n.index(); idx != -1 && idx+1 < len(n.Parent.Children) {
+		return n.Parent.Children[idx+1]
+	}
+	return nil
+}
+
+// PrevSibling returns the node preceding n in its parent (or nil)
+func (n *Node) PrevSibling() *Node {
+	if idx := n.index(); idx > 0 {
+		return n.Parent.Children[idx-1]
+	}
+	return nil
+}
+
+// attr returns the index of the attribute key (or -1 if not present)
+func (n *Node) attr(key []byte) int {
+	for idx, attr := range n.Attrs {
+		if bytes.Equal(attr.Key, key) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// same determines if two nodes could be the same node in different versions of a document
+func (n *Node) same(o *Node) bool {
+	return n.Kind == o.Kind && bytes.Equal(n.Name, o.Name)
+}
+
+// equal determines if two nodes (and all of their children) are identical
+func (n *Node) equal(o *Node) bool {
+	if !n.same(o) || !bytes.Equal(n.Raw, o.Raw) || len(n.Attrs) != len(o.Attrs) || len(n.Children) != len(o.Children) {
+		return false
+	}
+	for _, attr := range n.Attrs {
+		idx := o.attr(attr.Key)
+		if idx == -1 || !bytes.Equal(attr.Value, o.Attrs[idx].Value) {
+			return false
+		}
+	}
+	for idx, child := range n.Children {
+		if !child.equal(o.Children[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendXML appends the XML representation of the node to dst
+func (n *Node) appendXML(dst []byte) []byte {
+	if n.Kind != ElementNode && n.Kind != DocumentNode {
+		return append(dst, n.Raw...)
+	}
+	if n.Kind == ElementNode {
+		dst = append(dst, '<')
+		dst = append(dst, n.Name...)
+		for _, attr := range n.Attrs {
+			dst = append(dst, ' ')
+			dst = append(dst, attr.Key...)
+			dst = append(dst, '=', '"')
+			dst = append(dst, attr.Value...)
+			dst = append(dst, '"')
+		}
+		if len(n.Children) == 0 && n.SelfClosing {
+			return append(dst, '/', '>')
+		}
+		dst = append(dst, '>')
+	}
+	for _, child := range n.Children {
+		dst = child.appendXML(dst)
+	}
+	if n.Kind == ElementNode {
+		dst = append(dst, '<', '/')
+		dst = append(dst, n.Name...)
+		dst = append(dst, '>')
+	}
+	return dst
+}
+
+// step returns the XPath step selecting the node among its siblings given its 1-based position
+// amongst siblings of the same kind (and name)
+func (n *Node) step(position int) string {
+	switch n.Kind {
+	case TextNode:
+		return fmt.Sprintf("text()[%d]", position)
+	case CommentNode:
+		return fmt.Sprintf("comment()[%d]", position)
+	case ProcInstNode:
+		return fmt.Sprintf("processing-instruction()[%d]", position)
+	default:
+		return fmt.Sprintf("%s[%d]", n.Name, position)
+	}
+}
+
+// position returns the 1-based position of children[idx] amongst the preceding siblings which are the same
+func position(children []*Node, idx int) int {
+	pos := 1
+	for _, sibling := range children[:idx] {
+		if sibling.same(children[idx]) {
+			pos++
+		}
+	}
+	return pos
+}
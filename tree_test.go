@@ -0,0 +1,82 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	input := []byte(`<?xml version="1.0"?><root a="1" b="&amp;"><first>text</first><!-- c --><second/></root>`)
+	doc, err := Parse(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, DocumentNode, doc.Kind)
+	assert.Nil(t, doc.Parent)
+	assert.Equal(t, string(input), string(input[doc.Start:doc.End]))
+	if !assert.Len(t, doc.Children, 2) {
+		return
+	}
+	assert.Equal(t, ProcInstNode, doc.Children[0].Kind)
+	root := doc.Children[1]
+	assert.Equal(t, ElementNode, root.Kind)
+	assert.Equal(t, "root", string(root.Name))
+	assert.Equal(t, doc, root.Parent)
+	assert.Equal(t, `<root a="1" b="&amp;"><first>text</first><!-- c --><second/></root>`, string(input[root.Start:root.End]))
+	value, ok := root.AttrValue("b")
+	assert.True(t, ok)
+	assert.Equal(t, "&amp;", string(value))
+	_, ok = root.AttrValue("c")
+	assert.False(t, ok)
+	if !assert.Len(t, root.Children, 3) {
+		return
+	}
+	first, comment, second := root.Children[0], root.Children[1], root.Children[2]
+	assert.Equal(t, `<first>text</first>`, string(input[first.Start:first.End]))
+	assert.Equal(t, TextNode, first.Children[0].Kind)
+	assert.Equal(t, "text", string(first.Children[0].Raw))
+	assert.Equal(t, first, first.Children[0].Parent)
+	assert.Equal(t, CommentNode, comment.Kind)
+	assert.True(t, second.SelfClosing)
+	assert.Equal(t, `<second/>`, string(input[second.Start:second.End]))
+	assert.Equal(t, comment, first.NextSibling())
+	assert.Equal(t, second, comment.NextSibling())
+	assert.Nil(t, second.NextSibling())
+	assert.Equal(t, comment, second.PrevSibling())
+	assert.Nil(t, first.PrevSibling())
+	assert.Nil(t, doc.NextSibling())
+}
+
+func TestNode_Siblings(t *testing.T) {
+	// Walking every sibling is linear, not quadratic
+	doc, err := Parse([]byte(`<a>` + strings.Repeat(`<b/>`, 100000) + `</a>`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	root := doc.Children[0]
+	count := 0
+	for n := root.Children[0]; n != nil; n = n.NextSibling() {
+		count++
+	}
+	assert.Equal(t, len(root.Children), count)
+	// Children which have been moved are still found
+	first, last := root.Children[0], root.Children[len(root.Children)-1]
+	root.Children[0], root.Children[len(root.Children)-1] = last, first
+	assert.Equal(t, root.Children[1], last.NextSibling())
+	assert.Nil(t, last.PrevSibling())
+	assert.Equal(t, root.Children[len(root.Children)-2], first.PrevSibling())
+	root.Children = root.Children[1:]
+	assert.Nil(t, last.NextSibling())
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse([]byte(`<a></a></b>`))
+	assert.Equal(t, errUnexpectedEnd, err)
+	_, err = Parse([]byte(`<a><b></b>`))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = Parse([]byte(`<a><b></a></b>`))
+	assert.Equal(t, &MismatchError{StartName: "b", StartOffset: 3, EndName: "a", EndOffset: 6}, err)
+}
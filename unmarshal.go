@@ -0,0 +1,886 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The encoding/xml types are detected by name so that they are supported without importing encoding/xml
+const (
+	xmlPkgPath = "encoding/xml"
+	xmlName    = "XMLName"
+	xmlURL     = "http://www.w3.org/XML/1998/namespace"
+)
+
+// isXMLType checks if typ is the named type from encoding/xml (ex: xml.Name)
+func isXMLType(typ reflect.Type, name string) bool {
+	return typ.Name() == name && typ.PkgPath() == xmlPkgPath
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// UnmarshalError describes a document which does not match the value it is unmarshalled into
+type UnmarshalError string
+
+// Error implements the error interface
+func (e UnmarshalError) Error() string {
+	return string(e)
+}
+
+// field flags, the same as those used by encoding/xml
+const (
+	fElement = 1 << iota
+	fAttr
+	fCDATA
+	fCharData
+	fInnerXML
+	fComment
+	fAny
+	fOmitEmpty
+
+	fMode = fElement | fAttr | fCDATA | fCharData | fInnerXML | fComment | fAny
+)
+
+// fieldInfo holds the details of a single field from the xml struct tag
+type fieldInfo struct {
+	idx     []int
+	name    string
+	xmlns   string
+	flags   int
+	parents []string
+}
+
+// typeInfo holds the details of a struct type
+type typeInfo struct {
+	xmlname *fieldInfo
+	fields  []fieldInfo
+}
+
+// tinfoMap caches the *typeInfo for each reflect.Type
+var tinfoMap sync.Map
+
+// getTypeInfo returns the (cached) typeInfo for typ
+func getTypeInfo(typ reflect.Type) (*typeInfo, error) {
+	if ti, ok := tinfoMap.Load(typ); ok {
+		return ti.(*typeInfo), nil
+	}
+	tinfo := &typeInfo{}
+	if typ.Kind() == reflect.Struct && !isXMLType(typ, "Name") {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if (f.PkgPath != "" && !f.Anonymous) || f.Tag.Get("xml") == "-" {
+				continue
+			}
+			// Embedded structs have their fields promoted
+			if f.Anonymous {
+				t := f.Type
+				if t.Kind() == reflect.Ptr {
+					t = t.Elem()
+				}
+				if t.Kind() == reflect.Struct {
+					inner, err := getTypeInfo(t)
+					if err != nil {
+						return nil, err
+					}
+					if tinfo.xmlname == nil {
+						tinfo.xmlname = inner.xmlname
+					}
+					for _, finfo := range inner.fields {
+						finfo.idx = append([]int{i}, finfo.idx...)
+						tinfo.addField(&finfo)
+					}
+					continue
+				}
+			}
+			finfo, err := structFieldInfo(typ, &f)
+			if err != nil {
+				return nil, err
+			}
+			if f.Name == xmlName {
+				tinfo.xmlname = finfo
+				continue
+			}
+			tinfo.addField(finfo)
+		}
+	}
+	ti, _ := tinfoMap.LoadOrStore(typ, tinfo)
+	return ti.(*typeInfo), nil
+}
+
+// addField adds a field unless it conflicts with a field which is less nested
+func (tinfo *typeInfo) addField(newf *fieldInfo) {
+	for i := range tinfo.fields {
+		oldf := &tinfo.fields[i]
+		if oldf.flags&fMode != newf.flags&fMode || oldf.name != newf.name || oldf.xmlns != newf.xmlns ||
+			strings.Join(oldf.parents, ">") != strings.Join(newf.parents, ">") {
+			continue
+		}
+		if len(newf.idx) < len(oldf.idx) {
+			*oldf = *newf
+		}
+		return
+	}
+	tinfo.fields = append(tinfo.fields, *newf)
+}
+
+// structFieldInfo parses the xml struct tag of a field
+func structFieldInfo(typ reflect.Type, f *reflect.StructField) (*fieldInfo, error) {
+	finfo := &fieldInfo{idx: f.Index}
+	tag := f.Tag.Get("xml")
+	if idx := strings.IndexByte(tag, ' '); idx != -1 {
+		finfo.xmlns, tag = tag[:idx], tag[idx+1:]
+	}
+	tokens := strings.Split(tag, ",")
+	if len(tokens) == 1 {
+		finfo.flags = fElement
+	} else {
+		tag = tokens[0]
+		for _, flag := range tokens[1:] {
+			switch flag {
+			case "attr":
+				finfo.flags |= fAttr
+			case "cdata":
+				finfo.flags |= fCDATA
+			case "chardata":
+				finfo.flags |= fCharData
+			case "innerxml":
+				finfo.flags |= fInnerXML
+			case "comment":
+				finfo.flags |= fComment
+			case "any":
+				finfo.flags |= fAny
+			case "omitempty":
+				finfo.flags |= fOmitEmpty
+			}
+		}
+		valid := true
+		switch mode := finfo.flags & fMode; mode {
+		case 0:
+			finfo.flags |= fElement
+		case fAttr, fCDATA, fCharData, fInnerXML, fComment, fAny, fAny | fAttr:
+			if f.Name == xmlName || tag != "" && mode != fAttr {
+				valid = false
+			}
+		default:
+			valid = false
+		}
+		if finfo.flags&fMode == fAny {
+			finfo.flags |= fElement
+		}
+		if finfo.flags&fOmitEmpty != 0 && finfo.flags&(fElement|fAttr) == 0 {
+			valid = false
+		}
+		if !valid {
+			return nil, fmt.Errorf("xml: invalid tag in field %s of type %s: %q", f.Name, typ, f.Tag.Get("xml"))
+		}
+	}
+	if finfo.xmlns != "" && tag == "" {
+		return nil, fmt.Errorf("xml: namespace without name in field %s of type %s: %q", f.Name, typ, f.Tag.Get("xml"))
+	}
+	if f.Name == xmlName {
+		finfo.name = tag
+		return finfo, nil
+	}
+	if tag == "" {
+		// Use the XMLName of the field type if present, otherwise the field name
+		if xmlname := lookupXMLName(f.Type); xmlname != nil {
+			finfo.xmlns, finfo.name = xmlname.xmlns, xmlname.name
+		} else {
+			finfo.name = f.Name
+		}
+		return finfo, nil
+	}
+	parents := strings.Split(tag, ">")
+	if parents[0] == "" {
+		parents[0] = f.Name
+	}
+	if parents[len(parents)-1] == "" {
+		return nil, fmt.Errorf("xml: trailing '>' in field %s of type %s", f.Name, typ)
+	}
+	finfo.name = parents[len(parents)-1]
+	if len(parents) > 1 {
+		if finfo.flags&fElement == 0 {
+			return nil, fmt.Errorf("xml: %s chain not valid with %s flag", tag, strings.Join(tokens[1:], ","))
+		}
+		finfo.parents = parents[:len(parents)-1]
+	}
+	if finfo.flags&fElement != 0 {
+		if xmlname := lookupXMLName(f.Type); xmlname != nil && xmlname.name != finfo.name {
+			return nil, fmt.Errorf("xml: name %q in tag of %s.%s conflicts with name %q in %s.XMLName",
+				finfo.name, typ, f.Name, xmlname.name, f.Type)
+		}
+	}
+	return finfo, nil
+}
+
+// lookupXMLName returns the fieldInfo of the XMLName field of typ (if present and named)
+func lookupXMLName(typ reflect.Type) *fieldInfo {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	if f, ok := typ.FieldByName(xmlName); ok && len(f.Index) == 1 {
+		if finfo, err := structFieldInfo(typ, &f); err == nil && finfo.name != "" {
+			return finfo
+		}
+	}
+	return nil
+}
+
+// value returns the field of v allocating any nil embedded pointers
+func (finfo *fieldInfo) value(v reflect.Value) reflect.Value {
+	for i, x := range finfo.idx {
+		if i > 0 && v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// unmarshalName is a namespace resolved element or attribute name
+type unmarshalName struct {
+	space, local string
+}
+
+// unmarshalAttr is an attribute with a resolved name and decoded value
+type unmarshalAttr struct {
+	name  unmarshalName
+	value []byte
+}
+
+// unmarshalStart is a start element with resolved names
+type unmarshalStart struct {
+	token       []byte
+	raw         []byte // the raw element name
+	name        unmarshalName
+	attrs       []unmarshalAttr
+	selfClosing bool
+}
+
+// decodeState holds the state of a single Unmarshal
+type decodeState struct {
+	s    *Scanner
+	copy bool // strings are copied instead of referencing the input
+	ns   Namespaces
+}
+
+// str converts buf to a string, copying if needed
+func (d *decodeState) str(buf []byte) string {
+	if d.copy {
+		return string(buf)
+	}
+	return String(buf)
+}
+
+// normalizeNewlines converts "\r\n" and "\r" to "\n" as required by the XML spec
+func normalizeNewlines(buf []byte) []byte {
+	if bytes.IndexByte(buf, '\r') == -1 {
+		return buf
+	}
+	out := make([]byte, 0, len(buf))
+	for idx := 0; idx < len(buf); idx++ {
+		if buf[idx] == '\r' {
+			if idx+1 < len(buf) && buf[idx+1] == '\n' {
+				continue
+			}
+			out = append(out, '\n')
+			continue
+		}
+		out = append(out, buf[idx])
+	}
+	return out
+}
+
+// translate resolves the prefix of a name the same way encoding/xml does
+func (d *decodeState) translate(raw []byte, isElementName bool) unmarshalName {
+	space, local := d.ns.Resolve(raw, !isElementName)
+	if d.copy {
+		space = string(append([]byte(nil), space...))
+	}
+	return unmarshalName{space: space, local: d.str(local)}
+}
+
+// start parses a start element pushing any namespace declarations, the caller must Pop d.ns
+func (d *decodeState) start(token []byte) (*unmarshalStart, error) {
+	name, attrsToken := Element(token)
+	start := &unmarshalStart{
+		token:       token,
+		raw:         name,
+		selfClosing: d.s.SelfClosing(token),
+	}
+	if err := d.ns.Push(token); err != nil {
+		return nil, err
+	}
+	var attrErr error
+	if err := Attrs(attrsToken, func(key, value []byte) bool {
+		decoded, err := DecodeEntities(value, nil)
+		if err != nil {
+			attrErr = err
+			return false
+		}
+		start.attrs = append(start.attrs, unmarshalAttr{value: normalizeNewlines(decoded)})
+		return true
+	}); err != nil {
+		return nil, err
+	} else if attrErr != nil {
+		return nil, attrErr
+	}
+	idx := 0
+	if err := Attrs(attrsToken, func(key, _ []byte) bool {
+		start.attrs[idx].name = d.translate(key, false)
+		idx++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	start.name = d.translate(name, true)
+	return start, nil
+}
+
+// setName sets an xml.Name value
+func setName(v reflect.Value, n unmarshalName) {
+	v.FieldByName("Space").SetString(n.space)
+	v.FieldByName("Local").SetString(n.local)
+}
+
+// unsupported checks for the encoding/xml interfaces which require an *xml.Decoder
+func unsupported(v reflect.Value, method string) error {
+	typ := v.Type()
+	if _, ok := typ.MethodByName(method); ok {
+		return fmt.Errorf("fastxml: %s implements %s which is not supported", typ, method)
+	}
+	if v.CanAddr() {
+		if _, ok := reflect.PtrTo(typ).MethodByName(method); ok {
+			return fmt.Errorf("fastxml: %s implements %s which is not supported", typ, method)
+		}
+	}
+	return nil
+}
+
+// textUnmarshaler returns v as an encoding.TextUnmarshaler if implemented
+func textUnmarshaler(v reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if v.CanInterface() && v.Type().Implements(textUnmarshalerType) {
+		return v.Interface().(encoding.TextUnmarshaler), true
+	}
+	if v.CanAddr() {
+		if pv := v.Addr(); pv.CanInterface() && pv.Type().Implements(textUnmarshalerType) {
+			return pv.Interface().(encoding.TextUnmarshaler), true
+		}
+	}
+	return nil, false
+}
+
+// ElementUnmarshaler is implemented by types with generated (reflection-free) decoding, see cmd/fastxmlgen
+// Unmarshal calls it instead of using reflection for any value which implements it
+type ElementUnmarshaler interface {
+	// UnmarshalFastXMLElement decodes the element whose start element token was most recently read from s
+	// reading until (and including) the matching end element
+	UnmarshalFastXMLElement(s *Scanner, startToken []byte) error
+}
+
+// elementUnmarshaler returns v as an ElementUnmarshaler if implemented
+func elementUnmarshaler(v reflect.Value) (ElementUnmarshaler, bool) {
+	if v.CanAddr() {
+		if pv := v.Addr(); pv.CanInterface() {
+			eu, ok := pv.Interface().(ElementUnmarshaler)
+			return eu, ok
+		}
+	}
+	return nil, false
+}
+
+// UnmarshalElement reads the next start element from s and decodes it into v
+func UnmarshalElement(s *Scanner, v ElementUnmarshaler) error {
+	for {
+		token, chardata, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if !chardata && IsElement(token) && !IsEndElement(token) {
+			return v.UnmarshalFastXMLElement(s, token)
+		}
+	}
+}
+
+// copyValue sets dst (a basic type) from src
+func (d *decodeState) copyValue(dst reflect.Value, src []byte) error {
+	dst0 := dst
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		dst = dst.Elem()
+	}
+	switch dst.Kind() {
+	case reflect.Invalid:
+	default:
+		return errors.New("cannot unmarshal into " + dst0.Type().String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(src) == 0 {
+			dst.SetInt(0)
+			return nil
+		}
+		i, err := strconv.ParseInt(strings.TrimSpace(String(src)), 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if len(src) == 0 {
+			dst.SetUint(0)
+			return nil
+		}
+		u, err := strconv.ParseUint(strings.TrimSpace(String(src)), 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if len(src) == 0 {
+			dst.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(String(src)), dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Bool:
+		if len(src) == 0 {
+			dst.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(String(src)))
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.String:
+		dst.SetString(d.str(src))
+	case reflect.Slice:
+		if len(src) == 0 {
+			src = []byte{}
+		} else if d.copy {
+			src = append([]byte(nil), src...)
+		}
+		dst.SetBytes(src)
+	}
+	return nil
+}
+
+// unmarshalAttr sets val from an attribute
+func (d *decodeState) unmarshalAttr(val reflect.Value, attr unmarshalAttr) error {
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+	if err := unsupported(val, "UnmarshalXMLAttr"); err != nil {
+		return err
+	}
+	if tu, ok := textUnmarshaler(val); ok {
+		return tu.UnmarshalText(attr.value)
+	}
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8 {
+		n := val.Len()
+		val.Set(reflect.Append(val, reflect.Zero(val.Type().Elem())))
+		if err := d.unmarshalAttr(val.Index(n), attr); err != nil {
+			val.SetLen(n)
+			return err
+		}
+		return nil
+	}
+	if isXMLType(val.Type(), "Attr") {
+		setName(val.FieldByName("Name"), attr.name)
+		val.FieldByName("Value").SetString(d.str(attr.value))
+		return nil
+	}
+	return d.copyValue(val, attr.value)
+}
+
+// errUnexpectedEOF is returned if the document ends before the element being unmarshalled
+var errUnexpectedEOF = errors.New("unexpected EOF")
+
+// nextToken returns the next token treating io.EOF as unexpected
+func (d *decodeState) nextToken() ([]byte, bool, error) {
+	token, chardata, err := d.s.Next()
+	if err == io.EOF {
+		return nil, false, errUnexpectedEOF
+	}
+	return token, chardata, err
+}
+
+// end checks that the end element closes start
+func (d *decodeState) end(start *unmarshalStart, token []byte) error {
+	if name, _ := Element(token); !bytes.Equal(name, start.raw) {
+		return fmt.Errorf("element <%s> closed by </%s>", start.raw, name)
+	}
+	return nil
+}
+
+// skip skips the rest of the element start
+func (d *decodeState) skip(start *unmarshalStart) error {
+	if err := d.s.SkipElement(start.token); err == io.EOF {
+		return errUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// unmarshalText collects the CharData directly within the element for an encoding.TextUnmarshaler
+func (d *decodeState) unmarshalText(tu encoding.TextUnmarshaler, start *unmarshalStart) error {
+	var buf []byte
+	for depth := 1; depth > 0 && !start.selfClosing; {
+		token, chardata, err := d.nextToken()
+		if err != nil {
+			return err
+		}
+		switch {
+		case chardata:
+			if depth == 1 {
+				if buf, err = CharDataAppend(buf, token); err != nil {
+					return err
+				}
+			}
+		case !IsElement(token), d.s.SelfClosing(token):
+		case IsEndElement(token):
+			depth--
+		default:
+			depth++
+		}
+	}
+	return tu.UnmarshalText(normalizeNewlines(buf))
+}
+
+// unmarshal sets val from the element start
+func (d *decodeState) unmarshal(val reflect.Value, start *unmarshalStart) error {
+	if start == nil {
+		for {
+			token, chardata, err := d.s.Next()
+			if err != nil {
+				return err
+			}
+			if !chardata && IsElement(token) && !IsEndElement(token) {
+				if start, err = d.start(token); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	if val.Kind() == reflect.Interface && !val.IsNil() {
+		if e := val.Elem(); e.Kind() == reflect.Ptr && !e.IsNil() {
+			val = e
+		}
+	}
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+	if err := unsupported(val, "UnmarshalXML"); err != nil {
+		return err
+	}
+	if eu, ok := elementUnmarshaler(val); ok {
+		return eu.UnmarshalFastXMLElement(d.s, start.token)
+	}
+	if tu, ok := textUnmarshaler(val); ok {
+		return d.unmarshalText(tu, start)
+	}
+
+	var (
+		data, comment                  []byte
+		saveData, saveComment, saveAny reflect.Value
+		saveXML                        reflect.Value
+		sv                             reflect.Value
+		tinfo                          *typeInfo
+		err                            error
+	)
+	switch v := val; v.Kind() {
+	default:
+		return errors.New("unknown type " + v.Type().String())
+	case reflect.Interface:
+		return d.skip(start)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			saveData = v
+			break
+		}
+		n := v.Len()
+		v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		if err := d.unmarshal(v.Index(n), start); err != nil {
+			v.SetLen(n)
+			return err
+		}
+		return nil
+	case reflect.Bool, reflect.Float32, reflect.Float64, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		saveData = v
+	case reflect.Struct:
+		if isXMLType(v.Type(), "Name") {
+			setName(v, start.name)
+			break
+		}
+		sv = v
+		if tinfo, err = getTypeInfo(v.Type()); err != nil {
+			return err
+		}
+		if finfo := tinfo.xmlname; finfo != nil {
+			if finfo.name != "" && finfo.name != start.name.local {
+				return UnmarshalError("expected element type <" + finfo.name + "> but have <" + start.name.local + ">")
+			}
+			if finfo.xmlns != "" && finfo.xmlns != start.name.space {
+				e := "expected element <" + finfo.name + "> in name space " + finfo.xmlns + " but have "
+				if start.name.space == "" {
+					e += "no name space"
+				} else {
+					e += start.name.space
+				}
+				return UnmarshalError(e)
+			}
+			if fv := finfo.value(sv); isXMLType(fv.Type(), "Name") {
+				setName(fv, start.name)
+			}
+		}
+		for _, attr := range start.attrs {
+			handled := false
+			any := -1
+			for i := range tinfo.fields {
+				finfo := &tinfo.fields[i]
+				switch finfo.flags & fMode {
+				case fAttr:
+					if attr.name.local == finfo.name && (finfo.xmlns == "" || finfo.xmlns == attr.name.space) {
+						if err := d.unmarshalAttr(finfo.value(sv), attr); err != nil {
+							return err
+						}
+						handled = true
+					}
+				case fAny | fAttr:
+					if any == -1 {
+						any = i
+					}
+				}
+			}
+			if !handled && any >= 0 {
+				if err := d.unmarshalAttr(tinfo.fields[any].value(sv), attr); err != nil {
+					return err
+				}
+			}
+		}
+		for i := range tinfo.fields {
+			finfo := &tinfo.fields[i]
+			switch finfo.flags & fMode {
+			case fCharData:
+				if !saveData.IsValid() {
+					saveData = finfo.value(sv)
+				}
+			case fComment:
+				if !saveComment.IsValid() {
+					saveComment = finfo.value(sv)
+				}
+			case fAny, fAny | fElement:
+				if !saveAny.IsValid() {
+					saveAny = finfo.value(sv)
+				}
+			case fInnerXML:
+				if !saveXML.IsValid() {
+					saveXML = finfo.value(sv)
+				}
+			}
+		}
+	}
+
+	innerStart, innerEnd := d.s.Offset(), d.s.Offset()
+	for !start.selfClosing {
+		offset := d.s.Offset()
+		token, chardata, err := d.nextToken()
+		if err != nil {
+			return err
+		}
+		if chardata {
+			if saveData.IsValid() {
+				text, err := CharData(token, nil)
+				if err != nil {
+					return err
+				}
+				text = normalizeNewlines(text)
+				if data == nil && !d.copy {
+					// Reference the input when possible, capacity is limited so append copies
+					data = text[:len(text):len(text)]
+				} else {
+					data = append(data, text...)
+				}
+			}
+			continue
+		}
+		if IsComment(token) {
+			if saveComment.IsValid() {
+				comment = append(comment, Comment(token)...)
+			}
+			continue
+		}
+		if !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			if err := d.end(start, token); err != nil {
+				return err
+			}
+			innerEnd = offset
+			break
+		}
+		child, err := d.start(token)
+		if err != nil {
+			return err
+		}
+		consumed := false
+		if sv.IsValid() {
+			if consumed, err = d.unmarshalPath(tinfo, sv, nil, child); err != nil {
+				return err
+			}
+			if !consumed && saveAny.IsValid() {
+				consumed = true
+				if err := d.unmarshal(saveAny, child); err != nil {
+					return err
+				}
+			}
+		}
+		if !consumed {
+			if err := d.skip(child); err != nil {
+				return err
+			}
+		}
+		d.ns.Pop()
+	}
+
+	if saveData.IsValid() {
+		if tu, ok := textUnmarshaler(saveData); ok {
+			if err := tu.UnmarshalText(data); err != nil {
+				return err
+			}
+			saveData = reflect.Value{}
+		}
+	}
+	if err := d.copyValue(saveData, data); err != nil {
+		return err
+	}
+	switch t := saveComment; t.Kind() {
+	case reflect.String:
+		t.SetString(string(comment))
+	case reflect.Slice:
+		t.Set(reflect.ValueOf(comment))
+	}
+	inner := d.s.buf[innerStart:innerEnd]
+	switch t := saveXML; t.Kind() {
+	case reflect.String:
+		t.SetString(d.str(inner))
+	case reflect.Slice:
+		if t.Type().Elem().Kind() == reflect.Uint8 {
+			if d.copy {
+				inner = append([]byte{}, inner...)
+			}
+			t.Set(reflect.ValueOf(inner))
+		}
+	}
+	return nil
+}
+
+// unmarshalPath matches start against the fields of a struct, including fields with a parent path (ex: `xml:"a>b"`)
+func (d *decodeState) unmarshalPath(tinfo *typeInfo, sv reflect.Value, parents []string, start *unmarshalStart) (consumed bool, err error) {
+	recurse := false
+Loop:
+	for i := range tinfo.fields {
+		finfo := &tinfo.fields[i]
+		if finfo.flags&fElement == 0 || len(finfo.parents) < len(parents) || finfo.xmlns != "" && finfo.xmlns != start.name.space {
+			continue
+		}
+		for j := range parents {
+			if parents[j] != finfo.parents[j] {
+				continue Loop
+			}
+		}
+		if len(finfo.parents) == len(parents) && finfo.name == start.name.local {
+			return true, d.unmarshal(finfo.value(sv), start)
+		}
+		if len(finfo.parents) > len(parents) && finfo.parents[len(parents)] == start.name.local {
+			recurse = true
+			parents = finfo.parents[:len(parents)+1]
+			break
+		}
+	}
+	if !recurse {
+		return false, nil
+	}
+	for !start.selfClosing {
+		token, chardata, err := d.nextToken()
+		if err != nil {
+			return true, err
+		}
+		if chardata || !IsElement(token) {
+			continue
+		}
+		if IsEndElement(token) {
+			return true, d.end(start, token)
+		}
+		child, err := d.start(token)
+		if err != nil {
+			return true, err
+		}
+		consumed, err := d.unmarshalPath(tinfo, sv, parents, child)
+		if err != nil {
+			return true, err
+		}
+		if !consumed {
+			if err := d.skip(child); err != nil {
+				return true, err
+			}
+		}
+		d.ns.Pop()
+	}
+	return true, nil
+}
+
+// decode unmarshals the first element in data into v
+func decode(data []byte, v interface{}, copy bool) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr {
+		return errors.New("non-pointer passed to Unmarshal")
+	}
+	if val.IsNil() {
+		return errors.New("nil pointer passed to Unmarshal")
+	}
+	d := &decodeState{s: NewScanner(data), copy: copy}
+	return d.unmarshal(val.Elem(), nil)
+}
+
+// UnmarshalCompat is a drop-in replacement for xml.Unmarshal which produces identical results
+// for well-formed documents, every string is copied so the result does not reference data
+// Types implementing xml.Unmarshaler or xml.UnmarshalerAttr are not supported
+func UnmarshalCompat(data []byte, v interface{}) error {
+	return decode(data, v, true)
+}
+
+// Unmarshal parses the first element in data into v following the same rules as xml.Unmarshal
+// (struct tags such as `xml:"name,attr"`, `xml:",chardata"`, `xml:",innerxml"` and `xml:"a>b"`)
+// Strings and []byte values reference data whenever possible so data must not be modified afterwards,
+// use UnmarshalCompat if that is not possible
+func Unmarshal(data []byte, v interface{}) error {
+	return decode(data, v, false)
+}
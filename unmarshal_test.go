@@ -0,0 +1,242 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unmarshalAddress struct {
+	City  string `xml:"city"`
+	State string `xml:"state"`
+}
+
+type unmarshalEmail struct {
+	Where string `xml:"where,attr"`
+	Addr  string
+}
+
+type unmarshalBase struct {
+	ID      int    `xml:"id,attr"`
+	Comment string `xml:",comment"`
+}
+
+type unmarshalPerson struct {
+	XMLName xml.Name `xml:"person"`
+	unmarshalBase
+	Name    string           `xml:"FullName"`
+	Phone   string           `xml:"phone,omitempty"`
+	Email   []unmarshalEmail `xml:"email"`
+	Groups  []string         `xml:"group>value"`
+	Age     *uint8           `xml:"age"`
+	Height  float64          `xml:"height"`
+	Married bool             `xml:"married,attr"`
+	Born    time.Time        `xml:"born"`
+	Ignored string           `xml:"-"`
+	unmarshalAddress
+}
+
+type unmarshalAny struct {
+	XMLName xml.Name
+	Lang    string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Link    string     `xml:"urn:links link"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    []byte     `xml:",chardata"`
+	Inner   string     `xml:",innerxml"`
+	Other   []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+type unmarshalText struct {
+	Name  xml.Name    `xml:"name"`
+	Num   *int        `xml:"num"`
+	Data  []byte      `xml:"data"`
+	Nums  []int       `xml:"n"`
+	Iface interface{} `xml:"iface"`
+}
+
+// unmarshalCorpus is shared by the differential tests against encoding/xml
+var unmarshalCorpus = []struct {
+	Name  string
+	Input string
+	New   func() interface{}
+}{
+	{
+		Name: "person",
+		Input: `<?xml version="1.0"?>
+<!-- leading -->
+<person id="13" married="true">
+	<!-- a comment -->
+	<FullName>Grace R. Emlin</FullName>
+	<email where="home"><Addr>gre@example.com</Addr></email>
+	<email where="work"><Addr>gre@work.com</Addr></email>
+	<group><value>Friends</value><value>Squash</value></group>
+	<age> 42 </age>
+	<height>1.75</height>
+	<born>1985-01-02T03:04:05Z</born>
+	<city>Hanga Roa</city>
+	<state>Easter Island</state>
+	<unknown><city>nested is ignored</city></unknown>
+	<Ignored>x</Ignored>
+</person>`,
+		New: func() interface{} { return new(unmarshalPerson) },
+	}, {
+		Name:  "wrong root",
+		Input: `<people/>`,
+		New:   func() interface{} { return new(unmarshalPerson) },
+	}, {
+		Name:  "invalid int",
+		Input: `<person><age>old</age></person>`,
+		New:   func() interface{} { return new(unmarshalPerson) },
+	}, {
+		Name: "namespaces",
+		Input: `<root xmlns="urn:default" xmlns:l="urn:links" xml:lang="en" l:extra="1" plain="2">text &amp; more` +
+			"\r\n" + `<l:link>https://example.com</l:link><other xmlns="urn:other">a</other><l:link2>b</l:link2><![CDATA[<cdata>]]></root>`,
+		New: func() interface{} { return new(unmarshalAny) },
+	}, {
+		Name:  "text types",
+		Input: `<t><name xmlns="urn:n">ignored</name><num>7</num><data>bytes</data><n>1</n><n>2</n><iface>x</iface></t>`,
+		New:   func() interface{} { return new(unmarshalText) },
+	}, {
+		Name:  "empty values",
+		Input: `<t><num/><data></data><n></n></t>`,
+		New:   func() interface{} { return new(unmarshalText) },
+	}, {
+		Name:  "string",
+		Input: `<s>hello <b>world</b> &lt;3</s>`,
+		New:   func() interface{} { return new(string) },
+	}, {
+		Name:  "slice",
+		Input: `<n> 12 </n>`,
+		New:   func() interface{} { return new([]int) },
+	}, {
+		Name:  "empty",
+		Input: `  `,
+		New:   func() interface{} { return new(string) },
+	}, {
+		Name:  "unexpected EOF",
+		Input: `<person><FullName>x</FullName>`,
+		New:   func() interface{} { return new(unmarshalPerson) },
+	}, {
+		Name:  "mismatched end",
+		Input: `<person><FullName>x</Name></person>`,
+		New:   func() interface{} { return new(unmarshalPerson) },
+	},
+}
+
+func TestUnmarshalCompat(t *testing.T) {
+	for _, tc := range unmarshalCorpus {
+		t.Run(tc.Name, func(t *testing.T) {
+			expected := tc.New()
+			expectedErr := xml.Unmarshal([]byte(tc.Input), expected)
+			actual := tc.New()
+			actualErr := UnmarshalCompat([]byte(tc.Input), actual)
+			if expectedErr != nil {
+				assert.Error(t, actualErr, "encoding/xml failed with %v", expectedErr)
+				return
+			}
+			assert.NoError(t, actualErr)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, tc := range unmarshalCorpus {
+		t.Run(tc.Name, func(t *testing.T) {
+			expected := tc.New()
+			expectedErr := xml.Unmarshal([]byte(tc.Input), expected)
+			actual := tc.New()
+			actualErr := Unmarshal([]byte(tc.Input), actual)
+			if expectedErr != nil {
+				assert.Error(t, actualErr, "encoding/xml failed with %v", expectedErr)
+				return
+			}
+			assert.NoError(t, actualErr)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestUnmarshal_ZeroCopy(t *testing.T) {
+	input := []byte(`<s>hello<!-- comment -->world</s><other/>`)
+	var actual string
+	assert.NoError(t, Unmarshal(input, &actual))
+	assert.Equal(t, "helloworld", actual)
+	assert.Equal(t, `<s>hello<!-- comment -->world</s><other/>`, string(input))
+	var v struct {
+		Attr  string `xml:"attr,attr"`
+		Inner []byte `xml:",innerxml"`
+	}
+	input = []byte(`<v attr="value"><a/></v>`)
+	assert.NoError(t, Unmarshal(input, &v))
+	copy(input, strings.Repeat("x", len(input)))
+	if zeroCopy {
+		assert.Equal(t, "xxxxx", v.Attr)
+	} else {
+		assert.Equal(t, "value", v.Attr)
+	}
+	assert.Equal(t, "xxxx", string(v.Inner))
+}
+
+func TestUnmarshalCompat_Copy(t *testing.T) {
+	input := []byte(`<s>hello</s>`)
+	var actual string
+	assert.NoError(t, UnmarshalCompat(input, &actual))
+	copy(input, strings.Repeat("x", len(input)))
+	assert.Equal(t, "hello", actual)
+}
+
+type unmarshalCustom struct{}
+
+func (*unmarshalCustom) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return d.Skip()
+}
+
+func TestUnmarshalCompat_Errors(t *testing.T) {
+	var s string
+	assert.EqualError(t, UnmarshalCompat([]byte(`<a/>`), s), "non-pointer passed to Unmarshal")
+	assert.EqualError(t, UnmarshalCompat([]byte(`<a/>`), (*string)(nil)), "nil pointer passed to Unmarshal")
+	var m map[string]string
+	assert.EqualError(t, UnmarshalCompat([]byte(`<a/>`), &m), "unknown type map[string]string")
+	var c unmarshalCustom
+	assert.EqualError(t, UnmarshalCompat([]byte(`<a/>`), &c), "fastxml: fastxml.unmarshalCustom implements UnmarshalXML which is not supported")
+	var bad struct {
+		A string `xml:"a,chardata"`
+	}
+	assert.Error(t, UnmarshalCompat([]byte(`<a/>`), &bad))
+}
+
+type unmarshalGenerated struct {
+	Text string
+}
+
+func (v *unmarshalGenerated) UnmarshalFastXMLElement(s *Scanner, startToken []byte) (err error) {
+	name, _ := Element(startToken)
+	text, err := s.ElementText(nil)
+	v.Text = string(name) + "=" + string(text)
+	return err
+}
+
+func TestUnmarshal_ElementUnmarshaler(t *testing.T) {
+	var v struct {
+		Items []unmarshalGenerated `xml:"item"`
+		Ptr   *unmarshalGenerated  `xml:"ptr"`
+	}
+	assert.NoError(t, Unmarshal([]byte(`<root><item>a</item><item>b<x/></item><ptr>c</ptr><other/></root>`), &v))
+	assert.Equal(t, []unmarshalGenerated{{Text: "item=a"}, {Text: "item=b"}}, v.Items)
+	assert.Equal(t, &unmarshalGenerated{Text: "ptr=c"}, v.Ptr)
+}
+
+func TestUnmarshalElement(t *testing.T) {
+	var v unmarshalGenerated
+	assert.NoError(t, UnmarshalElement(NewScanner([]byte(`<?xml version="1.0"?><!-- c --><root>text</root>`)), &v))
+	assert.Equal(t, "root=text", v.Text)
+	assert.Equal(t, io.EOF, UnmarshalElement(NewScanner([]byte(`<!-- c -->`)), &v))
+}
@@ -1,7 +1,12 @@
+//go:build go1.20 && !fastxml_safe
+
 package fastxml
 
 import "unsafe"
 
+// zeroCopy is true when String references buf instead of copying it
+const zeroCopy = true
+
 // String performs an _unsafe_ no-copy string allocation from buf
 // https://github.com/golang/go/issues/25484 has more info on this.
 // The implementation is roughly taken from strings.Builder's
@@ -9,6 +14,8 @@ import "unsafe"
 // This function is used internally to build encoding/xml elements
 // without copying the underlying values on the assumption the
 // original bytes slice given to NewScanner was immutable.
+//
+// Building with the fastxml_safe tag replaces this with a copy, see safe.go
 func String(buf []byte) string {
-	return *(*string)(unsafe.Pointer(&buf))
+	return unsafe.String(unsafe.SliceData(buf), len(buf))
 }
@@ -0,0 +1,17 @@
+//go:build !go1.20 && !fastxml_safe
+
+package fastxml
+
+import "unsafe"
+
+// zeroCopy is true when String references buf instead of copying it
+const zeroCopy = true
+
+// String performs an _unsafe_ no-copy string allocation from buf
+// https://github.com/golang/go/issues/25484 has more info on this.
+// unsafe.String is not available before Go 1.20 so the slice header is reinterpreted instead
+//
+// Building with the fastxml_safe tag replaces this with a copy, see safe.go
+func String(buf []byte) string {
+	return *(*string)(unsafe.Pointer(&buf))
+}
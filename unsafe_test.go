@@ -9,4 +9,6 @@ import (
 func Test_String(t *testing.T) {
 	source := []byte("lorem ipsum dolor sit amet")
 	assert.Equal(t, "ipsum dolor", String(source[6:17]))
+	assert.Equal(t, "", String(nil))
+	assert.Equal(t, "", String(source[:0]))
 }
@@ -0,0 +1,256 @@
+package fastxml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is returned when the input is not well-formed XML
+type SyntaxError struct {
+	Msg    string
+	Offset int   // byte offset in the input
+	Line   int   // 1-based line number
+	Column int   // 1-based column (in bytes)
+	Err    error // the underlying error (if any)
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Unwrap returns the underlying error
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// lineColumn computes the 1-based line and column of offset in buf
+func lineColumn(buf []byte, offset int) (line int, column int) {
+	if offset > len(buf) {
+		offset = len(buf)
+	}
+	return bytes.Count(buf[:offset], []byte{'\n'}) + 1, offset - bytes.LastIndexByte(buf[:offset], '\n')
+}
+
+// newSyntaxError creates a *SyntaxError computing the line and column of offset in buf
+func newSyntaxError(buf []byte, offset int, format string, args ...interface{}) *SyntaxError {
+	if offset > len(buf) {
+		offset = len(buf)
+	}
+	line, column := lineColumn(buf, offset)
+	return &SyntaxError{
+		Msg:    fmt.Sprintf(format, args...),
+		Offset: offset,
+		Line:   line,
+		Column: column,
+	}
+}
+
+// errorOffset returns the offset an error occurred at if it records one (or fallback)
+func errorOffset(err error, fallback int) int {
+	switch err := err.(type) {
+	case *MismatchError:
+		return err.EndOffset
+	case *SecurityError:
+		return err.Offset
+	case *LimitError:
+		return err.Offset
+	case *TokenError:
+		return err.Offset
+	}
+	return fallback
+}
+
+// errorMessage returns the message of an error for a *SyntaxError, without the offset of a *TokenError
+func errorMessage(err error) string {
+	if err, ok := err.(*TokenError); ok {
+		return err.message()
+	}
+	return err.Error()
+}
+
+// isNameStart checks if r may start an XML name
+func isNameStart(r rune) bool {
+	return r == ':' || r == '_' || unicode.IsLetter(r)
+}
+
+// isNameChar checks if r may appear in an XML name after the first character
+func isNameChar(r rune) bool {
+	return isNameStart(r) || r == '-' || r == '.' || r == 0xB7 || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r)
+}
+
+// isName checks if name is a valid XML name
+func isName(name []byte) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for idx := 0; idx < len(name); {
+		r, size := utf8.DecodeRune(name[idx:])
+		if r == utf8.RuneError && size == 1 {
+			return false
+		} else if idx == 0 && !isNameStart(r) {
+			return false
+		} else if !isNameChar(r) {
+			return false
+		}
+		idx += size
+	}
+	return true
+}
+
+// checkReferences checks every '&' in text starts a terminated entity or character reference
+// returning the offset of the invalid reference (or -1)
+func checkReferences(text []byte) int {
+	for offset := 0; ; {
+		idx := bytes.IndexByte(text[offset:], '&')
+		if idx == -1 {
+			return -1
+		}
+		offset += idx
+		end := bytes.IndexByte(text[offset:], ';')
+		if end == -1 {
+			return offset
+		}
+		ref := text[offset+1 : offset+end]
+		if len(ref) > 1 && ref[0] == '#' {
+			digits, valid := ref[1:], "0123456789"
+			if digits[0] == 'x' {
+				digits, valid = digits[1:], "0123456789abcdefABCDEF"
+			}
+			if len(digits) == 0 || len(bytes.Trim(digits, valid)) > 0 {
+				return offset
+			}
+		} else if !isName(ref) {
+			return offset
+		}
+		offset += end + 1
+	}
+}
+
+// Validate checks that buf is well-formed XML returning a *SyntaxError describing the first problem found
+// It checks elements are balanced, names are valid, attributes are unique and every construct is terminated
+// Entities are not resolved so references to undeclared entities are not detected
+func Validate(buf []byte) error {
+	s := NewScanner(buf)
+	var open []openElement
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return newSyntaxError(buf, offset, "unterminated %s", describeToken(token))
+		}
+		switch {
+		case chardata:
+			if bytes.HasPrefix(token, prefixCDATA) {
+				continue
+			}
+			if idx := checkReferences(token); idx != -1 {
+				return newSyntaxError(buf, offset+idx, "invalid character or entity reference")
+			}
+			if len(open) == 0 && len(trimSpace(token)) > 0 {
+				return newSyntaxError(buf, offset, "character data outside of the root element")
+			}
+		case IsComment(token):
+			if !bytes.HasSuffix(token, []byte("-->")) || len(token) < 7 {
+				return newSyntaxError(buf, offset, "unterminated comment")
+			}
+		case IsProcInst(token):
+			if !bytes.HasSuffix(token, []byte("?>")) || len(token) < 4 {
+				return newSyntaxError(buf, offset, "unterminated processing instruction")
+			}
+			if target, _ := ProcInst(token); !isName(target) {
+				return newSyntaxError(buf, offset, "invalid processing instruction target %q", target)
+			}
+		case IsDirective(token):
+			continue
+		case !IsElement(token):
+			return newSyntaxError(buf, offset, "invalid token %q", token)
+		case IsEndElement(token):
+			name, attrs := Element(token)
+			if !isName(name) || len(trimSpace(attrs)) > 0 || IsSelfClosing(token) {
+				return newSyntaxError(buf, offset, "invalid end element %q", token)
+			}
+			if len(open) == 0 {
+				return newSyntaxError(buf, offset, "unexpected end element </%s>", name)
+			}
+			start := open[len(open)-1]
+			if !bytes.Equal(start.name, name) {
+				line, column := lineColumn(buf, start.offset)
+				return newSyntaxError(buf, offset, "element <%s> (line %d, column %d) closed by </%s>", start.name, line, column, name)
+			}
+			open = open[:len(open)-1]
+		default:
+			name, attrsToken := Element(token)
+			if !isName(name) {
+				return newSyntaxError(buf, offset, "invalid element name %q", name)
+			}
+			// The attributes follow `<name `
+			if err := validateAttrs(buf, offset+len(name)+2, attrsToken); err != nil {
+				return err
+			}
+			if !IsSelfClosing(token) {
+				open = append(open, openElement{offset: offset, name: name})
+			}
+		}
+	}
+	if len(open) > 0 {
+		start := open[len(open)-1]
+		return newSyntaxError(buf, start.offset, "unclosed element <%s>", start.name)
+	}
+	return nil
+}
+
+// validateAttrs checks the attributes of a start element, offset is the position of attrsToken in buf
+func validateAttrs(buf []byte, offset int, attrsToken []byte) error {
+	var err error
+	attrErr := RawAttrs(attrsToken, func(keyStart, keyEnd, valueStart, valueEnd int) bool {
+		key := attrsToken[keyStart:keyEnd]
+		if !isName(key) {
+			err = newSyntaxError(buf, offset+keyStart, "invalid attribute name %q", key)
+			return false
+		}
+		if idx := bytes.IndexByte(attrsToken[valueStart:valueEnd], '<'); idx != -1 {
+			err = newSyntaxError(buf, offset+valueStart+idx, "'<' in the value of attribute %q", key)
+			return false
+		}
+		if idx := checkReferences(attrsToken[valueStart:valueEnd]); idx != -1 {
+			err = newSyntaxError(buf, offset+valueStart+idx, "invalid character or entity reference")
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	} else if attrErr == nil {
+		attrErr = CheckDuplicateAttrs(attrsToken)
+	}
+	if attrErr, ok := attrErr.(*TokenError); ok {
+		// The offset of the *TokenError is relative to attrsToken
+		syntaxErr := newSyntaxError(buf, offset+attrErr.Offset, "%s", attrErr.message())
+		syntaxErr.Err = attrErr
+		return syntaxErr
+	} else if attrErr != nil {
+		return newSyntaxError(buf, offset, "%s", attrErr)
+	}
+	return nil
+}
+
+// describeToken names the kind of a (possibly incomplete) token for error messages
+func describeToken(token []byte) string {
+	switch {
+	case bytes.HasPrefix(token, prefixCDATA):
+		return "CDATA section"
+	case IsComment(token):
+		return "comment"
+	case IsProcInst(token):
+		return "processing instruction"
+	case IsDirective(token):
+		return "directive"
+	}
+	return "element"
+}
@@ -0,0 +1,88 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Input string
+		Error string
+	}{
+		{
+			Input: `<?xml version="1.0"?>` + "\n" + `<!DOCTYPE root><root a="1" b="&amp;&#60;&#x3C;"><!-- comment --><child/>text<![CDATA[&]]></root>` + "\n",
+		}, {
+			Input: "<root>\n  <a>\n  </b>\n</root>",
+			Error: "syntax error at line 3, column 3: element <a> (line 2, column 3) closed by </b>",
+		}, {
+			Input: `<root></root></extra>`,
+			Error: "syntax error at line 1, column 14: unexpected end element </extra>",
+		}, {
+			Input: "<root>\n<a>",
+			Error: "syntax error at line 2, column 1: unclosed element <a>",
+		}, {
+			Input: `<root a="1" b="2" a="3"/>`,
+			Error: `syntax error at line 1, column 19: duplicate attribute "a"`,
+		}, {
+			Input: `<1root/>`,
+			Error: `syntax error at line 1, column 1: invalid element name "1root"`,
+		}, {
+			Input: `<root -a="1"/>`,
+			Error: `syntax error at line 1, column 7: invalid attribute name "-a"`,
+		}, {
+			Input: `<root a="x<y"/>`,
+			Error: `syntax error at line 1, column 11: '<' in the value of attribute "a"`,
+		}, {
+			Input: `<root a="&bad"/>`,
+			Error: `syntax error at line 1, column 10: invalid character or entity reference`,
+		}, {
+			Input: `<root>R&D</root>`,
+			Error: `syntax error at line 1, column 8: invalid character or entity reference`,
+		}, {
+			Input: `<root>&#xZZ;</root>`,
+			Error: `syntax error at line 1, column 7: invalid character or entity reference`,
+		}, {
+			Input: `<root a=1/>`,
+			Error: `syntax error at line 1, column 9: expected Attr to start with '"'`,
+		}, {
+			Input: `<root></root a="1">`,
+			Error: `syntax error at line 1, column 7: invalid end element "</root a=\"1\">"`,
+		}, {
+			Input: `<root><a`,
+			Error: "syntax error at line 1, column 7: unterminated element",
+		}, {
+			Input: `<root><![CDATA[text</root>`,
+			Error: "syntax error at line 1, column 7: unterminated CDATA section",
+		}, {
+			Input: `<root><!-- a -></root>`,
+			Error: "syntax error at line 1, column 7: unterminated comment",
+		}, {
+			Input: `<?xml version="1.0"></root>`,
+			Error: "syntax error at line 1, column 1: unterminated processing instruction",
+		}, {
+			Input: `text<root/>`,
+			Error: "syntax error at line 1, column 1: character data outside of the root element",
+		}, {
+			// Only ASCII whitespace is XML whitespace
+			Input: "\u00a0<root/>",
+			Error: "syntax error at line 1, column 1: character data outside of the root element",
+		}, {
+			Input: `<café über="ü" ns:naïve="1"><ñ/></café>`,
+		}, {
+			Input: "<root\u3000a=\"1\"/>",
+			Error: `syntax error at line 1, column 1: invalid element name "root\u3000a=\"1\""`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			err := Validate([]byte(tc.Input))
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+)
+
+// Handler receives each token of a document from Walk, an error returned by any method stops the Walk
+// All slices reference the buffer being walked and are only valid as long as it is
+type Handler interface {
+	// OnStartElement is called for each start element with its name and attrsToken (see Element and Attrs)
+	// Returning ErrSkipElement skips the content of the element, OnEndElement is still called for it
+	OnStartElement(name, attrsToken []byte) error
+	// OnEndElement is called for each end element, including for self-closing elements
+	OnEndElement(name []byte) error
+	// OnCharData is called with each CharData token (including CDATA sections), see CharData to decode it
+	OnCharData(charToken []byte) error
+	// OnComment is called with the contents of each comment
+	OnComment(comment []byte) error
+	// OnProcInst is called with the target and instruction of each ProcInst
+	OnProcInst(target, inst []byte) error
+	// OnDirective is called with the contents of each Directive
+	OnDirective(directive []byte) error
+}
+
+// NopHandler implements Handler ignoring every token, it can be embedded to implement only some of the methods
+type NopHandler struct{}
+
+// OnStartElement implements Handler
+func (NopHandler) OnStartElement(name, attrsToken []byte) error { return nil }
+
+// OnEndElement implements Handler
+func (NopHandler) OnEndElement(name []byte) error { return nil }
+
+// OnCharData implements Handler
+func (NopHandler) OnCharData(charToken []byte) error { return nil }
+
+// OnComment implements Handler
+func (NopHandler) OnComment(comment []byte) error { return nil }
+
+// OnProcInst implements Handler
+func (NopHandler) OnProcInst(target, inst []byte) error { return nil }
+
+// OnDirective implements Handler
+func (NopHandler) OnDirective(directive []byte) error { return nil }
+
+// ErrSkipElement can be returned by Handler.OnStartElement to skip the content of the element
+var ErrSkipElement = errors.New("skip this element")
+
+// Walk calls the methods of handler for every token in buf, see Scanner.Walk
+func Walk(buf []byte, handler Handler) error {
+	return NewScanner(buf).Walk(handler)
+}
+
+// Walk calls the methods of handler for every remaining token in s until the end of the document
+// The error returned by handler (or s) is returned, reaching the end of the document returns nil
+func (s *Scanner) Walk(handler Handler) error {
+	for {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if chardata {
+			err = handler.OnCharData(token)
+		} else {
+			err = s.dispatch(token, handler)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch calls the method of handler for a token which is not CharData
+func (s *Scanner) dispatch(token []byte, handler Handler) error {
+	switch {
+	case IsEndElement(token):
+		name, _ := Element(token)
+		return handler.OnEndElement(name)
+	case IsElement(token):
+		name, attrsToken := Element(token)
+		selfClosing := s.SelfClosing(token)
+		if err := handler.OnStartElement(name, attrsToken); err == ErrSkipElement {
+			if !selfClosing {
+				if err := s.Skip(); err != nil {
+					if err == io.EOF {
+						return io.ErrUnexpectedEOF
+					}
+					return err
+				}
+			}
+		} else if err != nil {
+			return err
+		} else if !selfClosing {
+			return nil
+		}
+		return handler.OnEndElement(name)
+	case IsComment(token):
+		return handler.OnComment(Comment(token))
+	case IsProcInst(token):
+		return handler.OnProcInst(ProcInst(token))
+	case IsDirective(token):
+		return handler.OnDirective(Directive(token))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordHandler records each call as a string
+type recordHandler struct {
+	calls []string
+	skip  string
+	err   error
+}
+
+func (h *recordHandler) OnStartElement(name, attrsToken []byte) error {
+	h.calls = append(h.calls, "start "+string(name)+" "+string(attrsToken))
+	if string(name) == h.skip {
+		return ErrSkipElement
+	}
+	return h.err
+}
+
+func (h *recordHandler) OnEndElement(name []byte) error {
+	h.calls = append(h.calls, "end "+string(name))
+	return nil
+}
+
+func (h *recordHandler) OnCharData(charToken []byte) error {
+	h.calls = append(h.calls, "chardata "+string(charToken))
+	return nil
+}
+
+func (h *recordHandler) OnComment(comment []byte) error {
+	h.calls = append(h.calls, "comment "+string(comment))
+	return nil
+}
+
+func (h *recordHandler) OnProcInst(target, inst []byte) error {
+	h.calls = append(h.calls, "procinst "+string(target)+" "+string(inst))
+	return nil
+}
+
+func (h *recordHandler) OnDirective(directive []byte) error {
+	h.calls = append(h.calls, "directive "+string(directive))
+	return nil
+}
+
+func TestWalk(t *testing.T) {
+	buf := []byte(`<?xml version="1.0"?><!DOCTYPE a><a id="1"><!-- c --><b>x<c/></b><d/><![CDATA[y]]></a>`)
+	h := &recordHandler{}
+	assert.NoError(t, Walk(buf, h))
+	assert.Equal(t, []string{
+		`procinst xml version="1.0"`,
+		`directive DOCTYPE a`,
+		`start a id="1"`,
+		`comment  c `,
+		`start b `,
+		`chardata x`,
+		`start c `,
+		`end c`,
+		`end b`,
+		`start d `,
+		`end d`,
+		`chardata <![CDATA[y]]>`,
+		`end a`,
+	}, h.calls)
+
+	h = &recordHandler{skip: "b"}
+	assert.NoError(t, Walk(buf, h))
+	assert.Equal(t, []string{
+		`procinst xml version="1.0"`,
+		`directive DOCTYPE a`,
+		`start a id="1"`,
+		`comment  c `,
+		`start b `,
+		`end b`,
+		`start d `,
+		`end d`,
+		`chardata <![CDATA[y]]>`,
+		`end a`,
+	}, h.calls)
+
+	errStop := errors.New("stop")
+	h = &recordHandler{err: errStop}
+	assert.Equal(t, errStop, Walk(buf, h))
+	assert.Equal(t, []string{`procinst xml version="1.0"`, `directive DOCTYPE a`, `start a id="1"`}, h.calls)
+
+	h = &recordHandler{skip: "a"}
+	assert.Equal(t, io.ErrUnexpectedEOF, Walk([]byte(`<a><b>`), h))
+}
+
+func TestNopHandler(t *testing.T) {
+	var h struct {
+		NopHandler
+	}
+	assert.NoError(t, Walk([]byte(`<?a?><!b><a><!-- c --><b/>text</a>`), h))
+}
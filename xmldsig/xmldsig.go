@@ -0,0 +1,548 @@
+// Package xmldsig verifies XML Signatures (ex: of SAML assertions) using fastxml.Scanner without building a tree
+//
+// Only the algorithms used in practice are supported: Exclusive XML Canonicalization 1.0 (without comments), the
+// enveloped-signature transform, SHA-1 and SHA-2 digests and RSA (PKCS #1 v1.5) or ECDSA signatures. References
+// must be to the same document ("" for the document element or "#id" for the element with an unqualified ID, Id
+// or id attribute), an ID which is not unique in the document is rejected
+package xmldsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	// Register the hash functions of the supported algorithms
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Namespaces of signatures
+const (
+	Namespace    = "http://www.w3.org/2000/09/xmldsig#"
+	ExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	moreAlgSpace = "http://www.w3.org/2001/04/xmldsig-more#"
+)
+
+// Supported algorithm identifiers
+const (
+	EnvelopedSignature = Namespace + "enveloped-signature"
+	SHA1               = Namespace + "sha1"
+	SHA256             = "http://www.w3.org/2001/04/xmlenc#sha256"
+	SHA384             = moreAlgSpace + "sha384"
+	SHA512             = "http://www.w3.org/2001/04/xmlenc#sha512"
+	RSASHA1            = Namespace + "rsa-sha1"
+	RSASHA256          = moreAlgSpace + "rsa-sha256"
+	RSASHA384          = moreAlgSpace + "rsa-sha384"
+	RSASHA512          = moreAlgSpace + "rsa-sha512"
+	ECDSASHA1          = moreAlgSpace + "ecdsa-sha1"
+	ECDSASHA256        = moreAlgSpace + "ecdsa-sha256"
+	ECDSASHA384        = moreAlgSpace + "ecdsa-sha384"
+	ECDSASHA512        = moreAlgSpace + "ecdsa-sha512"
+)
+
+// digestMethods maps a digest algorithm to its hash
+var digestMethods = map[string]crypto.Hash{
+	SHA1:   crypto.SHA1,
+	SHA256: crypto.SHA256,
+	SHA384: crypto.SHA384,
+	SHA512: crypto.SHA512,
+}
+
+// signatureMethod is the hash and key type of a signature algorithm
+type signatureMethod struct {
+	hash  crypto.Hash
+	ecdsa bool
+}
+
+// signatureMethods maps a signature algorithm to its hash and key type
+var signatureMethods = map[string]signatureMethod{
+	RSASHA1:     {hash: crypto.SHA1},
+	RSASHA256:   {hash: crypto.SHA256},
+	RSASHA384:   {hash: crypto.SHA384},
+	RSASHA512:   {hash: crypto.SHA512},
+	ECDSASHA1:   {hash: crypto.SHA1, ecdsa: true},
+	ECDSASHA256: {hash: crypto.SHA256, ecdsa: true},
+	ECDSASHA384: {hash: crypto.SHA384, ecdsa: true},
+	ECDSASHA512: {hash: crypto.SHA512, ecdsa: true},
+}
+
+// Errors returned when a signature is not valid
+var (
+	ErrNoSignature          = errors.New("xmldsig: element has no Signature")
+	ErrUnsupportedAlgorithm = errors.New("xmldsig: unsupported algorithm")
+	ErrDigestMismatch       = errors.New("xmldsig: digest of the referenced content does not match")
+	ErrInvalidSignature     = errors.New("xmldsig: invalid signature value")
+	ErrDuplicateID          = errors.New("xmldsig: ID is not unique")
+	ErrNotReferenced        = errors.New("xmldsig: signature does not reference the element")
+)
+
+// Transform is a transform applied to the referenced content before it is digested
+type Transform struct {
+	Algorithm         string
+	InclusivePrefixes []string // the InclusiveNamespaces PrefixList of ExcC14N
+}
+
+// Reference is a reference to signed content in the SignedInfo
+type Reference struct {
+	URI          string
+	Transforms   []Transform
+	DigestMethod string
+	DigestValue  []byte
+}
+
+// Signature is a parsed ds:Signature element
+type Signature struct {
+	Start, End             int // offsets of the Signature element in the document
+	SignedInfo             int // offset of the SignedInfo element in the document
+	CanonicalizationMethod string
+	InclusivePrefixes      []string // the InclusiveNamespaces PrefixList of the CanonicalizationMethod
+	SignatureMethod        string
+	References             []Reference
+	SignatureValue         []byte
+	Certificates           [][]byte // DER encoded X509Certificate elements of the KeyInfo
+}
+
+// scanTo returns a Scanner positioned at offset start of buf with the namespace declarations of the ancestors in ns
+func scanTo(buf []byte, start int, ns *fastxml.Namespaces) (*fastxml.Scanner, error) {
+	s := fastxml.NewScanner(buf)
+	for s.Offset() < start {
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		}
+		if !fastxml.IsEndElement(token) {
+			if err := ns.Push(token); err != nil {
+				return nil, err
+			}
+			if !fastxml.IsSelfClosing(token) {
+				continue
+			}
+		}
+		if err := ns.Pop(); err != nil {
+			return nil, err
+		}
+	}
+	if s.Offset() != start {
+		return nil, fmt.Errorf("xmldsig: no element at offset %d", start)
+	}
+	return s, nil
+}
+
+// decodeBase64 decodes the text of a base64Binary element ignoring whitespace
+func decodeBase64(text []byte) ([]byte, error) {
+	compact := bytes.Join(bytes.Fields(text), nil)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(compact)))
+	n, err := base64.StdEncoding.Decode(decoded, compact)
+	if err != nil {
+		return nil, fmt.Errorf("xmldsig: %w", err)
+	}
+	return decoded[:n], nil
+}
+
+// attr returns the decoded value of the unqualified attribute key
+func attr(attrsToken []byte, key string) (string, error) {
+	value, _, err := fastxml.AttrString(attrsToken, []byte(key), nil)
+	return value, err
+}
+
+// ParseSignature parses the ds:Signature element at offset start of buf
+func ParseSignature(buf []byte, start int) (*Signature, error) {
+	var ns fastxml.Namespaces
+	s, err := scanTo(buf, start, &ns)
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{Start: start, SignedInfo: -1}
+	// path is the local names of the open elements in the Signature, "" if not in a known namespace
+	var path []string
+	var text []byte
+	for {
+		offset := s.Offset()
+		token, chardata, err := s.Next()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 && (chardata || !fastxml.IsElement(token) || fastxml.IsEndElement(token)) {
+			return nil, fmt.Errorf("xmldsig: no element at offset %d", start)
+		}
+		if chardata || !fastxml.IsElement(token) {
+			continue
+		}
+		if fastxml.IsEndElement(token) {
+			if err := ns.Pop(); err != nil {
+				return nil, err
+			}
+			if path = path[:len(path)-1]; len(path) == 0 {
+				break
+			}
+			continue
+		}
+		if err := ns.Push(token); err != nil {
+			return nil, err
+		}
+		name, attrsToken := fastxml.Element(token)
+		space, local := ns.Resolve(name, false)
+		switch space {
+		case Namespace:
+			path = append(path, string(local))
+		case ExcC14N:
+			path = append(path, "ec:"+string(local))
+		default:
+			path = append(path, "")
+		}
+		if len(path) == 1 && path[0] != "Signature" {
+			return nil, fmt.Errorf("xmldsig: <%s> at offset %d is not a Signature", name, start)
+		}
+		selfClosing := fastxml.IsSelfClosing(token)
+		// The text of the elements with a value, reading it consumes the end element
+		var value *[]byte
+		switch strings.Join(path, "/") {
+		case "Signature/SignedInfo":
+			if sig.SignedInfo != -1 {
+				return nil, errors.New("xmldsig: Signature has more than one SignedInfo")
+			}
+			sig.SignedInfo = offset
+		case "Signature/SignedInfo/CanonicalizationMethod":
+			sig.CanonicalizationMethod, err = attr(attrsToken, "Algorithm")
+		case "Signature/SignedInfo/CanonicalizationMethod/ec:InclusiveNamespaces":
+			var prefixes string
+			prefixes, err = attr(attrsToken, "PrefixList")
+			sig.InclusivePrefixes = strings.Fields(prefixes)
+		case "Signature/SignedInfo/SignatureMethod":
+			sig.SignatureMethod, err = attr(attrsToken, "Algorithm")
+		case "Signature/SignedInfo/Reference":
+			var ref Reference
+			ref.URI, err = attr(attrsToken, "URI")
+			sig.References = append(sig.References, ref)
+		case "Signature/SignedInfo/Reference/Transforms/Transform":
+			ref := &sig.References[len(sig.References)-1]
+			var algorithm string
+			algorithm, err = attr(attrsToken, "Algorithm")
+			ref.Transforms = append(ref.Transforms, Transform{Algorithm: algorithm})
+		case "Signature/SignedInfo/Reference/Transforms/Transform/ec:InclusiveNamespaces":
+			ref := &sig.References[len(sig.References)-1]
+			var prefixes string
+			if prefixes, err = attr(attrsToken, "PrefixList"); len(ref.Transforms) > 0 {
+				ref.Transforms[len(ref.Transforms)-1].InclusivePrefixes = strings.Fields(prefixes)
+			}
+		case "Signature/SignedInfo/Reference/DigestMethod":
+			sig.References[len(sig.References)-1].DigestMethod, err = attr(attrsToken, "Algorithm")
+		case "Signature/SignedInfo/Reference/DigestValue":
+			value = &sig.References[len(sig.References)-1].DigestValue
+		case "Signature/SignatureValue":
+			if sig.SignatureValue != nil {
+				return nil, errors.New("xmldsig: Signature has more than one SignatureValue")
+			}
+			value = &sig.SignatureValue
+		case "Signature/KeyInfo/X509Data/X509Certificate":
+			sig.Certificates = append(sig.Certificates, nil)
+			value = &sig.Certificates[len(sig.Certificates)-1]
+		}
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			if !selfClosing {
+				if text, err = s.ElementText(text[:0]); err != nil {
+					return nil, err
+				}
+				if *value, err = decodeBase64(text); err != nil {
+					return nil, err
+				}
+			}
+			selfClosing = true
+		}
+		if selfClosing {
+			if err := ns.Pop(); err != nil {
+				return nil, err
+			}
+			if path = path[:len(path)-1]; len(path) == 0 {
+				break
+			}
+		}
+	}
+	sig.End = s.Offset()
+	if sig.SignedInfo == -1 {
+		return nil, errors.New("xmldsig: Signature has no SignedInfo")
+	}
+	return sig, nil
+}
+
+// FindSignature parses the ds:Signature element which is a direct child of the element at offset start of buf
+// ErrNoSignature is returned if the element does not have one
+func FindSignature(buf []byte, start int) (*Signature, error) {
+	var ns fastxml.Namespaces
+	s, err := scanTo(buf, start, &ns)
+	if err != nil {
+		return nil, err
+	}
+	token, chardata, err := s.Next()
+	if err != nil {
+		return nil, err
+	} else if chardata || !fastxml.IsElement(token) || fastxml.IsEndElement(token) {
+		return nil, fmt.Errorf("xmldsig: no element at offset %d", start)
+	} else if fastxml.IsSelfClosing(token) {
+		return nil, ErrNoSignature
+	}
+	if err := ns.Push(token); err != nil {
+		return nil, err
+	}
+	for {
+		token, err := s.NextElement()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		if fastxml.IsEndElement(token) {
+			return nil, ErrNoSignature
+		}
+		offset, _ := s.TokenRange()
+		if err := ns.Push(token); err != nil {
+			return nil, err
+		}
+		name, _ := fastxml.Element(token)
+		if space, local := ns.Resolve(name, false); space == Namespace && string(local) == "Signature" {
+			return ParseSignature(buf, offset)
+		}
+		if err := ns.Pop(); err != nil {
+			return nil, err
+		}
+		if !fastxml.IsSelfClosing(token) {
+			if err := s.Skip(); err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Resolve returns the offset of the element referenced by uri, the document element for "" or the element
+// with an unqualified ID, Id or id attribute for "#id". ErrDuplicateID is returned if more than one element has the ID
+func Resolve(buf []byte, uri string) (int, error) {
+	if uri != "" && !strings.HasPrefix(uri, "#") {
+		return 0, fmt.Errorf("%w: reference %q is not to the same document", ErrUnsupportedAlgorithm, uri)
+	}
+	id := strings.TrimPrefix(uri, "#")
+	found := -1
+	var scratch []byte
+	s := fastxml.NewScanner(buf)
+	for {
+		token, err := s.NextElement()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		if fastxml.IsEndElement(token) {
+			continue
+		}
+		offset, _ := s.TokenRange()
+		if uri == "" {
+			return offset, nil
+		}
+		_, attrsToken := fastxml.Element(token)
+		matched := false
+		var attrErr error
+		if err := fastxml.Attrs(attrsToken, func(key, value []byte) bool {
+			switch string(key) {
+			case "ID", "Id", "id":
+				if scratch, attrErr = fastxml.DecodeEntitiesAppend(scratch[:0], value); attrErr != nil {
+					return false
+				}
+				if string(scratch) == id {
+					matched = true
+					return false
+				}
+			}
+			return true
+		}); err != nil {
+			return 0, err
+		} else if attrErr != nil {
+			return 0, attrErr
+		}
+		if matched {
+			if found != -1 {
+				return 0, fmt.Errorf("%w: %q", ErrDuplicateID, id)
+			}
+			found = offset
+		}
+	}
+	if found == -1 {
+		return 0, fmt.Errorf("xmldsig: no element with ID %q", id)
+	}
+	return found, nil
+}
+
+// Digest computes the digest of the content referenced by ref applying its transforms
+// The enveloped-signature transform removes the Signature sig from the content
+func (sig *Signature) Digest(buf []byte, ref *Reference) ([]byte, error) {
+	hash, ok := digestMethods[ref.DigestMethod]
+	if !ok {
+		return nil, fmt.Errorf("%w: digest %q", ErrUnsupportedAlgorithm, ref.DigestMethod)
+	}
+	start, err := Resolve(buf, ref.URI)
+	if err != nil {
+		return nil, err
+	}
+	opts := &fastxml.CanonicalizeOptions{}
+	canonicalized := false
+	for _, transform := range ref.Transforms {
+		switch transform.Algorithm {
+		case EnvelopedSignature:
+			opts.Exclude = func(offset int) bool {
+				return offset == sig.Start
+			}
+		case ExcC14N:
+			opts.InclusivePrefixes = transform.InclusivePrefixes
+			canonicalized = true
+		default:
+			return nil, fmt.Errorf("%w: transform %q", ErrUnsupportedAlgorithm, transform.Algorithm)
+		}
+	}
+	// Without a canonicalization transform inclusive canonicalization would be used
+	if !canonicalized {
+		return nil, fmt.Errorf("%w: reference %q is not canonicalized with %q", ErrUnsupportedAlgorithm, ref.URI, ExcC14N)
+	}
+	h := hash.New()
+	if err := fastxml.CanonicalizeElement(buf, start, h, opts); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// VerifyReferences checks the digest of every Reference matches the referenced content
+func (sig *Signature) VerifyReferences(buf []byte) error {
+	if len(sig.References) == 0 {
+		return errors.New("xmldsig: SignedInfo has no Reference")
+	}
+	for idx := range sig.References {
+		ref := &sig.References[idx]
+		digest, err := sig.Digest(buf, ref)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(digest, ref.DigestValue) != 1 {
+			return fmt.Errorf("%w: reference %q", ErrDigestMismatch, ref.URI)
+		}
+	}
+	return nil
+}
+
+// VerifySignedInfo checks the SignatureValue is a signature of the canonical SignedInfo by key
+// which must be an *rsa.PublicKey or *ecdsa.PublicKey matching the SignatureMethod
+func (sig *Signature) VerifySignedInfo(buf []byte, key crypto.PublicKey) error {
+	method, ok := signatureMethods[sig.SignatureMethod]
+	if !ok {
+		return fmt.Errorf("%w: signature %q", ErrUnsupportedAlgorithm, sig.SignatureMethod)
+	}
+	if sig.CanonicalizationMethod != ExcC14N {
+		return fmt.Errorf("%w: canonicalization %q", ErrUnsupportedAlgorithm, sig.CanonicalizationMethod)
+	}
+	h := method.hash.New()
+	if err := fastxml.CanonicalizeElement(buf, sig.SignedInfo, h, &fastxml.CanonicalizeOptions{
+		InclusivePrefixes: sig.InclusivePrefixes,
+	}); err != nil {
+		return err
+	}
+	hashed := h.Sum(nil)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if method.ecdsa {
+			break
+		}
+		if rsa.VerifyPKCS1v15(key, method.hash, hashed, sig.SignatureValue) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !method.ecdsa {
+			break
+		}
+		// The value is the concatenation of r and s
+		size := len(sig.SignatureValue) / 2
+		if size == 0 || len(sig.SignatureValue)%2 != 0 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig.SignatureValue[:size])
+		s := new(big.Int).SetBytes(sig.SignatureValue[size:])
+		if !ecdsa.Verify(key, hashed, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("xmldsig: %T cannot verify a %q signature", key, sig.SignatureMethod)
+}
+
+// Verify checks the SignatureValue using key and then the digest of every Reference
+func (sig *Signature) Verify(buf []byte, key crypto.PublicKey) error {
+	if err := sig.VerifySignedInfo(buf, key); err != nil {
+		return err
+	}
+	return sig.VerifyReferences(buf)
+}
+
+// Certificate parses the first X509Certificate of the KeyInfo (or returns nil)
+// The certificate is not trusted, it must be compared to a known certificate before its key is used
+func (sig *Signature) Certificate() (*x509.Certificate, error) {
+	if len(sig.Certificates) == 0 {
+		return nil, nil
+	}
+	return x509.ParseCertificate(sig.Certificates[0])
+}
+
+// VerifyEnveloped verifies the element at offset start of buf is signed by key with an enveloped signature
+// (ex: a SAML Assertion), the Signature must be a direct child of the element and its only Reference must be
+// to the element. Only the element is covered by the signature, the caller must not trust any other content
+// The document is checked with fastxml.Validate first so malformed input (ex: a tampered end element name or a
+// repeated ID attribute another parser would resolve differently) is rejected before the signature is considered
+func VerifyEnveloped(buf []byte, start int, key crypto.PublicKey) (*Signature, error) {
+	if err := fastxml.Validate(buf); err != nil {
+		return nil, fmt.Errorf("xmldsig: %w", err)
+	}
+	sig, err := FindSignature(buf, start)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig.References) != 1 {
+		return nil, fmt.Errorf("%w: expected 1 Reference, found %d", ErrNotReferenced, len(sig.References))
+	}
+	target, err := Resolve(buf, sig.References[0].URI)
+	if err != nil {
+		return nil, err
+	}
+	if target != start {
+		return nil, ErrNotReferenced
+	}
+	enveloped := false
+	for _, transform := range sig.References[0].Transforms {
+		enveloped = enveloped || transform.Algorithm == EnvelopedSignature
+	}
+	if !enveloped {
+		return nil, fmt.Errorf("%w: missing the %q transform", ErrNotReferenced, EnvelopedSignature)
+	}
+	if err := sig.Verify(buf, key); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
@@ -0,0 +1,231 @@
+package xmldsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bored-engineer/fastxml"
+	"github.com/stretchr/testify/assert"
+)
+
+const response = `<?xml version="1.0" encoding="UTF-8"?>
+<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" ID="_r1">
+  <saml:Assertion ID="_a1" Version="2.0">
+    <saml:Issuer>https://idp.example.com</saml:Issuer>
+    <ds:Signature>
+      <ds:SignedInfo>
+        <ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>
+        <ds:SignatureMethod Algorithm="{{METHOD}}"/>
+        <ds:Reference URI="{{URI}}">
+          <ds:Transforms>
+            <ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/>
+            <ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"><ec:InclusiveNamespaces xmlns:ec="http://www.w3.org/2001/10/xml-exc-c14n#" PrefixList="samlp"/></ds:Transform>
+          </ds:Transforms>
+          <ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>
+          <ds:DigestValue>{{DIGEST}}</ds:DigestValue>
+        </ds:Reference>
+      </ds:SignedInfo>
+      <ds:SignatureValue>
+        {{SIGNATURE}}
+      </ds:SignatureValue>
+      <ds:KeyInfo><ds:X509Data><ds:X509Certificate>AQID</ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+    </ds:Signature>
+    <saml:Subject><saml:NameID>{{NAME}}</saml:NameID></saml:Subject>
+  </saml:Assertion>
+</samlp:Response>`
+
+// canonicalAssertion is the canonical form of the Assertion without the Signature
+const canonicalAssertion = `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_a1" Version="2.0">
+    <saml:Issuer>https://idp.example.com</saml:Issuer>
+    
+    <saml:Subject><saml:NameID>alice@example.com</saml:NameID></saml:Subject>
+  </saml:Assertion>`
+
+// canonicalSignedInfo is the canonical form of the SignedInfo
+const canonicalSignedInfo = `<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:CanonicalizationMethod>
+        <ds:SignatureMethod Algorithm="{{METHOD}}"></ds:SignatureMethod>
+        <ds:Reference URI="#_a1">
+          <ds:Transforms>
+            <ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></ds:Transform>
+            <ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"><ec:InclusiveNamespaces xmlns:ec="http://www.w3.org/2001/10/xml-exc-c14n#" PrefixList="samlp"></ec:InclusiveNamespaces></ds:Transform>
+          </ds:Transforms>
+          <ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"></ds:DigestMethod>
+          <ds:DigestValue>{{DIGEST}}</ds:DigestValue>
+        </ds:Reference>
+      </ds:SignedInfo>`
+
+// signResponse creates a signed response using sign to produce the SignatureValue of the SignedInfo digest
+func signResponse(t *testing.T, method string, sign func(hashed []byte) []byte) string {
+	digest := sha256.Sum256([]byte(canonicalAssertion))
+	encodedDigest := base64.StdEncoding.EncodeToString(digest[:])
+	signedInfo := strings.NewReplacer("{{METHOD}}", method, "{{DIGEST}}", encodedDigest).Replace(canonicalSignedInfo)
+	hashed := sha256.Sum256([]byte(signedInfo))
+	return strings.NewReplacer(
+		"{{METHOD}}", method,
+		"{{URI}}", "#_a1",
+		"{{DIGEST}}", encodedDigest,
+		"{{SIGNATURE}}", base64.StdEncoding.EncodeToString(sign(hashed[:])),
+		"{{NAME}}", "alice@example.com",
+	).Replace(response)
+}
+
+func TestVerifyEnveloped(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc := signResponse(t, RSASHA256, func(hashed []byte) []byte {
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed)
+		assert.NoError(t, err)
+		return signature
+	})
+	assertion := strings.Index(doc, "<saml:Assertion")
+
+	sig, err := VerifyEnveloped([]byte(doc), assertion, &key.PublicKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, strings.Index(doc, "<ds:Signature>"), sig.Start)
+		assert.Equal(t, strings.Index(doc, "</ds:Signature>")+len("</ds:Signature>"), sig.End)
+		assert.Equal(t, ExcC14N, sig.CanonicalizationMethod)
+		assert.Equal(t, RSASHA256, sig.SignatureMethod)
+		if assert.Len(t, sig.References, 1) {
+			assert.Equal(t, "#_a1", sig.References[0].URI)
+			assert.Equal(t, []Transform{{Algorithm: EnvelopedSignature}, {Algorithm: ExcC14N, InclusivePrefixes: []string{"samlp"}}}, sig.References[0].Transforms)
+			assert.Equal(t, SHA256, sig.References[0].DigestMethod)
+		}
+		assert.Equal(t, [][]byte{{1, 2, 3}}, sig.Certificates)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if assert.NoError(t, err) {
+		_, err = VerifyEnveloped([]byte(doc), assertion, &other.PublicKey)
+		assert.Equal(t, ErrInvalidSignature, err)
+	}
+
+	// The content is modified after signing
+	tampered := strings.Replace(doc, "alice@example.com", "admin@example.com", 1)
+	_, err = VerifyEnveloped([]byte(tampered), assertion, &key.PublicKey)
+	assert.True(t, errors.Is(err, ErrDigestMismatch), "%v", err)
+
+	// Signature wrapping: a second element with the same ID
+	wrapped := strings.Replace(doc, `<saml:Assertion`, `<saml:Assertion ID="_a1"/><saml:Assertion`, 1)
+	_, err = VerifyEnveloped([]byte(wrapped), strings.LastIndex(wrapped, "<saml:Assertion"), &key.PublicKey)
+	assert.True(t, errors.Is(err, ErrDuplicateID), "%v", err)
+
+	// Malformed documents are rejected even if their canonical form is unchanged
+	mismatched := strings.Replace(doc, `</saml:Subject>`, `</saml:Other>`, 1)
+	_, err = VerifyEnveloped([]byte(mismatched), assertion, &key.PublicKey)
+	assert.EqualError(t, err, `xmldsig: syntax error at line 23, column 63: element <saml:Subject> (line 23, column 5) closed by </saml:Other>`)
+	duplicated := strings.Replace(doc, `<saml:Assertion ID="_a1"`, `<saml:Assertion ID="_a1" ID="_x"`, 1)
+	_, err = VerifyEnveloped([]byte(duplicated), assertion, &key.PublicKey)
+	assert.True(t, errors.Is(err, fastxml.ErrDuplicateAttr), "%v", err)
+
+	// The signature of the assertion does not sign the response
+	_, err = VerifyEnveloped([]byte(doc), strings.Index(doc, "<samlp:Response"), &key.PublicKey)
+	assert.Equal(t, ErrNoSignature, err)
+	moved := strings.Replace(doc, `URI="#_a1"`, `URI="#_r1"`, 1)
+	_, err = VerifyEnveloped([]byte(moved), assertion, &key.PublicKey)
+	assert.Equal(t, ErrNotReferenced, err)
+
+	_, err = VerifyEnveloped([]byte(doc), assertion+1, &key.PublicKey)
+	assert.EqualError(t, err, "xmldsig: no element at offset 216")
+	_, err = VerifyEnveloped([]byte(doc), assertion-3, &key.PublicKey)
+	assert.EqualError(t, err, "xmldsig: no element at offset 212")
+}
+
+func TestSignature_VerifyECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	doc := signResponse(t, ECDSASHA256, func(hashed []byte) []byte {
+		r, s, err := ecdsa.Sign(rand.Reader, key, hashed)
+		assert.NoError(t, err)
+		signature := make([]byte, 64)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(signature[32-len(rBytes):32], rBytes)
+		copy(signature[64-len(sBytes):], sBytes)
+		return signature
+	})
+	sig, err := FindSignature([]byte(doc), strings.Index(doc, "<saml:Assertion"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, sig.Verify([]byte(doc), &key.PublicKey))
+	rsaKey := &rsa.PublicKey{}
+	assert.EqualError(t, sig.Verify([]byte(doc), rsaKey), `xmldsig: *rsa.PublicKey cannot verify a "`+ECDSASHA256+`" signature`)
+	sig.SignatureValue[0] ^= 0xff
+	assert.Equal(t, ErrInvalidSignature, sig.Verify([]byte(doc), &key.PublicKey))
+}
+
+func TestParseSignature(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Input string
+		Error string
+	}{
+		{
+			Name:  "not a signature",
+			Input: `<Signature/>`,
+			Error: "xmldsig: <Signature> at offset 0 is not a Signature",
+		},
+		{
+			Name:  "no SignedInfo",
+			Input: `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignatureValue>AQID</SignatureValue></Signature>`,
+			Error: "xmldsig: Signature has no SignedInfo",
+		},
+		{
+			Name:  "two SignedInfo",
+			Input: `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo/><SignedInfo/></Signature>`,
+			Error: "xmldsig: Signature has more than one SignedInfo",
+		},
+		{
+			Name:  "invalid base64",
+			Input: `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignatureValue>!</SignatureValue></Signature>`,
+			Error: "xmldsig: illegal base64 data at input byte 0",
+		},
+		{
+			Name:  "unclosed",
+			Input: `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo>`,
+			Error: "unexpected EOF",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := ParseSignature([]byte(tc.Input), 0)
+			assert.EqualError(t, err, tc.Error)
+		})
+	}
+}
+
+func TestSignature_Digest(t *testing.T) {
+	doc := []byte(`<root ID="x"><a>text</a></root>`)
+	sig := &Signature{Start: -1}
+	ref := &Reference{URI: "", DigestMethod: SHA256, Transforms: []Transform{{Algorithm: ExcC14N}}}
+	digest, err := sig.Digest(doc, ref)
+	if assert.NoError(t, err) {
+		expected := sha256.Sum256([]byte(`<root ID="x"><a>text</a></root>`))
+		assert.Equal(t, expected[:], digest)
+	}
+	ref.URI = "#missing"
+	_, err = sig.Digest(doc, ref)
+	assert.EqualError(t, err, `xmldsig: no element with ID "missing"`)
+	ref.URI = "http://example.com/doc.xml"
+	_, err = sig.Digest(doc, ref)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+	ref.URI = "#x"
+	ref.Transforms = nil
+	_, err = sig.Digest(doc, ref)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+	ref.DigestMethod = "http://www.w3.org/2001/04/xmldsig-more#md5"
+	_, err = sig.Digest(doc, ref)
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm))
+}
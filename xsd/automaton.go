@@ -0,0 +1,325 @@
+package xsd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Limits on the size of a compiled content model, large maxOccurs values are expanded into copies of the particle
+const (
+	maxNFAStates = 1 << 16
+	maxDFAStates = 1 << 12
+)
+
+// errTooComplex is returned when a content model exceeds the limits
+var errTooComplex = errors.New("content model is too complex")
+
+// nfaEdge is a transition of the NFA consuming an element matching label
+type nfaEdge struct {
+	label *particle // an element or wildcard particle
+	to    int
+}
+
+// nfaState is a state of the Thompson NFA built from the particles of a content model
+type nfaState struct {
+	eps   []int
+	edges []nfaEdge
+}
+
+// nfa is built from a content model and converted to an automaton
+type nfa struct {
+	states []nfaState
+}
+
+// add creates a new state
+func (n *nfa) add() (int, error) {
+	if len(n.states) >= maxNFAStates {
+		return 0, errTooComplex
+	}
+	n.states = append(n.states, nfaState{})
+	return len(n.states) - 1, nil
+}
+
+// particle adds the states matching p (including its occurrences) starting at from returning the final state
+func (n *nfa) particle(p *particle, from int) (int, error) {
+	cur := from
+	var err error
+	for i := 0; i < p.min; i++ {
+		if cur, err = n.term(p, cur); err != nil {
+			return 0, err
+		}
+	}
+	if p.max == unbounded {
+		loop, err := n.add()
+		if err != nil {
+			return 0, err
+		}
+		n.states[cur].eps = append(n.states[cur].eps, loop)
+		end, err := n.term(p, loop)
+		if err != nil {
+			return 0, err
+		}
+		n.states[end].eps = append(n.states[end].eps, loop)
+		return loop, nil
+	}
+	for i := p.min; i < p.max; i++ {
+		end, err := n.term(p, cur)
+		if err != nil {
+			return 0, err
+		}
+		next, err := n.add()
+		if err != nil {
+			return 0, err
+		}
+		n.states[cur].eps = append(n.states[cur].eps, next)
+		n.states[end].eps = append(n.states[end].eps, next)
+		cur = next
+	}
+	return cur, nil
+}
+
+// term adds the states matching a single occurrence of p starting at from returning the final state
+func (n *nfa) term(p *particle, from int) (int, error) {
+	switch p.kind {
+	case particleElement, particleAny:
+		to, err := n.add()
+		if err != nil {
+			return 0, err
+		}
+		n.states[from].edges = append(n.states[from].edges, nfaEdge{label: p, to: to})
+		return to, nil
+	case particleSequence:
+		cur := from
+		for _, child := range p.children {
+			var err error
+			if cur, err = n.particle(child, cur); err != nil {
+				return 0, err
+			}
+		}
+		return cur, nil
+	case particleChoice:
+		// A choice without any particles is never satisfied so the end is unreachable
+		end, err := n.add()
+		if err != nil {
+			return 0, err
+		}
+		for _, child := range p.children {
+			last, err := n.particle(child, from)
+			if err != nil {
+				return 0, err
+			}
+			n.states[last].eps = append(n.states[last].eps, end)
+		}
+		return end, nil
+	}
+	return 0, errors.New("xs:all may only be used as the content of a complex type")
+}
+
+// closure expands set with every state reachable by epsilon transitions, returning it sorted
+// seen must have an entry for every state which are all false
+func (n *nfa) closure(set []int, seen []bool) []int {
+	stack := append([]int{}, set...)
+	set = set[:0]
+	for len(stack) > 0 {
+		state := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[state] {
+			continue
+		}
+		seen[state] = true
+		set = append(set, state)
+		stack = append(stack, n.states[state].eps...)
+	}
+	for _, state := range set {
+		seen[state] = false
+	}
+	sort.Ints(set)
+	return set
+}
+
+// dfaEdge is a transition of the automaton consuming an element
+type dfaEdge struct {
+	space string
+	local string
+	elem  *element  // the declaration of the element, nil if it matched a wildcard
+	any   *wildcard // the wildcard matching the element if elem is nil
+	next  int
+}
+
+// dfaState is a state of the automaton
+type dfaState struct {
+	accept bool
+	edges  map[string][]dfaEdge // by local name
+	any    []dfaEdge            // transitions for names not in edges which only match a wildcard
+}
+
+// automaton is a DFA recognizing the sequences of child elements allowed by a content model, the start is state 0
+type automaton struct {
+	states []dfaState
+}
+
+// step returns the transition from state for the element space:local (or nil if it is not allowed)
+func (a *automaton) step(state int, space string, local []byte) *dfaEdge {
+	s := &a.states[state]
+	edges := s.edges[string(local)]
+	for idx := range edges {
+		if edges[idx].space == space {
+			return &edges[idx]
+		}
+	}
+	for idx := range s.any {
+		if s.any[idx].any.allows(space) {
+			return &s.any[idx]
+		}
+	}
+	return nil
+}
+
+// expected lists the elements allowed in state for error messages
+func (a *automaton) expected(state int) []string {
+	s := &a.states[state]
+	var names []string
+	for local := range s.edges {
+		names = append(names, "<"+local+">")
+	}
+	sort.Strings(names)
+	if len(s.any) > 0 {
+		names = append(names, "any element")
+	}
+	if s.accept {
+		names = append(names, "the end of the element")
+	}
+	return names
+}
+
+// symbol is an element name in the alphabet of a content model
+type symbol struct {
+	space, local string
+}
+
+// compileAutomaton converts the content model p into an automaton
+// Element declarations which compete for the same name must be the same declaration
+func compileAutomaton(p *particle) (*automaton, error) {
+	n := &nfa{}
+	start, err := n.add()
+	if err != nil {
+		return nil, err
+	}
+	final := start
+	if p != nil {
+		if final, err = n.particle(p, start); err != nil {
+			return nil, err
+		}
+	}
+	// The alphabet is every element name in the content model
+	var alphabet []symbol
+	seenSymbol := make(map[symbol]bool)
+	var wildcards []*wildcard
+	seenWildcard := make(map[*wildcard]bool)
+	for _, state := range n.states {
+		for _, edge := range state.edges {
+			if edge.label.kind == particleAny {
+				if !seenWildcard[edge.label.any] {
+					seenWildcard[edge.label.any] = true
+					wildcards = append(wildcards, edge.label.any)
+				}
+				continue
+			}
+			sym := symbol{space: edge.label.elem.space, local: edge.label.elem.local}
+			if !seenSymbol[sym] {
+				seenSymbol[sym] = true
+				alphabet = append(alphabet, sym)
+			}
+		}
+	}
+	a := &automaton{}
+	ids := make(map[string]int)
+	seen := make([]bool, len(n.states))
+	var sets [][]int
+	// state returns the id of the DFA state for the NFA state set (creating it if needed)
+	key := make([]byte, 0, 64)
+	state := func(set []int) (int, error) {
+		key = key[:0]
+		for _, s := range set {
+			key = append(key, byte(s>>16), byte(s>>8), byte(s))
+		}
+		if id, ok := ids[string(key)]; ok {
+			return id, nil
+		}
+		if len(a.states) >= maxDFAStates {
+			return 0, errTooComplex
+		}
+		id := len(a.states)
+		ids[string(key)] = id
+		sets = append(sets, append([]int{}, set...))
+		accept := false
+		for _, s := range set {
+			if s == final {
+				accept = true
+			}
+		}
+		a.states = append(a.states, dfaState{accept: accept, edges: make(map[string][]dfaEdge)})
+		return id, nil
+	}
+	if _, err := state(n.closure([]int{start}, seen)); err != nil {
+		return nil, err
+	}
+	var moves []int
+	for id := 0; id < len(a.states); id++ {
+		for _, sym := range alphabet {
+			moves = moves[:0]
+			var elem *element
+			var wild *wildcard
+			for _, s := range sets[id] {
+				for _, edge := range n.states[s].edges {
+					if edge.label.kind == particleElement {
+						if edge.label.elem.space != sym.space || edge.label.elem.local != sym.local {
+							continue
+						}
+						if elem != nil && elem != edge.label.elem && !elem.equivalent(edge.label.elem) {
+							return nil, fmt.Errorf("ambiguous content model: element <%s> has more than one declaration", sym.local)
+						}
+						elem = edge.label.elem
+					} else if edge.label.any.allows(sym.space) {
+						wild = edge.label.any
+					} else {
+						continue
+					}
+					moves = append(moves, edge.to)
+				}
+			}
+			if len(moves) == 0 {
+				continue
+			}
+			next, err := state(n.closure(moves, seen))
+			if err != nil {
+				return nil, err
+			}
+			edge := dfaEdge{space: sym.space, local: sym.local, elem: elem, next: next}
+			if elem == nil {
+				edge.any = wild
+			}
+			a.states[id].edges[sym.local] = append(a.states[id].edges[sym.local], edge)
+		}
+		for _, w := range wildcards {
+			moves = moves[:0]
+			for _, s := range sets[id] {
+				for _, edge := range n.states[s].edges {
+					if edge.label.kind == particleAny && edge.label.any == w {
+						moves = append(moves, edge.to)
+					}
+				}
+			}
+			if len(moves) == 0 {
+				continue
+			}
+			next, err := state(n.closure(moves, seen))
+			if err != nil {
+				return nil, err
+			}
+			a.states[id].any = append(a.states[id].any, dfaEdge{any: w, next: next})
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,76 @@
+package xsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// accepts runs the sequence of local names through the automaton
+func accepts(a *automaton, names ...string) bool {
+	state := 0
+	for _, name := range names {
+		edge := a.step(state, "", []byte(name))
+		if edge == nil {
+			return false
+		}
+		state = edge.next
+	}
+	return a.states[state].accept
+}
+
+func TestCompileAutomaton(t *testing.T) {
+	a, b, c := &element{local: "a"}, &element{local: "b"}, &element{local: "c"}
+	el := func(e *element, min, max int) *particle {
+		return &particle{kind: particleElement, elem: e, min: min, max: max}
+	}
+	// (a, (b | c){0,2}, a*)
+	model := &particle{kind: particleSequence, min: 1, max: 1, children: []*particle{
+		el(a, 1, 1),
+		{kind: particleChoice, min: 0, max: 2, children: []*particle{el(b, 1, 1), el(c, 1, 1)}},
+		el(a, 0, unbounded),
+	}}
+	dfa, err := compileAutomaton(model)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, accepts(dfa, "a"))
+	assert.True(t, accepts(dfa, "a", "b", "c"))
+	assert.True(t, accepts(dfa, "a", "c", "a", "a", "a"))
+	assert.False(t, accepts(dfa))
+	assert.False(t, accepts(dfa, "b"))
+	assert.False(t, accepts(dfa, "a", "b", "c", "b"))
+	assert.False(t, accepts(dfa, "a", "a", "b"))
+	assert.Equal(t, []string{"<a>", "<b>", "<c>", "the end of the element"}, dfa.expected(1))
+
+	empty, err := compileAutomaton(nil)
+	if assert.NoError(t, err) {
+		assert.True(t, accepts(empty))
+		assert.False(t, accepts(empty, "a"))
+		assert.Equal(t, []string{"the end of the element"}, empty.expected(0))
+	}
+
+	wild := &wildcard{namespaces: []string{"urn:x"}, process: processLax}
+	withAny := &particle{kind: particleSequence, min: 1, max: 1, children: []*particle{
+		el(a, 1, 1),
+		{kind: particleAny, any: wild, min: 0, max: unbounded},
+	}}
+	dfa, err = compileAutomaton(withAny)
+	if assert.NoError(t, err) {
+		edge := dfa.step(1, "urn:x", []byte("anything"))
+		if assert.NotNil(t, edge) {
+			assert.Nil(t, edge.elem)
+			assert.Equal(t, wild, edge.any)
+		}
+		assert.Nil(t, dfa.step(1, "urn:y", []byte("anything")))
+	}
+
+	ambiguous := &particle{kind: particleChoice, min: 1, max: 1, children: []*particle{
+		el(a, 1, 1), el(&element{local: "a", simple: builtins["int"]}, 1, 1),
+	}}
+	_, err = compileAutomaton(ambiguous)
+	assert.EqualError(t, err, "ambiguous content model: element <a> has more than one declaration")
+
+	_, err = compileAutomaton(el(a, maxNFAStates, maxNFAStates))
+	assert.Equal(t, errTooComplex, err)
+}
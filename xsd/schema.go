@@ -0,0 +1,1094 @@
+// Package xsd validates documents against a W3C XML Schema (XSD 1.0) using fastxml.Scanner
+//
+// A schema is compiled once into an automaton for the content model of each complex type, documents are then
+// validated in a single pass without building a tree. The supported subset of XSD is:
+//   - global and local xs:element declarations (including ref, nillable and fixed)
+//   - xs:complexType with xs:sequence, xs:choice, xs:all, xs:any, xs:group, mixed content, xs:simpleContent
+//     and xs:complexContent (extension and restriction)
+//   - minOccurs and maxOccurs on every particle
+//   - xs:attribute, xs:attributeGroup and xs:anyAttribute
+//   - xs:simpleType restrictions of every built-in type with the enumeration, pattern, length, minLength,
+//     maxLength, minInclusive, maxInclusive, minExclusive, maxExclusive, totalDigits, fractionDigits and
+//     whiteSpace facets, xs:list and xs:union
+//
+// xs:include, xs:import, xs:redefine, substitution groups, identity constraints (xs:key, xs:unique, xs:keyref)
+// and xsi:type are not supported and a schema using them fails to compile (xsi:type is ignored in documents)
+package xsd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// Namespaces used by schemas and documents
+const (
+	Namespace         = "http://www.w3.org/2001/XMLSchema"
+	InstanceNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+	xmlNamespace      = "http://www.w3.org/XML/1998/namespace"
+)
+
+// processContents of a wildcard
+type processContents uint8
+
+// Values of processContents
+const (
+	processStrict processContents = iota
+	processLax
+	processSkip
+)
+
+// wildcard is the namespace constraint of xs:any or xs:anyAttribute
+type wildcard struct {
+	any        bool     // ##any
+	not        []string // ##other, any namespace except these
+	namespaces []string // a list of namespaces ("" for ##local)
+	process    processContents
+}
+
+// allows determines if the namespace space matches the wildcard
+func (w *wildcard) allows(space string) bool {
+	if w.any {
+		return true
+	}
+	if w.not != nil {
+		for _, ns := range w.not {
+			if ns == space {
+				return false
+			}
+		}
+		return true
+	}
+	for _, ns := range w.namespaces {
+		if ns == space {
+			return true
+		}
+	}
+	return false
+}
+
+// particleKind is the type of a particle
+type particleKind uint8
+
+// Kinds of particle
+const (
+	particleElement particleKind = iota
+	particleAny
+	particleSequence
+	particleChoice
+	particleAll
+)
+
+// particle is a term of a content model with its occurrence constraints
+type particle struct {
+	kind     particleKind
+	min, max int // max is unbounded (-1) for maxOccurs="unbounded"
+	elem     *element
+	any      *wildcard
+	children []*particle
+}
+
+// element is an element declaration
+type element struct {
+	space, local string
+	simple       *simpleType  // the type if it is a simple type
+	complex      *complexType // the type if it is a complex type
+	nillable     bool
+	fixed        *string
+}
+
+// equivalent determines if two declarations of the same name have the same type
+func (e *element) equivalent(o *element) bool {
+	return e.space == o.space && e.local == o.local && e.simple == o.simple && e.complex == o.complex
+}
+
+// attribute is an attribute declaration (or use)
+type attribute struct {
+	space, local string
+	typ          *simpleType
+	required     bool
+	prohibited   bool
+	fixed        *string
+}
+
+// complexType is a complex type definition
+type complexType struct {
+	name    string
+	mixed   bool
+	content *particle   // nil if the content is empty (or simple)
+	simple  *simpleType // the type of the text if the content is simple
+	attrs   []*attribute
+	anyAttr *wildcard
+	// The content model is compiled into a DFA, except for xs:all where the elements are counted
+	dfa *automaton
+	all []*particle
+}
+
+// String returns the name of the type
+func (ct *complexType) String() string {
+	if ct.name == "" {
+		return "anonymous type"
+	}
+	return ct.name
+}
+
+// anyType is the type of an element without a type which allows any content and attributes
+var anyType = &complexType{
+	name:    "xs:anyType",
+	mixed:   true,
+	content: &particle{kind: particleAny, max: unbounded, any: &wildcard{any: true, process: processLax}},
+	anyAttr: &wildcard{any: true, process: processLax},
+}
+
+func init() {
+	anyType.dfa, _ = compileAutomaton(anyType.content)
+}
+
+// qname is the namespace URI and local name of a global declaration
+type qname struct {
+	space, local string
+}
+
+// Schema is a compiled schema which can validate documents, it is safe for concurrent use
+type Schema struct {
+	elements   map[qname]*element
+	attributes map[qname]*attribute
+}
+
+// compiler holds the state of a single Compile call
+type compiler struct {
+	tns               string
+	qualifiedElements bool
+	qualifiedAttrs    bool
+	decls             map[string]map[string]*fastxml.Node // kind -> name -> global declaration
+	elements          map[string]*element
+	attributes        map[string]*attribute
+	complexTypes      map[string]*complexType
+	simpleTypes       map[string]*simpleType
+	building          map[*fastxml.Node]bool // named groups being built to detect recursion
+	compiled          []*complexType
+}
+
+// schemaError creates an error for the schema component n
+func schemaError(n *fastxml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("xsd: <%s> at offset %d: %s", n.Name, n.Start, fmt.Sprintf(format, args...))
+}
+
+// local returns the local name of a schema element
+func local(n *fastxml.Node) string {
+	_, l := fastxml.Name(n.Name)
+	return string(l)
+}
+
+// attr returns the decoded value of the attribute key of n
+func attr(n *fastxml.Node, key string) (string, bool) {
+	value, ok := n.AttrValue(key)
+	if !ok {
+		return "", false
+	}
+	decoded, err := fastxml.DecodeEntities(value, nil)
+	if err != nil {
+		return string(value), true
+	}
+	return string(decoded), true
+}
+
+// lookupNS returns the namespace bound to prefix in the scope of n
+func lookupNS(n *fastxml.Node, prefix string) (string, bool) {
+	key := "xmlns"
+	if prefix != "" {
+		key = "xmlns:" + prefix
+	}
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for ; n != nil; n = n.Parent {
+		if n.Kind != fastxml.ElementNode {
+			continue
+		}
+		if value, ok := attr(n, key); ok {
+			return value, true
+		}
+	}
+	return "", prefix == ""
+}
+
+// resolve splits the QName value (ex: `xs:string`) in the scope of n into its namespace and local name
+func resolve(n *fastxml.Node, value string) (qname, error) {
+	prefix, name := "", value
+	if idx := strings.IndexByte(value, ':'); idx != -1 {
+		prefix, name = value[:idx], value[idx+1:]
+	}
+	space, ok := lookupNS(n, prefix)
+	if !ok {
+		return qname{}, schemaError(n, "undeclared prefix %q in %q", prefix, value)
+	}
+	return qname{space: space, local: name}, nil
+}
+
+// isXSD determines if n is an element in the XML Schema namespace
+func isXSD(n *fastxml.Node) bool {
+	prefix, _ := fastxml.Name(n.Name)
+	space, _ := lookupNS(n, string(prefix))
+	return space == Namespace
+}
+
+// children returns the schema elements within n skipping annotations
+func children(n *fastxml.Node) ([]*fastxml.Node, error) {
+	var nodes []*fastxml.Node
+	for _, child := range n.Children {
+		if child.Kind != fastxml.ElementNode {
+			continue
+		}
+		if !isXSD(child) {
+			return nil, schemaError(child, "unexpected element in the schema")
+		}
+		if local(child) == "annotation" {
+			continue
+		}
+		nodes = append(nodes, child)
+	}
+	return nodes, nil
+}
+
+// Compile parses an XML Schema document
+func Compile(buf []byte) (*Schema, error) {
+	doc, err := fastxml.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	var root *fastxml.Node
+	for _, n := range doc.Children {
+		if n.Kind == fastxml.ElementNode {
+			root = n
+			break
+		}
+	}
+	if root == nil || local(root) != "schema" || !isXSD(root) {
+		return nil, errors.New("xsd: the document element is not an xs:schema")
+	}
+	c := &compiler{
+		decls:        make(map[string]map[string]*fastxml.Node),
+		elements:     make(map[string]*element),
+		attributes:   make(map[string]*attribute),
+		complexTypes: make(map[string]*complexType),
+		simpleTypes:  make(map[string]*simpleType),
+		building:     make(map[*fastxml.Node]bool),
+	}
+	c.tns, _ = attr(root, "targetNamespace")
+	if form, _ := attr(root, "elementFormDefault"); form == "qualified" {
+		c.qualifiedElements = true
+	}
+	if form, _ := attr(root, "attributeFormDefault"); form == "qualified" {
+		c.qualifiedAttrs = true
+	}
+	nodes, err := children(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		kind := local(n)
+		switch kind {
+		case "element", "attribute", "complexType", "simpleType", "group", "attributeGroup":
+		case "notation":
+			continue
+		default:
+			return nil, schemaError(n, "xs:%s is not supported", kind)
+		}
+		name, ok := attr(n, "name")
+		if !ok {
+			return nil, schemaError(n, "missing name")
+		}
+		if c.decls[kind] == nil {
+			c.decls[kind] = make(map[string]*fastxml.Node)
+		}
+		if c.decls[kind][name] != nil {
+			return nil, schemaError(n, "duplicate declaration of %q", name)
+		}
+		c.decls[kind][name] = n
+	}
+	// Every global component is built so errors are reported even if unused
+	s := &Schema{elements: make(map[qname]*element), attributes: make(map[qname]*attribute)}
+	for _, n := range nodes {
+		name, _ := attr(n, "name")
+		switch local(n) {
+		case "element":
+			e, err := c.globalElement(n, name)
+			if err != nil {
+				return nil, err
+			}
+			s.elements[qname{space: e.space, local: e.local}] = e
+		case "attribute":
+			a, err := c.globalAttribute(n, name)
+			if err != nil {
+				return nil, err
+			}
+			s.attributes[qname{space: a.space, local: a.local}] = a
+		case "complexType":
+			if _, err := c.namedComplexType(n, name); err != nil {
+				return nil, err
+			}
+		case "simpleType":
+			if _, err := c.namedSimpleType(n, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, ct := range c.compiled {
+		if ct.content != nil && ct.content.kind == particleAll {
+			ct.all = ct.content.children
+			continue
+		}
+		if ct.dfa, err = compileAutomaton(ct.content); err != nil {
+			return nil, fmt.Errorf("xsd: type %s: %w", ct, err)
+		}
+	}
+	return s, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled
+func MustCompile(buf []byte) *Schema {
+	s, err := Compile(buf)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// global returns the global declaration of kind referenced by the QName value in the scope of n
+func (c *compiler) global(n *fastxml.Node, kind string, value string) (*fastxml.Node, string, error) {
+	name, err := resolve(n, value)
+	if err != nil {
+		return nil, "", err
+	}
+	if name.space == c.tns {
+		if decl := c.decls[kind][name.local]; decl != nil {
+			return decl, name.local, nil
+		}
+	}
+	return nil, "", schemaError(n, "unknown %s %q", kind, value)
+}
+
+// occurs reads minOccurs and maxOccurs
+func occurs(n *fastxml.Node) (min int, max int, err error) {
+	min, max = 1, 1
+	if value, ok := attr(n, "minOccurs"); ok {
+		if min, err = strconv.Atoi(value); err != nil || min < 0 {
+			return 0, 0, schemaError(n, "invalid minOccurs %q", value)
+		}
+	}
+	if value, ok := attr(n, "maxOccurs"); ok {
+		if value == "unbounded" {
+			max = unbounded
+		} else if max, err = strconv.Atoi(value); err != nil || max < 0 {
+			return 0, 0, schemaError(n, "invalid maxOccurs %q", value)
+		}
+	}
+	if max != unbounded && max < min {
+		return 0, 0, schemaError(n, "maxOccurs is less than minOccurs")
+	}
+	return min, max, nil
+}
+
+// typeRef resolves the type named by the QName value to a simple or complex type
+func (c *compiler) typeRef(n *fastxml.Node, value string) (*simpleType, *complexType, error) {
+	name, err := resolve(n, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if name.space == Namespace {
+		if name.local == "anyType" {
+			return nil, anyType, nil
+		}
+		if t := builtins[name.local]; t != nil {
+			return t, nil, nil
+		}
+		return nil, nil, schemaError(n, "unknown built-in type %q", value)
+	}
+	if name.space == c.tns {
+		if decl := c.decls["complexType"][name.local]; decl != nil {
+			ct, err := c.namedComplexType(decl, name.local)
+			return nil, ct, err
+		}
+		if decl := c.decls["simpleType"][name.local]; decl != nil {
+			st, err := c.namedSimpleType(decl, name.local)
+			return st, nil, err
+		}
+	}
+	return nil, nil, schemaError(n, "unknown type %q", value)
+}
+
+// simpleTypeRef resolves the QName value which must name a simple type
+func (c *compiler) simpleTypeRef(n *fastxml.Node, value string) (*simpleType, error) {
+	st, ct, err := c.typeRef(n, value)
+	if err != nil {
+		return nil, err
+	} else if ct != nil {
+		return nil, schemaError(n, "type %q is not a simple type", value)
+	}
+	return st, nil
+}
+
+// globalElement builds the global element declaration n
+func (c *compiler) globalElement(n *fastxml.Node, name string) (*element, error) {
+	if e := c.elements[name]; e != nil {
+		return e, nil
+	}
+	e := &element{space: c.tns, local: name}
+	// Registered before the type is built as the type may contain the element
+	c.elements[name] = e
+	if err := c.elementType(n, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// localElement builds the element declaration (or reference) n within a content model
+func (c *compiler) localElement(n *fastxml.Node) (*element, error) {
+	if ref, ok := attr(n, "ref"); ok {
+		decl, name, err := c.global(n, "element", ref)
+		if err != nil {
+			return nil, err
+		}
+		return c.globalElement(decl, name)
+	}
+	name, ok := attr(n, "name")
+	if !ok {
+		return nil, schemaError(n, "missing name")
+	}
+	e := &element{local: name}
+	form, ok := attr(n, "form")
+	if form == "qualified" || !ok && c.qualifiedElements {
+		e.space = c.tns
+	}
+	return e, c.elementType(n, e)
+}
+
+// elementType builds the type and properties of the element declaration n
+func (c *compiler) elementType(n *fastxml.Node, e *element) error {
+	for _, unsupported := range []string{"substitutionGroup", "abstract"} {
+		if _, ok := attr(n, unsupported); ok {
+			return schemaError(n, "%s is not supported", unsupported)
+		}
+	}
+	if value, ok := attr(n, "nillable"); ok {
+		e.nillable = value == "true" || value == "1"
+	}
+	if value, ok := attr(n, "fixed"); ok {
+		e.fixed = &value
+	}
+	nodes, err := children(n)
+	if err != nil {
+		return err
+	}
+	if typ, ok := attr(n, "type"); ok {
+		e.simple, e.complex, err = c.typeRef(n, typ)
+		if err != nil {
+			return err
+		}
+	}
+	for _, child := range nodes {
+		switch local(child) {
+		case "complexType":
+			e.complex, err = c.complexType(child, "")
+		case "simpleType":
+			e.simple, err = c.simpleType(child, "")
+		case "key", "keyref", "unique":
+			err = schemaError(child, "identity constraints are not supported")
+		default:
+			err = schemaError(child, "unexpected element")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if e.simple == nil && e.complex == nil {
+		e.complex = anyType
+	}
+	if e.fixed != nil && e.simple != nil {
+		if err := e.simple.validate(*e.fixed); err != nil {
+			return schemaError(n, "fixed value: %s", err)
+		}
+	}
+	return nil
+}
+
+// globalAttribute builds the global attribute declaration n
+func (c *compiler) globalAttribute(n *fastxml.Node, name string) (*attribute, error) {
+	if a := c.attributes[name]; a != nil {
+		return a, nil
+	}
+	a := &attribute{space: c.tns, local: name}
+	if err := c.attributeType(n, a); err != nil {
+		return nil, err
+	}
+	c.attributes[name] = a
+	return a, nil
+}
+
+// attribute builds the attribute use n within a complex type
+func (c *compiler) attribute(n *fastxml.Node) (*attribute, error) {
+	var a *attribute
+	if ref, ok := attr(n, "ref"); ok {
+		decl, name, err := c.global(n, "attribute", ref)
+		if err != nil {
+			return nil, err
+		}
+		global, err := c.globalAttribute(decl, name)
+		if err != nil {
+			return nil, err
+		}
+		copied := *global
+		a = &copied
+	} else {
+		name, ok := attr(n, "name")
+		if !ok {
+			return nil, schemaError(n, "missing name")
+		}
+		a = &attribute{local: name}
+		form, ok := attr(n, "form")
+		if form == "qualified" || !ok && c.qualifiedAttrs {
+			a.space = c.tns
+		}
+		if err := c.attributeType(n, a); err != nil {
+			return nil, err
+		}
+	}
+	switch use, _ := attr(n, "use"); use {
+	case "required":
+		a.required = true
+	case "prohibited":
+		a.prohibited = true
+	}
+	if value, ok := attr(n, "fixed"); ok {
+		a.fixed = &value
+	}
+	return a, nil
+}
+
+// attributeType builds the type of the attribute declaration n
+func (c *compiler) attributeType(n *fastxml.Node, a *attribute) error {
+	a.typ = anySimpleType
+	if typ, ok := attr(n, "type"); ok {
+		st, err := c.simpleTypeRef(n, typ)
+		if err != nil {
+			return err
+		}
+		a.typ = st
+	}
+	nodes, err := children(n)
+	if err != nil {
+		return err
+	}
+	for _, child := range nodes {
+		if local(child) != "simpleType" {
+			return schemaError(child, "unexpected element")
+		}
+		if a.typ, err = c.simpleType(child, ""); err != nil {
+			return err
+		}
+	}
+	if value, ok := attr(n, "fixed"); ok {
+		if err := a.typ.validate(value); err != nil {
+			return schemaError(n, "fixed value: %s", err)
+		}
+		a.fixed = &value
+	}
+	return nil
+}
+
+// wildcard builds xs:any or xs:anyAttribute
+func (c *compiler) wildcard(n *fastxml.Node) (*wildcard, error) {
+	w := &wildcard{}
+	switch process, _ := attr(n, "processContents"); process {
+	case "", "strict":
+	case "lax":
+		w.process = processLax
+	case "skip":
+		w.process = processSkip
+	default:
+		return nil, schemaError(n, "invalid processContents %q", process)
+	}
+	namespace, ok := attr(n, "namespace")
+	if !ok {
+		namespace = "##any"
+	}
+	switch namespace {
+	case "##any":
+		w.any = true
+	case "##other":
+		w.not = []string{c.tns, ""}
+	default:
+		w.namespaces = []string{}
+		for _, ns := range strings.Fields(namespace) {
+			switch ns {
+			case "##local":
+				ns = ""
+			case "##targetNamespace":
+				ns = c.tns
+			}
+			w.namespaces = append(w.namespaces, ns)
+		}
+	}
+	return w, nil
+}
+
+// particle builds a particle of a content model
+func (c *compiler) particle(n *fastxml.Node) (*particle, error) {
+	min, max, err := occurs(n)
+	if err != nil {
+		return nil, err
+	}
+	p := &particle{min: min, max: max}
+	switch kind := local(n); kind {
+	case "element":
+		p.kind = particleElement
+		p.elem, err = c.localElement(n)
+		return p, err
+	case "any":
+		p.kind = particleAny
+		p.any, err = c.wildcard(n)
+		return p, err
+	case "group":
+		ref, ok := attr(n, "ref")
+		if !ok {
+			return nil, schemaError(n, "missing ref")
+		}
+		decl, _, err := c.global(n, "group", ref)
+		if err != nil {
+			return nil, err
+		}
+		if c.building[decl] {
+			return nil, schemaError(n, "group %q refers to itself", ref)
+		}
+		c.building[decl] = true
+		defer delete(c.building, decl)
+		nodes, err := children(decl)
+		if err != nil {
+			return nil, err
+		} else if len(nodes) != 1 {
+			return nil, schemaError(decl, "expected a single xs:sequence, xs:choice or xs:all")
+		}
+		group, err := c.particle(nodes[0])
+		if err != nil {
+			return nil, err
+		}
+		group.min, group.max = min, max
+		return group, nil
+	case "sequence", "choice", "all":
+		p.kind = map[string]particleKind{"sequence": particleSequence, "choice": particleChoice, "all": particleAll}[kind]
+		nodes, err := children(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range nodes {
+			childParticle, err := c.particle(child)
+			if err != nil {
+				return nil, err
+			}
+			if p.kind == particleAll && (childParticle.kind != particleElement || childParticle.max > 1) {
+				return nil, schemaError(child, "xs:all may only contain elements with maxOccurs of 1")
+			}
+			p.children = append(p.children, childParticle)
+		}
+		if p.kind == particleAll && max > 1 {
+			return nil, schemaError(n, "xs:all must have maxOccurs of 1")
+		}
+		return p, nil
+	}
+	return nil, schemaError(n, "unexpected element")
+}
+
+// namedComplexType builds the global complex type n
+func (c *compiler) namedComplexType(n *fastxml.Node, name string) (*complexType, error) {
+	if ct := c.complexTypes[name]; ct != nil {
+		return ct, nil
+	}
+	return c.complexType(n, name)
+}
+
+// complexType builds a complex type definition
+func (c *compiler) complexType(n *fastxml.Node, name string) (*complexType, error) {
+	ct := &complexType{name: name}
+	if name != "" {
+		// Registered before the content is built as the content may contain elements of the type
+		c.complexTypes[name] = ct
+	}
+	if _, ok := attr(n, "abstract"); ok {
+		return nil, schemaError(n, "abstract is not supported")
+	}
+	if mixed, _ := attr(n, "mixed"); mixed == "true" || mixed == "1" {
+		ct.mixed = true
+	}
+	nodes, err := children(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range nodes {
+		switch local(child) {
+		case "simpleContent":
+			err = c.simpleContent(child, ct)
+		case "complexContent":
+			err = c.complexContent(child, ct)
+		default:
+			err = c.content(child, ct)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.compiled = append(c.compiled, ct)
+	return ct, nil
+}
+
+// content adds a particle or attribute within a complex type (or a derivation) to ct
+func (c *compiler) content(n *fastxml.Node, ct *complexType) error {
+	switch local(n) {
+	case "sequence", "choice", "all", "group":
+		if ct.content != nil {
+			return schemaError(n, "a complex type may only have a single content model")
+		}
+		p, err := c.particle(n)
+		if err != nil {
+			return err
+		}
+		ct.content = p
+	case "attribute":
+		a, err := c.attribute(n)
+		if err != nil {
+			return err
+		}
+		ct.addAttr(a)
+	case "attributeGroup":
+		return c.attributeGroup(n, ct)
+	case "anyAttribute":
+		w, err := c.wildcard(n)
+		if err != nil {
+			return err
+		}
+		ct.anyAttr = w
+	default:
+		return schemaError(n, "unexpected element")
+	}
+	return nil
+}
+
+// addAttr adds an attribute use replacing any with the same name
+func (ct *complexType) addAttr(a *attribute) {
+	for idx, existing := range ct.attrs {
+		if existing.space == a.space && existing.local == a.local {
+			ct.attrs[idx] = a
+			return
+		}
+	}
+	ct.attrs = append(ct.attrs, a)
+}
+
+// attributeGroup adds the attributes of the referenced xs:attributeGroup to ct
+func (c *compiler) attributeGroup(n *fastxml.Node, ct *complexType) error {
+	ref, ok := attr(n, "ref")
+	if !ok {
+		return schemaError(n, "missing ref")
+	}
+	decl, _, err := c.global(n, "attributeGroup", ref)
+	if err != nil {
+		return err
+	}
+	if c.building[decl] {
+		return schemaError(n, "attribute group %q refers to itself", ref)
+	}
+	c.building[decl] = true
+	defer delete(c.building, decl)
+	nodes, err := children(decl)
+	if err != nil {
+		return err
+	}
+	for _, child := range nodes {
+		switch local(child) {
+		case "attribute", "attributeGroup", "anyAttribute":
+			if err := c.content(child, ct); err != nil {
+				return err
+			}
+		default:
+			return schemaError(child, "unexpected element")
+		}
+	}
+	return nil
+}
+
+// derivation returns the base attribute and children of an xs:extension or xs:restriction
+func derivation(n *fastxml.Node) (*fastxml.Node, string, []*fastxml.Node, error) {
+	nodes, err := children(n)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if len(nodes) != 1 || (local(nodes[0]) != "extension" && local(nodes[0]) != "restriction") {
+		return nil, "", nil, schemaError(n, "expected a single xs:extension or xs:restriction")
+	}
+	base, ok := attr(nodes[0], "base")
+	if !ok {
+		return nil, "", nil, schemaError(nodes[0], "missing base")
+	}
+	inner, err := children(nodes[0])
+	return nodes[0], base, inner, err
+}
+
+// simpleContent builds a complex type with simple content and attributes
+func (c *compiler) simpleContent(n *fastxml.Node, ct *complexType) error {
+	derived, base, nodes, err := derivation(n)
+	if err != nil {
+		return err
+	}
+	st, baseType, err := c.typeRef(derived, base)
+	if err != nil {
+		return err
+	}
+	if baseType != nil {
+		if baseType.simple == nil {
+			return schemaError(derived, "base type %q does not have simple content", base)
+		}
+		st = baseType.simple
+		ct.attrs = append(ct.attrs, baseType.attrs...)
+		ct.anyAttr = baseType.anyAttr
+	}
+	ct.simple = st
+	var facets []*fastxml.Node
+	for _, child := range nodes {
+		switch local(child) {
+		case "attribute", "attributeGroup", "anyAttribute":
+			if err := c.content(child, ct); err != nil {
+				return err
+			}
+		default:
+			if local(derived) == "extension" {
+				return schemaError(child, "unexpected element")
+			}
+			facets = append(facets, child)
+		}
+	}
+	if len(facets) > 0 {
+		ct.simple, err = c.restrict(st, "", facets)
+	}
+	return err
+}
+
+// complexContent builds a complex type derived from another complex type
+func (c *compiler) complexContent(n *fastxml.Node, ct *complexType) error {
+	if mixed, ok := attr(n, "mixed"); ok {
+		ct.mixed = mixed == "true" || mixed == "1"
+	}
+	derived, base, nodes, err := derivation(n)
+	if err != nil {
+		return err
+	}
+	_, baseType, err := c.typeRef(derived, base)
+	if err != nil {
+		return err
+	} else if baseType == nil {
+		return schemaError(derived, "base type %q is not a complex type", base)
+	} else if baseType == ct {
+		return schemaError(derived, "type %q is derived from itself", base)
+	}
+	// Attributes are inherited by both extension and restriction
+	ct.attrs = append(ct.attrs, baseType.attrs...)
+	ct.anyAttr = baseType.anyAttr
+	for _, child := range nodes {
+		if err := c.content(child, ct); err != nil {
+			return err
+		}
+	}
+	kept := ct.attrs[:0]
+	for _, a := range ct.attrs {
+		if !a.prohibited {
+			kept = append(kept, a)
+		}
+	}
+	ct.attrs = kept
+	if local(derived) == "extension" && baseType.content != nil {
+		if ct.content == nil {
+			ct.content = baseType.content
+		} else {
+			ct.content = &particle{kind: particleSequence, min: 1, max: 1, children: []*particle{baseType.content, ct.content}}
+		}
+	}
+	return nil
+}
+
+// namedSimpleType builds the global simple type n
+func (c *compiler) namedSimpleType(n *fastxml.Node, name string) (*simpleType, error) {
+	if st := c.simpleTypes[name]; st != nil {
+		return st, nil
+	}
+	if c.building[n] {
+		return nil, schemaError(n, "simple type %q is derived from itself", name)
+	}
+	c.building[n] = true
+	defer delete(c.building, n)
+	st, err := c.simpleType(n, name)
+	if err != nil {
+		return nil, err
+	}
+	c.simpleTypes[name] = st
+	return st, nil
+}
+
+// simpleType builds a simple type definition
+func (c *compiler) simpleType(n *fastxml.Node, name string) (*simpleType, error) {
+	nodes, err := children(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != 1 {
+		return nil, schemaError(n, "expected a single xs:restriction, xs:list or xs:union")
+	}
+	derived := nodes[0]
+	inner, err := children(derived)
+	if err != nil {
+		return nil, err
+	}
+	switch local(derived) {
+	case "restriction":
+		var base *simpleType
+		if value, ok := attr(derived, "base"); ok {
+			if base, err = c.simpleTypeRef(derived, value); err != nil {
+				return nil, err
+			}
+		} else if len(inner) > 0 && local(inner[0]) == "simpleType" {
+			if base, err = c.simpleType(inner[0], ""); err != nil {
+				return nil, err
+			}
+			inner = inner[1:]
+		} else {
+			return nil, schemaError(derived, "missing base")
+		}
+		return c.restrict(base, name, inner)
+	case "list":
+		st := newSimpleType(name, "", wsCollapse)
+		if value, ok := attr(derived, "itemType"); ok {
+			st.item, err = c.simpleTypeRef(derived, value)
+		} else if len(inner) == 1 && local(inner[0]) == "simpleType" {
+			st.item, err = c.simpleType(inner[0], "")
+		} else {
+			err = schemaError(derived, "missing itemType")
+		}
+		return st, err
+	case "union":
+		st := newSimpleType(name, "", wsCollapse)
+		// Members normalize their own values
+		st.ws = wsPreserve
+		if value, ok := attr(derived, "memberTypes"); ok {
+			for _, member := range strings.Fields(value) {
+				mt, err := c.simpleTypeRef(derived, member)
+				if err != nil {
+					return nil, err
+				}
+				st.members = append(st.members, mt)
+			}
+		}
+		for _, child := range inner {
+			if local(child) != "simpleType" {
+				return nil, schemaError(child, "unexpected element")
+			}
+			mt, err := c.simpleType(child, "")
+			if err != nil {
+				return nil, err
+			}
+			st.members = append(st.members, mt)
+		}
+		if len(st.members) == 0 {
+			return nil, schemaError(derived, "missing memberTypes")
+		}
+		return st, nil
+	}
+	return nil, schemaError(derived, "unexpected element")
+}
+
+// restrict derives a simple type from base with the facets in nodes
+func (c *compiler) restrict(base *simpleType, name string, nodes []*fastxml.Node) (*simpleType, error) {
+	st := base.derive(name)
+	for _, n := range nodes {
+		value, ok := attr(n, "value")
+		if !ok {
+			return nil, schemaError(n, "missing value")
+		}
+		var err error
+		switch facet := local(n); facet {
+		case "enumeration":
+			// Enumerated values are compared after normalization
+			value = normalize(value, st.ws)
+			if err := base.validateNormalized(value); err != nil {
+				return nil, schemaError(n, "%s", err)
+			}
+			st.enums = append(st.enums, value)
+		case "pattern":
+			re, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil {
+				return nil, schemaError(n, "invalid pattern %q: %s", value, err)
+			}
+			st.patterns = append(st.patterns, re)
+			st.patternSrc = append(st.patternSrc, value)
+		case "length", "minLength", "maxLength", "totalDigits", "fractionDigits":
+			size, convErr := strconv.Atoi(value)
+			if convErr != nil || size < 0 {
+				return nil, schemaError(n, "invalid %s %q", facet, value)
+			}
+			switch facet {
+			case "length":
+				st.length = size
+			case "minLength":
+				st.minLength = size
+			case "maxLength":
+				st.maxLength = size
+			case "totalDigits":
+				st.totalDigits = size
+			case "fractionDigits":
+				st.fractionDigits = size
+			}
+		case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+			if !st.numeric {
+				return nil, schemaError(n, "%s is only supported for numeric types", facet)
+			}
+			if err := base.validate(value); err != nil {
+				return nil, schemaError(n, "%s", err)
+			}
+			bound, ok := ratFromString(normalize(value, wsCollapse))
+			if !ok {
+				return nil, schemaError(n, "invalid %s %q", facet, value)
+			}
+			switch facet {
+			case "minInclusive":
+				st.minInclusive = bound
+			case "maxInclusive":
+				st.maxInclusive = bound
+			case "minExclusive":
+				st.minExclusive = bound
+			case "maxExclusive":
+				st.maxExclusive = bound
+			}
+		case "whiteSpace":
+			switch value {
+			case "preserve":
+				st.ws = wsPreserve
+			case "replace":
+				st.ws = wsReplace
+			case "collapse":
+				st.ws = wsCollapse
+			default:
+				err = schemaError(n, "invalid whiteSpace %q", value)
+			}
+			if st.ws < base.ws {
+				err = schemaError(n, "whiteSpace %q is weaker than the base type", value)
+			}
+		default:
+			err = schemaError(n, "unsupported facet xs:%s", facet)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,111 @@
+package xsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Schema string
+		Error  string
+	}{
+		{
+			Name:   "empty",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`,
+		},
+		{
+			Name:   "not a schema",
+			Schema: `<schema/>`,
+			Error:  "xsd: the document element is not an xs:schema",
+		},
+		{
+			Name:   "import",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:import namespace="urn:x"/></xs:schema>`,
+			Error:  "xsd: <xs:import> at offset 55: xs:import is not supported",
+		},
+		{
+			Name:   "unknown type",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a" type="xs:nope"/></xs:schema>`,
+			Error:  `xsd: <xs:element> at offset 55: unknown built-in type "xs:nope"`,
+		},
+		{
+			Name:   "duplicate",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"/><xs:element name="a"/></xs:schema>`,
+			Error:  `xsd: <xs:element> at offset 77: duplicate declaration of "a"`,
+		},
+		{
+			Name: "bad facet",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="t">
+				<xs:restriction base="xs:int"><xs:maxInclusive value="x"/></xs:restriction>
+			</xs:simpleType></xs:schema>`,
+			Error: `xsd: <xs:maxInclusive> at offset 114: value "x" is not a valid xs:decimal`,
+		},
+		{
+			Name: "occurs",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:complexType name="t">
+				<xs:sequence minOccurs="2" maxOccurs="1"/>
+			</xs:complexType></xs:schema>`,
+			Error: `xsd: <xs:sequence> at offset 85: maxOccurs is less than minOccurs`,
+		},
+		{
+			Name: "recursive group",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+				<xs:group name="g"><xs:sequence><xs:group ref="g"/></xs:sequence></xs:group>
+				<xs:complexType name="t"><xs:group ref="g"/></xs:complexType>
+			</xs:schema>`,
+			Error: `xsd: <xs:group> at offset 92: group "g" refers to itself`,
+		},
+		{
+			Name: "substitution group",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+				<xs:element name="a"/><xs:element name="b" substitutionGroup="a"/>
+			</xs:schema>`,
+			Error: `xsd: <xs:element> at offset 82: substitutionGroup is not supported`,
+		},
+		{
+			Name: "ambiguous",
+			Schema: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:complexType name="t">
+				<xs:choice><xs:element name="a" type="xs:int"/><xs:element name="a" type="xs:string"/></xs:choice>
+			</xs:complexType></xs:schema>`,
+			Error: `xsd: type t: ambiguous content model: element <a> has more than one declaration`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Compile([]byte(tc.Schema))
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.Error)
+			}
+		})
+	}
+	assert.Panics(t, func() { MustCompile([]byte(`<schema/>`)) })
+}
+
+func TestCompile_ComplexContent(t *testing.T) {
+	schema := MustCompile([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:complexType name="base">
+			<xs:sequence><xs:element name="a" type="xs:string"/></xs:sequence>
+			<xs:attribute name="x" type="xs:int"/>
+		</xs:complexType>
+		<xs:complexType name="derived">
+			<xs:complexContent>
+				<xs:extension base="base">
+					<xs:sequence><xs:element name="b" type="xs:string" minOccurs="0"/></xs:sequence>
+					<xs:attribute name="y" type="xs:int" use="required"/>
+				</xs:extension>
+			</xs:complexContent>
+		</xs:complexType>
+		<xs:element name="root" type="derived"/>
+	</xs:schema>`))
+	assert.NoError(t, schema.Validate([]byte(`<root x="1" y="2"><a/><b/></root>`)))
+	assert.NoError(t, schema.Validate([]byte(`<root y="2"><a/></root>`)))
+	assert.EqualError(t, schema.Validate([]byte(`<root y="2"><b/></root>`)),
+		"xsd: line 1, column 13: unexpected element <b> in <root>, expected <a>")
+	assert.EqualError(t, schema.Validate([]byte(`<root><a/></root>`)),
+		`xsd: line 1, column 1: element <root> is missing the required attribute "y"`)
+}
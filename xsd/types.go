@@ -0,0 +1,461 @@
+package xsd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// whitespace is the whiteSpace facet of a simple type
+type whitespace uint8
+
+// Values of the whiteSpace facet
+const (
+	wsPreserve whitespace = iota
+	wsReplace
+	wsCollapse
+)
+
+// normalize applies the whiteSpace facet ws to value
+func normalize(value string, ws whitespace) string {
+	switch {
+	case ws == wsCollapse:
+		return strings.Join(strings.Fields(value), " ")
+	case ws == wsReplace && strings.ContainsAny(value, "\t\r\n"):
+		return strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\r' || r == '\n' {
+				return ' '
+			}
+			return r
+		}, value)
+	}
+	return value
+}
+
+// unbounded is the maximum length of a simple type without a length facet
+const unbounded = -1
+
+// simpleType is a built-in or user-defined simple type
+type simpleType struct {
+	name      string
+	base      *simpleType
+	primitive string                   // name of the primitive built-in type it is derived from
+	check     func(value string) error // lexical check of a built-in type (in addition to its base)
+	numeric   bool                     // if the bounds facets apply
+	ws        whitespace
+	// Facets
+	enums                        []string
+	patterns                     []*regexp.Regexp // a value must match one of the patterns
+	patternSrc                   []string
+	length, minLength, maxLength int
+	minInclusive, maxInclusive   *big.Rat
+	minExclusive, maxExclusive   *big.Rat
+	totalDigits, fractionDigits  int
+	item                         *simpleType   // xs:list item type
+	members                      []*simpleType // xs:union member types
+}
+
+// newSimpleType creates a type without any facets
+func newSimpleType(name string, primitive string, ws whitespace) *simpleType {
+	return &simpleType{
+		name:           name,
+		primitive:      primitive,
+		ws:             ws,
+		length:         unbounded,
+		minLength:      unbounded,
+		maxLength:      unbounded,
+		totalDigits:    unbounded,
+		fractionDigits: unbounded,
+	}
+}
+
+// derive creates a restriction of t without any facets
+func (t *simpleType) derive(name string) *simpleType {
+	derived := newSimpleType(name, t.primitive, t.ws)
+	derived.base = t
+	derived.numeric = t.numeric
+	return derived
+}
+
+// String returns the name of the type
+func (t *simpleType) String() string {
+	if t.name == "" {
+		return "anonymous type"
+	} else if builtins[t.name] == t {
+		return "xs:" + t.name
+	}
+	return t.name
+}
+
+// validate checks value (which has not been normalized) is in the value space of t
+func (t *simpleType) validate(value string) error {
+	return t.validateNormalized(normalize(value, t.ws))
+}
+
+// validateNormalized checks value after whitespace normalization by the most derived type
+func (t *simpleType) validateNormalized(value string) error {
+	switch {
+	case t.item != nil:
+		for _, item := range strings.Fields(value) {
+			if err := t.item.validate(item); err != nil {
+				return err
+			}
+		}
+	case t.members != nil:
+		var err error
+		for _, member := range t.members {
+			if err = member.validate(value); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("value %q does not match any member of the union", value)
+		}
+	case t.base != nil:
+		if err := t.base.validateNormalized(value); err != nil {
+			return err
+		}
+	}
+	if t.check != nil {
+		if err := t.check(value); err != nil {
+			return err
+		}
+	}
+	return t.facets(value)
+}
+
+// size returns the length of value as measured by the length facets
+func (t *simpleType) size(value string) int {
+	switch {
+	case t.isList():
+		return len(strings.Fields(value))
+	case t.primitive == "hexBinary":
+		return len(value) / 2
+	case t.primitive == "base64Binary":
+		decoded, _ := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		return len(decoded)
+	}
+	return utf8.RuneCountInString(value)
+}
+
+// isList determines if t is (or is derived from) a list type
+func (t *simpleType) isList() bool {
+	for ; t != nil; t = t.base {
+		if t.item != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// facets checks the facets declared by t itself
+func (t *simpleType) facets(value string) error {
+	if len(t.enums) > 0 {
+		found := false
+		for _, enum := range t.enums {
+			if enum == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value %q is not one of the enumerated values %q", value, t.enums)
+		}
+	}
+	if len(t.patterns) > 0 {
+		found := false
+		for _, pattern := range t.patterns {
+			if pattern.MatchString(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value %q does not match the pattern %q", value, strings.Join(t.patternSrc, "|"))
+		}
+	}
+	if t.length != unbounded || t.minLength != unbounded || t.maxLength != unbounded {
+		size := t.size(value)
+		switch {
+		case t.length != unbounded && size != t.length:
+			return fmt.Errorf("value %q has length %d but must have length %d", value, size, t.length)
+		case t.minLength != unbounded && size < t.minLength:
+			return fmt.Errorf("value %q has length %d but must have a length of at least %d", value, size, t.minLength)
+		case t.maxLength != unbounded && size > t.maxLength:
+			return fmt.Errorf("value %q has length %d but must have a length of at most %d", value, size, t.maxLength)
+		}
+	}
+	if t.minInclusive != nil || t.maxInclusive != nil || t.minExclusive != nil || t.maxExclusive != nil {
+		// INF and NaN of float and double are not compared
+		num, ok := ratFromString(value)
+		if !ok {
+			return nil
+		}
+		switch {
+		case t.minInclusive != nil && num.Cmp(t.minInclusive) < 0:
+			return fmt.Errorf("value %q is less than the minimum %s", value, t.minInclusive.RatString())
+		case t.maxInclusive != nil && num.Cmp(t.maxInclusive) > 0:
+			return fmt.Errorf("value %q is greater than the maximum %s", value, t.maxInclusive.RatString())
+		case t.minExclusive != nil && num.Cmp(t.minExclusive) <= 0:
+			return fmt.Errorf("value %q must be greater than %s", value, t.minExclusive.RatString())
+		case t.maxExclusive != nil && num.Cmp(t.maxExclusive) >= 0:
+			return fmt.Errorf("value %q must be less than %s", value, t.maxExclusive.RatString())
+		}
+	}
+	if t.totalDigits != unbounded || t.fractionDigits != unbounded {
+		total, fraction := digits(value)
+		if t.totalDigits != unbounded && total > t.totalDigits {
+			return fmt.Errorf("value %q has more than %d digits", value, t.totalDigits)
+		}
+		if t.fractionDigits != unbounded && fraction > t.fractionDigits {
+			return fmt.Errorf("value %q has more than %d fraction digits", value, t.fractionDigits)
+		}
+	}
+	return nil
+}
+
+// ratFromString parses a decimal (or the mantissa and exponent of a float) as a big.Rat
+func ratFromString(value string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strings.TrimPrefix(value, "+"))
+}
+
+// digits counts the significant total and fraction digits of a decimal
+func digits(value string) (total int, fraction int) {
+	value = strings.TrimLeft(value, "+-")
+	integer, frac := value, ""
+	if idx := strings.IndexByte(value, '.'); idx != -1 {
+		integer, frac = value[:idx], value[idx+1:]
+	}
+	integer = strings.TrimLeft(integer, "0")
+	frac = strings.TrimRight(frac, "0")
+	return len(integer) + len(frac), len(frac)
+}
+
+// checkRegexp creates a lexical check requiring value to match re
+func checkRegexp(name string, re *regexp.Regexp) func(string) error {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("value %q is not a valid xs:%s", value, name)
+		}
+		return nil
+	}
+}
+
+// Lexical spaces of the built-in types
+var (
+	reDecimal    = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	reInteger    = regexp.MustCompile(`^[+-]?\d+$`)
+	reFloat      = regexp.MustCompile(`^([+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|-?INF|NaN)$`)
+	reDuration   = regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+	reTimezone   = `(Z|[+-]((0\d|1[0-3]):[0-5]\d|14:00))?`
+	reDateTime   = regexp.MustCompile(`^-?\d{4,}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])T(([01]\d|2[0-3]):[0-5]\d:[0-5]\d(\.\d+)?|24:00:00(\.0+)?)` + reTimezone + `$`)
+	reDate       = regexp.MustCompile(`^-?\d{4,}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])` + reTimezone + `$`)
+	reTime       = regexp.MustCompile(`^(([01]\d|2[0-3]):[0-5]\d:[0-5]\d(\.\d+)?|24:00:00(\.0+)?)` + reTimezone + `$`)
+	reGYearMonth = regexp.MustCompile(`^-?\d{4,}-(0[1-9]|1[0-2])` + reTimezone + `$`)
+	reGYear      = regexp.MustCompile(`^-?\d{4,}` + reTimezone + `$`)
+	reGMonthDay  = regexp.MustCompile(`^--(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])` + reTimezone + `$`)
+	reGDay       = regexp.MustCompile(`^---(0[1-9]|[12]\d|3[01])` + reTimezone + `$`)
+	reGMonth     = regexp.MustCompile(`^--(0[1-9]|1[0-2])` + reTimezone + `$`)
+	reHexBinary  = regexp.MustCompile(`^([0-9a-fA-F]{2})*$`)
+	reLanguage   = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+)
+
+// isNameStart checks if r may start an XML name
+func isNameStart(r rune) bool {
+	return r == ':' || r == '_' || unicode.IsLetter(r)
+}
+
+// isNameChar checks if r may appear in an XML name after the first character
+func isNameChar(r rune) bool {
+	return isNameStart(r) || r == '-' || r == '.' || r == 0xB7 || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r)
+}
+
+// isName checks if value is an XML name, optionally without a ':' (an NCName)
+func isName(value string, colon bool) bool {
+	if value == "" {
+		return false
+	}
+	for idx, r := range value {
+		if r == ':' && !colon {
+			return false
+		} else if idx == 0 && !isNameStart(r) || !isNameChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkName creates a lexical check for Name (colon) or NCName
+func checkName(name string, colon bool) func(string) error {
+	return func(value string) error {
+		if !isName(value, colon) {
+			return fmt.Errorf("value %q is not a valid xs:%s", value, name)
+		}
+		return nil
+	}
+}
+
+// checkNMTOKEN is the lexical check of xs:NMTOKEN
+func checkNMTOKEN(value string) error {
+	for _, r := range value {
+		if !isNameChar(r) {
+			return fmt.Errorf("value %q is not a valid xs:NMTOKEN", value)
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("value %q is not a valid xs:NMTOKEN", value)
+	}
+	return nil
+}
+
+// checkQName is the lexical check of xs:QName and xs:NOTATION
+func checkQName(value string) error {
+	local := value
+	if idx := strings.IndexByte(value, ':'); idx != -1 {
+		local = value[idx+1:]
+		if !isName(value[:idx], false) {
+			return fmt.Errorf("value %q is not a valid xs:QName", value)
+		}
+	}
+	if !isName(local, false) {
+		return fmt.Errorf("value %q is not a valid xs:QName", value)
+	}
+	return nil
+}
+
+// checkBoolean is the lexical check of xs:boolean
+func checkBoolean(value string) error {
+	switch value {
+	case "true", "false", "1", "0":
+		return nil
+	}
+	return fmt.Errorf("value %q is not a valid xs:boolean", value)
+}
+
+// checkFloat is the lexical check of xs:float and xs:double
+func checkFloat(name string, bits int) func(string) error {
+	return func(value string) error {
+		if !reFloat.MatchString(value) {
+			return fmt.Errorf("value %q is not a valid xs:%s", value, name)
+		}
+		if _, err := strconv.ParseFloat(value, bits); err != nil && value != "INF" && value != "-INF" && value != "NaN" {
+			// Values out of range round to INF as specified by XML Schema
+			if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+				return fmt.Errorf("value %q is not a valid xs:%s", value, name)
+			}
+		}
+		return nil
+	}
+}
+
+// checkDuration is the lexical check of xs:duration
+func checkDuration(value string) error {
+	if !reDuration.MatchString(value) || strings.HasSuffix(value, "P") || strings.HasSuffix(value, "T") {
+		return fmt.Errorf("value %q is not a valid xs:duration", value)
+	}
+	return nil
+}
+
+// checkBase64 is the lexical check of xs:base64Binary
+func checkBase64(value string) error {
+	if _, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), "")); err != nil {
+		return fmt.Errorf("value %q is not a valid xs:base64Binary", value)
+	}
+	return nil
+}
+
+// builtins are the built-in simple types by local name
+var builtins = make(map[string]*simpleType)
+
+// builtin registers a built-in type derived from base (or a primitive if base is nil)
+func builtin(name string, base *simpleType, ws whitespace, check func(string) error) *simpleType {
+	var t *simpleType
+	if base == nil {
+		t = newSimpleType(name, name, ws)
+	} else {
+		t = base.derive(name)
+		t.ws = ws
+	}
+	t.check = check
+	builtins[name] = t
+	return t
+}
+
+// mustRat parses a bound of a built-in type
+func mustRat(value string) *big.Rat {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		panic("xsd: invalid bound " + value)
+	}
+	return r
+}
+
+// anySimpleType is the base of every simple type
+var anySimpleType = builtin("anySimpleType", nil, wsPreserve, nil)
+
+func init() {
+	str := builtin("string", nil, wsPreserve, nil)
+	normalized := builtin("normalizedString", str, wsReplace, nil)
+	token := builtin("token", normalized, wsCollapse, nil)
+	builtin("language", token, wsCollapse, checkRegexp("language", reLanguage))
+	nmtoken := builtin("NMTOKEN", token, wsCollapse, checkNMTOKEN)
+	name := builtin("Name", token, wsCollapse, checkName("Name", true))
+	ncname := builtin("NCName", name, wsCollapse, checkName("NCName", false))
+	builtin("ID", ncname, wsCollapse, nil)
+	idref := builtin("IDREF", ncname, wsCollapse, nil)
+	entity := builtin("ENTITY", ncname, wsCollapse, nil)
+	for list, item := range map[string]*simpleType{"NMTOKENS": nmtoken, "IDREFS": idref, "ENTITIES": entity} {
+		t := builtin(list, nil, wsCollapse, nil)
+		t.item = item
+		t.minLength = 1
+	}
+
+	builtin("boolean", nil, wsCollapse, checkBoolean)
+	builtin("float", nil, wsCollapse, checkFloat("float", 32)).numeric = true
+	builtin("double", nil, wsCollapse, checkFloat("double", 64)).numeric = true
+	builtin("duration", nil, wsCollapse, checkDuration)
+	builtin("dateTime", nil, wsCollapse, checkRegexp("dateTime", reDateTime))
+	builtin("time", nil, wsCollapse, checkRegexp("time", reTime))
+	builtin("date", nil, wsCollapse, checkRegexp("date", reDate))
+	builtin("gYearMonth", nil, wsCollapse, checkRegexp("gYearMonth", reGYearMonth))
+	builtin("gYear", nil, wsCollapse, checkRegexp("gYear", reGYear))
+	builtin("gMonthDay", nil, wsCollapse, checkRegexp("gMonthDay", reGMonthDay))
+	builtin("gDay", nil, wsCollapse, checkRegexp("gDay", reGDay))
+	builtin("gMonth", nil, wsCollapse, checkRegexp("gMonth", reGMonth))
+	builtin("hexBinary", nil, wsCollapse, checkRegexp("hexBinary", reHexBinary))
+	builtin("base64Binary", nil, wsCollapse, checkBase64)
+	builtin("anyURI", nil, wsCollapse, nil)
+	builtin("QName", nil, wsCollapse, checkQName)
+	builtin("NOTATION", nil, wsCollapse, checkQName)
+
+	decimal := builtin("decimal", nil, wsCollapse, checkRegexp("decimal", reDecimal))
+	decimal.numeric = true
+	integer := builtin("integer", decimal, wsCollapse, checkRegexp("integer", reInteger))
+	bounded := func(name string, base *simpleType, min, max string) *simpleType {
+		t := builtin(name, base, wsCollapse, nil)
+		if min != "" {
+			t.minInclusive = mustRat(min)
+		}
+		if max != "" {
+			t.maxInclusive = mustRat(max)
+		}
+		return t
+	}
+	nonPositive := bounded("nonPositiveInteger", integer, "", "0")
+	bounded("negativeInteger", nonPositive, "", "-1")
+	long := bounded("long", integer, "-9223372036854775808", "9223372036854775807")
+	intType := bounded("int", long, "-2147483648", "2147483647")
+	short := bounded("short", intType, "-32768", "32767")
+	bounded("byte", short, "-128", "127")
+	nonNegative := bounded("nonNegativeInteger", integer, "0", "")
+	unsignedLong := bounded("unsignedLong", nonNegative, "", "18446744073709551615")
+	unsignedInt := bounded("unsignedInt", unsignedLong, "", "4294967295")
+	unsignedShort := bounded("unsignedShort", unsignedInt, "", "65535")
+	bounded("unsignedByte", unsignedShort, "", "255")
+	bounded("positiveInteger", nonNegative, "1", "")
+}
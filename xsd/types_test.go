@@ -0,0 +1,104 @@
+package xsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, " a\tb\n", normalize(" a\tb\n", wsPreserve))
+	assert.Equal(t, " a b  ", normalize(" a\tb\r\n", wsReplace))
+	assert.Equal(t, "a b", normalize(" a\t \nb\r\n", wsCollapse))
+}
+
+func TestSimpleType_Builtins(t *testing.T) {
+	testCases := []struct {
+		Type  string
+		Value string
+		Valid bool
+	}{
+		{"string", " any\tthing ", true},
+		{"boolean", " true ", true},
+		{"boolean", "0", true},
+		{"boolean", "yes", false},
+		{"decimal", "-1.50", true},
+		{"decimal", "1e3", false},
+		{"integer", "+42", true},
+		{"integer", "4.0", false},
+		{"byte", "127", true},
+		{"byte", "128", false},
+		{"unsignedLong", "18446744073709551615", true},
+		{"unsignedLong", "18446744073709551616", false},
+		{"nonPositiveInteger", "-0", true},
+		{"positiveInteger", "0", false},
+		{"float", "INF", true},
+		{"float", "1.5E-3", true},
+		{"float", "inf", false},
+		{"double", "NaN", true},
+		{"date", "2024-02-29", true},
+		{"date", "2024-2-29", false},
+		{"dateTime", "2024-02-29T12:00:00.5Z", true},
+		{"dateTime", "2024-02-29 12:00:00", false},
+		{"time", "23:59:59+01:00", true},
+		{"duration", "P1Y2M3DT4H5M6.7S", true},
+		{"duration", "P", false},
+		{"duration", "PT", false},
+		{"base64Binary", "aGVs bG8=", true},
+		{"base64Binary", "aGVsbG8", false},
+		{"hexBinary", "0aFF", true},
+		{"hexBinary", "0aF", false},
+		{"NCName", "a-b.c", true},
+		{"NCName", "a:b", false},
+		{"QName", "a:b", true},
+		{"QName", "a:", false},
+		{"NMTOKEN", "1abc", true},
+		{"NMTOKENS", " a  b ", true},
+		{"NMTOKENS", "", false},
+		{"IDREFS", "a b", true},
+		{"language", "en-US", true},
+		{"language", "englishes", false},
+		{"anyURI", "http://example.com/", true},
+	}
+	for _, tc := range testCases {
+		err := builtins[tc.Type].validate(tc.Value)
+		if tc.Valid {
+			assert.NoError(t, err, "%s %q", tc.Type, tc.Value)
+		} else {
+			assert.Error(t, err, "%s %q", tc.Type, tc.Value)
+		}
+	}
+	assert.Equal(t, "xs:integer", builtins["integer"].String())
+}
+
+func TestSimpleType_Facets(t *testing.T) {
+	typ := builtins["decimal"].derive("price")
+	typ.totalDigits = 4
+	typ.fractionDigits = 2
+	typ.minExclusive = mustRat("0")
+	assert.NoError(t, typ.validate("10.25"))
+	assert.NoError(t, typ.validate("010.250"))
+	assert.EqualError(t, typ.validate("0"), `value "0" must be greater than 0`)
+	assert.EqualError(t, typ.validate("100.25"), `value "100.25" has more than 4 digits`)
+	assert.EqualError(t, typ.validate("1.255"), `value "1.255" has more than 2 fraction digits`)
+
+	code := builtins["string"].derive("code")
+	code.minLength, code.maxLength = 2, 3
+	assert.NoError(t, code.validate("ab"))
+	assert.NoError(t, code.validate("äöü"))
+	assert.EqualError(t, code.validate("a"), `value "a" has length 1 but must have a length of at least 2`)
+	assert.EqualError(t, code.validate("abcd"), `value "abcd" has length 4 but must have a length of at most 3`)
+
+	list := builtins["anySimpleType"].derive("list")
+	list.item = builtins["int"]
+	list.length = 2
+	assert.NoError(t, list.validate(" 1\n 2 "))
+	assert.EqualError(t, list.validate("1 2 3"), `value "1 2 3" has length 3 but must have length 2`)
+	assert.EqualError(t, list.validate("1 x"), `value "x" is not a valid xs:decimal`)
+
+	union := builtins["anySimpleType"].derive("union")
+	union.members = []*simpleType{builtins["int"], builtins["boolean"]}
+	assert.NoError(t, union.validate("12"))
+	assert.NoError(t, union.validate("false"))
+	assert.EqualError(t, union.validate("x"), `value "x" does not match any member of the union`)
+}
@@ -0,0 +1,338 @@
+package xsd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bored-engineer/fastxml"
+)
+
+// ValidationError is returned when a document is not valid according to the Schema
+type ValidationError struct {
+	Msg    string
+	Offset int // byte offset of the token in the document
+	Line   int // 1-based line number
+	Column int // 1-based column (in bytes)
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("xsd: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// frame is an open element being validated
+type frame struct {
+	name   []byte
+	offset int
+	elem   *element
+	state  int   // state of the automaton of a complex type
+	counts []int // occurrences of each element of an xs:all
+	text   []byte
+	nil    bool // if xsi:nil="true"
+}
+
+// validator holds the state of a single Validate call
+type validator struct {
+	schema  *Schema
+	buf     []byte
+	s       *fastxml.Scanner
+	ns      fastxml.Namespaces
+	stack   []frame
+	seen    []bool // attribute uses seen on the current element
+	scratch []byte
+	root    bool
+}
+
+// errorAt creates a *ValidationError at offset
+func (v *validator) errorAt(offset int, format string, args ...interface{}) error {
+	if offset > len(v.buf) {
+		offset = len(v.buf)
+	}
+	return &ValidationError{
+		Msg:    fmt.Sprintf(format, args...),
+		Offset: offset,
+		Line:   bytes.Count(v.buf[:offset], []byte{'\n'}) + 1,
+		Column: offset - bytes.LastIndexByte(v.buf[:offset], '\n'),
+	}
+}
+
+// Validate checks buf is a valid instance of the schema returning the first violation as a *ValidationError
+// The document is streamed through a fastxml.Scanner once, errors from the Scanner are returned as-is
+func (sc *Schema) Validate(buf []byte) error {
+	v := &validator{schema: sc, buf: buf, s: fastxml.NewScanner(buf)}
+	return v.run()
+}
+
+// run validates every token of the document
+func (v *validator) run() error {
+	for {
+		offset := v.s.Offset()
+		token, chardata, err := v.s.Next()
+		if err == io.EOF {
+			if len(v.stack) > 0 {
+				return io.ErrUnexpectedEOF
+			} else if !v.root {
+				return v.errorAt(offset, "no root element")
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case chardata:
+			err = v.charData(offset, token)
+		case fastxml.IsEndElement(token):
+			err = v.end(offset)
+		case fastxml.IsElement(token):
+			err = v.start(offset, token)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// charData checks text is allowed in the current element
+func (v *validator) charData(offset int, token []byte) error {
+	if len(v.stack) == 0 {
+		if !fastxml.IsWhitespace(token) {
+			return v.errorAt(offset, "text outside of the root element")
+		}
+		return nil
+	}
+	f := &v.stack[len(v.stack)-1]
+	e := f.elem
+	switch {
+	case f.nil:
+		return v.errorAt(offset, "element <%s> is nil but has content", f.name)
+	case e.simple != nil || e.complex.simple != nil:
+		var err error
+		if f.text, err = fastxml.CharDataAppend(f.text, token); err != nil {
+			return err
+		}
+	case e.complex.mixed || fastxml.IsWhitespace(token):
+	default:
+		return v.errorAt(offset, "element <%s> cannot contain text", f.name)
+	}
+	return nil
+}
+
+// start validates a start element and its attributes
+func (v *validator) start(offset int, token []byte) error {
+	if err := v.ns.Push(token); err != nil {
+		return err
+	}
+	name, attrsToken := fastxml.Element(token)
+	space, local := v.ns.Resolve(name, false)
+	var decl *element
+	process := processStrict
+	if len(v.stack) == 0 {
+		if v.root {
+			return v.errorAt(offset, "more than one root element")
+		}
+		v.root = true
+		if decl = v.schema.elements[qname{space: space, local: string(local)}]; decl == nil {
+			return v.errorAt(offset, "no declaration for the root element <%s>", name)
+		}
+	} else {
+		parent := &v.stack[len(v.stack)-1]
+		pe := parent.elem
+		switch {
+		case parent.nil:
+			return v.errorAt(offset, "element <%s> is nil but has content", parent.name)
+		case pe.simple != nil || pe.complex.simple != nil:
+			return v.errorAt(offset, "element <%s> has simple content and cannot contain <%s>", parent.name, name)
+		}
+		var wild *wildcard
+		var err error
+		if decl, wild, err = v.child(offset, parent, space, local, name); err != nil {
+			return err
+		}
+		if decl == nil {
+			process = wild.process
+			if process != processSkip {
+				decl = v.schema.elements[qname{space: space, local: string(local)}]
+			}
+			if decl == nil && process == processStrict {
+				return v.errorAt(offset, "no declaration for the element <%s> matching a wildcard", name)
+			}
+		}
+	}
+	// Elements matching a lax or skip wildcard without a declaration are not validated
+	if decl == nil {
+		if !v.s.SelfClosing(token) {
+			if err := v.s.Skip(); err == io.EOF {
+				return io.ErrUnexpectedEOF
+			} else if err != nil {
+				return err
+			}
+		}
+		return v.ns.Pop()
+	}
+	f := frame{name: name, offset: offset, elem: decl}
+	if decl.complex != nil && decl.complex.all != nil {
+		f.counts = make([]int, len(decl.complex.all))
+	}
+	var err error
+	if f.nil, err = v.attrs(offset, &f, attrsToken); err != nil {
+		return err
+	}
+	v.stack = append(v.stack, f)
+	if v.s.SelfClosing(token) {
+		return v.end(offset)
+	}
+	return nil
+}
+
+// child matches a child element against the content model of the parent
+func (v *validator) child(offset int, parent *frame, space string, local []byte, name []byte) (*element, *wildcard, error) {
+	ct := parent.elem.complex
+	if ct.all != nil {
+		for idx, p := range ct.all {
+			if p.elem.space == space && p.elem.local == string(local) {
+				if parent.counts[idx] > 0 {
+					return nil, nil, v.errorAt(offset, "element <%s> may only occur once in <%s>", name, parent.name)
+				}
+				parent.counts[idx]++
+				return p.elem, nil, nil
+			}
+		}
+		return nil, nil, v.errorAt(offset, "unexpected element <%s> in <%s>", name, parent.name)
+	}
+	edge := ct.dfa.step(parent.state, space, local)
+	if edge == nil {
+		expected := ct.dfa.expected(parent.state)
+		if len(expected) == 0 {
+			return nil, nil, v.errorAt(offset, "unexpected element <%s> in <%s>, no child elements are allowed", name, parent.name)
+		}
+		return nil, nil, v.errorAt(offset, "unexpected element <%s> in <%s>, expected %s", name, parent.name, strings.Join(expected, ", "))
+	}
+	parent.state = edge.next
+	return edge.elem, edge.any, nil
+}
+
+// attrs validates the attributes of an element returning if it has xsi:nil="true"
+func (v *validator) attrs(offset int, f *frame, attrsToken []byte) (isNil bool, err error) {
+	var uses []*attribute
+	var anyAttr *wildcard
+	if ct := f.elem.complex; ct != nil {
+		uses, anyAttr = ct.attrs, ct.anyAttr
+	}
+	if cap(v.seen) < len(uses) {
+		v.seen = make([]bool, len(uses))
+	}
+	seen := v.seen[:len(uses)]
+	for idx := range seen {
+		seen[idx] = false
+	}
+	var attrErr error
+	if err := fastxml.Attrs(attrsToken, func(key []byte, value []byte) bool {
+		prefix, _ := fastxml.Name(key)
+		if string(prefix) == "xmlns" || string(key) == "xmlns" {
+			return true
+		}
+		space, local := v.ns.Resolve(key, true)
+		if v.scratch, attrErr = fastxml.DecodeEntitiesAppend(v.scratch[:0], value); attrErr != nil {
+			return false
+		}
+		decoded := string(v.scratch)
+		if space == InstanceNamespace {
+			if string(local) == "nil" {
+				isNil = strings.TrimSpace(decoded) == "true" || strings.TrimSpace(decoded) == "1"
+				if isNil && !f.elem.nillable {
+					attrErr = v.errorAt(offset, "element <%s> is not nillable", f.name)
+					return false
+				}
+			}
+			return true
+		}
+		for idx, use := range uses {
+			if use.space != space || use.local != string(local) {
+				continue
+			}
+			seen[idx] = true
+			if attrErr = use.typ.validate(decoded); attrErr != nil {
+				attrErr = v.errorAt(offset, "attribute %q of <%s>: %s", key, f.name, attrErr)
+				return false
+			}
+			if use.fixed != nil && normalize(decoded, use.typ.ws) != normalize(*use.fixed, use.typ.ws) {
+				attrErr = v.errorAt(offset, "attribute %q of <%s> must be %q", key, f.name, *use.fixed)
+				return false
+			}
+			return true
+		}
+		if space == xmlNamespace {
+			return true
+		}
+		if anyAttr == nil || !anyAttr.allows(space) {
+			attrErr = v.errorAt(offset, "attribute %q is not allowed on <%s>", key, f.name)
+			return false
+		}
+		if anyAttr.process == processSkip {
+			return true
+		}
+		global := v.schema.attributes[qname{space: space, local: string(local)}]
+		if global == nil {
+			if anyAttr.process == processStrict {
+				attrErr = v.errorAt(offset, "no declaration for the attribute %q matching a wildcard", key)
+				return false
+			}
+			return true
+		}
+		if attrErr = global.typ.validate(decoded); attrErr != nil {
+			attrErr = v.errorAt(offset, "attribute %q of <%s>: %s", key, f.name, attrErr)
+			return false
+		}
+		return true
+	}); err != nil {
+		return false, v.errorAt(offset, "%s", err)
+	}
+	if attrErr != nil {
+		return false, attrErr
+	}
+	for idx, use := range uses {
+		if use.required && !seen[idx] {
+			return false, v.errorAt(offset, "element <%s> is missing the required attribute %q", f.name, use.local)
+		}
+	}
+	return isNil, nil
+}
+
+// end checks the content of the current element is complete
+func (v *validator) end(offset int) error {
+	if len(v.stack) == 0 {
+		return v.errorAt(offset, "unexpected end element")
+	}
+	f := &v.stack[len(v.stack)-1]
+	e := f.elem
+	switch {
+	case f.nil:
+		if e.fixed != nil {
+			return v.errorAt(f.offset, "element <%s> has a fixed value and cannot be nil", f.name)
+		}
+	case e.simple != nil || e.complex.simple != nil:
+		st := e.simple
+		if st == nil {
+			st = e.complex.simple
+		}
+		text := string(f.text)
+		if err := st.validate(text); err != nil {
+			return v.errorAt(f.offset, "element <%s>: %s", f.name, err)
+		}
+		if e.fixed != nil && normalize(text, st.ws) != normalize(*e.fixed, st.ws) {
+			return v.errorAt(f.offset, "element <%s> must be %q", f.name, *e.fixed)
+		}
+	case e.complex.all != nil:
+		for idx, p := range e.complex.all {
+			if f.counts[idx] < p.min {
+				return v.errorAt(f.offset, "element <%s> is missing the child element <%s>", f.name, p.elem.local)
+			}
+		}
+	case !e.complex.dfa.states[f.state].accept:
+		return v.errorAt(f.offset, "element <%s> is incomplete, expected %s", f.name, strings.Join(e.complex.dfa.expected(f.state), ", "))
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+	return v.ns.Pop()
+}
@@ -0,0 +1,247 @@
+package xsd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderSchema = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+	xmlns="urn:orders" targetNamespace="urn:orders" elementFormDefault="qualified">
+	<xs:annotation><xs:documentation>Orders</xs:documentation></xs:annotation>
+	<xs:element name="order" type="Order"/>
+	<xs:complexType name="Order">
+		<xs:sequence>
+			<xs:element name="customer" type="xs:string"/>
+			<xs:element name="item" type="Item" maxOccurs="unbounded"/>
+			<xs:choice minOccurs="0">
+				<xs:element name="note" type="xs:string"/>
+				<xs:element name="gift" type="xs:boolean"/>
+			</xs:choice>
+			<xs:any namespace="##other" processContents="lax" minOccurs="0" maxOccurs="unbounded"/>
+		</xs:sequence>
+		<xs:attribute name="id" type="xs:positiveInteger" use="required"/>
+		<xs:attribute name="status" type="Status" default="new"/>
+		<xs:attribute name="currency" type="xs:string" fixed="USD"/>
+	</xs:complexType>
+	<xs:complexType name="Item">
+		<xs:all>
+			<xs:element name="sku" type="SKU"/>
+			<xs:element name="quantity">
+				<xs:simpleType>
+					<xs:restriction base="xs:int">
+						<xs:minInclusive value="1"/>
+						<xs:maxExclusive value="100"/>
+					</xs:restriction>
+				</xs:simpleType>
+			</xs:element>
+			<xs:element name="price" type="Price" minOccurs="0"/>
+		</xs:all>
+	</xs:complexType>
+	<xs:complexType name="Price">
+		<xs:simpleContent>
+			<xs:extension base="xs:decimal">
+				<xs:attribute name="tax" type="xs:boolean"/>
+			</xs:extension>
+		</xs:simpleContent>
+	</xs:complexType>
+	<xs:simpleType name="SKU">
+		<xs:restriction base="xs:token">
+			<xs:pattern value="[A-Z]{3}-\d{3}"/>
+		</xs:restriction>
+	</xs:simpleType>
+	<xs:simpleType name="Status">
+		<xs:restriction base="xs:string">
+			<xs:enumeration value="new"/>
+			<xs:enumeration value="shipped"/>
+		</xs:restriction>
+	</xs:simpleType>
+</xs:schema>`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := Compile([]byte(orderSchema))
+	if !assert.NoError(t, err) {
+		return
+	}
+	testCases := []struct {
+		Name  string
+		Input string
+		Error string
+	}{
+		{
+			Name: "valid",
+			Input: `<?xml version="1.0"?>
+<o:order xmlns:o="urn:orders" id="7" status="shipped" currency="USD">
+	<!-- comment -->
+	<o:customer>Ann &amp; Bob</o:customer>
+	<o:item><o:quantity> 2 </o:quantity><o:sku>ABC-123</o:sku></o:item>
+	<o:item><o:sku>XYZ-999</o:sku><o:quantity>99</o:quantity><o:price tax="true">9.99</o:price></o:item>
+	<o:gift>true</o:gift>
+	<ext:extra xmlns:ext="urn:ext"><anything/></ext:extra>
+</o:order>`,
+		},
+		{
+			Name:  "default namespace",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1</quantity></item></order>`,
+		},
+		{
+			Name:  "wrong namespace",
+			Input: `<order id="1"/>`,
+			Error: "xsd: line 1, column 1: no declaration for the root element <order>",
+		},
+		{
+			Name:  "missing required attribute",
+			Input: `<order xmlns="urn:orders"><customer/></order>`,
+			Error: `xsd: line 1, column 1: element <order> is missing the required attribute "id"`,
+		},
+		{
+			Name:  "invalid attribute",
+			Input: `<order xmlns="urn:orders" id="0"/>`,
+			Error: `xsd: line 1, column 1: attribute "id" of <order>: value "0" is less than the minimum 1`,
+		},
+		{
+			Name:  "enumeration",
+			Input: `<order xmlns="urn:orders" id="1" status="lost"/>`,
+			Error: `xsd: line 1, column 1: attribute "status" of <order>: value "lost" is not one of the enumerated values ["new" "shipped"]`,
+		},
+		{
+			Name:  "fixed",
+			Input: `<order xmlns="urn:orders" id="1" currency="EUR"/>`,
+			Error: `xsd: line 1, column 1: attribute "currency" of <order> must be "USD"`,
+		},
+		{
+			Name:  "undeclared attribute",
+			Input: `<order xmlns="urn:orders" id="1" other="x"/>`,
+			Error: `xsd: line 1, column 1: attribute "other" is not allowed on <order>`,
+		},
+		{
+			Name:  "incomplete",
+			Input: `<order xmlns="urn:orders" id="1"><customer/></order>`,
+			Error: `xsd: line 1, column 1: element <order> is incomplete, expected <item>`,
+		},
+		{
+			Name:  "unexpected element",
+			Input: "<order xmlns=\"urn:orders\" id=\"1\">\n<item/>",
+			Error: `xsd: line 2, column 1: unexpected element <item> in <order>, expected <customer>`,
+		},
+		{
+			Name:  "choice",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1</quantity></item><note/><gift>1</gift></order>`,
+			Error: `xsd: line 1, column 105: unexpected element <gift> in <order>, expected any element, the end of the element`,
+		},
+		{
+			Name:  "all missing",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><quantity>1</quantity></item></order>`,
+			Error: `xsd: line 1, column 45: element <item> is missing the child element <sku>`,
+		},
+		{
+			Name:  "all repeated",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><sku>ABC-123</sku></item></order>`,
+			Error: `xsd: line 1, column 69: element <sku> may only occur once in <item>`,
+		},
+		{
+			Name:  "pattern",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>abc-123</sku><quantity>1</quantity></item></order>`,
+			Error: `xsd: line 1, column 51: element <sku>: value "abc-123" does not match the pattern "[A-Z]{3}-\\d{3}"`,
+		},
+		{
+			Name:  "bounds",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>100</quantity></item></order>`,
+			Error: `xsd: line 1, column 69: element <quantity>: value "100" must be less than 100`,
+		},
+		{
+			Name:  "built-in type",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1.5</quantity></item></order>`,
+			Error: `xsd: line 1, column 69: element <quantity>: value "1.5" is not a valid xs:integer`,
+		},
+		{
+			Name:  "simple content",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1</quantity><price tax="maybe">1</price></item></order>`,
+			Error: `xsd: line 1, column 91: attribute "tax" of <price>: value "maybe" is not a valid xs:boolean`,
+		},
+		{
+			Name:  "child of simple content",
+			Input: `<order xmlns="urn:orders" id="1"><customer><b/></customer></order>`,
+			Error: `xsd: line 1, column 44: element <customer> has simple content and cannot contain <b>`,
+		},
+		{
+			Name:  "text in element-only content",
+			Input: `<order xmlns="urn:orders" id="1">text</order>`,
+			Error: `xsd: line 1, column 34: element <order> cannot contain text`,
+		},
+		{
+			Name:  "strict wildcard in target namespace",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1</quantity></item><order id="2"/></order>`,
+			Error: `xsd: line 1, column 98: unexpected element <order> in <order>, expected <gift>, <item>, <note>, any element, the end of the element`,
+		},
+		{
+			Name:  "text outside root",
+			Input: `<order xmlns="urn:orders" id="1"><customer/><item><sku>ABC-123</sku><quantity>1</quantity></item></order>x`,
+			Error: `xsd: line 1, column 106: text outside of the root element`,
+		},
+		{
+			Name:  "no root",
+			Input: `<!-- nothing -->`,
+			Error: `xsd: line 1, column 17: no root element`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := schema.Validate([]byte(tc.Input))
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.Error)
+			}
+		})
+	}
+	assert.Equal(t, io.ErrUnexpectedEOF, schema.Validate([]byte(`<order xmlns="urn:orders" id="1"><customer>`)))
+	err = schema.Validate([]byte(`<order xmlns="urn:orders" id="1"/>`))
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Equal(t, 0, err.(*ValidationError).Offset)
+	}
+}
+
+func TestSchema_ValidateNillable(t *testing.T) {
+	schema := MustCompile([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="root">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="a" type="xs:int" nillable="true" maxOccurs="2"/>
+					<xs:element name="b" type="xs:int" minOccurs="0"/>
+				</xs:sequence>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`))
+	xsi := ` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`
+	assert.NoError(t, schema.Validate([]byte(`<root`+xsi+`><a xsi:nil="true"/><a>1</a></root>`)))
+	assert.EqualError(t, schema.Validate([]byte(`<root`+xsi+`><a xsi:nil="true">1</a></root>`)),
+		"xsd: line 1, column 79: element <a> is nil but has content")
+	assert.EqualError(t, schema.Validate([]byte(`<root`+xsi+`><a>1</a><b xsi:nil="true"/></root>`)),
+		"xsd: line 1, column 69: element <b> is not nillable")
+	assert.EqualError(t, schema.Validate([]byte(`<root><a>1</a><a>2</a><a>3</a></root>`)),
+		"xsd: line 1, column 23: unexpected element <a> in <root>, expected <b>, the end of the element")
+}
+
+func TestSchema_ValidateRecursive(t *testing.T) {
+	schema := MustCompile([]byte(`<schema xmlns="http://www.w3.org/2001/XMLSchema" xmlns:t="urn:tree" targetNamespace="urn:tree">
+		<element name="node" type="t:Node"/>
+		<complexType name="Node" mixed="true">
+			<sequence>
+				<element ref="t:node" minOccurs="0" maxOccurs="unbounded"/>
+			</sequence>
+			<attributeGroup ref="t:common"/>
+		</complexType>
+		<attributeGroup name="common">
+			<attribute name="label" type="string"/>
+			<anyAttribute namespace="##other" processContents="skip"/>
+		</attributeGroup>
+	</schema>`))
+	assert.NoError(t, schema.Validate([]byte(`<t:node xmlns:t="urn:tree" label="a">x<t:node xmlns:o="urn:o" o:any="1">y<t:node/></t:node></t:node>`)))
+	assert.EqualError(t, schema.Validate([]byte(`<t:node xmlns:t="urn:tree"><node/></t:node>`)),
+		"xsd: line 1, column 28: unexpected element <node> in <t:node>, expected <node>, the end of the element")
+	assert.EqualError(t, schema.Validate([]byte(`<t:node xmlns:t="urn:tree" t:label="x"/>`)),
+		`xsd: line 1, column 1: attribute "t:label" is not allowed on <t:node>`)
+}